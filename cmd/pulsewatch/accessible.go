@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/locale"
+	"github.com/nitis/pulseWatch/internal/types"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	for _, c := range []*cobra.Command{watchCmd, replayCmd} {
+		c.Flags().Bool("accessible", false, "Print plain, linear text updates for screen readers instead of the dashboard")
+	}
+}
+
+// runAccessible replaces the dashboard with plain text a screen reader can
+// follow: no box drawing, colors, or cursor movement, and one line per tick
+// summarizing the last minute, printed only when it changed. Anomalies are
+// printed as they are recorded. It returns like runHeadless, after printing
+// the report for finite input.
+func runAccessible(ctx context.Context, metricsCh <-chan types.Metrics, anomalies <-chan types.Anomaly, once bool) {
+	fmt.Println("Pulsewatch accessible mode. Press Ctrl+C to exit.")
+	var last string
+	learning := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case a := <-anomalies:
+			fmt.Printf("%s anomaly at %s. %s: %s\n", capitalize(a.Severity), locale.Time(a.Timestamp), a.Type, a.Message)
+		case m, ok := <-metricsCh:
+			if !ok {
+				return
+			}
+			if wm, ok := m.Windows["all"]; ok {
+				fmt.Println(plainWindow("Report for all input", wm, false))
+				if m.Final || once {
+					return
+				}
+				continue
+			}
+			if m.Learning != learning {
+				learning = m.Learning
+				if learning {
+					fmt.Println("Learning normal traffic. Anomaly detection starts after the warm-up.")
+				} else {
+					fmt.Println("Warm-up done. Anomaly detection is on.")
+				}
+			}
+			wm, ok := m.Windows["1m"]
+			if !ok {
+				continue
+			}
+			line := plainWindow("Last minute", wm, true)
+			if line == last {
+				continue
+			}
+			last = line
+			fmt.Printf("At %s. %s\n", locale.Time(time.Now()), line)
+		}
+	}
+}
+
+// plainWindow describes a window's traffic in one sentence. The "all"
+// window of finite input has no meaningful rate, so withRPS leaves it out.
+func plainWindow(label string, wm types.WindowedMetrics, withRPS bool) string {
+	rps := ""
+	if withRPS {
+		rps = fmt.Sprintf(", %s requests per second", locale.Float(wm.RPS, 1))
+	}
+	line := fmt.Sprintf("%s: %s requests%s, %s errors, median latency %s, 95th percentile %s, 99th percentile %s.",
+		label, locale.Int(int64(wm.TotalRequests)), rps, locale.Percent(wm.ErrorRate, 1),
+		locale.Duration(wm.P50Latency), locale.Duration(wm.P95Latency), locale.Duration(wm.P99Latency))
+	for _, s := range wm.LatencySLA {
+		line += fmt.Sprintf(" %s within %s", locale.Percent(s.Percent, 1), locale.Duration(s.Threshold))
+		if s.Missed() {
+			line += fmt.Sprintf(", below the %s target", locale.Percent(s.Target, -1))
+		}
+		line += "."
+	}
+	return line
+}
+
+// printPlainSummary prints the report summary and checks as sentences, one
+// per line, without separators or column alignment, for report --plain.
+func printPlainSummary(d reportData, checks []reportCheck) {
+	s := d.summary()
+	fmt.Printf("Report from %s %s to %s %s, with %s minutes of data.\n",
+		locale.Date(d.from), locale.ShortTime(d.from), locale.Date(d.to), locale.ShortTime(d.to), locale.Int(int64(len(d.rollups))))
+	fmt.Printf("Requests: %s. Errors: %s, which is %s.\n", locale.Int(int64(s.requests)), locale.Int(int64(s.errors)), locale.Percent(s.errorRate, 2))
+	fmt.Printf("Requests per second: %s on average, %s at peak.\n", locale.Float(s.avgRPS, 2), locale.Float(s.peakRPS, 2))
+	fmt.Printf("Worst minute latency: median %s, 95th percentile %s, 99th percentile %s.\n", locale.Duration(s.p50), locale.Duration(s.p95), locale.Duration(s.p99))
+
+	bySeverity := make(map[string]int)
+	for _, a := range d.anomalies {
+		bySeverity[a.Severity]++
+	}
+	fmt.Printf("Anomalies: %d. %d critical, %d warning, %d info.\n", len(d.anomalies),
+		bySeverity[types.SeverityCritical], bySeverity[types.SeverityWarning], bySeverity[types.SeverityInfo])
+	for _, ev := range d.events {
+		fmt.Printf("Event at %s, %s: %s.\n", locale.DateTime(ev.Timestamp), ev.Type, ev.Title)
+	}
+	for _, a := range d.annotations {
+		fmt.Printf("Annotation at %s, %s: %s.\n", annotationSpan(a, locale.DateTime), a.Label, a.Note)
+	}
+
+	for _, c := range checks {
+		if c.failure != "" {
+			fmt.Printf("Check %s %s failed: %s.\n", c.suite, c.name, c.failure)
+		} else {
+			fmt.Printf("Check %s %s passed.\n", c.suite, c.name)
+		}
+	}
+}
+
+// capitalize upper-cases the first letter of s.
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
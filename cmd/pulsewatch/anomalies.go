@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/locale"
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var anomaliesCmd = &cobra.Command{
+	Use:   "anomalies",
+	Short: "Inspect detected anomalies",
+}
+
+var anomaliesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List persisted anomalies",
+	Long:  `Lists anomalies stored in the database, most recent first, with the metrics at the time each fired. Safe to run while another pulsewatch process is using the database.`,
+	Args:  cobra.NoArgs,
+	Run:   runAnomaliesList,
+}
+
+func init() {
+	anomaliesListCmd.Flags().String("type", "", "Only anomalies whose type contains this text (case-insensitive)")
+	anomaliesListCmd.Flags().String("severity", "", "Only anomalies of this severity: critical, warning, or info")
+	anomaliesListCmd.Flags().Duration("since", 24*time.Hour, "Only anomalies fired within this long ago (0 for all)")
+	anomaliesListCmd.Flags().Int("limit", 50, "Maximum number of anomalies to list (0 for no limit)")
+	anomaliesListCmd.Flags().BoolP("verbose", "v", false, "Show each anomaly's message, metrics snapshot, and contributors")
+	anomaliesCmd.AddCommand(anomaliesListCmd)
+	rootCmd.AddCommand(anomaliesCmd)
+}
+
+func runAnomaliesList(cmd *cobra.Command, args []string) {
+	// The config only sets the locale here
+	if _, err := loadConfig(cmd); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	var f types.AnomalyFilter
+	f.Type, _ = cmd.Flags().GetString("type")
+	f.Severity, _ = cmd.Flags().GetString("severity")
+	f.Limit, _ = cmd.Flags().GetInt("limit")
+	if since, _ := cmd.Flags().GetDuration("since"); since > 0 {
+		f.Since = time.Now().Add(-since)
+	}
+	switch f.Severity {
+	case "", types.SeverityCritical, types.SeverityWarning, types.SeverityInfo:
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid --severity %q: use critical, warning, or info\n", f.Severity)
+		os.Exit(1)
+	}
+
+	dbPath, _ := cmd.Flags().GetString("db-path")
+	stor, err := storage.OpenReadOnly(dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer stor.Close()
+
+	anomalies, err := stor.QueryAnomalies(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing anomalies: %v\n", err)
+		os.Exit(1)
+	}
+	if len(anomalies) == 0 {
+		fmt.Println("No anomalies match.")
+		return
+	}
+
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	for _, a := range anomalies {
+		severity := a.Severity
+		if severity == "" {
+			severity = "-"
+		}
+		fmt.Printf("%s  %-8s  %s\n", locale.DateTime(a.Timestamp.Local()), severity, a.Type)
+		if !verbose {
+			continue
+		}
+		s := a.Snapshot
+		fmt.Printf("    %s\n", a.Message)
+		fmt.Printf("    At firing (1m): %s rps, %s requests, %s errors, p50 %s, p95 %s, p99 %s\n",
+			locale.Float(s.RPS, 2), locale.Int(int64(s.Requests)), locale.Percent(s.ErrorRate, 2), locale.Duration(s.P50Latency), locale.Duration(s.P95Latency), locale.Duration(s.P99Latency))
+		for _, c := range a.Contributors {
+			fmt.Printf("    %s=%s: %.0f%% of window (baseline %.0f%%)\n", c.Dimension, c.Value, c.Share, c.BaselineShare)
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/tui"
+	"github.com/nitis/pulseWatch/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// applyBaseline compares the dashboard's cards with the session in the
+// --baseline database, if given.
+func applyBaseline(cmd *cobra.Command, model *tui.Model) {
+	path, _ := cmd.Flags().GetString("baseline")
+	if path == "" {
+		return
+	}
+	b, err := loadBaseline(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	model.SetBaseline(b)
+}
+
+// loadBaseline summarizes the latest session stored in the database at
+// path: the rollups since its last "session" event, or all of them.
+func loadBaseline(path string) (*types.SessionBaseline, error) {
+	stor, err := storage.OpenReadOnly(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening baseline database: %w", err)
+	}
+	defer stor.Close()
+
+	from := time.Time{}
+	sessions, err := stor.GetEventsBetween(time.Time{}, time.Now(), "session")
+	if err != nil {
+		return nil, fmt.Errorf("loading baseline sessions: %w", err)
+	}
+	if len(sessions) > 0 {
+		from = sessions[len(sessions)-1].Timestamp
+	}
+	rollups, err := stor.GetRollupsSince(from)
+	if err != nil {
+		return nil, fmt.Errorf("loading baseline rollups: %w", err)
+	}
+
+	b := &types.SessionBaseline{Source: path}
+	var requests, errors int
+	var p50, p95, p99 []time.Duration
+	for _, r := range rollups {
+		if r.Requests == 0 {
+			continue // Idle minutes before and after a test
+		}
+		if b.Minutes == 0 {
+			b.From = r.Timestamp
+		}
+		b.To = r.Timestamp
+		b.Minutes++
+		requests += r.Requests
+		errors += r.Errors
+		p50, p95, p99 = append(p50, r.P50), append(p95, r.P95), append(p99, r.P99)
+	}
+	if b.Minutes == 0 {
+		return nil, fmt.Errorf("baseline database %s has no traffic in its latest session", path)
+	}
+	b.RPS = float64(requests) / float64(b.Minutes) / time.Minute.Seconds()
+	b.ErrorRate = float64(errors) / float64(requests) * 100
+	b.Percentiles = []types.PercentileValue{
+		{Percentile: 50, Latency: median(p50)},
+		{Percentile: 95, Latency: median(p95)},
+		{Percentile: 99, Latency: median(p99)},
+	}
+	return b, nil
+}
+
+func median(ds []time.Duration) time.Duration {
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+	return ds[len(ds)/2]
+}
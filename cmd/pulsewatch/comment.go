@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/review"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	reportCmd.Flags().String("comment", "", "Post the summary as a pull/merge request comment: github or gitlab")
+	reportCmd.Flags().String("comment-repo", "", "Repository (owner/name) or GitLab project path; defaults from GITHUB_REPOSITORY or CI_PROJECT_ID")
+	reportCmd.Flags().Int("comment-pr", 0, "Pull request number or merge request IID; defaults from PR_NUMBER or CI_MERGE_REQUEST_IID")
+	reportCmd.Flags().String("comment-token", "", "API token; defaults to GITHUB_TOKEN or GITLAB_TOKEN")
+	reportCmd.Flags().String("comment-api-url", "", "API base URL for GitHub Enterprise or self-hosted GitLab; defaults from GITHUB_API_URL or CI_API_V4_URL")
+}
+
+// commentPoster returns the poster configured by the --comment flags, or nil
+// if no comment was requested. Unset flags fall back to the CI environment.
+func commentPoster(cmd *cobra.Command) (review.Poster, error) {
+	provider, _ := cmd.Flags().GetString("comment")
+	if provider == "" {
+		return nil, nil
+	}
+	repo, _ := cmd.Flags().GetString("comment-repo")
+	number, _ := cmd.Flags().GetInt("comment-pr")
+	token, _ := cmd.Flags().GetString("comment-token")
+	apiURL, _ := cmd.Flags().GetString("comment-api-url")
+
+	env := map[string][4]string{ // repo, number, token, API URL
+		"github": {"GITHUB_REPOSITORY", "PR_NUMBER", "GITHUB_TOKEN", "GITHUB_API_URL"},
+		"gitlab": {"CI_PROJECT_ID", "CI_MERGE_REQUEST_IID", "GITLAB_TOKEN", "CI_API_V4_URL"},
+	}[provider]
+	if env[0] != "" {
+		if repo == "" {
+			repo = os.Getenv(env[0])
+		}
+		if number == 0 {
+			number, _ = strconv.Atoi(os.Getenv(env[1]))
+		}
+		if token == "" {
+			token = os.Getenv(env[2])
+		}
+		if apiURL == "" {
+			apiURL = os.Getenv(env[3])
+		}
+	}
+	return review.New(provider, apiURL, token, repo, number)
+}
+
+// markdownReport renders the report summary and checks for a review comment.
+func markdownReport(d reportData, checks []reportCheck) string {
+	s := d.summary()
+	var b strings.Builder
+
+	failed := 0
+	for _, c := range checks {
+		if c.failure != "" {
+			failed++
+		}
+	}
+	status := ":white_check_mark: all checks passed"
+	if failed > 0 {
+		status = fmt.Sprintf(":x: %d of %d checks failed", failed, len(checks))
+	}
+	fmt.Fprintf(&b, "### pulsewatch report: %s\n\n", status)
+	fmt.Fprintf(&b, "%s - %s (%d minutes of data)\n\n", d.from.Format("2006-01-02 15:04"), d.to.Format("2006-01-02 15:04"), len(d.rollups))
+
+	b.WriteString("| Requests | Errors | Avg RPS | Peak RPS | P50 | P95 | P99 |\n")
+	b.WriteString("|---:|---:|---:|---:|---:|---:|---:|\n")
+	fmt.Fprintf(&b, "| %d | %d (%.2f%%) | %.2f | %.2f | %v | %v | %v |\n\n", s.requests, s.errors, s.errorRate, s.avgRPS, s.peakRPS, s.p50, s.p95, s.p99)
+
+	b.WriteString("| Check | Result |\n|---|---|\n")
+	for _, c := range checks {
+		result := ":white_check_mark: " + firstLine(c.output)
+		if c.failure != "" {
+			result = ":x: " + c.failure
+		}
+		fmt.Fprintf(&b, "| %s/%s | %s |\n", c.suite, c.name, strings.ReplaceAll(result, "|", "\\|"))
+	}
+
+	if len(d.events) > 0 {
+		b.WriteString("\n**Events**\n\n")
+		for _, ev := range d.events {
+			fmt.Fprintf(&b, "- `%s` %s: %s\n", ev.Timestamp.Format("01-02 15:04:05"), ev.Type, ev.Title)
+		}
+	}
+
+	if len(d.annotations) > 0 {
+		b.WriteString("\n**Annotations**\n\n")
+		for _, a := range d.annotations {
+			span := annotationSpan(a, func(t time.Time) string { return t.Format("01-02 15:04:05") })
+			fmt.Fprintf(&b, "- `%s` **%s**: %s\n", span, a.Label, a.Note)
+			if a.Line != "" {
+				fmt.Fprintf(&b, "  `%s`\n", strings.ReplaceAll(a.Line, "`", "'"))
+			}
+		}
+	}
+
+	if len(d.anomalies) > 0 {
+		fmt.Fprintf(&b, "\n<details><summary>%d anomalies</summary>\n\n", len(d.anomalies))
+		for i, a := range d.anomalies {
+			if i == 20 {
+				fmt.Fprintf(&b, "- ... and %d more\n", len(d.anomalies)-i)
+				break
+			}
+			fmt.Fprintf(&b, "- `%s` **%s** (%s): %s\n", a.Timestamp.Format("01-02 15:04:05"), a.Type, a.Severity, a.Message)
+		}
+		b.WriteString("\n</details>\n")
+	}
+	return b.String()
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + " ..."
+	}
+	return s
+}
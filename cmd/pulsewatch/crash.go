@@ -0,0 +1,80 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/charmbracelet/bubbletea"
+	"github.com/nitis/pulseWatch/internal/analysis"
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/crash"
+	"github.com/nitis/pulseWatch/internal/ingest"
+	"gopkg.in/yaml.v3"
+)
+
+// setupCrashHandling prepares a session for a panic in any pipeline
+// goroutine: buffered exports are flushed and the database closed cleanly,
+// and the crash report carries the config hash and the storage and ingest
+// self-metrics.
+func setupCrashHandling(cfg *config.Config, dbPath string, engine *analysis.Engine, guard *ingest.Guard) {
+	crash.Configure(version, configHash(cfg), dbPath)
+	crash.OnCrash("exports", engine.FlushExports)
+	crash.OnCrash("database", engine.Stop)
+	crash.AddReport("Storage", func(w io.Writer) {
+		st, err := engine.StorageStats()
+		if err != nil {
+			fmt.Fprintf(w, "unavailable: %v\n", err)
+			return
+		}
+		fmt.Fprintf(w, "Size:        %d bytes (WAL %d bytes)\n", st.SizeBytes, st.WALBytes)
+		fmt.Fprintf(w, "Inserts:     %d\n", st.Inserts)
+		fmt.Fprintf(w, "Queries:     %d (avg %s)\n", st.Queries, st.AvgQueryLatency)
+		fmt.Fprintf(w, "Pruned rows: %d\n", st.PrunedRows)
+		tables := make([]string, 0, len(st.TableRows))
+		for table := range st.TableRows {
+			tables = append(tables, table)
+		}
+		sort.Strings(tables)
+		for _, table := range tables {
+			fmt.Fprintf(w, "Rows in %s: %d\n", table, st.TableRows[table])
+		}
+	})
+	crash.AddReport("Ingest", func(w io.Writer) {
+		if summary := guard.Summary(); summary != "" {
+			fmt.Fprintln(w, summary)
+		} else {
+			fmt.Fprintln(w, "No lines truncated or skipped.")
+		}
+	})
+}
+
+// configHash identifies the effective config, flags included, without
+// putting its secrets in the report.
+func configHash(cfg *config.Config) string {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return "unknown"
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// runDashboard runs the TUI. A crash elsewhere stops it to get the terminal
+// back; a panic in the TUI itself, which bubbletea recovers from, becomes a
+// crash report too.
+func runDashboard(p *tea.Program) error {
+	crash.SetTerminal(func() {
+		p.Kill()
+		p.Wait()
+	})
+	err := p.Start()
+	crash.Wait()
+	if errors.Is(err, tea.ErrProgramPanic) {
+		crash.Handle("dashboard", err, nil)
+	}
+	return err
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/analysis"
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/control"
+	"github.com/nitis/pulseWatch/internal/locale"
+	"github.com/spf13/cobra"
+)
+
+var ctlCmd = &cobra.Command{
+	Use:   "ctl",
+	Short: "Control a running watch --headless daemon",
+	Long:  `Talks to the control socket of a pulsewatch daemon (watch --headless) on this machine: control.socket in the config, or the database path plus ".sock". Use --socket or the same --db-path and --config as the daemon to reach it.`,
+}
+
+var ctlStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the daemon's current metrics, filter, and silences",
+	Args:  cobra.NoArgs,
+	Run:   runCtl(ctlStatus),
+}
+
+var ctlReloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Re-read the config file and apply the detection thresholds and SLO target",
+	Args:  cobra.NoArgs,
+	Run: runCtl(func(ctx context.Context, c *control.Client, cmd *cobra.Command, args []string) (string, error) {
+		return c.Reload(ctx)
+	}),
+}
+
+var ctlRotateCmd = &cobra.Command{
+	Use:   "rotate-session",
+	Short: "Start a new session, clearing recent anomalies, trends, and error streaks",
+	Args:  cobra.NoArgs,
+	Run: runCtl(func(ctx context.Context, c *control.Client, cmd *cobra.Command, args []string) (string, error) {
+		return c.RotateSession(ctx)
+	}),
+}
+
+var ctlFilterCmd = &cobra.Command{
+	Use:     "filter [expression]",
+	Short:   "Only process entries matching a filter expression; without one, clear it",
+	Example: `  pulsewatch ctl filter 'endpoint !~ "^/health"'`,
+	Args:    cobra.MaximumNArgs(1),
+	Run: runCtl(func(ctx context.Context, c *control.Client, cmd *cobra.Command, args []string) (string, error) {
+		return c.SetFilter(ctx, strings.Join(args, ""))
+	}),
+}
+
+var ctlSilenceCmd = &cobra.Command{
+	Use:     "silence <anomaly type|all>",
+	Short:   "Stop notifications for an anomaly type for a while",
+	Example: "  pulsewatch ctl silence \"Error Spike\" --for 2h\n  pulsewatch ctl silence all --for 0   # Resume",
+	Args:    cobra.ExactArgs(1),
+	Run: runCtl(func(ctx context.Context, c *control.Client, cmd *cobra.Command, args []string) (string, error) {
+		d, _ := cmd.Flags().GetDuration("for")
+		anomalyType := args[0]
+		if anomalyType == "all" {
+			anomalyType = analysis.SilenceAll
+		}
+		return c.Silence(ctx, anomalyType, d)
+	}),
+}
+
+var ctlPauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Pause ingestion, holding new records until it resumes",
+	Args:  cobra.NoArgs,
+	Run: runCtl(func(ctx context.Context, c *control.Client, cmd *cobra.Command, args []string) (string, error) {
+		return c.Pause(ctx)
+	}),
+}
+
+var ctlResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume ingestion, processing the held records first",
+	Args:  cobra.NoArgs,
+	Run: runCtl(func(ctx context.Context, c *control.Client, cmd *cobra.Command, args []string) (string, error) {
+		return c.Resume(ctx)
+	}),
+}
+
+var ctlFlushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Process the records held so far while ingestion stays paused",
+	Args:  cobra.NoArgs,
+	Run: runCtl(func(ctx context.Context, c *control.Client, cmd *cobra.Command, args []string) (string, error) {
+		return c.Flush(ctx)
+	}),
+}
+
+func init() {
+	ctlCmd.PersistentFlags().String("socket", "", "Control socket of the daemon (default: control.socket, or the database path plus .sock)")
+	ctlCmd.PersistentFlags().Duration("timeout", 5*time.Second, "Give up if the daemon doesn't answer within this long")
+	ctlSilenceCmd.Flags().Duration("for", time.Hour, "How long to silence; 0 resumes notifications")
+	ctlCmd.AddCommand(ctlStatusCmd, ctlReloadCmd, ctlRotateCmd, ctlFilterCmd, ctlSilenceCmd, ctlPauseCmd, ctlResumeCmd, ctlFlushCmd)
+	rootCmd.AddCommand(ctlCmd)
+}
+
+// controlSocket returns the daemon's control socket path.
+func controlSocket(cmd *cobra.Command, cfg *config.Config) string {
+	if cmd.Flags().Lookup("socket") != nil {
+		if path, _ := cmd.Flags().GetString("socket"); path != "" {
+			return path
+		}
+	}
+	if cfg.Control.Socket != "" {
+		return cfg.Control.Socket
+	}
+	dbPath, _ := cmd.Flags().GetString("db-path")
+	return dbPath + ".sock"
+}
+
+// runCtl runs a control call and prints the daemon's answer, exiting with
+// status 1 on failure.
+func runCtl(call func(ctx context.Context, c *control.Client, cmd *cobra.Command, args []string) (string, error)) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+			os.Exit(1)
+		}
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		msg, err := call(ctx, control.NewClient(controlSocket(cmd, cfg)), cmd, args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(msg)
+	}
+}
+
+func ctlStatus(ctx context.Context, c *control.Client, cmd *cobra.Command, args []string) (string, error) {
+	st, err := c.Status(ctx)
+	if err != nil {
+		return "", err
+	}
+	s := st.Status
+	var b strings.Builder
+	fmt.Fprintf(&b, "PID %d, session started %s\n", st.PID, locale.DateTime(s.Started))
+	fmt.Fprintf(&b, "Last minute: %s requests/s, %s errors, p95 %s", locale.Float(s.Snapshot.RPS, 2),
+		locale.Percent(s.Snapshot.ErrorRate, 2), locale.Duration(s.Snapshot.P95Latency))
+	if s.Learning {
+		b.WriteString(" (learning baselines)")
+	}
+	fmt.Fprintf(&b, "\nAnomalies (5m): %d, %d critical\n", s.Anomalies, s.Critical)
+	if st.Filter != "" {
+		fmt.Fprintf(&b, "Filter: %s\n", st.Filter)
+	}
+	if in := st.Ingest; in != nil && in.Paused {
+		fmt.Fprintf(&b, "Ingestion paused since %s, %s records held", locale.DateTime(in.Since), locale.Int(int64(in.Held)))
+		if in.Full {
+			b.WriteString(" (buffer full; inputs not read)")
+		}
+		b.WriteString("\n")
+	}
+	silenced := make([]string, 0, len(st.Silences))
+	for t := range st.Silences {
+		silenced = append(silenced, t)
+	}
+	sort.Strings(silenced)
+	for _, t := range silenced {
+		name := t
+		if t == analysis.SilenceAll {
+			name = "all"
+		}
+		fmt.Fprintf(&b, "Silenced: %s until %s\n", name, locale.DateTime(st.Silences[t]))
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nitis/pulseWatch/internal/analysis"
+	"github.com/nitis/pulseWatch/internal/ingest"
+	"github.com/nitis/pulseWatch/internal/tui"
+	"github.com/nitis/pulseWatch/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var dashboardCmd = &cobra.Command{
+	Use:   "dashboard",
+	Short: "Show the dashboard for the stored history without reading logs",
+	Long:  `Opens the dashboard over the database (--db-path) without reading any input. The windows, trends, and anomalies come from what earlier runs stored, or from a watch --headless writing the same database, and are refreshed every tick. Nothing is written to the database.`,
+	Args:  cobra.NoArgs,
+	Run:   runDashboardCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(dashboardCmd)
+}
+
+func runDashboardCmd(cmd *cobra.Command, args []string) {
+	ctx, cancel, err := runContext(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		cancel()
+	}()
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	dbPath, _ := cmd.Flags().GetString("db-path")
+	engine, err := analysis.NewViewEngine(dbPath, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening %s: %v\n", dbPath, err)
+		os.Exit(1)
+	}
+	setupCrashHandling(cfg, dbPath, engine, ingest.NewGuard(cfg.Ingest.MaxLineLength))
+
+	// No input: the entries channel stays open and empty, and the log pane
+	// shows nothing
+	metricsChan := engine.Start(make(chan types.LogEntry))
+	rawLines := make(chan string)
+	close(rawLines)
+	model := tui.NewModel(metricsChan, rawLines, false, engine, thresholdSaver(cmd), nil, engine, engine, engine)
+	p := tea.NewProgram(model, tea.WithAltScreen())
+
+	quitOnDone(ctx, p)
+	if err := runDashboard(p); err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting TUI: %v\n", err)
+		os.Exit(1)
+	}
+	printRunLimit(ctx)
+	fmt.Println("Pulsewatch shutting down.")
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/digest"
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var digestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "Send the configured email digest now",
+	Long:  `Builds the email digest configured under digest: in the config file for the period ending now and sends it. With --dry-run the HTML is printed instead, e.g. to preview a custom template. With --report it delivers the named scheduled report from reports: instead. Running pulsewatch watch with a digest schedule or reports sends them automatically.`,
+	Args:  cobra.NoArgs,
+	Run:   runDigest,
+}
+
+func init() {
+	digestCmd.Flags().Bool("dry-run", false, "Print the digest HTML instead of sending it")
+	digestCmd.Flags().String("report", "", "Deliver this scheduled report for the period ending now")
+	rootCmd.AddCommand(digestCmd)
+}
+
+// storageSource serves a read-only database as a digest.Source.
+type storageSource struct {
+	*storage.Storage
+}
+
+func (s storageSource) Rollups(from, to time.Time) ([]storage.Rollup, error) {
+	return s.GetRollupsBetween(from, to)
+}
+
+func (s storageSource) AnomalyHistory(f types.AnomalyFilter) ([]types.Anomaly, error) {
+	return s.QueryAnomalies(f)
+}
+
+func (s storageSource) TopErrors(from, to time.Time, limit int) ([]storage.ErrorCount, error) {
+	return s.TopErrorsBetween(from, to, limit)
+}
+
+func runDigest(cmd *cobra.Command, args []string) {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if name, _ := cmd.Flags().GetString("report"); name != "" {
+		runReportNow(cmd, cfg, name, dryRun)
+		return
+	}
+	if cfg.Digest.Schedule == "" && !dryRun {
+		fmt.Fprintln(os.Stderr, "No digest configured: set digest.schedule, from, to and smtp in the config file")
+		os.Exit(1)
+	}
+
+	dbPath, _ := cmd.Flags().GetString("db-path")
+	stor, err := storage.OpenReadOnly(dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer stor.Close()
+
+	scheduler, err := digest.NewScheduler(cfg.Digest, storageSource{stor})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if dryRun {
+		html, err := scheduler.Render(time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(html)
+		return
+	}
+	if err := scheduler.Send(time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error sending digest: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Digest sent to %d recipients\n", len(cfg.Digest.To))
+}
+
+// runReportNow delivers the scheduled report called name for the period
+// ending now, or prints its HTML with dryRun.
+func runReportNow(cmd *cobra.Command, cfg *config.Config, name string, dryRun bool) {
+	var report *config.ReportConfig
+	for i := range cfg.Reports {
+		if cfg.Reports[i].Name == name {
+			report = &cfg.Reports[i]
+		}
+	}
+	if report == nil {
+		fmt.Fprintf(os.Stderr, "No report named %q under reports: in the config file\n", name)
+		os.Exit(1)
+	}
+
+	dbPath, _ := cmd.Flags().GetString("db-path")
+	stor, err := storage.OpenReadOnly(dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer stor.Close()
+
+	scheduler, err := digest.NewReportScheduler(*report, cfg.Digest, storageSource{stor})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if dryRun {
+		html, err := scheduler.Render(time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(html)
+		return
+	}
+	if err := scheduler.Deliver(time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error delivering report %s: %v\n", name, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Report %s delivered\n", name)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nitis/pulseWatch/internal/analysis"
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// newRunEngine creates the engine of watch and replay on --db-path. With
+// --dry-run it runs on a throwaway database instead, without notifications,
+// forwarding, exports, or archival, tallying what they would have done; the
+// returned cleanup removes the database.
+func newRunEngine(cmd *cobra.Command, cfg *config.Config, initialScan bool) (*analysis.Engine, func()) {
+	dbPath, _ := cmd.Flags().GetString("db-path")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	cleanup := func() {}
+	engineCfg := cfg
+	if dryRun {
+		dir, err := os.MkdirTemp("", "pulsewatch-dry-run-")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating dry-run database: %v\n", err)
+			os.Exit(1)
+		}
+		dbPath = filepath.Join(dir, "pulsewatch.db")
+		cleanup = func() { os.RemoveAll(dir) }
+		engineCfg = analysis.DryRunConfig(cfg)
+	}
+
+	engine, err := analysis.NewEngine(dbPath, initialScan, engineCfg)
+	if err != nil {
+		cleanup()
+		fmt.Fprintf(os.Stderr, "Error creating engine: %v\n", err)
+		os.Exit(1)
+	}
+	if dryRun {
+		if err := engine.SetDryRun(cfg); err != nil {
+			cleanup()
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Dry run: analysing on a throwaway database; nothing is stored, notified, forwarded, or exported.")
+	}
+	return engine, cleanup
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// runHeadless consumes the engine's output without a dashboard until ctx is
+// cancelled or finite input has been fully reported; with once set, after
+// the first report. Anomalies are printed as they are recorded.
+func runHeadless(ctx context.Context, metricsCh <-chan types.Metrics, anomalies <-chan types.Anomaly, once bool) {
+	fmt.Println("Running headless. Press Ctrl+C to exit.")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case a := <-anomalies:
+			fmt.Printf("[%s] %s anomaly: %s: %s\n", a.Timestamp.Format("2006-01-02 15:04:05"), a.Severity, a.Type, a.Message)
+		case m, ok := <-metricsCh:
+			if !ok || m.Final || once {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/ingest"
+	"github.com/nitis/pulseWatch/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+// watchInputs starts every input of watch: each file, directory, or s3://
+// argument ("-" is stdin), and each of --journald, --docker, --syslog,
+// --gelf, --pulsewatch, --cloudwatch, and --gcp. Without any, stdin is read. Each input is named after its argument
+// or flag and has its continuation lines joined separately. pipedStdin
+// reports whether stdin is a pipe that will end; the caller must defer
+// finish, which saves --resume checkpoints, and give dirs the engine to
+// record processed files in.
+func watchInputs(ctx context.Context, cmd *cobra.Command, cfg *config.Config, args []string, guard *ingest.Guard, telemetry *ingest.Telemetry) (inputs []ingest.Input, sources []tui.SourceReporter, dirs []*ingest.DirIngester, pipedStdin bool, finish func()) {
+	initialScan, _ := cmd.Flags().GetBool("initial-scan")
+	var checkpoints *ingest.Checkpoints
+	var files []*ingest.FileIngester
+	finish = func() {
+		for _, f := range files {
+			f.SaveCheckpoint()
+		}
+	}
+	add := func(name string, ingester ingest.Ingester) {
+		records, err := ingest.Records(ctx, ingester)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting ingestion of %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		records = telemetry.Measure(ctx, name, ingester, records)
+		inputs = append(inputs, ingest.Input{Name: name, Records: assembleMultiline(cfg.Ingest.Multiline, records)})
+	}
+	fail := func(err error) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	flagged := false
+	for _, name := range []string{"journald", "docker", "syslog", "gelf", "pulsewatch", "cloudwatch", "gcp"} {
+		if on, _ := cmd.Flags().GetBool(name); on {
+			flagged = true
+		}
+	}
+	if len(args) == 0 && !flagged {
+		args = []string{"-"}
+	}
+	stdin := false
+	for _, arg := range args {
+		switch {
+		case arg == "-":
+			if stdin {
+				fail(fmt.Errorf("stdin can only be read once"))
+			}
+			stdin = true
+			// Piped input usually ends (e.g. cat access.log | pulsewatch watch);
+			// report on it then instead of waiting forever
+			if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice == 0 {
+				pipedStdin = true
+			}
+			fmt.Println("Watching stdin. Press Ctrl+C to exit.")
+			add("stdin", ingest.NewStdinIngester(guard))
+		case ingest.IsS3URL(arg):
+			s := cfg.Ingest.S3
+			s3Ingester, err := ingest.NewS3Ingester(arg, s.Region, s.Endpoint, initialScan, s.Since, s.PollInterval, guard)
+			if err != nil {
+				fail(err)
+			}
+			fmt.Printf("Watching %s. Press Ctrl+C to exit.\n", arg)
+			add(arg, s3Ingester)
+		case isDir(arg):
+			d := cfg.Ingest.Directory
+			dirIngester := ingest.NewDirIngester(arg, d.Pattern, d.Settle, d.PollInterval, initialScan, guard)
+			dirs = append(dirs, dirIngester)
+			fmt.Printf("Watching %s for new files. Press Ctrl+C to exit.\n", arg)
+			add(arg, dirIngester)
+		default:
+			fileIngester := ingest.NewFileIngester(arg, initialScan, guard)
+			if !initialScan {
+				sources = append(sources, fileIngester)
+			}
+			if dryRun, _ := cmd.Flags().GetBool("dry-run"); cfg.Ingest.Resume && !initialScan && !dryRun {
+				if checkpoints == nil {
+					checkpointPath := cfg.Ingest.CheckpointFile
+					if checkpointPath == "" {
+						dbPath, _ := cmd.Flags().GetString("db-path")
+						checkpointPath = dbPath + ".offsets"
+					}
+					var err error
+					if checkpoints, err = ingest.OpenCheckpoints(checkpointPath); err != nil {
+						fail(err)
+					}
+				}
+				fileIngester.SetCheckpoints(checkpoints)
+				files = append(files, fileIngester)
+			}
+			add(arg, fileIngester)
+		}
+	}
+
+	if on, _ := cmd.Flags().GetBool("journald"); on {
+		j := cfg.Ingest.Journald
+		fmt.Println("Watching the systemd journal. Press Ctrl+C to exit.")
+		add("journald", ingest.NewJournaldIngester(j.Units, j.Priority, j.Output == config.JournaldJSON, initialScan, guard))
+	}
+	if on, _ := cmd.Flags().GetBool("docker"); on {
+		d := cfg.Ingest.Docker
+		dockerIngester, err := ingest.NewDockerIngester(d.Host, d.Containers, d.Labels, initialScan, guard)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Println("Watching Docker containers. Press Ctrl+C to exit.")
+		add("docker", dockerIngester)
+	}
+	if on, _ := cmd.Flags().GetBool("syslog"); on {
+		if initialScan {
+			fail(fmt.Errorf("--initial-scan doesn't apply to --syslog, which only receives new messages"))
+		}
+		s := cfg.Ingest.Syslog
+		syslogIngester, err := ingest.NewSyslogIngester(s.Listen, s.TLS.Cert, s.TLS.Key, s.TLS.ClientCA, s.TLS.ClientNames, s.Insecure, guard)
+		if err != nil {
+			fail(err)
+		}
+		syslogIngester.SetLimits(s.MaxClients, s.IdleTimeout)
+		fmt.Printf("Receiving syslog on %s. Press Ctrl+C to exit.\n", s.Listen)
+		add("syslog", syslogIngester)
+	}
+	if on, _ := cmd.Flags().GetBool("gelf"); on {
+		if initialScan {
+			fail(fmt.Errorf("--initial-scan doesn't apply to --gelf, which only receives new messages"))
+		}
+		g := cfg.Ingest.GELF
+		fmt.Printf("Receiving GELF on udp %s. Press Ctrl+C to exit.\n", g.Listen)
+		add("gelf", ingest.NewGELFIngester(g.Listen, g.Output == config.GELFJSON, guard))
+	}
+	if on, _ := cmd.Flags().GetBool("pulsewatch"); on {
+		if initialScan {
+			fail(fmt.Errorf("--initial-scan doesn't apply to --pulsewatch, which only receives new entries"))
+		}
+		p := cfg.Ingest.Pulsewatch
+		streamIngester, err := ingest.NewStreamIngester(p.Listen, p.Token, p.TLS.Cert, p.TLS.Key, p.TLS.ClientCA, p.TLS.ClientNames, p.MaxConnections)
+		if err != nil {
+			fail(err)
+		}
+		if !streamIngester.Secured() && !loopbackAddr(p.Listen) {
+			fmt.Fprintf(os.Stderr, "Warning: %s accepts pulsewatch streams from anyone who can reach it; set ingest.pulsewatch.token or tls\n", p.Listen)
+		}
+		fmt.Printf("Receiving pulsewatch streams on tcp %s. Press Ctrl+C to exit.\n", p.Listen)
+		add("pulsewatch", streamIngester)
+	}
+	if on, _ := cmd.Flags().GetBool("cloudwatch"); on {
+		c := cfg.Ingest.CloudWatch
+		cloudWatchIngester, err := ingest.NewCloudWatchIngester(c.Group, c.StreamPrefix, c.FilterPattern, c.Region, c.Endpoint, initialScan, c.Since, c.PollInterval, guard)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("Watching CloudWatch log group %s. Press Ctrl+C to exit.\n", c.Group)
+		add("cloudwatch", cloudWatchIngester)
+	}
+	if on, _ := cmd.Flags().GetBool("gcp"); on {
+		g := cfg.Ingest.GCP
+		gcpIngester, err := ingest.NewGCPLoggingIngester(g.Project, g.Filter, g.Endpoint, initialScan, g.Since, g.PollInterval, guard)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("Watching Cloud Logging entries of project %s. Press Ctrl+C to exit.\n", gcpIngester.Project)
+		add("gcp", gcpIngester)
+	}
+	return inputs, sources, dirs, pipedStdin, finish
+}
+
+// loopbackAddr reports whether the listen address addr only accepts local
+// connections.
+func loopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
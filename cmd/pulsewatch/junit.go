@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// reportCheck is one pass/fail gate evaluated over a report.
+type reportCheck struct {
+	suite   string
+	name    string
+	failure string // Empty when the check passed
+	output  string // Details shown either way
+}
+
+// reportGates are the optional thresholds a report is checked against.
+type reportGates struct {
+	sloTarget    float64       // Availability target in percent
+	maxP95       time.Duration // 0 disables the check
+	maxP99       time.Duration
+	maxErrorRate float64 // Percent; 0 disables the check
+	failOn       string  // Lowest anomaly severity that fails a check
+}
+
+// evaluateChecks maps the SLO, latency and error rate thresholds, and the
+// anomalies of the period, to checks. Each anomaly type is one check that
+// fails if it fired at gates.failOn severity or worse.
+func evaluateChecks(d reportData, gates reportGates) []reportCheck {
+	s := d.summary()
+	var checks []reportCheck
+
+	availability := 100 - s.errorRate
+	c := reportCheck{suite: "slo", name: "availability", output: fmt.Sprintf("%.3f%% of %d requests succeeded (target %g%%)", availability, s.requests, gates.sloTarget)}
+	if s.requests > 0 && availability < gates.sloTarget {
+		c.failure = fmt.Sprintf("availability %.3f%% is below the %g%% target", availability, gates.sloTarget)
+	}
+	checks = append(checks, c)
+
+	latencyCheck := func(name string, worst, limit time.Duration) {
+		if limit == 0 {
+			return
+		}
+		c := reportCheck{suite: "thresholds", name: name, output: fmt.Sprintf("worst minute %v (limit %v)", worst, limit)}
+		if worst > limit {
+			c.failure = fmt.Sprintf("%s %v exceeds %v", name, worst, limit)
+		}
+		checks = append(checks, c)
+	}
+	latencyCheck("p95 latency", s.p95, gates.maxP95)
+	latencyCheck("p99 latency", s.p99, gates.maxP99)
+	if gates.maxErrorRate > 0 {
+		c := reportCheck{suite: "thresholds", name: "error rate", output: fmt.Sprintf("%.2f%% (limit %g%%)", s.errorRate, gates.maxErrorRate)}
+		if s.errorRate > gates.maxErrorRate {
+			c.failure = fmt.Sprintf("error rate %.2f%% exceeds %g%%", s.errorRate, gates.maxErrorRate)
+		}
+		checks = append(checks, c)
+	}
+
+	byType := make(map[string][]types.Anomaly)
+	for _, a := range d.anomalies {
+		byType[a.Type] = append(byType[a.Type], a)
+	}
+	names := make([]string, 0, len(byType))
+	for name := range byType {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var lines []string
+		failing := 0
+		for _, a := range byType[name] {
+			lines = append(lines, fmt.Sprintf("[%s] %s: %s", a.Timestamp.Format("2006-01-02 15:04:05"), a.Severity, a.Message))
+			if types.SeverityRank(a.Severity) <= types.SeverityRank(gates.failOn) {
+				failing++
+			}
+		}
+		c := reportCheck{suite: "anomalies", name: name, output: strings.Join(lines, "\n")}
+		if failing > 0 {
+			c.failure = fmt.Sprintf("fired %d times at %s severity or worse", failing, gates.failOn)
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit writes checks as a JUnit XML report, one test suite per check
+// suite, so CI systems show each gate as a test case.
+func writeJUnit(w io.Writer, checks []reportCheck, at time.Time) error {
+	root := junitTestSuites{Name: "pulsewatch"}
+	index := make(map[string]int)
+	for _, c := range checks {
+		i, ok := index[c.suite]
+		if !ok {
+			i = len(root.Suites)
+			index[c.suite] = i
+			root.Suites = append(root.Suites, junitTestSuite{Name: "pulsewatch." + c.suite, Timestamp: at.Format("2006-01-02T15:04:05")})
+		}
+		suite := &root.Suites[i]
+		tc := junitTestCase{Name: c.name, Classname: "pulsewatch." + c.suite, SystemOut: c.output}
+		if c.failure != "" {
+			tc.Failure = &junitFailure{Message: c.failure, Type: c.suite, Text: c.output}
+			suite.Failures++
+			root.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+		suite.Tests++
+		root.Tests++
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
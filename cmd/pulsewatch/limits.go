@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+
+	"github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	for _, c := range []*cobra.Command{watchCmd, replayCmd} {
+		c.Flags().Int("cpu-limit", 0, "Use at most this many CPU cores (GOMAXPROCS); 0 uses all")
+		c.Flags().String("max-disk", "", "Keep the database below this size, e.g. 2GB, by pruning the oldest raw entries")
+		c.Flags().Duration("run-for", 0, "Shut down cleanly after this long, e.g. 24h; 0 runs until interrupted")
+	}
+	dashboardCmd.Flags().Duration("run-for", 0, "Close the dashboard after this long, e.g. 1h; 0 runs until interrupted")
+}
+
+// runContext applies --cpu-limit and returns the session's context, which
+// is cancelled once --run-for has passed.
+func runContext(cmd *cobra.Command) (context.Context, context.CancelFunc, error) {
+	cpus, _ := cmd.Flags().GetInt("cpu-limit")
+	if cpus < 0 {
+		return nil, nil, fmt.Errorf("--cpu-limit must not be negative")
+	}
+	if cpus > 0 {
+		runtime.GOMAXPROCS(cpus)
+	}
+	runFor, _ := cmd.Flags().GetDuration("run-for")
+	if runFor < 0 {
+		return nil, nil, fmt.Errorf("--run-for must not be negative")
+	}
+	if runFor == 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		return ctx, cancel, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), runFor)
+	return ctx, cancel, nil
+}
+
+// quitOnDone closes the dashboard when ctx ends, e.g. on SIGTERM or when
+// --run-for has passed.
+func quitOnDone(ctx context.Context, p *tea.Program) {
+	go func() {
+		<-ctx.Done()
+		p.Quit()
+	}()
+}
+
+// printRunLimit notes a session ended by --run-for.
+func printRunLimit(ctx context.Context) {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		fmt.Println("The --run-for time limit was reached.")
+	}
+}
@@ -5,12 +5,19 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"regexp"
 	"sort"
+	"strings"
 	"syscall"
 	"time"
 
-	"github.com/nitis/pulseWatch/internal/analysis"
+	"github.com/nitis/pulseWatch/internal/api"
+	"github.com/nitis/pulseWatch/internal/bus"
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/control"
+	"github.com/nitis/pulseWatch/internal/digest"
 	"github.com/nitis/pulseWatch/internal/ingest"
+	"github.com/nitis/pulseWatch/internal/locale"
 	"github.com/nitis/pulseWatch/internal/parser"
 	"github.com/nitis/pulseWatch/internal/replay"
 	"github.com/nitis/pulseWatch/internal/tui"
@@ -24,10 +31,16 @@ func printReport(metrics types.Metrics) {
 		fmt.Println("Historical Report")
 		fmt.Println()
 
-		fmt.Printf("Total Requests: %d | Errors: %.2f%%\n", wm.TotalRequests, wm.ErrorRate)
+		fmt.Printf("Total Requests: %s | Errors: %s\n", locale.Int(int64(wm.TotalRequests)), locale.Percent(wm.ErrorRate, 2))
 		fmt.Println()
 
-		fmt.Printf("P50: %v | P90: %v | P95: %v | P99: %v\n", wm.P50Latency.Truncate(time.Millisecond), wm.P90Latency.Truncate(time.Millisecond), wm.P95Latency.Truncate(time.Millisecond), wm.P99Latency.Truncate(time.Millisecond))
+		fmt.Println(types.FormatPercentiles(wm.Percentiles, " | "))
+		if t := wm.Timing; t.Samples > 0 {
+			fmt.Printf("Queue P50/P95: %s/%s | Service P50/P95: %s/%s | Queueing: %s of time\n", locale.Duration(t.QueueP50), locale.Duration(t.QueueP95), locale.Duration(t.ServiceP50), locale.Duration(t.ServiceP95), locale.Percent(t.QueueShare, 0))
+		}
+		for _, c := range wm.Breakdown.Components {
+			fmt.Printf("%s: avg %s, p95 %s, %s of component time\n", c.Component, locale.Duration(c.Avg), locale.Duration(c.P95), locale.Percent(c.Share, 1))
+		}
 		fmt.Println()
 
 		if len(wm.TopEndpoints) > 0 {
@@ -42,21 +55,154 @@ func printReport(metrics types.Metrics) {
 			}
 			sort.Slice(ec, func(i, j int) bool { return ec[i].count > ec[j].count })
 			for _, e := range ec {
-				fmt.Printf("%s: %d\n", e.endpoint, e.count)
+				fmt.Printf("%s: %s\n", e.endpoint, locale.Int(int64(e.count)))
+			}
+			fmt.Println()
+		}
+
+		if metrics.GroupBy != "" && len(wm.TopGroups) > 0 {
+			fmt.Printf("Top groups by %s:\n", metrics.GroupBy)
+			for _, g := range wm.TopGroups {
+				fmt.Printf("%s: %s\n", g.Key, locale.Int(int64(g.Count)))
+			}
+			fmt.Println()
+		}
+
+		if len(wm.Methods) > 0 {
+			fmt.Println("Methods:")
+			requests := make(map[string]int, len(wm.Methods))
+			for method, ms := range wm.Methods {
+				requests[method] = ms.Requests
+			}
+			for _, method := range byCount(requests) {
+				ms := wm.Methods[method]
+				fmt.Printf("%s: %s requests, %s errors\n", method, locale.Int(int64(ms.Requests)), locale.Percent(ms.ErrorRate(), 2))
+			}
+			fmt.Println()
+		}
+
+		if len(wm.Protocols) > 0 || len(wm.TLSVersions) > 0 {
+			fmt.Println("Protocols:")
+			for _, protocol := range byCount(wm.Protocols) {
+				fmt.Printf("%s: %s\n", protocol, locale.Int(int64(wm.Protocols[protocol])))
+			}
+			for _, version := range byCount(wm.TLSVersions) {
+				fmt.Printf("%s: %s\n", version, locale.Int(int64(wm.TLSVersions[version])))
+			}
+			fmt.Println()
+		}
+
+		if len(wm.GRPCStatuses) > 0 {
+			fmt.Println("gRPC:")
+			for _, code := range byCount(wm.GRPCStatuses) {
+				fmt.Printf("%s: %s\n", code, locale.Int(int64(wm.GRPCStatuses[code])))
+			}
+			calls := make(map[string]int, len(wm.GRPCMethods))
+			for method, gm := range wm.GRPCMethods {
+				calls[method] = gm.Calls
+			}
+			for _, method := range byCount(calls) {
+				gm := wm.GRPCMethods[method]
+				fmt.Printf("%s: %s calls, %s errors\n", method, locale.Int(int64(gm.Calls)), locale.Percent(gm.ErrorRate(), 2))
+			}
+			fmt.Println()
+		}
+
+		if len(wm.Tenants) > 0 {
+			fmt.Println("Top Tenants:")
+			for _, tenant := range byRequests(wm.Tenants) {
+				t := wm.Tenants[tenant]
+				fmt.Printf("%s: %s requests (%s), %s errors, avg %s, p95 %s\n", tenant, locale.Int(int64(t.Requests)), locale.Percent(t.Share, 1), locale.Percent(t.ErrorRate, 2), locale.Duration(t.AvgLatency), locale.Duration(t.P95Latency))
+			}
+			fmt.Println()
+		}
+
+		if len(wm.Versions) > 0 {
+			fmt.Println("Versions:")
+			for _, version := range byRequests(wm.Versions) {
+				v := wm.Versions[version]
+				fmt.Printf("%s: %s requests (%s), %s errors, avg %s, p95 %s\n", version, locale.Int(int64(v.Requests)), locale.Percent(v.Share, 1), locale.Percent(v.ErrorRate, 2), locale.Duration(v.AvgLatency), locale.Duration(v.P95Latency))
+			}
+			fmt.Println()
+		}
+
+		if len(wm.Operations) > 0 {
+			fmt.Println("Top GraphQL Operations:")
+			for _, operation := range byRequests(wm.Operations) {
+				o := wm.Operations[operation]
+				fmt.Printf("%s: %s requests (%s), %s errors, avg %s, p95 %s\n", operation, locale.Int(int64(o.Requests)), locale.Percent(o.Share, 1), locale.Percent(o.ErrorRate, 2), locale.Duration(o.AvgLatency), locale.Duration(o.P95Latency))
+			}
+			fmt.Println()
+		}
+
+		if wm.Sessions.Sessions > 0 {
+			fmt.Printf("Sessions: %s, %s requests/session\n", locale.Int(int64(wm.Sessions.Sessions)), locale.Float(wm.Sessions.RequestsPerSession, 1))
+			for _, t := range wm.Sessions.TopTransitions {
+				fmt.Printf("%s -> %s: %s\n", t.From, t.To, locale.Int(int64(t.Count)))
+			}
+			fmt.Println()
+		}
+
+		if wm.Retries.Retries > 0 {
+			fmt.Printf("Likely retries: %s of %s requests, amplification %sx\n", locale.Int(int64(wm.Retries.Retries)), locale.Int(int64(wm.Retries.Requests)), locale.Float(wm.Retries.Amplification, 2))
+			for _, r := range wm.Retries.TopEndpoints {
+				fmt.Printf("%s: %s retries, %sx\n", r.Endpoint, locale.Int(int64(r.Retries)), locale.Float(r.Amplification, 2))
+			}
+			fmt.Println()
+		}
+
+		if len(wm.ErrorCategories) > 0 {
+			fmt.Println("Error categories:")
+			for _, c := range wm.ErrorCategories {
+				fmt.Printf("%s: %s errors (%s of requests)\n", c.Name, locale.Int(int64(c.Errors)), locale.Percent(c.Rate, 2))
+			}
+			fmt.Println()
+		}
+
+		if wm.Cache.Lookups > 0 {
+			fmt.Printf("Cache hit ratio: %s (%s/%s)\n", locale.Percent(wm.Cache.HitRatio(), 1), locale.Int(int64(wm.Cache.Hits)), locale.Int(int64(wm.Cache.Lookups)))
+			lookups := make(map[string]int, len(wm.EndpointCache))
+			for endpoint, c := range wm.EndpointCache {
+				lookups[endpoint] = c.Lookups
+			}
+			for _, endpoint := range byCount(lookups) {
+				c := wm.EndpointCache[endpoint]
+				fmt.Printf("%s: %s hit (%s/%s)\n", endpoint, locale.Percent(c.HitRatio(), 1), locale.Int(int64(c.Hits)), locale.Int(int64(c.Lookups)))
+			}
+			fmt.Println()
+		}
+
+		if len(wm.EndpointPercentiles) > 0 {
+			fmt.Println("Endpoint Latency:")
+			endpoints := make([]string, 0, len(wm.EndpointPercentiles))
+			for endpoint := range wm.EndpointPercentiles {
+				endpoints = append(endpoints, endpoint)
+			}
+			sort.Strings(endpoints)
+			for _, endpoint := range endpoints {
+				fmt.Printf("%s: %s\n", endpoint, types.FormatPercentiles(wm.EndpointPercentiles[endpoint], " | "))
 			}
 			fmt.Println()
 		}
 
 		fmt.Println("Status Codes:")
 		for code, count := range wm.StatusCodeDistribution {
-			fmt.Printf("%s: %d\n", code, count)
+			fmt.Printf("%s: %s\n", code, locale.Int(int64(count)))
 		}
 		fmt.Println()
 
 		if len(wm.Custom) > 0 {
 			fmt.Println("Custom Metrics:")
 			for name, value := range wm.Custom {
-				fmt.Printf("%s: %d\n", name, value)
+				fmt.Printf("%s: %s\n", name, locale.Int(int64(value)))
+			}
+			fmt.Println()
+		}
+
+		for _, l := range metrics.RateLimits {
+			fmt.Printf("Rate limit %s (%s/s, burst %s per %s): %s of %s requests limited (%s)\n", l.Name, locale.Float(l.Rate, 2), locale.Int(int64(l.Burst)), l.Key, locale.Int(int64(l.Limited)), locale.Int(int64(l.Requests)), locale.Percent(l.LimitedPct, 2))
+			for _, k := range l.TopKeys {
+				fmt.Printf("%s: %s of %s limited\n", k.Key, locale.Int(int64(k.Limited)), locale.Int(int64(k.Requests)))
 			}
 			fmt.Println()
 		}
@@ -81,10 +227,9 @@ var rootCmd = &cobra.Command{
 }
 
 var watchCmd = &cobra.Command{
-	Use:   "watch [file]",
+	Use:   "watch [file...]",
 	Short: "Watch a log file in real-time",
-	Long:  `Tails a log file and displays a live dashboard of metrics and anomalies. If no file is specified, it reads from stdin.`,
-	Args:  cobra.MaximumNArgs(1),
+	Long:  `Tails log files and displays a live dashboard of metrics and anomalies. If no file or other input is specified, it reads from stdin; "-" reads stdin alongside other inputs. An s3://bucket/prefix location reads the log objects under that prefix, polling for new ones. Several inputs are read at once, each entry tagged with the input it came from.`,
 	Run:   runWatch,
 }
 
@@ -96,9 +241,171 @@ var replayCmd = &cobra.Command{
 	Run:   runReplay,
 }
 
+// byCount returns the keys of counts, most frequent first and ties by key.
+func byCount(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// byRequests returns the keys of stats, busiest first and ties by key, so
+// breakdowns print in a stable order.
+func byRequests(stats map[string]types.RequestStats) []string {
+	keys := make([]string, 0, len(stats))
+	for k := range stats {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := stats[keys[i]].Requests, stats[keys[j]].Requests
+		if a != b {
+			return a > b
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	path, _ := cmd.Flags().GetString("config")
+	preset, _ := cmd.Flags().GetString("preset")
+	cfg, err := config.LoadWithPreset(path, preset)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refresh flags override the config file
+	if cmd.Flags().Changed("tick") {
+		cfg.Refresh.Tick, _ = cmd.Flags().GetDuration("tick")
+		if cfg.Refresh.Tick <= 0 {
+			return nil, fmt.Errorf("--tick must be positive")
+		}
+	}
+	if cmd.Flags().Changed("adaptive-tick") {
+		cfg.Refresh.Adaptive, _ = cmd.Flags().GetBool("adaptive-tick")
+	}
+	if cmd.Flags().Changed("multiline-start") {
+		cfg.Ingest.Multiline.Start, _ = cmd.Flags().GetString("multiline-start")
+		if _, err := regexp.Compile(cfg.Ingest.Multiline.Start); err != nil {
+			return nil, fmt.Errorf("--multiline-start: %w", err)
+		}
+	}
+	if cmd.Flags().Changed("sample") {
+		cfg.Display.Sample, _ = cmd.Flags().GetBool("sample")
+	}
+	if cmd.Flags().Changed("accessible") {
+		cfg.Display.Accessible, _ = cmd.Flags().GetBool("accessible")
+	}
+	if cmd.Flags().Changed("resume") {
+		cfg.Ingest.Resume, _ = cmd.Flags().GetBool("resume")
+	}
+	if cmd.Flags().Changed("max-disk") {
+		cfg.Storage.MaxSize, _ = cmd.Flags().GetString("max-disk")
+		if _, err := cfg.Storage.MaxSizeBytes(); err != nil {
+			return nil, fmt.Errorf("--max-disk: %w", err)
+		}
+	}
+	if cmd.Flags().Changed("listen") {
+		cfg.API.Listen, _ = cmd.Flags().GetString("listen")
+	}
+	if cmd.Flags().Changed("unit") {
+		cfg.Ingest.Journald.Units, _ = cmd.Flags().GetStringSlice("unit")
+	}
+	if cmd.Flags().Changed("container") {
+		cfg.Ingest.Docker.Containers, _ = cmd.Flags().GetStringSlice("container")
+	}
+	if cmd.Flags().Changed("container-label") {
+		cfg.Ingest.Docker.Labels, _ = cmd.Flags().GetStringSlice("container-label")
+	}
+	if cmd.Flags().Changed("syslog-listen") {
+		cfg.Ingest.Syslog.Listen, _ = cmd.Flags().GetString("syslog-listen")
+	}
+	if cmd.Flags().Changed("gelf-listen") {
+		cfg.Ingest.GELF.Listen, _ = cmd.Flags().GetString("gelf-listen")
+	}
+	if cmd.Flags().Changed("pulsewatch-listen") {
+		cfg.Ingest.Pulsewatch.Listen, _ = cmd.Flags().GetString("pulsewatch-listen")
+	}
+	if cmd.Flags().Changed("log-group") {
+		cfg.Ingest.CloudWatch.Group, _ = cmd.Flags().GetString("log-group")
+	}
+	if cmd.Flags().Changed("stream-prefix") {
+		cfg.Ingest.CloudWatch.StreamPrefix, _ = cmd.Flags().GetString("stream-prefix")
+	}
+	if cmd.Flags().Changed("gcp-project") {
+		cfg.Ingest.GCP.Project, _ = cmd.Flags().GetString("gcp-project")
+	}
+	if cmd.Flags().Changed("gcp-filter") {
+		cfg.Ingest.GCP.Filter, _ = cmd.Flags().GetString("gcp-filter")
+	}
+	if cmd.Flags().Changed("region") {
+		cfg.Ingest.CloudWatch.Region, _ = cmd.Flags().GetString("region")
+	}
+	if cmd.Flags().Changed("priority") {
+		cfg.Ingest.Journald.Priority, _ = cmd.Flags().GetString("priority")
+		if err := cfg.Ingest.Journald.Validate(); err != nil {
+			return nil, fmt.Errorf("--priority: %w", err)
+		}
+	}
+	l, err := cfg.Locale.Resolve()
+	if err != nil {
+		return nil, fmt.Errorf("locale: %w", err)
+	}
+	locale.Set(l)
+	return cfg, nil
+}
+
+// thresholdSaver writes thresholds adjusted in the TUI back to the --config file.
+func thresholdSaver(cmd *cobra.Command) func(types.Thresholds) error {
+	path, _ := cmd.Flags().GetString("config")
+	return func(t types.Thresholds) error {
+		return config.SaveThresholds(path, t)
+	}
+}
+
 func init() {
+	rootCmd.PersistentFlags().StringP("config", "c", "", "Config file (YAML) for custom metrics and detection settings")
+	rootCmd.PersistentFlags().String("preset", "", "Bundled settings for a technology, applied under --config: "+strings.Join(config.Presets(), ", "))
+	rootCmd.PersistentFlags().String("db-path", "pulsewatch.db", "SQLite database file; use a separate path to run several instances in one directory")
+	rootCmd.PersistentFlags().Duration("tick", time.Second, "How often metrics are recomputed and the dashboard refreshed")
+	rootCmd.PersistentFlags().String("listen", "", "Serve the HTTP API (Grafana JSON datasource) on this address, e.g. :9100")
+	rootCmd.PersistentFlags().Bool("adaptive-tick", false, "Slow the refresh under very high ingest rates to prioritize processing")
 	replayCmd.Flags().Float64P("speed", "s", 1.0, "Speed multiplier for replaying logs")
 	watchCmd.Flags().BoolP("initial-scan", "i", false, "Process existing logs before tailing for new ones")
+	watchCmd.Flags().Bool("resume", false, "Continue a tailed file from where the last watch stopped instead of its end")
+	watchCmd.Flags().Bool("headless", false, "Run without the dashboard, e.g. as a daemon serving the API and sending digests")
+	watchCmd.Flags().Bool("journald", false, "Read the systemd journal (via journalctl) instead of a file or stdin")
+	watchCmd.Flags().StringSlice("unit", nil, "With --journald, only read these systemd units (repeatable)")
+	watchCmd.Flags().String("priority", "", "With --journald, only read messages at least this important, e.g. warning or err..warning")
+	watchCmd.Flags().Bool("docker", false, "Read container logs from the Docker API instead of a file or stdin")
+	watchCmd.Flags().StringSlice("container", nil, "With --docker, read containers whose name matches (repeatable)")
+	watchCmd.Flags().StringSlice("container-label", nil, "With --docker, read containers with this label, key or key=value (repeatable)")
+	watchCmd.Flags().Bool("syslog", false, "Receive syslog over TLS (RFC 5425) instead of reading a file or stdin")
+	watchCmd.Flags().String("syslog-listen", "", "With --syslog, the address to listen on (default: :6514)")
+	watchCmd.Flags().Bool("gelf", false, "Receive GELF messages over UDP instead of reading a file or stdin")
+	watchCmd.Flags().String("gelf-listen", "", "With --gelf, the UDP address to listen on (default: :12201)")
+	watchCmd.Flags().Bool("pulsewatch", false, "Receive parsed entries forwarded by other pulsewatch instances instead of reading a file or stdin")
+	watchCmd.Flags().String("pulsewatch-listen", "", "With --pulsewatch, the TCP address to listen on (default: 127.0.0.1:9470)")
+	watchCmd.Flags().Bool("cloudwatch", false, "Read an AWS CloudWatch Logs group instead of a file or stdin")
+	watchCmd.Flags().String("log-group", "", "With --cloudwatch, the log group to read")
+	watchCmd.Flags().String("stream-prefix", "", "With --cloudwatch, only read log streams whose name starts with this")
+	watchCmd.Flags().String("region", "", "With --cloudwatch, the AWS region (default: $AWS_REGION, then us-east-1)")
+	watchCmd.Flags().Bool("gcp", false, "Read Google Cloud Logging entries instead of a file or stdin")
+	watchCmd.Flags().String("gcp-project", "", "With --gcp, the project to read (default: the credentials' project)")
+	watchCmd.Flags().String("gcp-filter", "", "With --gcp, only read entries matching this Logging query, e.g. 'resource.type=\"cloud_run_revision\"'")
+	for _, c := range []*cobra.Command{watchCmd, replayCmd} {
+		c.Flags().Bool("sample", false, "Show one line per log pattern every display.sample_interval instead of every line")
+		c.Flags().Bool("dry-run", false, "Ingest, parse, and analyse on a throwaway database without storing, notifying, forwarding, or exporting, then print what would have been done")
+		c.Flags().String("multiline-start", "", "Regex matching the first line of a record; other lines are joined onto the record before them, e.g. for stack traces")
+		c.Flags().String("baseline", "", "Database of a saved session, e.g. an earlier load test, to show each dashboard metric's change from")
+	}
 	rootCmd.AddCommand(watchCmd)
 	rootCmd.AddCommand(replayCmd)
 }
@@ -111,7 +418,11 @@ func main() {
 }
 
 func runWatch(cmd *cobra.Command, args []string) {
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel, err := runContext(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 	defer cancel()
 
 	// Set up signal handling for graceful shutdown
@@ -122,83 +433,145 @@ func runWatch(cmd *cobra.Command, args []string) {
 		cancel()
 	}()
 
-	var ingester ingest.Ingester
-	if len(args) > 0 {
-		initialScan, _ := cmd.Flags().GetBool("initial-scan")
-		ingester = ingest.NewFileIngester(args[0], initialScan)
-	} else {
-		fmt.Println("Watching stdin. Press Ctrl+C to exit.")
-		ingester = ingest.NewStdinIngester()
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
 	}
+	guard := ingest.NewGuard(cfg.Ingest.MaxLineLength)
+	telemetry := ingest.NewTelemetry(time.Second)
+	inputs, sources, dirs, pipedStdin, finishInputs := watchInputs(ctx, cmd, cfg, args, guard, telemetry)
+	if cfg.Export.RemoteWrite.Source == "" {
+		names := make([]string, len(inputs))
+		for i, in := range inputs {
+			names[i] = in.Name
+		}
+		cfg.Export.RemoteWrite.Source = strings.Join(names, ",")
+	}
+	gate := ingest.NewGate(cfg.Ingest.PauseBuffer)
+	records := gate.Run(ctx, ingest.Merge(inputs))
 
-	rawLogChan, err := ingester.Ingest(ctx)
+	multiParser, err := parser.NewChain(cfg.Parsers.Chain(), cfg.Parsers.Custom...)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error starting ingestion: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error creating parsers: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Fan-out rawLogChan to separate channels for parser and TUI
-	rawLogChanForParser := make(chan string, 1000)
-	rawLogChanForTUI := make(chan string, 1000)
-
-	go func() {
-		defer close(rawLogChanForParser)
-		defer close(rawLogChanForTUI)
-		for line := range rawLogChan {
-			select {
-			case rawLogChanForParser <- line:
-			case <-ctx.Done():
-				return
-			}
-			select {
-			case rawLogChanForTUI <- line:
-			case <-ctx.Done():
-				return
+	initialScan, _ := cmd.Flags().GetBool("initial-scan")
+	dbPath, _ := cmd.Flags().GetString("db-path")
+	engine, cleanup := newRunEngine(cmd, cfg, initialScan)
+	run := &session{ctx: ctx, cancel: cancel, engine: engine, guard: guard, cleanup: cleanup}
+	run.onShutdown(finishInputs)
+	for _, d := range dirs {
+		d.SetTracker(engine)
+	}
+	engine.SetInputHealth(telemetry.Updates())
+	engine.SetReportOnEOF(pipedStdin)
+	setupCrashHandling(cfg, dbPath, engine, guard)
+	engine.StartProbes()
+	if cfg.API.Listen != "" {
+		server := api.NewServer(cfg.API.Listen, cfg.API.EventsToken, engine)
+		if err := server.Start(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting API server: %v\n", err)
+			os.Exit(1)
+		}
+		run.onShutdown(func() { server.Shutdown(context.Background()) })
+	}
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); cfg.Digest.Schedule != "" && !dryRun {
+		scheduler, err := digest.NewScheduler(cfg.Digest, engine)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		go scheduler.Run(ctx)
+	}
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); !dryRun {
+		for _, r := range cfg.Reports {
+			scheduler, err := digest.NewReportScheduler(r, cfg.Digest, engine)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
 			}
+			go scheduler.Run(ctx)
 		}
-	}()
-
-	multiParser := parser.NewMultiParser(
-		&parser.JSONParser{},
-		parser.NewNginxParser(),
-		&parser.LineParser{},
-	)
+	}
 
-	logEntryChan := make(chan types.LogEntry, 1000)
-	go func() {
-		defer close(logEntryChan)
-		for line := range rawLogChanForParser {
-			if entry, ok := multiParser.Parse(line); ok {
-				logEntryChan <- entry
+	pipeline := bus.New()
+	engine.SetAnomalyTopic(pipeline.Anomalies)
+	engine.SetPipeline(pipeline)
+	metricsChan := pipeline.Metrics.Subscribe("dashboard", 0, bus.Block)
+	if headless, _ := cmd.Flags().GetBool("headless"); headless {
+		if !cfg.Control.Disable {
+			ctl := control.NewServer(controlSocket(cmd, cfg), engine, func() (string, error) {
+				next, err := loadConfig(cmd)
+				if err != nil {
+					return "", err
+				}
+				engine.SetThresholds(next.Thresholds())
+				return "Applied the detection thresholds and SLO target; other settings take effect after a restart", nil
+			})
+			ctl.SetIngestion(gate)
+			if err := ctl.Start(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error starting control socket: %v\n", err)
+				os.Exit(1)
 			}
+			run.onShutdown(func() { ctl.Shutdown(context.Background()) })
 		}
-	}()
-
-	initialScan, _ := cmd.Flags().GetBool("initial-scan")
-	engine, err := analysis.NewEngine("pulsewatch.db", initialScan, []types.CustomMetric{})
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating engine: %v\n", err)
-		os.Exit(1)
+		anomalies := pipeline.Anomalies.Subscribe("alerts", cfg.Pipeline.Alerts.Buffer, cfg.Pipeline.Alerts.BusPolicy())
+		startPipeline(ctx, cfg.Pipeline, pipeline, records, multiParser, engine, len(inputs) > 1)
+		runHeadless(ctx, metricsChan, anomalies, initialScan)
+		shutdown(run)
+		return
+	}
+	if cfg.Display.Accessible {
+		anomalies := pipeline.Anomalies.Subscribe("alerts", cfg.Pipeline.Alerts.Buffer, cfg.Pipeline.Alerts.BusPolicy())
+		startPipeline(ctx, cfg.Pipeline, pipeline, records, multiParser, engine, len(inputs) > 1)
+		runAccessible(ctx, metricsChan, anomalies, initialScan)
+		shutdown(run)
+		return
 	}
-	metricsChan := engine.Start(logEntryChan)
 
-	model := tui.NewModel(metricsChan, rawLogChanForTUI, initialScan)
+	rawLines := pipeline.RawLines.Subscribe("dashboard", cfg.Pipeline.Dashboard.Buffer, cfg.Pipeline.Dashboard.BusPolicy())
+	alerts := pipeline.Anomalies.Subscribe("alerts", cfg.Pipeline.Alerts.Buffer, cfg.Pipeline.Alerts.BusPolicy())
+	startPipeline(ctx, cfg.Pipeline, pipeline, records, multiParser, engine, len(inputs) > 1)
+	model := tui.NewModel(metricsChan, rawLines, initialScan, engine, thresholdSaver(cmd), sources, engine, engine, engine)
+	model.SetAlerts(alerts)
+	model.SetSampling(cfg.Display.SampleInterval, cfg.Display.Sample)
+	applyBaseline(cmd, &model)
+	model.SetIngestion(gate)
+	model.SetAnnotator(engine)
 	var opts []tea.ProgramOption
-	if !initialScan {
+	if pipedStdin {
+		// Keys are read from the terminal since stdin carries the logs; without
+		// one (e.g. in CI) run without key input. The final report is printed
+		// to the normal screen so it stays visible.
+		if tty, err := os.Open("/dev/tty"); err == nil {
+			tty.Close()
+		} else {
+			opts = append(opts, tea.WithInput(nil))
+		}
+	} else if !initialScan {
 		opts = append(opts, tea.WithAltScreen())
 	}
 	p := tea.NewProgram(model, opts...)
 
-	if err := p.Start(); err != nil {
+	started := time.Now()
+	quitOnDone(ctx, p)
+	if err := runDashboard(p); err != nil {
 		fmt.Fprintf(os.Stderr, "Error starting TUI: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Println("Pulsewatch shutting down.")
+	run.report = func() { printSessionAnnotations(engine, started) }
+	shutdown(run)
 }
 
 func runReplay(cmd *cobra.Command, args []string) {
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel, err := runContext(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 	defer cancel()
 
 	// Set up signal handling for graceful shutdown
@@ -209,67 +582,71 @@ func runReplay(cmd *cobra.Command, args []string) {
 		cancel()
 	}()
 
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	guard := ingest.NewGuard(cfg.Ingest.MaxLineLength)
+	if cfg.Export.RemoteWrite.Source == "" {
+		cfg.Export.RemoteWrite.Source = args[0]
+	}
+
 	speed, _ := cmd.Flags().GetFloat64("speed")
-	replayer := replay.NewReplayer(args[0], speed)
+	replayer := replay.NewReplayer(args[0], speed, guard)
 
 	rawLogChan, err := replayer.Replay(ctx)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error starting replay: %v\n", err)
 		os.Exit(1)
 	}
+	records := ingest.Merge([]ingest.Input{{Name: args[0], Records: assembleMultiline(cfg.Ingest.Multiline, ingest.LineRecords(rawLogChan))}})
 
-	// Fan-out rawLogChan to separate channels for parser and TUI
-	rawLogChanForParser := make(chan string, 1000)
-	rawLogChanForTUI := make(chan string, 1000)
-
-	go func() {
-		defer close(rawLogChanForParser)
-		defer close(rawLogChanForTUI)
-		for line := range rawLogChan {
-			select {
-			case rawLogChanForParser <- line:
-			case <-ctx.Done():
-				return
-			}
-			select {
-			case rawLogChanForTUI <- line:
-			case <-ctx.Done():
-				return
-			}
-		}
-	}()
-
-	multiParser := parser.NewMultiParser(
-		&parser.JSONParser{},
-		parser.NewNginxParser(),
-		&parser.LineParser{},
-	)
-
-	logEntryChan := make(chan types.LogEntry, 1000)
-	go func() {
-		defer close(logEntryChan)
-		for line := range rawLogChanForParser {
-			if entry, ok := multiParser.Parse(line); ok {
-				logEntryChan <- entry
-			}
-		}
-	}()
-
-	initialScan, _ := cmd.Flags().GetBool("initial-scan")
-	engine, err := analysis.NewEngine("pulsewatch.db", initialScan, []types.CustomMetric{})
+	multiParser, err := parser.NewChain(cfg.Parsers.Chain(), cfg.Parsers.Custom...)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating engine: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error creating parsers: %v\n", err)
 		os.Exit(1)
 	}
-	metricsChan := engine.Start(logEntryChan)
 
-	model := tui.NewModel(metricsChan, rawLogChanForTUI, false) // TUI now reads from rawLogChanForTUI
+	initialScan, _ := cmd.Flags().GetBool("initial-scan")
+	dbPath, _ := cmd.Flags().GetString("db-path")
+	engine, cleanup := newRunEngine(cmd, cfg, initialScan)
+	run := &session{ctx: ctx, cancel: cancel, engine: engine, guard: guard, cleanup: cleanup}
+	setupCrashHandling(cfg, dbPath, engine, guard)
+	if cfg.API.Listen != "" {
+		server := api.NewServer(cfg.API.Listen, cfg.API.EventsToken, engine)
+		if err := server.Start(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting API server: %v\n", err)
+			os.Exit(1)
+		}
+		run.onShutdown(func() { server.Shutdown(context.Background()) })
+	}
+
+	pipeline := bus.New()
+	engine.SetAnomalyTopic(pipeline.Anomalies)
+	engine.SetPipeline(pipeline)
+	metricsChan := pipeline.Metrics.Subscribe("dashboard", 0, bus.Block)
+	if cfg.Display.Accessible {
+		anomalies := pipeline.Anomalies.Subscribe("alerts", cfg.Pipeline.Alerts.Buffer, cfg.Pipeline.Alerts.BusPolicy())
+		startPipeline(ctx, cfg.Pipeline, pipeline, records, multiParser, engine, false)
+		runAccessible(ctx, metricsChan, anomalies, false)
+		shutdown(run)
+		return
+	}
+	rawLines := pipeline.RawLines.Subscribe("dashboard", cfg.Pipeline.Dashboard.Buffer, cfg.Pipeline.Dashboard.BusPolicy())
+	alerts := pipeline.Anomalies.Subscribe("alerts", cfg.Pipeline.Alerts.Buffer, cfg.Pipeline.Alerts.BusPolicy())
+	startPipeline(ctx, cfg.Pipeline, pipeline, records, multiParser, engine, false)
+	model := tui.NewModel(metricsChan, rawLines, false, engine, thresholdSaver(cmd), nil, engine, engine, engine)
+	model.SetAlerts(alerts)
+	model.SetSampling(cfg.Display.SampleInterval, cfg.Display.Sample)
+	applyBaseline(cmd, &model)
 	p := tea.NewProgram(model, tea.WithAltScreen())
 
-	if err := p.Start(); err != nil {
+	quitOnDone(ctx, p)
+	if err := runDashboard(p); err != nil {
 		fmt.Fprintf(os.Stderr, "Error starting TUI: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Println("Pulsewatch shutting down.")
+	shutdown(run)
 }
\ No newline at end of file
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nitis/pulseWatch/internal/ingest"
+	"github.com/nitis/pulseWatch/internal/parser"
+	"github.com/nitis/pulseWatch/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var parsersCmd = &cobra.Command{
+	Use:   "parsers",
+	Short: "Inspect log parsers",
+}
+
+var parsersTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Validate a parser against a sample file",
+	Long:  `Runs a parser over a sample log file and reports the parse success rate, how many entries got each field, and examples of lines that did not parse, so a format can be checked before relying on live metrics.`,
+	Args:  cobra.NoArgs,
+	Run:   runParsersTest,
+}
+
+func init() {
+	parsersTestCmd.Flags().StringP("file", "f", "", "Sample log file")
+	parsersTestCmd.Flags().StringP("parser", "p", "auto", "Parser to test: "+strings.Join(parser.Names, ", ")+", or a custom parser's name; auto is the configured chain")
+	parsersTestCmd.Flags().Int("examples", 5, "Unparsed lines to print")
+	parsersTestCmd.MarkFlagRequired("file")
+	parsersCmd.AddCommand(parsersTestCmd)
+	rootCmd.AddCommand(parsersCmd)
+}
+
+// fieldCoverage names a field and reports whether an entry has it.
+var fieldCoverage = []struct {
+	name string
+	has  func(types.LogEntry) bool
+}{
+	{"status", func(e types.LogEntry) bool { return e.StatusCode != 0 }},
+	{"endpoint", func(e types.LogEntry) bool { return e.Endpoint != "" }},
+	{"method", func(e types.LogEntry) bool { return e.Method != "" }},
+	{"latency", func(e types.LogEntry) bool { return e.Latency > 0 }},
+	{"protocol", func(e types.LogEntry) bool { return e.Protocol != "" }},
+	{"grpc_status", func(e types.LogEntry) bool { return e.GRPCStatus != "" }},
+	{"operation", func(e types.LogEntry) bool { return e.Operation != "" }},
+}
+
+func runParsersTest(cmd *cobra.Command, args []string) {
+	path, _ := cmd.Flags().GetString("file")
+	name, _ := cmd.Flags().GetString("parser")
+	maxExamples, _ := cmd.Flags().GetInt("examples")
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	var p parser.Parser
+	if name == "auto" {
+		if p, err = parser.NewChain(cfg.Parsers.Chain(), cfg.Parsers.Custom...); err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating parsers: %v\n", err)
+			os.Exit(1)
+		}
+		name = "auto (" + strings.Join(cfg.Parsers.Chain(), ", ") + ")"
+	} else if p, err = parser.Find(name, cfg.Parsers.Custom); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	file, err := ingest.OpenLog(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening sample file: %v\n", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	var lines, parsed int
+	covered := make([]int, len(fieldCoverage))
+	var unparsed []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines++
+		entry, ok := p.Parse(line)
+		if !ok {
+			if len(unparsed) < maxExamples {
+				unparsed = append(unparsed, line)
+			}
+			continue
+		}
+		parsed++
+		for i, field := range fieldCoverage {
+			if field.has(entry) {
+				covered[i]++
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading sample file: %v\n", err)
+		os.Exit(1)
+	}
+	if lines == 0 {
+		fmt.Println("The sample file has no lines.")
+		return
+	}
+
+	fmt.Printf("Parser: %s | File: %s\n", name, path)
+	fmt.Printf("Parsed: %d of %d lines (%.1f%%)\n", parsed, lines, percentOf(parsed, lines))
+	if parsed > 0 {
+		fmt.Println("\nField coverage (of parsed entries):")
+		for i, field := range fieldCoverage {
+			fmt.Printf("  %-9s %6d (%.1f%%)\n", field.name, covered[i], percentOf(covered[i], parsed))
+		}
+	}
+	if len(unparsed) > 0 {
+		fmt.Printf("\nUnparsed examples (%d of %d):\n", len(unparsed), lines-parsed)
+		for _, line := range unparsed {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+}
+
+func percentOf(n, total int) float64 {
+	return float64(n) / float64(total) * 100
+}
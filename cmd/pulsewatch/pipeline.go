@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/nitis/pulseWatch/internal/analysis"
+	"github.com/nitis/pulseWatch/internal/bus"
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/crash"
+	"github.com/nitis/pulseWatch/internal/ingest"
+	"github.com/nitis/pulseWatch/internal/parser"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// startPipeline publishes the input's lines on the bus, prefixed with their
+// input's name if tagLines is set, parses them into entries (adding each
+// record's source fields and input; records parsed upstream skip the
+// parsers) for the engine, and publishes the engine's metrics. Parse
+// outcomes are counted per container, or per input. The engine's buffer is
+// sized as pipeline.analysis says. Consumers must subscribe before it is
+// called so they see the input from the start.
+func startPipeline(ctx context.Context, cfg config.PipelineConfig, pipeline *bus.Bus, records <-chan ingest.Record, p *parser.MultiParser, engine *analysis.Engine, tagLines bool) {
+	entries := pipeline.Entries.Subscribe("analysis", cfg.Analysis.Buffer, cfg.Analysis.BusPolicy())
+
+	go func() {
+		defer pipeline.RawLines.Close()
+		defer pipeline.Entries.Close()
+		defer crash.Recover("parser")
+		for rec := range records {
+			raw := rec.Line
+			if tagLines {
+				raw = "[" + rec.Source + "] " + raw
+			}
+			if !pipeline.RawLines.Publish(ctx, raw) {
+				return
+			}
+			var entry types.LogEntry
+			if rec.Entry != nil {
+				// Parsed by an upstream pulsewatch
+				entry = *rec.Entry
+				engine.CountParse(rec.Source, rec.Line, true)
+			} else {
+				var matched parser.Parser
+				entry, matched = p.ParseWith(rec.Line)
+				source := rec.Fields["container"]
+				if source == "" {
+					source = rec.Source
+				}
+				engine.CountParse(source, rec.Line, parser.Structured(matched))
+				if matched == nil {
+					continue
+				}
+			}
+			entry.Source = rec.Source
+			if len(rec.Fields) > 0 && entry.Fields == nil {
+				entry.Fields = make(map[string]interface{}, len(rec.Fields))
+			}
+			for k, v := range rec.Fields {
+				entry.Fields[k] = v
+			}
+			if !pipeline.Entries.Publish(ctx, entry) {
+				return
+			}
+		}
+	}()
+	metrics := engine.Start(entries)
+	go func() {
+		defer crash.Recover("metrics publisher")
+		pipeline.Metrics.PublishAll(ctx, metrics)
+	}()
+}
+
+// assembleMultiline joins continuation lines into records as configured by
+// ingest.multiline, or returns records as they are if it isn't.
+func assembleMultiline(cfg config.MultilineConfig, records <-chan ingest.Record) <-chan ingest.Record {
+	if cfg.Start == "" {
+		return records
+	}
+	m, err := ingest.NewMultiline(cfg.Start, cfg.Timeout, cfg.MaxLines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return m.Assemble(records)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var presetsCmd = &cobra.Command{
+	Use:   "presets [name]",
+	Short: "List the bundled presets, or print one",
+	Long:  `Lists the bundled presets selectable with --preset. With a name, prints that preset's config, e.g. to copy it into a config file and adapt it.`,
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runPresets,
+}
+
+func init() {
+	rootCmd.AddCommand(presetsCmd)
+}
+
+func runPresets(cmd *cobra.Command, args []string) {
+	if len(args) == 1 {
+		data, err := config.Preset(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+		return
+	}
+	for _, name := range config.Presets() {
+		fmt.Printf("%-12s %s\n", name, config.PresetDescription(name))
+	}
+}
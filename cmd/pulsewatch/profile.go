@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/groupby"
+	"github.com/nitis/pulseWatch/internal/ingest"
+	"github.com/nitis/pulseWatch/internal/parser"
+	"github.com/nitis/pulseWatch/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile <file>",
+	Short: "Profile a log file's format and fields",
+	Long:  `Samples the start of a log file and reports which formats parse it, how many entries get each field, the time range covered, and the cardinality of key fields, then suggests parser, grouping, tenant, and session settings for the config file. Use it before committing to a long watch.`,
+	Args:  cobra.ExactArgs(1),
+	Run:   runProfile,
+}
+
+func init() {
+	profileCmd.Flags().IntP("lines", "n", 10000, "Lines to sample from the start of the file")
+	rootCmd.AddCommand(profileCmd)
+}
+
+// structuredParsers are the formats a file is profiled against. The line
+// parser accepts anything, so it says nothing about the format.
+var structuredParsers = []string{"json", "nginx", "apache", "syslog"}
+
+// formatShare is the share of sampled lines a parser understood.
+type formatShare struct {
+	name  string
+	share float64
+}
+
+type namedCount struct {
+	name  string
+	count int
+}
+
+// maxEndpointGroups is how many distinct endpoints the dashboard lists
+// comfortably; above it, profile suggests grouping by path prefix.
+const maxEndpointGroups = 50
+
+// fieldHints map parsed field names to the settings they suit.
+var (
+	tenantFieldHints  = []string{"tenant", "tenant_id", "org_id", "customer_id", "account_id", "api_key"}
+	sessionFieldHints = []string{"session", "session_id", "sid", "trace_id"}
+	versionFieldHints = []string{"version", "app_version", "service_version", "service.version", "release"}
+)
+
+func runProfile(cmd *cobra.Command, args []string) {
+	path := args[0]
+	maxLines, _ := cmd.Flags().GetInt("lines")
+	if maxLines <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --lines must be positive")
+		os.Exit(1)
+	}
+
+	lines, err := sampleLines(path, maxLines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	if len(lines) == 0 {
+		fmt.Println("The file has no lines.")
+		return
+	}
+	fmt.Printf("File: %s | Sampled: %d lines\n", path, len(lines))
+
+	shares := formatShares(lines)
+	fmt.Println("\nFormats (share of lines parsed):")
+	for _, s := range shares {
+		fmt.Printf("  %-7s %5.1f%%\n", s.name, s.share*100)
+	}
+	best := shares[0]
+	if best.share == 0 {
+		fmt.Println("\nNo structured format matched; entries would only carry the raw message (line parser).")
+		return
+	}
+
+	p, _ := parser.ByName(best.name)
+	var entries []types.LogEntry
+	for _, line := range lines {
+		if entry, ok := p.Parse(line); ok {
+			entries = append(entries, entry)
+		}
+	}
+	fmt.Printf("\nProfiled as %s (%d entries).\n", best.name, len(entries))
+
+	fmt.Println("\nField coverage:")
+	for _, field := range fieldCoverage {
+		n := 0
+		for _, e := range entries {
+			if field.has(e) {
+				n++
+			}
+		}
+		fmt.Printf("  %-9s %6d (%.1f%%)\n", field.name, n, percentOf(n, len(entries)))
+	}
+
+	printTimeRange(entries)
+
+	endpoints := distinct(entries, func(e types.LogEntry) string { return e.Endpoint })
+	cardinality := []namedCount{
+		{"endpoint", endpoints},
+		{"method", distinct(entries, func(e types.LogEntry) string { return e.Method })},
+		{"status", distinct(entries, func(e types.LogEntry) string {
+			if e.StatusCode == 0 {
+				return ""
+			}
+			return fmt.Sprint(e.StatusCode)
+		})},
+	}
+	fields := fieldNames(entries)
+	for _, name := range fields {
+		n := distinct(entries, func(e types.LogEntry) string { return fieldString(e, name) })
+		cardinality = append(cardinality, namedCount{"fields." + name, n})
+	}
+	width := 0
+	for _, c := range cardinality {
+		width = max(width, len(c.name))
+	}
+	fmt.Println("\nCardinality (distinct values):")
+	for _, c := range cardinality {
+		fmt.Printf("  %-*s %6d\n", width, c.name, c.count)
+	}
+
+	printProfileSuggestions(best, entries, endpoints, fields)
+}
+
+func printTimeRange(entries []types.LogEntry) {
+	var first, last time.Time
+	missing, backwards := 0, 0
+	var prev time.Time
+	for _, e := range entries {
+		if e.Timestamp.IsZero() {
+			missing++
+			continue
+		}
+		if first.IsZero() || e.Timestamp.Before(first) {
+			first = e.Timestamp
+		}
+		if e.Timestamp.After(last) {
+			last = e.Timestamp
+		}
+		if e.Timestamp.Before(prev) {
+			backwards++
+		}
+		prev = e.Timestamp
+	}
+	fmt.Println("\nTimestamps:")
+	if first.IsZero() {
+		fmt.Println("  none parsed; entries are timed by when pulsewatch reads them")
+		return
+	}
+	fmt.Printf("  %s to %s (%s)\n", first.Format("2006-01-02 15:04:05"), last.Format("2006-01-02 15:04:05"), last.Sub(first).Round(time.Second))
+	if missing > 0 {
+		fmt.Printf("  %d entries without a timestamp\n", missing)
+	}
+	if backwards > 0 {
+		fmt.Printf("  %d entries earlier than the one before (out of order)\n", backwards)
+	}
+}
+
+func printProfileSuggestions(best formatShare, entries []types.LogEntry, endpoints int, fields []string) {
+	var notes []string
+	yaml := []string{"parsers:", fmt.Sprintf("  order: [%s]", quoteList(formatOrder(best.name)))}
+	if best.share < 0.9 {
+		notes = append(notes, fmt.Sprintf("Only %.0f%% of the lines parse as %s; the rest are kept as plain messages. Check the unparsed ones with pulsewatch parsers test.", best.share*100, best.name))
+	}
+
+	if endpoints > maxEndpointGroups {
+		if depth := segmentDepth(entries); depth > 0 {
+			yaml = append(yaml, "grouping:", fmt.Sprintf("  by: \"{method} {endpoint|segments:%d}\"", depth))
+			notes = append(notes, fmt.Sprintf("%d distinct endpoints (IDs in paths?); grouping by the first %d path segments keeps the list readable.", endpoints, depth))
+		}
+	}
+	if name := hintedField(fields, tenantFieldHints); name != "" {
+		yaml = append(yaml, "tenant:", "  field: "+name)
+	}
+	if name := hintedField(fields, sessionFieldHints); name != "" {
+		yaml = append(yaml, "session:", "  field: "+name)
+	}
+	if name := hintedField(fields, versionFieldHints); name != "" {
+		yaml = append(yaml, "version:", "  field: "+name)
+	}
+
+	latencies := 0
+	for _, e := range entries {
+		if e.Latency > 0 {
+			latencies++
+		}
+	}
+	if latencies == 0 {
+		notes = append(notes, "No entry has a latency, so latency percentiles and latency anomalies stay empty; log the request time (e.g. nginx $request_time) to get them.")
+	}
+
+	fmt.Println("\nSuggested config:")
+	for _, line := range yaml {
+		fmt.Println("  " + line)
+	}
+	for _, note := range notes {
+		fmt.Println("\nNote: " + note)
+	}
+}
+
+// sampleLines reads up to max non-empty lines from the start of path,
+// decompressing it if needed.
+func sampleLines(path string, max int) ([]string, error) {
+	file, err := ingest.OpenLog(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for len(lines) < max && scanner.Scan() {
+		if line := scanner.Text(); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// formatShares returns the share of lines each structured parser
+// understood, best first.
+func formatShares(lines []string) []formatShare {
+	shares := make([]formatShare, 0, len(structuredParsers))
+	for _, name := range structuredParsers {
+		p, err := parser.ByName(name)
+		if err != nil {
+			continue
+		}
+		parsed := 0
+		for _, line := range lines {
+			if _, ok := p.Parse(line); ok {
+				parsed++
+			}
+		}
+		shares = append(shares, formatShare{name, float64(parsed) / float64(len(lines))})
+	}
+	sort.SliceStable(shares, func(i, j int) bool { return shares[i].share > shares[j].share })
+	return shares
+}
+
+func distinct(entries []types.LogEntry, key func(types.LogEntry) string) int {
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if k := key(e); k != "" {
+			seen[k] = true
+		}
+	}
+	return len(seen)
+}
+
+// fieldNames returns the parsed field names, sorted.
+func fieldNames(entries []types.LogEntry) []string {
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		for name := range e.Fields {
+			seen[name] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func fieldString(e types.LogEntry, name string) string {
+	if v, ok := e.Fields[name]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// segmentDepth returns the deepest path prefix that keeps the distinct
+// endpoints within maxEndpointGroups, or 0 if even one segment does not.
+func segmentDepth(entries []types.LogEntry) int {
+	depth := 0
+	for n := 1; n <= 4; n++ {
+		expr, err := groupby.Parse(fmt.Sprintf("{endpoint|segments:%d}", n))
+		if err != nil {
+			break
+		}
+		if distinct(entries, expr.Eval) > maxEndpointGroups {
+			break
+		}
+		depth = n
+	}
+	return depth
+}
+
+func hintedField(fields, hints []string) string {
+	for _, hint := range hints {
+		for _, name := range fields {
+			if strings.EqualFold(name, hint) {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+func quoteList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = fmt.Sprintf("%q", item)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/analysis"
+	"github.com/nitis/pulseWatch/internal/charts"
+	"github.com/nitis/pulseWatch/internal/locale"
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Summarize stored metrics and optionally render trend charts",
+	Long:  `Summarizes the per-minute metrics and anomalies stored in the database over a period. With --charts it also writes RPS, latency percentile, and error rate charts as SVG and/or PNG files, e.g. for postmortems. With --format junit it instead prints the SLO, threshold, and anomaly checks as a JUnit report and exits with status 1 if any failed, for CI gates. With --comment it also posts the summary and checks as a GitHub pull request or GitLab merge request comment, updating its previous comment on reruns. Safe to run while another pulsewatch process is using the database.`,
+	Args:  cobra.NoArgs,
+	Run:   runReport,
+}
+
+func init() {
+	reportCmd.Flags().Duration("since", 24*time.Hour, "Period to report on, ending now")
+	reportCmd.Flags().String("charts", "", "Directory to write trend charts to")
+	reportCmd.Flags().StringSlice("chart-format", []string{"svg", "png"}, "Chart file formats: svg, png")
+	reportCmd.Flags().Bool("plain", false, "Print the text summary as plain sentences for screen readers")
+	reportCmd.Flags().String("format", "text", "Output format: text, or junit to report SLO and threshold checks as test cases")
+	reportCmd.Flags().Duration("max-p95", 0, "Fail the check if the worst minute's P95 latency exceeds this")
+	reportCmd.Flags().Duration("max-p99", 0, "Fail the check if the worst minute's P99 latency exceeds this")
+	reportCmd.Flags().Float64("max-error-rate", 0, "Fail the check if the error rate in percent exceeds this")
+	reportCmd.Flags().String("fail-on", types.SeverityCritical, "Lowest anomaly severity that fails a check: critical, warning, or info")
+	rootCmd.AddCommand(reportCmd)
+}
+
+// reportData is the stored history a report covers.
+type reportData struct {
+	from, to    time.Time
+	rollups     []storage.Rollup
+	anomalies   []types.Anomaly
+	events      []types.Event
+	annotations []types.Annotation
+}
+
+func loadReportData(cmd *cobra.Command) (reportData, error) {
+	since, _ := cmd.Flags().GetDuration("since")
+	d := reportData{to: time.Now()}
+	d.from = d.to.Add(-since)
+
+	dbPath, _ := cmd.Flags().GetString("db-path")
+	stor, err := storage.OpenReadOnly(dbPath)
+	if err != nil {
+		return d, fmt.Errorf("opening database: %w", err)
+	}
+	defer stor.Close()
+
+	if d.rollups, err = stor.GetRollupsSince(d.from); err != nil {
+		return d, fmt.Errorf("loading rollups: %w", err)
+	}
+	if d.anomalies, err = stor.QueryAnomalies(types.AnomalyFilter{Since: d.from}); err != nil {
+		return d, fmt.Errorf("loading anomalies: %w", err)
+	}
+	if d.events, err = stor.GetEventsBetween(d.from, d.to, ""); err != nil {
+		return d, fmt.Errorf("loading events: %w", err)
+	}
+	if d.annotations, err = stor.GetAnnotationsBetween(d.from, d.to); err != nil {
+		return d, fmt.Errorf("loading annotations: %w", err)
+	}
+	return d, nil
+}
+
+// reportSummary aggregates a report's rollups.
+type reportSummary struct {
+	requests, errors int
+	errorRate        float64
+	avgRPS, peakRPS  float64
+	p50, p95, p99    time.Duration // Worst minute
+}
+
+func (d reportData) summary() reportSummary {
+	var s reportSummary
+	for _, r := range d.rollups {
+		s.requests += r.Requests
+		s.errors += r.Errors
+		s.peakRPS = max(s.peakRPS, float64(r.Requests)/time.Minute.Seconds())
+		s.p50 = max(s.p50, r.P50)
+		s.p95 = max(s.p95, r.P95)
+		s.p99 = max(s.p99, r.P99)
+	}
+	if s.requests > 0 {
+		s.errorRate = float64(s.errors) / float64(s.requests) * 100
+	}
+	if len(d.rollups) > 0 {
+		s.avgRPS = float64(s.requests) / float64(len(d.rollups)) / time.Minute.Seconds()
+	}
+	return s
+}
+
+func runReport(cmd *cobra.Command, args []string) {
+	format, _ := cmd.Flags().GetString("format")
+	if format != "text" && format != "junit" {
+		fmt.Fprintf(os.Stderr, "Invalid --format %q: use text or junit\n", format)
+		os.Exit(1)
+	}
+	gates, err := reportGatesFromFlags(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	poster, err := commentPoster(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	d, err := loadReportData(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	checks := evaluateChecks(d, gates)
+	if poster != nil {
+		if err := poster.Post(markdownReport(d, checks)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error posting comment: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr, "Posted report comment")
+	}
+	if format == "junit" {
+		if err := writeJUnit(os.Stdout, checks, d.to); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JUnit report: %v\n", err)
+			os.Exit(1)
+		}
+		for _, c := range checks {
+			if c.failure != "" {
+				os.Exit(1)
+			}
+		}
+		return
+	}
+
+	if plain, _ := cmd.Flags().GetBool("plain"); plain {
+		printPlainSummary(d, checks)
+	} else {
+		printSummary(d)
+		fmt.Println("\nChecks:")
+		for _, c := range checks {
+			if c.failure != "" {
+				fmt.Printf("  FAIL %s/%s: %s\n", c.suite, c.name, c.failure)
+			} else {
+				fmt.Printf("  PASS %s/%s\n", c.suite, c.name)
+			}
+		}
+	}
+
+	if dir, _ := cmd.Flags().GetString("charts"); dir != "" {
+		formats, _ := cmd.Flags().GetStringSlice("chart-format")
+		files, err := writeCharts(d, dir, formats)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing charts: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\nCharts written: %s\n", strings.Join(files, ", "))
+	}
+}
+
+func reportGatesFromFlags(cmd *cobra.Command) (reportGates, error) {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return reportGates{}, err
+	}
+	gates := reportGates{sloTarget: cfg.SLO.Target}
+	gates.maxP95, _ = cmd.Flags().GetDuration("max-p95")
+	gates.maxP99, _ = cmd.Flags().GetDuration("max-p99")
+	gates.maxErrorRate, _ = cmd.Flags().GetFloat64("max-error-rate")
+	gates.failOn, _ = cmd.Flags().GetString("fail-on")
+	switch gates.failOn {
+	case types.SeverityCritical, types.SeverityWarning, types.SeverityInfo:
+	default:
+		return gates, fmt.Errorf("invalid --fail-on %q: use critical, warning, or info", gates.failOn)
+	}
+	return gates, nil
+}
+
+func printSummary(d reportData) {
+	s := d.summary()
+	fmt.Printf("Report %s %s - %s %s (%s minutes of data)\n\n", locale.Date(d.from), locale.ShortTime(d.from), locale.Date(d.to), locale.ShortTime(d.to), locale.Int(int64(len(d.rollups))))
+	fmt.Printf("Requests: %s | Errors: %s (%s)\n", locale.Int(int64(s.requests)), locale.Int(int64(s.errors)), locale.Percent(s.errorRate, 2))
+	fmt.Printf("RPS: %s avg, %s peak\n", locale.Float(s.avgRPS, 2), locale.Float(s.peakRPS, 2))
+	fmt.Printf("Worst minute P50/P95/P99: %s / %s / %s\n", locale.Duration(s.p50), locale.Duration(s.p95), locale.Duration(s.p99))
+
+	bySeverity := make(map[string]int)
+	for _, a := range d.anomalies {
+		bySeverity[a.Severity]++
+	}
+	fmt.Printf("Anomalies: %d (%d critical, %d warning, %d info)\n", len(d.anomalies),
+		bySeverity[types.SeverityCritical], bySeverity[types.SeverityWarning], bySeverity[types.SeverityInfo])
+
+	if len(d.events) > 0 {
+		fmt.Printf("\nEvents (%d):\n", len(d.events))
+		for _, ev := range d.events {
+			fmt.Printf("  [%s] %-8s %s\n", locale.DateTime(ev.Timestamp), ev.Type, ev.Title)
+		}
+	}
+
+	if len(d.annotations) > 0 {
+		fmt.Printf("\nAnnotations (%d):\n", len(d.annotations))
+		for _, a := range d.annotations {
+			fmt.Printf("  [%s] %s: %s\n", annotationSpan(a, locale.DateTime), a.Label, a.Note)
+			if a.Line != "" {
+				fmt.Printf("      %s\n", a.Line)
+			}
+		}
+	}
+}
+
+// printSessionAnnotations lists the annotations made in the dashboard since
+// started, so the incident trail stays on screen after it closes.
+func printSessionAnnotations(engine *analysis.Engine, started time.Time) {
+	annotations, err := engine.Annotations(started, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading annotations: %v\n", err)
+		return
+	}
+	if len(annotations) == 0 {
+		return
+	}
+	fmt.Printf("Annotations this session (%d):\n", len(annotations))
+	for _, a := range annotations {
+		fmt.Printf("  [%s] %s: %s\n", annotationSpan(a, locale.Time), a.Label, a.Note)
+		if a.Line != "" {
+			fmt.Printf("      %s\n", a.Line)
+		}
+	}
+}
+
+// annotationSpan formats when an annotation applies: its time for a tagged
+// line, or its start and end for a time range.
+func annotationSpan(a types.Annotation, format func(time.Time) string) string {
+	if !a.End.After(a.Start) {
+		return format(a.Start)
+	}
+	return format(a.Start) + " - " + format(a.End)
+}
+
+// reportCharts builds the trend charts shown in the TUI's Trends tab from
+// the stored per-minute rollups, with events as markers.
+func reportCharts(rollups []storage.Rollup, events []types.Event) map[string]charts.Chart {
+	times := make([]time.Time, len(rollups))
+	rps := make([]float64, len(rollups))
+	errRate := make([]float64, len(rollups))
+	p50 := make([]float64, len(rollups))
+	p95 := make([]float64, len(rollups))
+	p99 := make([]float64, len(rollups))
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	for i, r := range rollups {
+		times[i] = r.Timestamp
+		rps[i] = float64(r.Requests) / time.Minute.Seconds()
+		if r.Requests > 0 {
+			errRate[i] = float64(r.Errors) / float64(r.Requests) * 100
+		}
+		p50[i], p95[i], p99[i] = ms(r.P50), ms(r.P95), ms(r.P99)
+	}
+
+	markers := make([]charts.Marker, len(events))
+	for i, ev := range events {
+		markers[i] = charts.Marker{Time: ev.Timestamp, Label: ev.Type + ": " + ev.Title}
+	}
+
+	return map[string]charts.Chart{
+		"rps":        {Title: "Requests per second", Times: times, Lines: []charts.Line{{Name: "RPS", Values: rps}}, Markers: markers},
+		"latency":    {Title: "Latency percentiles", Unit: "ms", Times: times, Lines: []charts.Line{{Name: "P50", Values: p50}, {Name: "P95", Values: p95}, {Name: "P99", Values: p99}}, Markers: markers},
+		"error_rate": {Title: "Error rate", Unit: "%", Times: times, Lines: []charts.Line{{Name: "Errors", Values: errRate}}, Markers: markers},
+	}
+}
+
+// writeCharts writes each chart to dir in each format and returns the files.
+func writeCharts(d reportData, dir string, formats []string) ([]string, error) {
+	if len(d.rollups) == 0 {
+		return nil, fmt.Errorf("no stored metrics in the period")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var files []string
+	markers := append([]types.Event(nil), d.events...)
+	for _, a := range d.annotations {
+		markers = append(markers, types.Event{Timestamp: a.Start, Type: "note", Title: a.Label})
+	}
+	all := reportCharts(d.rollups, markers)
+	for _, name := range []string{"rps", "latency", "error_rate"} {
+		chart := all[name]
+		for _, format := range formats {
+			path := filepath.Join(dir, name+"."+format)
+			f, err := os.Create(path)
+			if err != nil {
+				return files, err
+			}
+			switch format {
+			case "svg":
+				err = chart.WriteSVG(f)
+			case "png":
+				err = chart.WritePNG(f)
+			default:
+				err = fmt.Errorf("unknown chart format %q: use svg or png", format)
+			}
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return files, err
+			}
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
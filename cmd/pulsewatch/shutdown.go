@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/analysis"
+	"github.com/nitis/pulseWatch/internal/ingest"
+)
+
+// drainTimeout bounds how long shutdown waits for the entries already read
+// to be analysed and stored.
+const drainTimeout = 5 * time.Second
+
+// session is what a watch or replay run tears down when it ends.
+type session struct {
+	ctx     context.Context
+	cancel  context.CancelFunc // Stops the ingesters
+	engine  *analysis.Engine
+	guard   *ingest.Guard
+	closers []func() // Servers and inputs, closed in reverse order
+	report  func()   // Printed before the summaries; may be nil
+	cleanup func()   // Run once the database is closed, e.g. to remove a --dry-run one
+}
+
+// onShutdown registers fn to run once the bus has been drained, before the
+// engine stops.
+func (s *session) onShutdown(fn func()) {
+	s.closers = append(s.closers, fn)
+}
+
+// shutdown ends a run the same way whichever way it ran: it stops the
+// ingesters, drains the bus into the engine, closes the servers and inputs,
+// flushes the forwarders and exports, prints the session report, and stops
+// the engine, which stops the notifiers and closes the database.
+func shutdown(s *session) {
+	s.cancel()
+	if !s.engine.Drain(drainTimeout) {
+		fmt.Fprintln(os.Stderr, "Warning: gave up waiting for the last entries read to be stored")
+	}
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		s.closers[i]()
+	}
+	s.engine.FlushExports()
+
+	if s.report != nil {
+		s.report()
+	}
+	if summary := s.guard.Summary(); summary != "" {
+		fmt.Println(summary)
+	}
+	fmt.Print(s.engine.DryRunSummary())
+	printRunLimit(s.ctx)
+
+	s.engine.Stop()
+	if s.cleanup != nil {
+		s.cleanup()
+	}
+	fmt.Println("Pulsewatch shutting down.")
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/api"
+	"github.com/spf13/cobra"
+)
+
+// defaultStatusFormat is the line printed without --format.
+const defaultStatusFormat = `{{.RPS}} rps {{.ErrorRate}}% err p95 {{.P95}}{{if .Anomalies}} {{.Anomalies}} anomalies{{end}}`
+
+var statusCmd = &cobra.Command{
+	Use:     "status",
+	Short:   "Print a running instance's current metrics on one line",
+	Long:    `Queries the HTTP API of a running pulsewatch (api.listen, or --listen) and prints one line built from a Go template, for tmux, starship, and other status bars. Fields: .RPS, .ErrorRate (percent), .Requests, .Errors, .P50, .P95, .P99 (durations), .Anomalies and .Critical (in the last 5 minutes), .Learning, and .Age (since the metrics were computed). Exits with status 1 and prints nothing to stdout if the instance can't be reached.`,
+	Example: `  pulsewatch status --listen :9100 --format 'rps={{.RPS}} err={{.ErrorRate}}%'`,
+	Args:    cobra.NoArgs,
+	Run:     runStatus,
+}
+
+func init() {
+	statusCmd.Flags().String("format", defaultStatusFormat, "Go template for the line")
+	statusCmd.Flags().Duration("timeout", 2*time.Second, "Give up if the instance doesn't answer within this long")
+	rootCmd.AddCommand(statusCmd)
+}
+
+// statusLine is the data the --format template renders. Rates are rounded
+// to two decimals and latencies to the millisecond.
+type statusLine struct {
+	RPS, ErrorRate      float64
+	Requests, Errors    int
+	P50, P95, P99       time.Duration
+	Anomalies, Critical int
+	Learning            bool
+	Age                 time.Duration
+}
+
+func runStatus(cmd *cobra.Command, args []string) {
+	format, _ := cmd.Flags().GetString("format")
+	tmpl, err := template.New("status").Parse(format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --format: %v\n", err)
+		os.Exit(1)
+	}
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	if cfg.API.Listen == "" {
+		fmt.Fprintln(os.Stderr, "Error: no API address; set api.listen in the config or pass --listen")
+		os.Exit(1)
+	}
+
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	st, err := api.FetchStatus(ctx, cfg.API.Listen)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	round := func(f float64) float64 { return math.Round(f*100) / 100 }
+	ms := func(v float64) time.Duration {
+		return time.Duration(v * float64(time.Millisecond)).Round(time.Millisecond)
+	}
+	line := statusLine{
+		RPS:       round(st.RPS),
+		ErrorRate: round(st.ErrorRate),
+		Requests:  st.Requests,
+		Errors:    st.Errors,
+		P50:       ms(st.P50),
+		P95:       ms(st.P95),
+		P99:       ms(st.P99),
+		Anomalies: st.Anomalies,
+		Critical:  st.Critical,
+		Learning:  st.Learning,
+		Age:       time.Since(st.Time).Round(time.Second),
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, line); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --format: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(b.String())
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/nitis/pulseWatch/internal/update"
+	"github.com/spf13/cobra"
+)
+
+// version is set when building releases: go build -ldflags "-X main.version=v1.2.3".
+var version = "dev"
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version, optionally checking for a newer release",
+	Args:  cobra.NoArgs,
+	Run:   runVersion,
+}
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Replace this binary with the latest release",
+	Long:  `Downloads the latest release's binary for this platform, verifies the Ed25519 signature of the release's checksums.txt against the key built into this binary and the binary's SHA-256 in it, and atomically replaces the running executable. Builds without a signing key, such as those built from source, refuse and print the steps to update by hand. Set update.disable in the config or PULSEWATCH_NO_UPDATE=1 to turn update checks off, e.g. where a package manager owns the binary.`,
+	Args:  cobra.NoArgs,
+	Run:   runSelfUpdate,
+}
+
+func init() {
+	versionCmd.Flags().Bool("check", false, "Check GitHub for a newer release")
+	selfUpdateCmd.Flags().Bool("force", false, "Update even if this build is current or was built from source")
+	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(selfUpdateCmd)
+}
+
+// updateChecker returns a release checker, or an error if updates are
+// turned off.
+func updateChecker(cmd *cobra.Command) (*update.Checker, error) {
+	if os.Getenv("PULSEWATCH_NO_UPDATE") != "" {
+		return nil, fmt.Errorf("update checks are disabled by PULSEWATCH_NO_UPDATE")
+	}
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	if cfg.Update.Disable {
+		return nil, fmt.Errorf("update checks are disabled by update.disable in the config")
+	}
+	return update.NewChecker(cfg.Update.APIURL, cfg.Update.Repo), nil
+}
+
+func runVersion(cmd *cobra.Command, args []string) {
+	fmt.Printf("pulsewatch %s (%s %s/%s)\n", version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	if check, _ := cmd.Flags().GetBool("check"); !check {
+		return
+	}
+	checker, err := updateChecker(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	latest, err := checker.Latest(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if update.Newer(latest.Version, version) {
+		fmt.Printf("A newer release is available: %s (%s)\nRun pulsewatch self-update to install it.\n", latest.Version, latest.URL)
+	} else {
+		fmt.Printf("Up to date (latest release: %s).\n", latest.Version)
+	}
+}
+
+func runSelfUpdate(cmd *cobra.Command, args []string) {
+	force, _ := cmd.Flags().GetBool("force")
+	checker, err := updateChecker(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	latest, err := checker.Latest(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !update.CanApply() {
+		printManualUpdate(latest)
+		os.Exit(1)
+	}
+	if version == "dev" && !force {
+		fmt.Fprintf(os.Stderr, "This binary was built from source; use --force to replace it with release %s.\n", latest.Version)
+		os.Exit(1)
+	}
+	if !update.Newer(latest.Version, version) && !force {
+		fmt.Printf("Already up to date (%s).\n", version)
+		return
+	}
+
+	path, err := os.Executable()
+	if err == nil {
+		path, err = filepath.EvalSymlinks(path)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error locating the running binary: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Updating %s from %s to %s...\n", path, version, latest.Version)
+	if err := checker.Apply(context.Background(), latest, path); err != nil {
+		fmt.Fprintf(os.Stderr, "Update failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Signature and checksum verified.")
+	fmt.Printf("Updated to %s.\n", latest.Version)
+}
+
+// printManualUpdate explains how to install latest by hand, for builds that
+// can't verify a release themselves.
+func printManualUpdate(latest *update.Release) {
+	fmt.Fprintf(os.Stderr, "Refusing to self-update: %v, so release %s can't be verified.\n", update.ErrUnsigned, latest.Version)
+	fmt.Fprintf(os.Stderr, "To update by hand, download %s, %s and %s from\n  %s\n", update.BinaryName(), update.ChecksumsName, update.SignatureName, latest.URL)
+	fmt.Fprintf(os.Stderr, "check %s against the project's published signing key, check the binary with\n  sha256sum --ignore-missing -c %s\n", update.SignatureName, update.ChecksumsName)
+	fmt.Fprintln(os.Stderr, "and replace this executable with it.")
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/parser"
+	"github.com/nitis/pulseWatch/internal/types"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a starter config file interactively",
+	Long:  `Asks which logs to watch and in what format, how long to keep data, the SLO target, and where to send anomaly notifications, then writes a starter config file. The format is suggested by sampling the first log file. Press Enter to accept the default shown in brackets.`,
+	Args:  cobra.NoArgs,
+	Run:   runInit,
+}
+
+func init() {
+	initCmd.Flags().StringP("output", "o", "pulsewatch.yaml", "Config file to write")
+	initCmd.Flags().Bool("force", false, "Overwrite the output file without asking")
+	rootCmd.AddCommand(initCmd)
+}
+
+// starterConfig is the subset of the config the wizard sets. Durations are
+// strings so they are written as "168h" rather than nanoseconds.
+type starterConfig struct {
+	Parsers *struct {
+		Order []string `yaml:"order"`
+	} `yaml:"parsers,omitempty"`
+	Refresh *struct {
+		Tick string `yaml:"tick"`
+	} `yaml:"refresh,omitempty"`
+	Storage struct {
+		Retention struct {
+			Raw        string `yaml:"raw"`
+			Aggregates string `yaml:"aggregates"`
+		} `yaml:"retention"`
+	} `yaml:"storage"`
+	SLO *struct {
+		Target float64 `yaml:"target"`
+	} `yaml:"slo,omitempty"`
+	Notify *starterNotify `yaml:"notify,omitempty"`
+}
+
+type starterNotify struct {
+	MinSeverity string `yaml:"min_severity"`
+	Ntfy        *struct {
+		Topic  string `yaml:"topic"`
+		Server string `yaml:"server"`
+	} `yaml:"ntfy,omitempty"`
+	Pushover *struct {
+		Token string `yaml:"token"`
+		User  string `yaml:"user"`
+	} `yaml:"pushover,omitempty"`
+}
+
+func runInit(cmd *cobra.Command, args []string) {
+	output, _ := cmd.Flags().GetString("output")
+	force, _ := cmd.Flags().GetBool("force")
+	p := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+
+	fmt.Println("This writes a starter pulsewatch config. Press Enter to accept the default in brackets.")
+	fmt.Println()
+	if _, err := os.Stat(output); err == nil && !force {
+		if !p.askBool(fmt.Sprintf("%s exists. Overwrite it?", output), false) {
+			fmt.Println("Nothing written.")
+			return
+		}
+	}
+
+	var logs []string
+	for _, path := range strings.Split(p.ask("Log files to watch, comma-separated (empty for stdin)", defaultLogPath()), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			logs = append(logs, path)
+		}
+	}
+
+	var sc starterConfig
+	suggested := "auto"
+	if len(logs) > 0 {
+		if name, share := detectFormat(logs[0]); name != "" {
+			fmt.Printf("  %.0f%% of the sampled lines of %s parse as %s.\n", share*100, logs[0], name)
+			suggested = name
+		}
+	}
+	format := p.askChoice("Log format", suggested, parser.Names[:len(parser.Names)-1])
+	if format != "auto" {
+		sc.Parsers = &struct {
+			Order []string `yaml:"order"`
+		}{Order: formatOrder(format)}
+	}
+
+	fmt.Println("\nMetrics are computed over 1m, 5m, and 1h windows.")
+	if tick := p.askDuration("How often to recompute them", time.Second); tick != time.Second {
+		sc.Refresh = &struct {
+			Tick string `yaml:"tick"`
+		}{Tick: shortDuration(tick)}
+	}
+	sc.Storage.Retention.Raw = shortDuration(p.askDuration("Keep raw log entries for", 7*24*time.Hour))
+	sc.Storage.Retention.Aggregates = shortDuration(p.askDuration("Keep per-minute rollups and anomalies for", 90*24*time.Hour))
+
+	if target := p.askFloat("\nAvailability SLO target in percent, e.g. 99.9 (0 for none)", 0, 0, 100); target > 0 {
+		sc.SLO = &struct {
+			Target float64 `yaml:"target"`
+		}{Target: target}
+	}
+
+	fmt.Println("\nAnomalies can be pushed to your phone via ntfy or Pushover.")
+	notify := &starterNotify{}
+	if topic := p.ask("ntfy topic (empty to skip)", ""); topic != "" {
+		notify.Ntfy = &struct {
+			Topic  string `yaml:"topic"`
+			Server string `yaml:"server"`
+		}{Topic: topic, Server: p.ask("ntfy server", "https://ntfy.sh")}
+	}
+	if token := p.ask("Pushover application token (empty to skip)", ""); token != "" {
+		notify.Pushover = &struct {
+			Token string `yaml:"token"`
+			User  string `yaml:"user"`
+		}{Token: token, User: p.ask("Pushover user key", "")}
+	}
+	if notify.Ntfy != nil || notify.Pushover != nil {
+		notify.MinSeverity = p.askChoice("Least severe anomaly that notifies", types.SeverityCritical,
+			[]string{types.SeverityCritical, types.SeverityWarning, types.SeverityInfo})
+		sc.Notify = notify
+	}
+
+	data, err := renderStarterConfig(sc, logs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(output, data, 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing config: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := config.Load(output); err != nil {
+		fmt.Fprintf(os.Stderr, "The written config does not load: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("\nWrote %s. Start watching with:\n  %s\n", output, watchCommand(output, logs))
+}
+
+// renderStarterConfig encodes sc, headed by a comment naming the logs it was
+// written for.
+func renderStarterConfig(sc starterConfig, logs []string) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Written by pulsewatch init on %s.\n", time.Now().Format("2006-01-02"))
+	if len(logs) > 0 {
+		fmt.Fprintf(&buf, "# Logs: %s\n", strings.Join(logs, ", "))
+	}
+	buf.WriteString("# See the README for every setting.\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(sc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func watchCommand(configPath string, logs []string) string {
+	command := "pulsewatch watch -c " + configPath
+	if len(logs) == 0 {
+		return "<your command> | " + command
+	}
+	return command + " " + strings.Join(logs, " ")
+}
+
+// defaultLogPath suggests the first common access log that exists.
+func defaultLogPath() string {
+	for _, path := range []string{"/var/log/nginx/access.log", "/var/log/apache2/access.log", "/var/log/httpd/access_log", "access.log"} {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// detectFormat samples the start of a log file and returns the structured
+// parser that understands most lines, with the share it understood. It
+// returns "" if the file can't be read or no parser gets half of the lines.
+func detectFormat(path string) (string, float64) {
+	lines, err := sampleLines(path, 50)
+	if err != nil || len(lines) == 0 {
+		return "", 0
+	}
+	best := formatShares(lines)[0]
+	if best.share < 0.5 {
+		return "", 0
+	}
+	return best.name, best.share
+}
+
+// formatOrder puts the chosen parser first in the default chain.
+func formatOrder(format string) []string {
+	order := []string{format}
+	for _, name := range parser.DefaultOrder {
+		if name != format {
+			order = append(order, name)
+		}
+	}
+	return order
+}
+
+// shortDuration formats d without trailing zero units, e.g. 168h, 1m30s.
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// prompter asks questions on a terminal. At the end of input every question
+// takes its default, so the wizard also runs unattended.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (p *prompter) ask(question, def string) string {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	line, err := p.in.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintln(p.out)
+	}
+	if line = strings.TrimSpace(line); line != "" {
+		return line
+	}
+	return def
+}
+
+func (p *prompter) askBool(question string, def bool) bool {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		switch strings.ToLower(p.ask(question+" ("+hint+")", "")) {
+		case "":
+			return def
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		}
+		fmt.Fprintln(p.out, "  Please answer y or n.")
+	}
+}
+
+func (p *prompter) askChoice(question, def string, choices []string) string {
+	for {
+		answer := strings.ToLower(p.ask(fmt.Sprintf("%s (%s)", question, strings.Join(choices, ", ")), def))
+		for _, c := range choices {
+			if answer == c {
+				return c
+			}
+		}
+		fmt.Fprintf(p.out, "  Please choose one of: %s.\n", strings.Join(choices, ", "))
+	}
+}
+
+func (p *prompter) askDuration(question string, def time.Duration) time.Duration {
+	for {
+		d, err := time.ParseDuration(p.ask(question, shortDuration(def)))
+		if err == nil && d > 0 {
+			return d
+		}
+		fmt.Fprintln(p.out, "  Please enter a positive duration such as 30s, 5m, or 168h.")
+	}
+}
+
+func (p *prompter) askFloat(question string, def, min, max float64) float64 {
+	for {
+		v, err := strconv.ParseFloat(p.ask(question, strconv.FormatFloat(def, 'f', -1, 64)), 64)
+		if err == nil && v >= min && v < max {
+			return v
+		}
+		fmt.Fprintf(p.out, "  Please enter a number of at least %g and below %g.\n", min, max)
+	}
+}
@@ -0,0 +1,93 @@
+package analysis
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+)
+
+const (
+	baselineSampleInterval = 1 * time.Minute // How often the 1m window is folded into the seasonal baseline
+	minBaselineSamples     = 30              // Samples a bucket needs before it replaces the rolling history
+	minRollingSamples      = 10              // Samples the rolling history needs before detection kicks in
+)
+
+// Seasonal baseline metric names, as stored in the baselines table.
+const (
+	baselineRPS       = "rps"
+	baselineErrorRate = "error_rate"
+	baselineP95       = "p95_ms"
+
+	baselineCacheHitRatio = "cache_hit_ratio"
+)
+
+// seasonalBucket identifies a day-of-week/hour-of-day slot.
+type seasonalBucket struct {
+	weekday time.Weekday
+	hour    int
+}
+
+func bucketFor(t time.Time) seasonalBucket {
+	return seasonalBucket{weekday: t.Weekday(), hour: t.Hour()}
+}
+
+func (b seasonalBucket) String() string {
+	return fmt.Sprintf("%s %02d:00", b.weekday.String()[:3], b.hour)
+}
+
+// refreshBaselines samples the current 1m window into the matching seasonal
+// bucket and reloads the cached statistics when the bucket changes.
+func (e *Engine) refreshBaselines(now time.Time) {
+	bucket := bucketFor(now)
+	reload := bucket != e.baselineBucket || e.baselines == nil
+
+	if now.Sub(e.lastBaselineSample) >= baselineSampleInterval {
+		if wm, ok := e.metrics.Windows["1m"]; ok && wm.TotalRequests > 0 {
+			samples := map[string]float64{
+				baselineRPS:       wm.RPS,
+				baselineErrorRate: wm.ErrorRate,
+				baselineP95:       float64(wm.P95Latency.Milliseconds()),
+			}
+			if wm.Cache.Lookups > 0 {
+				samples[baselineCacheHitRatio] = wm.Cache.HitRatio()
+			}
+			for metric, value := range samples {
+				if err := e.storage.UpdateBaseline(bucket.weekday, bucket.hour, metric, value); err != nil {
+					log.Printf("Error updating baseline %s: %v", metric, err)
+				}
+			}
+			reload = true
+		}
+		e.lastBaselineSample = now
+	}
+
+	if !reload {
+		return
+	}
+	e.baselineBucket = bucket
+	e.baselines = make(map[string]storage.Baseline)
+	for _, metric := range []string{baselineRPS, baselineErrorRate, baselineP95, baselineCacheHitRatio} {
+		b, err := e.storage.GetBaseline(bucket.weekday, bucket.hour, metric)
+		if err != nil {
+			log.Printf("Error loading baseline %s: %v", metric, err)
+			continue
+		}
+		e.baselines[metric] = b
+	}
+}
+
+// baselineFor returns the mean and standard deviation to compare a metric
+// against: the seasonal bucket once it has enough samples, otherwise the
+// rolling history. The label describes which one was used.
+func (e *Engine) baselineFor(metric string, rolling []float64) (mean, std float64, label string, ok bool) {
+	if b, found := e.baselines[metric]; found && b.Count >= minBaselineSamples {
+		return b.Mean, b.Std(), e.baselineBucket.String() + " baseline", true
+	}
+	if len(rolling) > minRollingSamples {
+		mean, std = calculateMeanStd(rolling)
+		return mean, std, "rolling baseline", true
+	}
+	return 0, 0, "", false
+}
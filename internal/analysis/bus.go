@@ -0,0 +1,44 @@
+package analysis
+
+import (
+	"github.com/nitis/pulseWatch/internal/bus"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// SetAnomalyTopic makes the engine publish each anomaly when it is recorded.
+// Slow subscribers miss anomalies rather than stall analysis. Call it before
+// Start.
+func (e *Engine) SetAnomalyTopic(t *bus.Topic[types.Anomaly]) {
+	e.anomalyTopic = t
+}
+
+// SetPipeline makes the engine report the buffers and drop counts of b's
+// consumers in its internals. Call it before Start.
+func (e *Engine) SetPipeline(b *bus.Bus) {
+	e.pipeline = b
+}
+
+// maxQueueDepthSamples bounds the queue depth history kept per consumer.
+const maxQueueDepthSamples = 120
+
+// updatePipeline samples the pipeline's consumer stats, every tick so the
+// queue depth history shows backlogs building and draining.
+func (e *Engine) updatePipeline() {
+	if e.pipeline == nil {
+		return
+	}
+	if e.queueDepths == nil {
+		e.queueDepths = make(map[string][]int)
+	}
+	stats := e.pipeline.Stats()
+	for i, s := range stats {
+		key := s.Topic + "/" + s.Consumer
+		depths := append(e.queueDepths[key], s.Queued)
+		if len(depths) > maxQueueDepthSamples {
+			depths = depths[1:]
+		}
+		e.queueDepths[key] = depths
+		stats[i].History = append([]int(nil), depths...)
+	}
+	e.metrics.Internals.Pipeline = stats
+}
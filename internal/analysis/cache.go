@@ -0,0 +1,48 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// cacheTotals sums per-status counts into lookups and hits.
+func cacheTotals(statuses map[string]int) types.CacheStats {
+	var c types.CacheStats
+	for status, count := range statuses {
+		c.Lookups += count
+		if status == types.CacheHit {
+			c.Hits += count
+		}
+	}
+	return c
+}
+
+// detectCacheHitDrops fires when the 1m cache hit ratio falls more than the
+// configured number of standard deviations below its baseline. Only drops are flagged: a rising
+// hit ratio is good news.
+func (e *Engine) detectCacheHitDrops(ac *anomalyContext) {
+	wm, ok := e.metrics.Windows["1m"]
+	if !ok || wm.Cache.Lookups == 0 {
+		return
+	}
+	avg, std, label, ok := e.baselineFor(baselineCacheHitRatio, e.cacheHitHistory)
+	if !ok {
+		return
+	}
+	current := wm.Cache.HitRatio()
+	sigma := e.Thresholds().Sigma
+	if current >= avg-sigma*std {
+		return
+	}
+
+	contributors := ac.contributors(storage.EntryFilter{CacheMiss: true})
+	e.addAnomaly(types.Anomaly{
+		Timestamp:    e.clock.Now(),
+		Type:         "Cache Hit Ratio Drop",
+		Severity:     types.SeverityWarning,
+		Message:      fmt.Sprintf("Cache hit ratio %.1f%% is below %g-sigma range of %s (avg: %.1f%%, std: %.1f%%)", current, sigma, label, avg, std) + formatContributors(contributors),
+		Contributors: contributors,
+	}, ac, evidenceNone)
+}
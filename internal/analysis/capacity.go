@@ -0,0 +1,108 @@
+package analysis
+
+import (
+	"log"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+const (
+	capacityInterval   = 1 * time.Minute    // Refit at most this often
+	capacityHistory    = 7 * 24 * time.Hour // Stored minutes fitted
+	capacityMinSamples = 30                 // Minutes with traffic needed for a fit
+	capacityMinSpread  = 1.5                // Busiest minute's RPS over the quietest's
+)
+
+// updateCapacity refits the capacity estimate from stored rollups if it is
+// stale.
+func (e *Engine) updateCapacity(now time.Time) {
+	if now.Sub(e.metrics.Capacity.GeneratedAt) < capacityInterval {
+		return
+	}
+	rollups, err := e.storage.GetRollupsSince(now.Add(-capacityHistory))
+	if err != nil {
+		log.Printf("Error loading history for capacity: %v", err)
+		return
+	}
+	current := 0.0
+	if wm, ok := e.metrics.Windows["5m"]; ok {
+		current = wm.RPS
+	}
+	e.metrics.Capacity = buildCapacity(rollups, current, e.p95SLO, now)
+}
+
+// buildCapacity fits 1/P95 = a + b*RPS over the minutes with traffic, as
+// for a single-server queue whose latency grows as 1/(capacity - load), and
+// solves it for the RPS at which P95 reaches slo.
+func buildCapacity(rollups []storage.Rollup, current float64, slo time.Duration, now time.Time) types.Capacity {
+	c := types.Capacity{GeneratedAt: now, P95SLO: slo, CurrentRPS: current}
+	var xs, ys []float64
+	for _, r := range rollups {
+		if r.Requests == 0 || r.P95 <= 0 {
+			continue
+		}
+		xs = append(xs, float64(r.Requests)/60)
+		ys = append(ys, 1/r.P95.Seconds())
+	}
+	c.Samples = len(xs)
+	if c.Samples < capacityMinSamples {
+		c.Note = "not enough stored history yet"
+		return c
+	}
+	lo, hi := xs[0], xs[0]
+	for _, x := range xs {
+		lo, hi = min(lo, x), max(hi, x)
+	}
+	if hi < lo*capacityMinSpread {
+		c.Note = "load has been too steady to see how latency responds to it"
+		return c
+	}
+
+	a, b, r2 := linearFit(xs, ys)
+	c.R2 = r2
+	target := 1 / slo.Seconds()
+	switch {
+	case b >= 0:
+		c.Note = "P95 hasn't risen with load in the stored history"
+		return c
+	case a <= target:
+		c.Note = "P95 is above the objective even at low load"
+		return c
+	}
+	c.BreachRPS = (target - a) / b
+	c.Headroom = (c.BreachRPS - current) / c.BreachRPS * 100
+	return c
+}
+
+// linearFit returns the least-squares line y = a + b*x through the points
+// and its coefficient of determination.
+func linearFit(xs, ys []float64) (a, b, r2 float64) {
+	n := float64(len(xs))
+	var sx, sy, sxx, sxy float64
+	for i := range xs {
+		sx += xs[i]
+		sy += ys[i]
+		sxx += xs[i] * xs[i]
+		sxy += xs[i] * ys[i]
+	}
+	den := n*sxx - sx*sx
+	if den == 0 {
+		return sy / n, 0, 0
+	}
+	b = (n*sxy - sx*sy) / den
+	a = (sy - b*sx) / n
+
+	mean := sy / n
+	var ssRes, ssTot float64
+	for i := range xs {
+		fit := a + b*xs[i]
+		ssRes += (ys[i] - fit) * (ys[i] - fit)
+		ssTot += (ys[i] - mean) * (ys[i] - mean)
+	}
+	if ssTot > 0 {
+		r2 = max(1-ssRes/ssTot, 0)
+	}
+	return a, b, r2
+}
@@ -0,0 +1,18 @@
+package analysis
+
+import (
+	"github.com/nitis/pulseWatch/pkg/clock"
+)
+
+// SetClock makes the engine and its store read the time from c instead of
+// the system clock, for deterministic tests of windowing, ticking, and
+// retention. Call it before Start.
+func (e *Engine) SetClock(c clock.Clock) {
+	now := c.Now()
+	e.clock = c
+	e.storage.SetClock(c)
+	e.lastTick = now
+	e.lastPrune = now
+	e.lastVacuum = now
+	e.metrics.StartTime = now
+}
@@ -0,0 +1,137 @@
+package analysis
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/types"
+	"github.com/nitis/pulseWatch/pkg/clock"
+)
+
+var fakeStart = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// startFakeEngine starts an engine on a temp database whose clock only
+// moves when the test advances it.
+func startFakeEngine(t *testing.T, cfg *config.Config) (*Engine, *clock.Fake, chan<- types.LogEntry, <-chan types.Metrics) {
+	t.Helper()
+	e, err := NewEngine(filepath.Join(t.TempDir(), "pulsewatch.db"), false, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := clock.NewFake(fakeStart)
+	e.SetClock(fake)
+	logs := make(chan types.LogEntry)
+	metrics := e.Start(logs)
+	t.Cleanup(e.Stop)
+	return e, fake, logs, metrics
+}
+
+func request(ts time.Time) types.LogEntry {
+	return types.LogEntry{Timestamp: ts, Level: types.InfoLevel, StatusCode: 200, Endpoint: "/", Method: "GET", Latency: 10 * time.Millisecond}
+}
+
+// waitFor polls cond until it holds, failing the test after a few seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// storedEntries counts the entries in the engine's database.
+func storedEntries(t *testing.T, e *Engine) int {
+	t.Helper()
+	entries, err := e.storage.GetLogEntriesSince(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
+
+// nextMetrics advances the fake clock a second at a time until the engine's
+// ticker publishes metrics.
+func nextMetrics(t *testing.T, fake *clock.Fake, metrics <-chan types.Metrics) types.Metrics {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if fake.Waiters() > 0 {
+			fake.Advance(time.Second)
+		}
+		select {
+		case m := <-metrics:
+			return m
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	t.Fatal("timed out waiting for metrics")
+	return types.Metrics{}
+}
+
+func TestWindowsFollowFakeClock(t *testing.T) {
+	e, fake, logs, metrics := startFakeEngine(t, config.Default())
+	for i := 0; i < 10; i++ {
+		logs <- request(fakeStart.Add(-30 * time.Second))
+	}
+	for i := 0; i < 5; i++ {
+		logs <- request(fakeStart.Add(-150 * time.Second))
+	}
+	waitFor(t, "entries to be stored", func() bool { return storedEntries(t, e) == 15 })
+
+	m := nextMetrics(t, fake, metrics)
+	if got := m.Windows["1m"].TotalRequests; got != 10 {
+		t.Errorf("1m window has %d requests, want 10", got)
+	}
+	if got := m.Windows["5m"].TotalRequests; got != 15 {
+		t.Errorf("5m window has %d requests, want 15", got)
+	}
+
+	// Four minutes on, only the newer entries are within five minutes
+	fake.Advance(4 * time.Minute)
+	logs <- request(fake.Now())
+	waitFor(t, "entry to be stored", func() bool { return storedEntries(t, e) == 16 })
+	m = nextMetrics(t, fake, metrics)
+	if got := m.Windows["1m"].TotalRequests; got != 1 {
+		t.Errorf("1m window has %d requests after 4m, want 1", got)
+	}
+	if got := m.Windows["5m"].TotalRequests; got != 11 {
+		t.Errorf("5m window has %d requests after 4m, want 11", got)
+	}
+}
+
+func TestPruneFollowsFakeClock(t *testing.T) {
+	cfg := config.Default()
+	cfg.Storage.Retention.Raw = time.Hour
+	e, fake, _, metrics := startFakeEngine(t, cfg)
+	for _, ts := range []time.Time{fakeStart.Add(-30 * time.Minute), fakeStart.Add(45 * time.Minute)} {
+		if err := e.storage.InsertLogEntry(request(ts)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Take the first metrics so the ticker isn't left blocked sending them.
+	// Nothing is due for pruning until an hour has passed on the fake clock
+	nextMetrics(t, fake, metrics)
+	fake.Advance(30 * time.Minute)
+	time.Sleep(50 * time.Millisecond)
+	if n := storedEntries(t, e); n != 2 {
+		t.Fatalf("%d entries left before the prune interval, want 2", n)
+	}
+
+	// At 90m the cutoff is 30m: the entry from before the start goes
+	fake.Advance(time.Hour)
+	want := fake.Now()
+	waitFor(t, "the old entry to be pruned", func() bool { return storedEntries(t, e) == 1 })
+	stats, err := e.storage.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stats.LastPrune.Equal(want) {
+		t.Errorf("LastPrune = %v, want the fake time %v", stats.LastPrune, want)
+	}
+}
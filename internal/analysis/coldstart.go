@@ -0,0 +1,74 @@
+package analysis
+
+import (
+	"log"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// coldStartAnomalies is how far back stored anomalies are shown on start.
+const coldStartAnomalies = time.Hour
+
+// loadExistingEntries restores context from the database when watching
+// live, so reopening pulsewatch, e.g. after a crash, shows the stored
+// windows and the last hour's anomalies right away instead of an empty
+// dashboard. The windows are aggregated from the database anyway; they only
+// need publishing before the first new line arrives.
+func (e *Engine) loadExistingEntries() {
+	if e.initialScan {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.clock.Now()
+	e.loadStoredAnomalies(now)
+	// An anomaly still firing isn't stored and notified again right away
+	for _, a := range e.metrics.Anomalies {
+		if a.Timestamp.After(e.lastRecorded[a.Type]) {
+			e.lastRecorded[a.Type] = a.Timestamp
+		}
+	}
+	e.dirty = true
+}
+
+// loadStoredAnomalies replaces the recent anomalies with those persisted
+// over the last coldStartAnomalies.
+func (e *Engine) loadStoredAnomalies(now time.Time) {
+	stored, err := e.storage.QueryAnomalies(types.AnomalyFilter{Since: now.Add(-coldStartAnomalies), Limit: maxRecentAnomalies})
+	if err != nil {
+		log.Printf("Error loading stored anomalies: %v", err)
+		return
+	}
+	anomalies := make([]types.Anomaly, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- { // Stored most recent first
+		anomalies = append(anomalies, stored[i])
+	}
+	e.metrics.Anomalies = anomalies
+}
+
+// NewViewEngine creates an engine that only reads the database at dbPath,
+// for a dashboard over the history of earlier runs or of a watch --headless
+// writing the same file. Windows are recomputed every tick, anomalies are
+// the stored ones rather than detected, and nothing is written, pruned,
+// exported, or notified.
+func NewViewEngine(dbPath string, cfg *config.Config) (*Engine, error) {
+	stor, err := storage.OpenReadOnly(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	view := *cfg
+	view.Export = config.ExportConfig{}
+	view.Forward = nil
+	view.Notify = config.NotifyConfig{}
+	view.Probes = nil
+	e, err := newEngine(stor, false, &view)
+	if err != nil {
+		return nil, err
+	}
+	e.viewOnly = true
+	e.metrics.Learning = false // Nothing is detected, so nothing to learn
+	return e, nil
+}
@@ -0,0 +1,56 @@
+package analysis
+
+import (
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+const (
+	endpointTrendBucket  = 1 * time.Minute
+	endpointTrendBuckets = 10
+)
+
+// EndpointTrend returns the endpoint's RPS, error rate, and P95 latency per
+// minute over the last endpointTrendBuckets minutes, oldest first. Minutes
+// without requests are included as zero points. It only reads the database
+// and is safe to call from any goroutine.
+func (e *Engine) EndpointTrend(endpoint string) ([]types.SeriesPoint, error) {
+	end := e.clock.Now().Truncate(endpointTrendBucket).Add(endpointTrendBucket)
+	start := end.Add(-endpointTrendBuckets * endpointTrendBucket)
+	samples, err := e.storage.EndpointSamplesSince(start, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]types.SeriesPoint, endpointTrendBuckets)
+	latencies := make([][]float64, endpointTrendBuckets)
+	for i := range points {
+		points[i].Start = start.Add(time.Duration(i) * endpointTrendBucket)
+	}
+	for _, s := range samples {
+		i := int(s.Timestamp.Sub(start) / endpointTrendBucket)
+		if i < 0 || i >= len(points) {
+			continue
+		}
+		points[i].Requests++
+		if s.StatusCode >= 400 {
+			points[i].Errors++
+		} else if s.LatencyMs > 0 {
+			latencies[i] = append(latencies[i], s.LatencyMs)
+		}
+	}
+
+	for i := range points {
+		p := &points[i]
+		if p.Requests == 0 {
+			continue
+		}
+		p.RPS = float64(p.Requests) / endpointTrendBucket.Seconds()
+		p.ErrorRate = float64(p.Errors) / float64(p.Requests) * 100
+		if ps := computePercentiles(latencies[i], []float64{95}); len(ps) == 1 {
+			p.P95Latency = ps[0].Latency
+		}
+	}
+	return points, nil
+}
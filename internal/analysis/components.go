@@ -0,0 +1,91 @@
+package analysis
+
+import (
+	"sort"
+	"time"
+
+	"github.com/montanaflynn/stats"
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// timingBreakdown splits a window's request time by component, overall and
+// for the top endpoints by time spent in components.
+func timingBreakdown(agg storage.WindowAggregate, top int) types.TimingBreakdown {
+	if len(agg.ComponentTimes) == 0 {
+		return types.TimingBreakdown{}
+	}
+
+	all := make(map[string][]float64)
+	requests := 0
+	var endpoints []types.EndpointTimings
+	totals := make(map[string]float64)
+	for endpoint, components := range agg.ComponentTimes {
+		requests += agg.ComponentRequests[endpoint]
+		for component, values := range components {
+			all[component] = append(all[component], values...)
+		}
+		if endpoint == "" {
+			continue
+		}
+		et := types.EndpointTimings{
+			Endpoint:   endpoint,
+			Requests:   agg.ComponentRequests[endpoint],
+			Components: componentTimings(components, agg.ComponentRequests[endpoint]),
+		}
+		for _, values := range components {
+			sum, _ := stats.Sum(values)
+			totals[endpoint] += sum
+		}
+		endpoints = append(endpoints, et)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		a, b := endpoints[i].Endpoint, endpoints[j].Endpoint
+		if totals[a] != totals[b] {
+			return totals[a] > totals[b]
+		}
+		return a < b
+	})
+	if len(endpoints) > top {
+		endpoints = endpoints[:top]
+	}
+	return types.TimingBreakdown{
+		Components: componentTimings(all, requests),
+		Endpoints:  endpoints,
+	}
+}
+
+// componentTimings summarises each component's milliseconds, largest share
+// first. requests is how many requests logged any component.
+func componentTimings(components map[string][]float64, requests int) []types.ComponentTiming {
+	ms := func(v float64) time.Duration { return time.Duration(v * float64(time.Millisecond)) }
+	sums := make(map[string]float64, len(components))
+	total := 0.0
+	for component, values := range components {
+		sums[component], _ = stats.Sum(values)
+		total += sums[component]
+	}
+
+	timings := make([]types.ComponentTiming, 0, len(components))
+	for component, values := range components {
+		p95, _ := stats.Percentile(values, 95)
+		t := types.ComponentTiming{
+			Component:  component,
+			Samples:    len(values),
+			Avg:        ms(sums[component] / float64(len(values))),
+			P95:        ms(p95),
+			PerRequest: ms(sums[component] / float64(max(requests, 1))),
+		}
+		if total > 0 {
+			t.Share = sums[component] / total * 100
+		}
+		timings = append(timings, t)
+	}
+	sort.Slice(timings, func(i, j int) bool {
+		if timings[i].Share != timings[j].Share {
+			return timings[i].Share > timings[j].Share
+		}
+		return timings[i].Component < timings[j].Component
+	})
+	return timings
+}
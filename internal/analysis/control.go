@@ -0,0 +1,99 @@
+package analysis
+
+import (
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/filter"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// SilenceAll silences notifications for every anomaly type.
+const SilenceAll = "*"
+
+// SetFilter keeps only entries matching expr from now on; nil keeps all.
+// Dropped entries are neither stored nor counted. The filter lasts until
+// the process exits.
+func (e *Engine) SetFilter(expr *filter.Expr) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.filter = expr
+}
+
+// Filter returns the expression set with SetFilter, or "" for none.
+func (e *Engine) Filter() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.filter == nil {
+		return ""
+	}
+	return e.filter.String()
+}
+
+// Silence suppresses notifications for anomalyType (SilenceAll for every
+// type) for d from now on the engine's clock, and returns when the silence
+// ends; a d of 0 lifts the silence. Anomalies are still detected and stored.
+func (e *Engine) Silence(anomalyType string, d time.Duration) time.Time {
+	e.silenceMu.Lock()
+	defer e.silenceMu.Unlock()
+	until := e.clock.Now().Add(d)
+	if d <= 0 {
+		delete(e.silences, anomalyType)
+		return until
+	}
+	if e.silences == nil {
+		e.silences = make(map[string]time.Time)
+	}
+	e.silences[anomalyType] = until
+	return until
+}
+
+// Silences returns the active silences and when they end.
+func (e *Engine) Silences() map[string]time.Time {
+	e.silenceMu.Lock()
+	defer e.silenceMu.Unlock()
+	now := e.clock.Now()
+	active := make(map[string]time.Time, len(e.silences))
+	for t, until := range e.silences {
+		if until.After(now) {
+			active[t] = until
+		} else {
+			delete(e.silences, t)
+		}
+	}
+	return active
+}
+
+func (e *Engine) silenced(anomalyType string, now time.Time) bool {
+	e.silenceMu.Lock()
+	defer e.silenceMu.Unlock()
+	return now.Before(e.silences[anomalyType]) || now.Before(e.silences[SilenceAll])
+}
+
+// RotateSession starts a new session: the recent anomalies, trend history,
+// and error streaks start over, every anomaly type may notify again right
+// away, and a "session" event marks the boundary in reports and Grafana.
+// Windows and baselines come from stored data and carry on. It returns when
+// the previous session started.
+func (e *Engine) RotateSession() (time.Time, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.clock.Now()
+	previous := e.metrics.StartTime
+	e.metrics.StartTime = now
+	e.metrics.Anomalies = []types.Anomaly{}
+	e.metrics.ErrorStreaks = nil
+	e.metrics.FailureIntervals = nil
+	e.metricsHistory = e.metricsHistory[:0]
+	e.metrics.TrendHistory = nil
+	e.streaks = make(map[string]*endpointStreak)
+	e.failures = make(map[string]*endpointFailures)
+	e.lastRecorded = make(map[string]time.Time)
+	e.dirty = true
+	return previous, e.storage.InsertEvent(types.Event{
+		Timestamp: now,
+		Type:      "session",
+		Title:     "New session",
+		Text:      "Session started " + previous.Format(time.RFC3339) + " was rotated",
+		Source:    "pulsewatch ctl",
+	})
+}
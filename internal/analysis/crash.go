@@ -0,0 +1,19 @@
+package analysis
+
+import (
+	"github.com/nitis/pulseWatch/internal/crash"
+	"github.com/nitis/pulseWatch/internal/storage"
+)
+
+// goSafe runs fn in a goroutine that turns a panic into a crash report.
+func goSafe(name string, fn func()) {
+	go func() {
+		defer crash.Recover(name)
+		fn()
+	}()
+}
+
+// StorageStats samples the database, for the crash report.
+func (e *Engine) StorageStats() (storage.Stats, error) {
+	return e.storage.Stats()
+}
@@ -0,0 +1,253 @@
+package analysis
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// maxRegressionsListed bounds the endpoints named in a regression's message.
+const maxRegressionsListed = 5
+
+// deployRegression is an endpoint that got significantly slower or failed
+// significantly more often after a deploy.
+type deployRegression struct {
+	endpoint                string
+	before, after           int // Requests on each side
+	medianBefore            time.Duration
+	medianAfter             time.Duration
+	latencyP                float64
+	errorsBefore            float64 // Percent
+	errorsAfter             float64
+	errorP                  float64
+	slower, moreErrors      bool
+	shareBefore, shareAfter float64
+}
+
+// pValue is the lower of the significant tests' p-values.
+func (r deployRegression) pValue() float64 {
+	p := 1.0
+	if r.slower {
+		p = math.Min(p, r.latencyP)
+	}
+	if r.moreErrors {
+		p = math.Min(p, r.errorP)
+	}
+	return p
+}
+
+// noteDeploy queues a deploy marker for comparison once the window after it
+// has passed.
+func (e *Engine) noteDeploy(ev types.Event) {
+	cfg := e.detection.DeployRegression
+	if cfg.Disable || e.viewOnly {
+		return
+	}
+	for _, t := range cfg.EventTypes {
+		if strings.EqualFold(t, ev.Type) {
+			e.mu.Lock()
+			e.deploys = append(e.deploys, ev)
+			e.mu.Unlock()
+			return
+		}
+	}
+}
+
+// detectDeployRegressions compares each endpoint before and after the
+// deploys whose window has passed, raising one anomaly per deploy that
+// regressed any endpoint.
+func (e *Engine) detectDeployRegressions(now time.Time, ac *anomalyContext) {
+	window := e.detection.DeployRegression.Window
+	pending := e.deploys[:0]
+	for _, ev := range e.deploys {
+		if now.Before(ev.Timestamp.Add(window)) {
+			pending = append(pending, ev)
+			continue
+		}
+		e.checkDeploy(ev, ac)
+	}
+	e.deploys = pending
+}
+
+func (e *Engine) checkDeploy(ev types.Event, ac *anomalyContext) {
+	cfg := e.detection.DeployRegression
+	before, err := e.storage.EndpointSamplesBetween(ev.Timestamp.Add(-cfg.Window), ev.Timestamp)
+	if err != nil {
+		log.Printf("deploy regression: %v", err)
+		return
+	}
+	after, err := e.storage.EndpointSamplesBetween(ev.Timestamp, ev.Timestamp.Add(cfg.Window))
+	if err != nil {
+		log.Printf("deploy regression: %v", err)
+		return
+	}
+
+	totalBefore, totalAfter := 0, 0
+	for _, s := range before {
+		totalBefore += len(s)
+	}
+	for _, s := range after {
+		totalAfter += len(s)
+	}
+	var regressions []deployRegression
+	for endpoint, post := range after {
+		r, ok := compareDeploy(before[endpoint], post, cfg)
+		if !ok {
+			continue
+		}
+		r.endpoint = endpoint
+		r.shareBefore = float64(r.before) / float64(totalBefore) * 100
+		r.shareAfter = float64(r.after) / float64(totalAfter) * 100
+		regressions = append(regressions, r)
+	}
+	if len(regressions) == 0 {
+		return
+	}
+	sort.Slice(regressions, func(i, j int) bool {
+		if regressions[i].pValue() != regressions[j].pValue() {
+			return regressions[i].pValue() < regressions[j].pValue()
+		}
+		return regressions[i].endpoint < regressions[j].endpoint
+	})
+
+	severity, kind := types.SeverityWarning, evidenceSlowest
+	var listed []string
+	var contributors []types.Contributor
+	for i, r := range regressions {
+		if r.moreErrors {
+			severity, kind = types.SeverityCritical, evidenceErrors
+		}
+		if i < maxRegressionsListed {
+			listed = append(listed, r.describe())
+		}
+		contributors = append(contributors, types.Contributor{Dimension: "endpoint", Value: r.endpoint, Share: r.shareAfter, BaselineShare: r.shareBefore})
+	}
+	if more := len(regressions) - len(listed); more > 0 {
+		listed = append(listed, fmt.Sprintf("and %d more", more))
+	}
+	e.addAnomaly(types.Anomaly{
+		Timestamp:    e.clock.Now(),
+		Type:         "Deploy Regression",
+		Severity:     severity,
+		Message:      fmt.Sprintf("%d endpoint(s) regressed after %s %q: %s", len(regressions), ev.Type, ev.Title, strings.Join(listed, ", ")),
+		Contributors: contributors,
+	}, ac, kind)
+}
+
+func (r deployRegression) describe() string {
+	var parts []string
+	if r.slower {
+		parts = append(parts, fmt.Sprintf("median %s, was %s, p=%.2g", r.medianAfter.Round(time.Millisecond), r.medianBefore.Round(time.Millisecond), r.latencyP))
+	}
+	if r.moreErrors {
+		parts = append(parts, fmt.Sprintf("errors %.1f%%, was %.1f%%, p=%.2g", r.errorsAfter, r.errorsBefore, r.errorP))
+	}
+	return fmt.Sprintf("%s (%s)", r.endpoint, strings.Join(parts, "; "))
+}
+
+// compareDeploy tests whether an endpoint's requests after a deploy are
+// slower (Mann-Whitney U on successful latencies) or fail more often (two
+// proportion z-test) than before it, one-sided. ok reports a regression.
+func compareDeploy(pre, post []storage.RequestSample, cfg config.DeployRegressionConfig) (r deployRegression, ok bool) {
+	if len(pre) < cfg.MinRequests || len(post) < cfg.MinRequests {
+		return r, false
+	}
+	r.before, r.after = len(pre), len(post)
+
+	preLatencies, preErrors := splitSamples(pre)
+	postLatencies, postErrors := splitSamples(post)
+	r.errorsBefore = float64(preErrors) / float64(len(pre)) * 100
+	r.errorsAfter = float64(postErrors) / float64(len(post)) * 100
+	r.errorP = proportionIncreaseP(preErrors, len(pre), postErrors, len(post))
+	r.moreErrors = r.errorP < cfg.Significance && r.errorsAfter-r.errorsBefore >= cfg.ErrorIncrease
+
+	if len(preLatencies) >= cfg.MinRequests && len(postLatencies) >= cfg.MinRequests {
+		r.medianBefore = computePercentiles(preLatencies, []float64{50})[0].Latency
+		r.medianAfter = computePercentiles(postLatencies, []float64{50})[0].Latency
+		r.latencyP = mannWhitneyIncreaseP(preLatencies, postLatencies)
+		r.slower = r.latencyP < cfg.Significance &&
+			float64(r.medianAfter) >= float64(r.medianBefore)*(1+cfg.LatencyIncrease/100)
+	}
+	return r, r.slower || r.moreErrors
+}
+
+// splitSamples returns the latencies of the successful requests and the
+// number of failed ones.
+func splitSamples(samples []storage.RequestSample) (latencies []float64, errors int) {
+	for _, s := range samples {
+		if s.StatusCode >= 400 {
+			errors++
+		} else if s.LatencyMs > 0 {
+			latencies = append(latencies, s.LatencyMs)
+		}
+	}
+	return latencies, errors
+}
+
+// proportionIncreaseP is the one-sided p-value of the second proportion
+// being higher than the first.
+func proportionIncreaseP(x1, n1, x2, n2 int) float64 {
+	pooled := float64(x1+x2) / float64(n1+n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+	if se == 0 {
+		return 1
+	}
+	z := (float64(x2)/float64(n2) - float64(x1)/float64(n1)) / se
+	return upperTail(z)
+}
+
+// mannWhitneyIncreaseP is the one-sided p-value of values in b tending to
+// be larger than those in a, by the normal approximation with a correction
+// for ties.
+func mannWhitneyIncreaseP(a, b []float64) float64 {
+	type ranked struct {
+		value float64
+		fromB bool
+	}
+	all := make([]ranked, 0, len(a)+len(b))
+	for _, v := range a {
+		all = append(all, ranked{v, false})
+	}
+	for _, v := range b {
+		all = append(all, ranked{v, true})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].value < all[j].value })
+
+	n := float64(len(all))
+	var rankSumB, ties float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].value == all[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // Average of ranks i+1..j
+		for k := i; k < j; k++ {
+			if all[k].fromB {
+				rankSumB += rank
+			}
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+
+	na, nb := float64(len(a)), float64(len(b))
+	u := rankSumB - nb*(nb+1)/2
+	variance := na * nb / 12 * ((n + 1) - ties/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	return upperTail((u - na*nb/2) / math.Sqrt(variance))
+}
+
+// upperTail is P(Z > z) for a standard normal Z.
+func upperTail(z float64) float64 {
+	return 0.5 * math.Erfc(z/math.Sqrt2)
+}
@@ -0,0 +1,69 @@
+package analysis
+
+import (
+	"log"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	// sizeTarget is the share of the limit pruning brings usage down to, so
+	// it does not run again with the next few inserts.
+	sizeTarget = 0.9
+	// maxPruneRounds bounds the pruning done in one check.
+	maxPruneRounds = 5
+)
+
+// enforceMaxSize keeps the database below storage.max_size by pruning the
+// oldest raw entries (archiving them first if configured). The space they
+// free is reused by SQLite, so the file stops growing rather than shrinking.
+func (e *Engine) enforceMaxSize(now time.Time) {
+	if e.maxSize == 0 {
+		return
+	}
+
+	for round := 0; round < maxPruneRounds; round++ {
+		used, err := e.storage.UsedBytes()
+		if err != nil {
+			log.Printf("Error measuring DB size: %v", err)
+			return
+		}
+		if used <= e.maxSize {
+			e.sizeWarned = false
+			return
+		}
+		rows, err := e.storage.CountLogEntries()
+		if err != nil {
+			log.Printf("Error counting DB entries: %v", err)
+			return
+		}
+		if rows == 0 {
+			if !e.sizeWarned {
+				log.Printf("DB uses %s, above its %s limit, with no raw entries left to prune; lower storage.retention.aggregates", humanize.IBytes(uint64(used)), humanize.IBytes(uint64(e.maxSize)))
+				e.sizeWarned = true
+			}
+			return
+		}
+
+		// Assume the raw entries take the space, and prune the oldest share
+		// that brings usage down to the target
+		share := 1 - sizeTarget*float64(e.maxSize)/float64(used)
+		n := max(int64(float64(rows)*share), 1)
+		cutoff, err := e.storage.LogEntryTimeAt(min(n, rows-1))
+		if err != nil {
+			log.Printf("Error finding DB prune cutoff: %v", err)
+			return
+		}
+		if n >= rows {
+			cutoff = cutoff.Add(time.Nanosecond) // Include the newest entry
+		}
+		if !e.pruneEntriesBefore(cutoff, now) {
+			return
+		}
+		if err := e.storage.Checkpoint(); err != nil {
+			log.Printf("Error checkpointing DB: %v", err)
+			return
+		}
+	}
+}
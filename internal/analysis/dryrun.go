@@ -0,0 +1,142 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/filter"
+	"github.com/nitis/pulseWatch/internal/locale"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// DryRunConfig returns a copy of cfg without the sinks that leave the
+// process: notifications, forwarding, exports, and archival. Create the
+// engine of a dry run from it, on a throwaway database, and pass the
+// original to SetDryRun.
+func DryRunConfig(cfg *config.Config) *config.Config {
+	dry := *cfg
+	dry.Notify = config.NotifyConfig{}
+	dry.Forward = nil
+	dry.Export = config.ExportConfig{}
+	dry.Storage.Archive = config.ArchiveConfig{}
+	return &dry
+}
+
+// dryRun tallies what a dry run would have stored, alerted, and shipped.
+type dryRun struct {
+	mu            sync.Mutex
+	cfg           *config.Config // The real configuration
+	channels      []string       // Configured notification channels
+	entries       int
+	anomalies     map[string]int // Type -> times recorded
+	severities    map[string]string
+	notifications int
+	forwards      []dryForward
+}
+
+// dryForward counts the entries a forward rule matches.
+type dryForward struct {
+	rule    config.ForwardRule
+	expr    *filter.Expr
+	matched int
+}
+
+// SetDryRun makes the engine tally what cfg, the configuration it stands
+// in for, would have stored, notified, forwarded, and exported, for
+// DryRunSummary. Call it before Start.
+func (e *Engine) SetDryRun(cfg *config.Config) error {
+	d := &dryRun{cfg: cfg, anomalies: make(map[string]int), severities: make(map[string]string)}
+	for _, n := range newNotifiers(cfg.Notify) {
+		d.channels = append(d.channels, n.Name())
+	}
+	for _, r := range cfg.Forward {
+		expr, err := forwardFilter(r)
+		if err != nil {
+			return err
+		}
+		d.forwards = append(d.forwards, dryForward{rule: r, expr: expr})
+	}
+	e.dryRun = d
+	return nil
+}
+
+// offer counts an entry that would have been stored and shipped.
+func (d *dryRun) offer(entry types.LogEntry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries++
+	for i := range d.forwards {
+		if d.forwards[i].expr.Match(entry) {
+			d.forwards[i].matched++
+		}
+	}
+}
+
+// anomaly counts a recorded anomaly and whether it would have notified.
+func (d *dryRun) anomaly(a types.Anomaly, silenced bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.anomalies[a.Type]++
+	d.severities[a.Type] = a.Severity
+	if len(d.channels) > 0 && !silenced && types.SeverityRank(a.Severity) <= types.SeverityRank(d.cfg.Notify.MinSeverity) {
+		d.notifications++
+	}
+}
+
+// DryRunSummary describes what the dry run would have done; empty if the
+// engine isn't in a dry run.
+func (e *Engine) DryRunSummary() string {
+	d := e.dryRun
+	if d == nil {
+		return ""
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("Dry run: nothing was stored, notified, forwarded, or exported.\n")
+	fmt.Fprintf(&b, "Would store %s entries.\n", locale.Int(int64(d.entries)))
+
+	recorded := 0
+	kinds := make([]string, 0, len(d.anomalies))
+	for t, n := range d.anomalies {
+		recorded += n
+		kinds = append(kinds, t)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if d.anomalies[kinds[i]] != d.anomalies[kinds[j]] {
+			return d.anomalies[kinds[i]] > d.anomalies[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
+	})
+	fmt.Fprintf(&b, "Would record %s anomalies.\n", locale.Int(int64(recorded)))
+	for _, t := range kinds {
+		fmt.Fprintf(&b, "  %s × %s (%s)\n", locale.Int(int64(d.anomalies[t])), t, d.severities[t])
+	}
+	if len(d.channels) > 0 {
+		fmt.Fprintf(&b, "Would send %s notifications via %s (min severity %s).\n",
+			locale.Int(int64(d.notifications)), strings.Join(d.channels, ", "), d.cfg.Notify.MinSeverity)
+	}
+
+	for _, f := range d.forwards {
+		line := fmt.Sprintf("Would forward %s entries to %s (%s)", locale.Int(int64(f.matched)), f.rule.Name, f.rule.Type)
+		if f.rule.Sample < 1 {
+			line += fmt.Sprintf(", about %s after sampling", locale.Int(int64(float64(f.matched)*f.rule.Sample)))
+		}
+		b.WriteString(line + ".\n")
+	}
+	if ch := d.cfg.Export.ClickHouse; ch.DSN != "" {
+		fmt.Fprintf(&b, "Would export %s entries to ClickHouse table %s.\n", locale.Int(int64(d.entries)), ch.Table)
+	}
+	if rw := d.cfg.Export.RemoteWrite; rw.URL != "" {
+		fmt.Fprintf(&b, "Would push metrics to %s every %s.\n", rw.URL, rw.Interval)
+	}
+	if a := d.cfg.Storage.Archive; a.Dir != "" || a.S3.Bucket != "" {
+		fmt.Fprintf(&b, "Would archive entries older than %s before pruning them.\n", d.cfg.Storage.Retention.Raw.Round(time.Minute))
+	}
+	return b.String()
+}
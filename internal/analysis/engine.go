@@ -5,29 +5,43 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"regexp"
 	"sync"
 	"time"
 
 	"github.com/VividCortex/ewma"
 	"github.com/montanaflynn/stats"
+	"github.com/nitis/pulseWatch/internal/archive"
+	"github.com/nitis/pulseWatch/internal/bus"
+	"github.com/nitis/pulseWatch/internal/clickhouse"
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/filter"
+	"github.com/nitis/pulseWatch/internal/forward"
+	"github.com/nitis/pulseWatch/internal/groupby"
+	"github.com/nitis/pulseWatch/internal/notify"
+	"github.com/nitis/pulseWatch/internal/parser"
+	"github.com/nitis/pulseWatch/internal/remotewrite"
 	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/topk"
 	"github.com/nitis/pulseWatch/internal/types"
+	"github.com/nitis/pulseWatch/pkg/clock"
 )
 
 const (
 	defaultWindow         = 5 * time.Minute
-	defaultTickInterval   = 1 * time.Second
 	latencyPercentile     = 95
-	errorRateSpikeThreshold = 3.0 // 3x increase
 	pruneInterval         = 1 * time.Hour // Prune DB every hour
-	maxDBAge              = 7 * 24 * time.Hour // Keep 7 days in DB
 	maxMetricsHistory     = 20 // Keep last 20 metrics for trends
 )
 
 // Engine is the analysis engine for pulsewatch.
 type Engine struct {
 	windowDuration time.Duration
-	tickInterval   time.Duration
+	refresh        config.RefreshConfig
+	tickInterval   time.Duration // Effective interval; grows in adaptive mode
+	ingested       int           // Entries added since the last tick
+	lastTick       time.Time
+	clock          clock.Clock
 	windows        map[string]time.Duration
 	initialScan    bool
 	customMetrics  []types.CustomMetric
@@ -37,11 +51,18 @@ type Engine struct {
 	mu         sync.Mutex
 	dirty      bool // New field to track if new logs have been added
 
-	rpsEWMA ewma.MovingAverage
+	detection     config.DetectionConfig
+	rpsEWMA       ewma.MovingAverage
+	errorRateEWMA ewma.MovingAverage
+	latencyEWMA   ewma.MovingAverage
+	deploys       []types.Event // Deploy markers awaiting the regression check
 
 	metrics                types.Metrics
 	metricsChan            chan types.Metrics
 	doneChan               chan struct{}
+	draining               chan struct{} // Closed by Drain: metrics are no longer consumed
+	drained                chan struct{} // Closed once processLogs has returned
+	drainOnce, stopOnce    sync.Once
 	statusCodeDistribution map[string]int
 	storage                *storage.Storage
 	lastPrune              time.Time
@@ -49,49 +70,225 @@ type Engine struct {
 	rpsHistory             []float64
 	errorRateHistory       []float64
 	latencyHistory         []float64
+	cacheHitHistory        []float64
+	baselineBucket         seasonalBucket
+	baselines              map[string]storage.Baseline
+	lastBaselineSample     time.Time
+	lastRecorded           map[string]time.Time // Anomaly type -> last time it was persisted
+	samplesSeen            int
+	archiver               *archive.Archiver
+	retention              config.RetentionConfig
+	lastRollup             time.Time
+	lastInternals          time.Time
+	lastVacuum             time.Time
+	maxSize                int64 // Bytes; 0 for no limit
+	sizeWarned             bool
+	percentiles            config.PercentilesConfig
+	latencySLA             config.LatencySLAConfig
+	tenant                 config.TenantConfig
+	grpcStatusCodes        map[string]int // Configured gRPC status -> HTTP status overrides
+	graphQLTop             int
+	version                config.VersionConfig
+	timing                 config.TimingConfig
+	groupBy                *groupby.Expr // nil groups by endpoint
+	groupTop               int
+	maxKeys                int // Values tracked per high-cardinality dimension
+	streaks                map[string]*endpointStreak
+	failures               map[string]*endpointFailures
+	session                config.SessionConfig
+	sessions               map[string]sessionState
+	retries                config.RetryConfig
+	retryClients           map[string]retrySeen // Client, method, and endpoint -> last request
+	rateLimiters           []*rateLimiter       // Simulated rate-limit policies
+	p95SLO                 time.Duration        // P95 objective capacity headroom is measured against
+	errorCategories        []errorCategory      // Configured error categories, first match wins
+	fatalPatterns          []*regexp.Regexp     // Crash lines alerted on as soon as they are read
+	retryKeys              map[string]retrySeen // Idempotency key -> last request
+	reportOnEOF            bool
+	remoteWrite            config.RemoteWriteConfig
+	remoteWriter           *remotewrite.Client // nil when remote write is off
+	remoteWriteCh          chan []remotewrite.Series
+	histogram              *latencyHistogram
+	pushedExemplars        []types.Exemplar // Per histogram bucket, the last exemplar remote write sent
+	notifiers              []notify.Notifier // Empty when no push channel is configured
+	notifyMinSeverity      string
+	notifyCh               chan types.Anomaly
+	anomalyTopic           *bus.Topic[types.Anomaly] // nil when nothing subscribes to anomalies
+	pipeline               *bus.Bus                  // nil unless SetPipeline was called
+	queueDepths            map[string][]int          // Topic/consumer -> queued values per tick, oldest first
+	inputHealth            <-chan types.InputHealth  // nil unless SetInputHealth was called
+	inputs                 map[string]types.InputHealth
+	viewOnly               bool                      // Only read the store; see NewViewEngine
+	dryRun                 *dryRun                   // nil unless SetDryRun was called
+	clickhouse             *clickhouse.Sink // nil when the ClickHouse sink is off
+	forwarders             []*forward.Forwarder
+	lastRemoteWrite        time.Time
+	inputLog               []types.LogEntry // Entries kept for the EOF report
+
+	thresholdsMu sync.RWMutex // Separate from mu so the TUI never waits on a metrics send
+	thresholds   types.Thresholds
+
+	statusMu sync.RWMutex // Separate from mu so API requests never wait on a metrics send
+	status   types.Status
+
+	parseMu          sync.Mutex // Separate from mu so parsing never waits on a tick
+	parseCounts      map[string]*parseCount
+	parseWindowStart time.Time
+
+	probes        []*probeState // Empty without probes or for historical scans
+	probeMu       sync.Mutex    // Guards probes' state; results arrive from probe goroutines
+	probesChanged bool
+
+	filter      *filter.Expr // Set at runtime; nil keeps every entry
+	scopeMu     sync.Mutex   // Guards the scope, read by the ticker before it takes mu
+	scope       string       // Text the scoped windows are limited to; "" for none
+	scopeSource string       // Input the scoped windows are limited to; "" for all
+	silenceMu   sync.Mutex
+	silences    map[string]time.Time // Anomaly type (or SilenceAll) -> end of its silence
 }
 
 // NewEngine creates a new analysis engine.
-func NewEngine(dbPath string, initialScan bool, customMetrics []types.CustomMetric) (*Engine, error) {
-	stor, err := storage.NewStorage(dbPath)
+func NewEngine(dbPath string, initialScan bool, cfg *config.Config) (*Engine, error) {
+	stor, err := storage.NewStorage(dbPath, storage.Options{Compress: cfg.Storage.Compress})
 	if err != nil {
 		return nil, err
 	}
+	return newEngine(stor, initialScan, cfg)
+}
 
+// newEngine creates an engine over stor, which it closes on failure.
+func newEngine(stor *storage.Storage, initialScan bool, cfg *config.Config) (*Engine, error) {
 	windows := map[string]time.Duration{
 		"1m":  1 * time.Minute,
 		"5m":  5 * time.Minute,
 		"1h":  1 * time.Hour,
 	}
 
+	maxSize, err := cfg.Storage.MaxSizeBytes()
+	if err != nil {
+		stor.Close()
+		return nil, err
+	}
+
+	stor.SetTopKeys(cfg.Cardinality.MaxKeys)
+	clk := clock.Real()
 	e := &Engine{
+		clock:          clk,
 		windowDuration: defaultWindow,
-		tickInterval:   defaultTickInterval,
+		refresh:        cfg.Refresh,
+		tickInterval:   cfg.Refresh.Tick,
+		lastTick:       clk.Now(),
 		windows:        windows,
 		initialScan:    initialScan,
+		customMetrics:  cfg.CustomMetrics,
+		retention:      cfg.Storage.Retention,
+		detection:      cfg.Detection,
+		thresholds:     cfg.Thresholds(),
+		percentiles:    cfg.Percentiles,
+		latencySLA:     cfg.LatencySLA,
+		tenant:         cfg.Tenant,
+		session:        cfg.Session,
+		remoteWrite:    cfg.Export.RemoteWrite,
+		groupTop:       cfg.Grouping.Top,
+		maxKeys:        cfg.Cardinality.MaxKeys,
 		logEntries:     list.New(),
-		rpsEWMA:        ewma.NewMovingAverage(),
+		rpsEWMA:        newEWMA(cfg.Detection.EWMA.Alpha),
+		errorRateEWMA:  newEWMA(cfg.Detection.EWMA.Alpha),
+		latencyEWMA:    newEWMA(cfg.Detection.EWMA.Alpha),
 		metricsChan:    make(chan types.Metrics),
 		doneChan:       make(chan struct{}),
+		draining:       make(chan struct{}),
+		drained:        make(chan struct{}),
 		metrics: types.Metrics{
 			Windows:   make(map[string]types.WindowedMetrics),
 			Anomalies: []types.Anomaly{},
-			StartTime: time.Now(),
+			StartTime: clk.Now(),
+			Learning:  !initialScan,
 		},
 		statusCodeDistribution: make(map[string]int),
 		storage:                stor,
 		dirty:                  false,
-		lastPrune:              time.Now(),
+		lastPrune:              clk.Now(),
+		parseWindowStart:       clk.Now(),
+		lastVacuum:             clk.Now(),
+		maxSize:                maxSize,
 		metricsHistory:         make([]types.TrendPoint, 0, maxMetricsHistory),
 		rpsHistory:             make([]float64, 0, maxMetricsHistory),
 		errorRateHistory:       make([]float64, 0, maxMetricsHistory),
 		latencyHistory:         make([]float64, 0, maxMetricsHistory),
+		lastRecorded:           make(map[string]time.Time),
+		streaks:                make(map[string]*endpointStreak),
+		failures:               make(map[string]*endpointFailures),
+		sessions:               make(map[string]sessionState),
+		grpcStatusCodes:        cfg.GRPC.Overrides(),
+		graphQLTop:             cfg.GraphQL.Top,
+		version:                cfg.Version,
+		timing:                 cfg.Timing,
+		retries:                cfg.Retries,
+		retryClients:           make(map[string]retrySeen),
+		retryKeys:              make(map[string]retrySeen),
+		rateLimiters:           newRateLimiters(cfg.RateLimits, cfg.Cardinality.MaxKeys),
+		p95SLO:                 cfg.SLO.P95,
+		histogram:              newLatencyHistogram(cfg.Export.Histogram, clk.Now()),
 	}
 
 	if initialScan {
 		e.windowDuration = 10 * 365 * 24 * time.Hour // Keep all for initial scan
 	}
 
+	if e.archiver, err = newArchiver(cfg.Storage.Archive); err != nil {
+		stor.Close()
+		return nil, err
+	}
+
+	if e.errorCategories, err = newErrorCategories(cfg.ErrorCategories); err != nil {
+		stor.Close()
+		return nil, err
+	}
+
+	if e.fatalPatterns, err = newFatalPatterns(cfg.Fatal); err != nil {
+		stor.Close()
+		return nil, err
+	}
+
+	if cfg.Grouping.By != "" {
+		if e.groupBy, err = groupby.Parse(cfg.Grouping.By); err != nil {
+			stor.Close()
+			return nil, fmt.Errorf("grouping: %w", err)
+		}
+		e.metrics.GroupBy = e.groupBy.String()
+	}
+
+	if rw := cfg.Export.RemoteWrite; rw.URL != "" && !initialScan {
+		e.remoteWriter = remotewrite.NewClient(rw.URL, rw.Username, rw.Password, rw.Headers)
+		e.remoteWriteCh = make(chan []remotewrite.Series, 1)
+	}
+
+	if ch := cfg.Export.ClickHouse; ch.DSN != "" {
+		if e.clickhouse, err = clickhouse.NewSink(ch.DSN, ch.Table, ch.Columns, ch.BatchSize, ch.FlushInterval); err != nil {
+			stor.Close()
+			return nil, err
+		}
+		if ch.CreateTable {
+			if err := e.clickhouse.CreateTable(); err != nil {
+				stor.Close()
+				return nil, fmt.Errorf("creating ClickHouse table: %w", err)
+			}
+		}
+	}
+
+	if e.forwarders, err = newForwarders(cfg.Forward); err != nil {
+		stor.Close()
+		return nil, err
+	}
+
+	if !initialScan {
+		e.probes = newProbes(cfg.Probes)
+		e.notifiers = newNotifiers(cfg.Notify)
+		e.notifyMinSeverity = cfg.Notify.MinSeverity
+		e.notifyCh = make(chan types.Anomaly, maxPendingNotifications)
+	}
+
 	return e, nil
 }
 
@@ -99,35 +296,76 @@ func NewEngine(dbPath string, initialScan bool, customMetrics []types.CustomMetr
 func (e *Engine) Start(logChan <-chan types.LogEntry) <-chan types.Metrics {
 	// Load existing entries from DB
 	e.loadExistingEntries()
-	go e.processLogs(logChan)
-	go e.runTicker()
+	goSafe("analysis", func() { e.processLogs(logChan) })
+	goSafe("ticker", e.runTicker)
+	if e.remoteWriter != nil {
+		goSafe("remote write", e.runRemoteWrite)
+	}
+	if len(e.notifiers) > 0 {
+		goSafe("notifier", e.runNotifier)
+	}
+	if e.clickhouse != nil {
+		goSafe("clickhouse export", e.clickhouse.Run)
+	}
+	for _, f := range e.forwarders {
+		goSafe("forwarder", f.Run)
+	}
 	return e.metricsChan
 }
 
-// Stop halts the analysis engine.
-func (e *Engine) Stop() {
-	e.storage.Close()
-	close(e.doneChan)
+// FlushExports sends entries still buffered for external sinks. Call it once
+// before exiting.
+func (e *Engine) FlushExports() {
+	if e.clickhouse != nil {
+		e.clickhouse.Close()
+	}
+	for _, f := range e.forwarders {
+		f.Close()
+	}
 }
 
-func (e *Engine) loadExistingEntries() {
-	// entries, err := e.storage.GetLogEntriesSince(time.Now().Add(-maxDBAge))
-	// if err != nil {
-	// 	log.Printf("Error loading existing entries: %v", err)
-	// 	return
-	// }
-	// for _, entry := range entries {
-	// 	e.addLogEntry(entry)
-	// }
+// Drain waits up to timeout for the entries already on the bus to be
+// analysed and stored, once the bus has been closed. Metrics computed
+// meanwhile are discarded, as their consumer is gone. It reports whether
+// every entry made it.
+func (e *Engine) Drain(timeout time.Duration) bool {
+	e.drainOnce.Do(func() { close(e.draining) })
+	select {
+	case <-e.drained:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
 
+// Stop halts the analysis engine and closes the database. It is safe to
+// call more than once.
+func (e *Engine) Stop() {
+	e.stopOnce.Do(func() {
+		close(e.doneChan)
+		e.storage.Close()
+	})
+}
+
+// sendMetrics hands the current metrics to Start's consumer, unless the
+// engine is draining or stopped.
+func (e *Engine) sendMetrics() {
+	select {
+	case e.metricsChan <- e.metrics:
+	case <-e.draining:
+	case <-e.doneChan:
+	}
 }
 
 func (e *Engine) processLogs(logChan <-chan types.LogEntry) {
+	defer close(e.drained)
 	for {
 		select {
 		case logEntry, ok := <-logChan:
 			if !ok {
-				if e.initialScan {
+				if !e.initialScan && e.reportOnEOF {
+					e.sendFinalReport()
+				} else if e.initialScan {
 					e.calculateMetrics()
 					e.detectAnomalies()
 					// Append to history
@@ -136,31 +374,9 @@ func (e *Engine) processLogs(logChan <-chan types.LogEntry) {
 						wm, ok = e.metrics.Windows["1m"]
 					}
 					if ok {
-						tp := types.TrendPoint{
-							RPS:       wm.RPS,
-							P95Latency: wm.P95Latency,
-							ErrorRate: wm.ErrorRate,
-						}
-						e.metricsHistory = append(e.metricsHistory, tp)
-						if len(e.metricsHistory) > maxMetricsHistory {
-							e.metricsHistory = e.metricsHistory[1:]
-						}
-						e.rpsHistory = append(e.rpsHistory, wm.RPS)
-						if len(e.rpsHistory) > maxMetricsHistory {
-							e.rpsHistory = e.rpsHistory[1:]
-						}
-						e.errorRateHistory = append(e.errorRateHistory, wm.ErrorRate)
-						if len(e.errorRateHistory) > maxMetricsHistory {
-							e.errorRateHistory = e.errorRateHistory[1:]
-						}
-						e.latencyHistory = append(e.latencyHistory, float64(wm.P95Latency.Milliseconds()))
-						if len(e.latencyHistory) > maxMetricsHistory {
-							e.latencyHistory = e.latencyHistory[1:]
-						}
+						e.recordTrendPoint(wm)
 					}
-					e.metrics.TrendHistory = make([]types.TrendPoint, len(e.metricsHistory))
-					copy(e.metrics.TrendHistory, e.metricsHistory)
-					e.metricsChan <- e.metrics
+					e.sendMetrics()
 				}
 				return
 			}
@@ -175,8 +391,42 @@ func (e *Engine) addLogEntry(entry types.LogEntry) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	now := time.Now()
+	now := e.clock.Now()
+	if e.tenant.Field != "" {
+		entry.Tenant = fieldString(entry, e.tenant.Field)
+	}
+	if e.version.Field != "" {
+		entry.Version = fieldString(entry, e.version.Field)
+	}
+	entry.Timings = parser.ComponentTimings(entry.Fields, e.timing.Components)
+	if status, ok := e.grpcStatusCodes[entry.GRPCStatus]; ok {
+		entry.StatusCode = status
+	}
+	e.classifyError(&entry)
+	if e.groupBy != nil {
+		entry.GroupKey = e.groupBy.Eval(entry)
+	}
+	if e.filter != nil && !e.filter.Match(entry) {
+		return
+	}
+	e.recordSession(&entry)
+	e.recordRetry(&entry)
+	e.recordRateLimits(entry)
+	e.checkFatal(entry)
+	e.keepForReport(entry)
+	e.ingested++
 	e.logEntries.PushBack(entry)
+	e.recordStreak(entry)
+	e.histogram.observe(entry)
+	if e.clickhouse != nil {
+		e.clickhouse.Add(entry)
+	}
+	for _, f := range e.forwarders {
+		f.Offer(entry)
+	}
+	if e.dryRun != nil {
+		e.dryRun.offer(entry)
+	}
 
 	// Insert to DB
 	if err := e.storage.InsertLogEntry(entry); err != nil {
@@ -214,75 +464,151 @@ func (e *Engine) prune(now time.Time) {
 	}
 }
 
+func newArchiver(cfg config.ArchiveConfig) (*archive.Archiver, error) {
+	switch {
+	case cfg.Dir != "":
+		return archive.NewArchiver(&archive.LocalSink{Dir: cfg.Dir}), nil
+	case cfg.S3.Bucket != "":
+		sink, err := archive.NewS3Sink(cfg.S3.Bucket, cfg.S3.Prefix, cfg.S3.Region, cfg.S3.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("archive: %w", err)
+		}
+		return archive.NewArchiver(sink), nil
+	}
+	return nil, nil
+}
+
 func (e *Engine) pruneDB(now time.Time) {
-	olderThan := now.Add(-maxDBAge)
-	if err := e.storage.PruneOldEntries(olderThan); err != nil {
+	if err := e.storage.PruneAggregates(now.Add(-e.retention.Aggregates)); err != nil {
+		log.Printf("Error pruning aggregates: %v", err)
+	}
+
+	e.pruneEntriesBefore(now.Add(-e.retention.Raw), now)
+}
+
+// pruneEntriesBefore deletes raw entries older than olderThan, archiving them
+// first if archival is configured. It reports whether they were deleted.
+func (e *Engine) pruneEntriesBefore(olderThan, now time.Time) bool {
+	var archive func([]storage.StoredEntry) error
+	if e.archiver != nil {
+		archive = func(entries []storage.StoredEntry) error {
+			if err := e.archiver.Archive(entries, now); err != nil {
+				// Keep the data rather than delete something we couldn't archive
+				return fmt.Errorf("archiving entries, stopped pruning: %w", err)
+			}
+			return nil
+		}
+	}
+	if err := e.storage.PruneOldEntries(olderThan, archive); err != nil {
 		log.Printf("Error pruning DB: %v", err)
+		return false
+	}
+	return true
+}
+
+// recordTrendPoint appends the window's values to the trend and detection
+// histories and feeds the EWMA smoothers.
+func (e *Engine) recordTrendPoint(wm types.WindowedMetrics) {
+	latencyMs := float64(wm.P95Latency.Milliseconds())
+	e.samplesSeen++
+	e.rpsEWMA.Add(wm.RPS)
+	e.errorRateEWMA.Add(wm.ErrorRate)
+	e.latencyEWMA.Add(latencyMs)
+
+	tp := types.TrendPoint{
+		RPS:               wm.RPS,
+		P95Latency:        wm.P95Latency,
+		ErrorRate:         wm.ErrorRate,
+		SmoothedRPS:       smoothedOr(e.rpsEWMA, wm.RPS),
+		SmoothedP95:       time.Duration(smoothedOr(e.latencyEWMA, latencyMs)) * time.Millisecond,
+		SmoothedErrorRate: smoothedOr(e.errorRateEWMA, wm.ErrorRate),
+		CacheHitRatio:     wm.Cache.HitRatio(),
+		CacheLookups:      wm.Cache.Lookups,
+		LevelRPS:          levelRPS(wm.Levels),
 	}
+	e.metricsHistory = appendBounded(e.metricsHistory, tp)
+	e.rpsHistory = appendBounded(e.rpsHistory, wm.RPS)
+	e.errorRateHistory = appendBounded(e.errorRateHistory, wm.ErrorRate)
+	e.latencyHistory = appendBounded(e.latencyHistory, latencyMs)
+	if wm.Cache.Lookups > 0 {
+		e.cacheHitHistory = appendBounded(e.cacheHitHistory, wm.Cache.HitRatio())
+	}
+
+	e.metrics.TrendHistory = make([]types.TrendPoint, len(e.metricsHistory))
+	copy(e.metrics.TrendHistory, e.metricsHistory)
+}
+
+func appendBounded[T any](history []T, v T) []T {
+	history = append(history, v)
+	if len(history) > maxMetricsHistory {
+		history = history[1:]
+	}
+	return history
 }
 
 func (e *Engine) runTicker() {
-	ticker := time.NewTicker(e.tickInterval)
+	ticker := e.clock.NewTicker(e.tickInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-ticker.C():
+			// Queried before taking mu, so a slow scope never holds up ingestion
+			scoped := e.scopedWindows()
 			e.mu.Lock() // Lock to check and modify dirty flag
+			e.metrics.Scope, e.metrics.ScopeSource, e.metrics.ScopedWindows = scoped.scope, scoped.source, scoped.windows
+			if e.viewOnly {
+				e.dirty = true // Another process may be writing the store
+			}
+			if tick := e.adaptTick(e.clock.Now()); tick != e.tickInterval {
+				e.tickInterval = tick
+				ticker.Reset(tick)
+			}
+			e.detectParseFailures(e.clock.Now())
+			e.updateProbes(e.clock.Now())
+			e.updatePipeline()
+			e.updateInputHealth(e.clock.Now())
+			e.expireRetries(e.clock.Now())
 			if e.dirty {
 				e.calculateMetrics()
-				e.detectAnomalies()
+				if e.viewOnly {
+					e.loadStoredAnomalies(e.clock.Now())
+				} else {
+					e.detectAnomalies()
+					e.recordRollup(e.clock.Now())
+				}
+				e.updateForecast(e.clock.Now())
+				e.updateCapacity(e.clock.Now())
+				e.updateRateLimits()
+				e.updateInternals(e.clock.Now())
+				if !e.viewOnly {
+					e.enforceMaxSize(e.clock.Now())
+				}
 				// Append to history
 				if wm, ok := e.metrics.Windows["1m"]; ok {
-					tp := types.TrendPoint{
-						RPS:       wm.RPS,
-						P95Latency: wm.P95Latency,
-						ErrorRate: wm.ErrorRate,
-					}
-					e.metricsHistory = append(e.metricsHistory, tp)
-					if len(e.metricsHistory) > maxMetricsHistory {
-						e.metricsHistory = e.metricsHistory[1:]
-					}
-					e.rpsHistory = append(e.rpsHistory, wm.RPS)
-					if len(e.rpsHistory) > maxMetricsHistory {
-						e.rpsHistory = e.rpsHistory[1:]
-					}
-					e.errorRateHistory = append(e.errorRateHistory, wm.ErrorRate)
-					if len(e.errorRateHistory) > maxMetricsHistory {
-						e.errorRateHistory = e.errorRateHistory[1:]
-					}
-					e.latencyHistory = append(e.latencyHistory, float64(wm.P95Latency.Milliseconds()))
-					if len(e.latencyHistory) > maxMetricsHistory {
-						e.latencyHistory = e.latencyHistory[1:]
-					}
+					e.recordTrendPoint(wm)
 				}
-				e.metrics.TrendHistory = make([]types.TrendPoint, len(e.metricsHistory))
-				copy(e.metrics.TrendHistory, e.metricsHistory)
-				e.metricsChan <- e.metrics
+				e.publishStatus(e.clock.Now())
+				e.sendMetrics()
 				e.dirty = false
+			} else if e.remoteWriteDue(e.clock.Now()) || e.statusDue(e.clock.Now()) {
+				// Keep exported rates current while no logs arrive
+				e.calculateMetrics()
+				e.publishStatus(e.clock.Now())
 			}
+			e.queueRemoteWrite(e.clock.Now())
 
 			// Periodic prune
-			if time.Since(e.lastPrune) > pruneInterval {
-				now := time.Now()
+			if !e.viewOnly && e.clock.Now().Sub(e.lastPrune) > pruneInterval {
+				now := e.clock.Now()
 				e.pruneDB(now)
+				e.maybeVacuum(now)
+				e.expireSessions(now)
 				e.lastPrune = now
 			}
 			e.mu.Unlock() // Unlock after operations
 		case <-e.doneChan:
 			return
-		default:
-			// For live monitoring, send metrics if dirty
-			if !e.initialScan {
-				e.mu.Lock()
-				if e.dirty {
-					e.calculateMetrics()
-					e.detectAnomalies()
-					e.metricsChan <- e.metrics
-					e.dirty = false
-				}
-				e.mu.Unlock()
-			}
 		}
 	}
 }
@@ -299,79 +625,189 @@ func (e *Engine) calculateMetrics() {
 		wm := e.computeWindowedMetrics(entries, 0)
 		e.metrics.Windows["all"] = wm
 	} else {
+		now := e.clock.Now()
 		for key, window := range e.windows {
-			entries, err := e.storage.GetEntriesInWindow(window)
+			wm, err := e.liveWindow(now.Add(-window), window, storage.EntryFilter{})
 			if err != nil {
-				log.Printf("Error getting entries for window %s: %v", key, err)
+				log.Printf("Error aggregating window %s: %v", key, err)
 				continue
 			}
-
-			wm := e.computeWindowedMetrics(entries, window)
 			e.metrics.Windows[key] = wm
 		}
 	}
 }
 
-func (e *Engine) computeWindowedMetrics(entries []types.LogEntry, window time.Duration) types.WindowedMetrics {
-	if len(entries) == 0 {
-		return types.WindowedMetrics{
-			TopEndpoints:           make(map[string]int),
-			StatusCodeDistribution: make(map[string]int),
-			Custom:                 make(map[string]int),
-		}
+// liveWindow computes a window's metrics from the stored entries since since
+// that match f, aggregated in SQL rather than loaded into memory. It only
+// reads the engine's configuration and storage, so it doesn't need e.mu.
+func (e *Engine) liveWindow(since time.Time, window time.Duration, f storage.EntryFilter) (types.WindowedMetrics, error) {
+	agg, err := e.storage.AggregateMatching(since, f)
+	if err != nil {
+		return types.WindowedMetrics{}, err
 	}
+	wm := windowedMetricsFromAggregate(agg, window, e.percentiles.Default)
+	wm.EndpointPercentiles = e.liveEndpointPercentiles(since, f, wm.TopEndpoints)
+	wm.LatencySLA = computeLatencySLA(agg.Latencies, e.latencySLA.Default)
+	wm.EndpointLatencySLA = e.liveEndpointLatencySLA(since, f, wm.TopEndpoints)
+	wm.Tenants = e.tenantStats(agg, window, e.liveTenantLatencies(since, f))
+	wm.TopGroups = e.topGroups(agg)
+	wm.Sessions = e.sessionStats(agg)
+	wm.Sources = sourceStats(agg, window)
+	wm.Operations = requestStats(agg.Operations, agg.Total, e.graphQLTop, window, e.liveOperationLatencies(since, f))
+	wm.Versions = e.versionStats(agg, window, e.liveVersionLatencies(since, f))
+	wm.Retries = e.retryStats(agg)
+	wm.ErrorCategories = errorCategoryStats(agg)
+	wm.Breakdown = timingBreakdown(agg, e.timing.Top)
+	return wm, nil
+}
 
-	var latencies []float64
-	topEndpoints := make(map[string]int)
-	statusCodeDist := make(map[string]int)
-	totalRequests := len(entries)
-	totalErrors := 0
-
+func (e *Engine) computeWindowedMetrics(entries []types.LogEntry, window time.Duration) types.WindowedMetrics {
+	agg := storage.NewWindowAggregate()
+	agg.Total = len(entries)
+	tenantLatencies := make(map[string][]float64)
+	operationLatencies := make(map[string][]float64)
+	versionLatencies := make(map[string][]float64)
+	sessions := make(map[string]bool)
+	endpoints, groups := topk.New(e.maxKeys), topk.New(e.maxKeys)
 	for _, entry := range entries {
+		agg.AddCacheStatus(entry.Endpoint, entry.CacheStatus, 1)
+		agg.AddTimings(entry.Endpoint, entry.Timings)
+		if entry.ServiceTime > 0 {
+			agg.QueueTimes = append(agg.QueueTimes, float64(entry.QueueTime.Milliseconds()))
+			agg.ServiceTimes = append(agg.ServiceTimes, float64(entry.ServiceTime.Milliseconds()))
+		}
+		isError := 0
 		if entry.StatusCode >= 400 {
-			totalErrors++
+			agg.Errors++
+			isError = 1
 		}
+		agg.AddMethod(entry.Endpoint, entry.Method, 1, isError)
+		agg.AddGRPC(entry.Endpoint, entry.GRPCStatus, 1, isError)
 		if entry.Endpoint != "" {
-			topEndpoints[entry.Endpoint]++
+			endpoints.Add(entry.Endpoint, 1)
 		}
 		if entry.StatusCode < 400 && entry.Latency > 0 {
-			latencies = append(latencies, float64(entry.Latency.Milliseconds()))
-		}
-
-		statusCodeCategory := func(code int) string {
-			switch {
-			case code >= 100 && code < 200:
-				return "1xx"
-			case code >= 200 && code < 300:
-				return "2xx"
-			case code >= 300 && code < 400:
-				return "3xx"
-			case code >= 400 && code < 500:
-				return "4xx"
-			case code >= 500 && code < 600:
-				return "5xx"
-			default:
-				return "Other"
+			agg.Latencies = append(agg.Latencies, float64(entry.Latency.Milliseconds()))
+		}
+		if entry.GroupKey != "" {
+			groups.Add(entry.GroupKey, 1)
+		}
+		if entry.Protocol != "" {
+			agg.Protocols[entry.Protocol]++
+		}
+		if entry.TLSVersion != "" {
+			agg.TLSVersions[entry.TLSVersion]++
+		}
+		if entry.Level != "" {
+			agg.Levels[string(entry.Level)]++
+		}
+		if entry.ErrorCategory != "" {
+			agg.ErrorCategories[entry.ErrorCategory]++
+		}
+		if entry.Session != "" {
+			sessions[entry.Session] = true
+			agg.SessionRequests++
+		}
+		if entry.PrevEndpoint != "" {
+			agg.Transitions[storage.Transition{From: entry.PrevEndpoint, To: entry.Endpoint}]++
+		}
+		if entry.Tenant != "" {
+			t := agg.Tenants[entry.Tenant]
+			t.Requests++
+			t.Errors += isError
+			if entry.StatusCode < 400 && entry.Latency > 0 {
+				ms := float64(entry.Latency.Milliseconds())
+				t.LatencySum += ms
+				t.LatencyCount++
+				tenantLatencies[entry.Tenant] = append(tenantLatencies[entry.Tenant], ms)
+			}
+			agg.Tenants[entry.Tenant] = t
+		}
+		if entry.Operation != "" {
+			o := agg.Operations[entry.Operation]
+			o.Requests++
+			o.Errors += isError
+			if entry.StatusCode < 400 && entry.Latency > 0 {
+				ms := float64(entry.Latency.Milliseconds())
+				o.LatencySum += ms
+				o.LatencyCount++
+				operationLatencies[entry.Operation] = append(operationLatencies[entry.Operation], ms)
+			}
+			agg.Operations[entry.Operation] = o
+		}
+		if entry.Version != "" {
+			v := agg.Versions[entry.Version]
+			v.Requests++
+			v.Errors += isError
+			if entry.StatusCode < 400 && entry.Latency > 0 {
+				ms := float64(entry.Latency.Milliseconds())
+				v.LatencySum += ms
+				v.LatencyCount++
+				versionLatencies[entry.Version] = append(versionLatencies[entry.Version], ms)
+			}
+			agg.Versions[entry.Version] = v
+		}
+		if entry.Source != "" {
+			s := agg.Sources[entry.Source]
+			s.Requests++
+			s.Errors += isError
+			if entry.StatusCode < 400 && entry.Latency > 0 {
+				s.LatencySum += float64(entry.Latency.Milliseconds())
+				s.LatencyCount++
 			}
-		}(entry.StatusCode)
-		statusCodeDist[statusCodeCategory]++
+			agg.Sources[entry.Source] = s
+		}
+		if entry.Retry {
+			agg.AddRetries(entry.Endpoint, 1)
+		}
+		agg.StatusCodes[entry.StatusCode]++
+	}
+	agg.Endpoints, agg.Groups = endpoints.Counts(), groups.Counts()
+	agg.EndpointsTotal, agg.GroupsTotal = endpoints.Total(), groups.Total()
+	wm := windowedMetricsFromAggregate(agg, window, e.percentiles.Default)
+	wm.EndpointPercentiles = e.scanEndpointPercentiles(entries)
+	wm.LatencySLA = computeLatencySLA(agg.Latencies, e.latencySLA.Default)
+	wm.EndpointLatencySLA = e.scanEndpointLatencySLA(entries)
+	wm.Tenants = e.tenantStats(agg, window, func(tenant string) []float64 { return tenantLatencies[tenant] })
+	wm.TopGroups = e.topGroups(agg)
+	agg.Sessions = len(sessions)
+	wm.Sessions = e.sessionStats(agg)
+	wm.Sources = sourceStats(agg, window)
+	wm.Operations = requestStats(agg.Operations, agg.Total, e.graphQLTop, window, func(operation string) []float64 { return operationLatencies[operation] })
+	wm.Versions = e.versionStats(agg, window, func(version string) []float64 { return versionLatencies[version] })
+	wm.Retries = e.retryStats(agg)
+	wm.ErrorCategories = errorCategoryStats(agg)
+	wm.Breakdown = timingBreakdown(agg, e.timing.Top)
+	return wm
+}
+
+func windowedMetricsFromAggregate(agg storage.WindowAggregate, window time.Duration, percentiles []float64) types.WindowedMetrics {
+	if agg.Total == 0 {
+		return types.WindowedMetrics{
+			TopEndpoints:           make(map[string]int),
+			StatusCodeDistribution: make(map[string]int),
+			Custom:                 make(map[string]int),
+			Percentiles:            computePercentiles(nil, percentiles),
+		}
+	}
+
+	statusCodeDist := make(map[string]int)
+	for code, count := range agg.StatusCodes {
+		statusCodeDist[statusCodeCategory(code)] += count
 	}
 
 	rps := 0.0
 	if window > 0 {
-		rps = float64(totalRequests) / window.Seconds()
-	}
-	errorRate := 0.0
-	if totalRequests > 0 {
-		errorRate = (float64(totalErrors) / float64(totalRequests)) * 100
+		rps = float64(agg.Total) / window.Seconds()
 	}
+	errorRate := (float64(agg.Errors) / float64(agg.Total)) * 100
 
 	var p50, p90, p95, p99 time.Duration
-	if len(latencies) > 0 {
-		p50v, _ := stats.Percentile(latencies, 50)
-		p90v, _ := stats.Percentile(latencies, 90)
-		p95v, _ := stats.Percentile(latencies, 95)
-		p99v, _ := stats.Percentile(latencies, 99)
+	if len(agg.Latencies) > 0 {
+		p50v, _ := stats.Percentile(agg.Latencies, 50)
+		p90v, _ := stats.Percentile(agg.Latencies, 90)
+		p95v, _ := stats.Percentile(agg.Latencies, 95)
+		p99v, _ := stats.Percentile(agg.Latencies, 99)
 		p50 = time.Duration(p50v) * time.Millisecond
 		p90 = time.Duration(p90v) * time.Millisecond
 		p95 = time.Duration(p95v) * time.Millisecond
@@ -385,10 +821,39 @@ func (e *Engine) computeWindowedMetrics(entries []types.LogEntry, window time.Du
 		P90Latency:             p90,
 		P95Latency:             p95,
 		P99Latency:             p99,
-		TopEndpoints:           topEndpoints,
-		TotalRequests:          totalRequests,
-		TotalErrors:            totalErrors,
+		TopEndpoints:           agg.Endpoints,
+		TotalRequests:          agg.Total,
+		TotalErrors:            agg.Errors,
 		StatusCodeDistribution: statusCodeDist,
+		Percentiles:            computePercentiles(agg.Latencies, percentiles),
+		Methods:                agg.Methods,
+		EndpointMethods:        agg.EndpointMethods,
+		Cache:                  cacheTotals(agg.CacheStatuses),
+		CacheStatuses:          agg.CacheStatuses,
+		EndpointCache:          agg.EndpointCache,
+		Timing:                 timingStats(agg.QueueTimes, agg.ServiceTimes),
+		Protocols:              agg.Protocols,
+		TLSVersions:            agg.TLSVersions,
+		Levels:                 levelStats(agg.Levels, agg.Total, window),
+		GRPCStatuses:           agg.GRPCStatuses,
+		GRPCMethods:            agg.GRPCMethods,
+	}
+}
+
+func statusCodeCategory(code int) string {
+	switch {
+	case code >= 100 && code < 200:
+		return "1xx"
+	case code >= 200 && code < 300:
+		return "2xx"
+	case code >= 300 && code < 400:
+		return "3xx"
+	case code >= 400 && code < 500:
+		return "4xx"
+	case code >= 500 && code < 600:
+		return "5xx"
+	default:
+		return "Other"
 	}
 }
 
@@ -398,56 +863,95 @@ func (e *Engine) detectAnomalies() {
 	if !ok {
 		return
 	}
+	now := e.clock.Now()
+	e.refreshBaselines(now)
+	e.updateErrorStreaks(now)
+	e.updateFailureIntervals(now)
+	if !e.updateWarmup(now) {
+		return
+	}
+	ac := e.newAnomalyContext()
+	e.detectContinuousFailures(ac)
+	e.detectShrinkingMTBF(ac)
+	e.detectLatencySLABreach(ac)
+	e.detectDeployRegressions(now, ac)
+	e.detectErrorSpike(ac)
+	e.detectRetryStorm(ac)
+	e.detectErrorCategories(ac)
+
+	if e.detection.Detector == config.DetectorEWMA || e.detection.Detector == config.DetectorBoth {
+		if current, ok := e.metrics.Windows["1m"]; ok {
+			e.detectEWMADeviations(current, ac)
+		}
+	}
+	if e.detection.Detector == config.DetectorEWMA {
+		return
+	}
+
+	sigma := e.Thresholds().Sigma
 
 	// Detect RPS anomalies
-	if len(e.rpsHistory) > 10 {
-		avgRPS, stdRPS := calculateMeanStd(e.rpsHistory)
+	if avgRPS, stdRPS, label, ok := e.baselineFor(baselineRPS, e.rpsHistory); ok {
 		currentRPS := wm.RPS
-		if currentRPS > avgRPS+3*stdRPS || currentRPS < avgRPS-3*stdRPS {
-			e.metrics.Anomalies = append(e.metrics.Anomalies, types.Anomaly{
-				Timestamp: time.Now(),
-				Type:      "RPS Anomaly",
-				Message:   fmt.Sprintf("RPS %.2f is outside 3-sigma range (avg: %.2f, std: %.2f)", currentRPS, avgRPS, stdRPS),
-			})
+		if currentRPS > avgRPS+sigma*stdRPS || currentRPS < avgRPS-sigma*stdRPS {
+			contributors := ac.contributors(storage.EntryFilter{})
+			e.addAnomaly(types.Anomaly{
+				Timestamp:    e.clock.Now(),
+				Type:         "RPS Anomaly",
+				Severity:     types.SeverityWarning,
+				Message:      fmt.Sprintf("RPS %.2f is outside %g-sigma range of %s (avg: %.2f, std: %.2f)", currentRPS, sigma, label, avgRPS, stdRPS) + formatContributors(contributors),
+				Contributors: contributors,
+			}, ac, evidenceNone)
 		}
 	}
 
 	// Detect Error Rate anomalies
-	if len(e.errorRateHistory) > 10 {
-		avgErr, stdErr := calculateMeanStd(e.errorRateHistory)
+	if avgErr, stdErr, label, ok := e.baselineFor(baselineErrorRate, e.errorRateHistory); ok {
 		currentErr := wm.ErrorRate
-		if currentErr > avgErr+3*stdErr || currentErr < avgErr-3*stdErr {
-			e.metrics.Anomalies = append(e.metrics.Anomalies, types.Anomaly{
-				Timestamp: time.Now(),
-				Type:      "Error Rate Anomaly",
-				Message:   fmt.Sprintf("Error rate %.2f%% is outside 3-sigma range (avg: %.2f%%, std: %.2f%%)", currentErr, avgErr, stdErr),
-			})
+		if currentErr > avgErr+sigma*stdErr || currentErr < avgErr-sigma*stdErr {
+			contributors := ac.contributors(storage.EntryFilter{Errors: true})
+			e.addAnomaly(types.Anomaly{
+				Timestamp:    e.clock.Now(),
+				Type:         "Error Rate Anomaly",
+				Severity:     types.SeverityWarning,
+				Message:      fmt.Sprintf("Error rate %.2f%% is outside %g-sigma range of %s (avg: %.2f%%, std: %.2f%%)", currentErr, sigma, label, avgErr, stdErr) + formatContributors(contributors),
+				Contributors: contributors,
+			}, ac, evidenceErrors)
 		}
 	}
 
 	// Detect Latency anomalies
-	if len(e.latencyHistory) > 10 {
-		avgLat, stdLat := calculateMeanStd(e.latencyHistory)
+	if avgLat, stdLat, label, ok := e.baselineFor(baselineP95, e.latencyHistory); ok {
 		currentLat := float64(wm.P95Latency.Milliseconds())
-		if currentLat > avgLat+3*stdLat || currentLat < avgLat-3*stdLat {
-			e.metrics.Anomalies = append(e.metrics.Anomalies, types.Anomaly{
-				Timestamp: time.Now(),
-				Type:      "Latency Anomaly",
-				Message:   fmt.Sprintf("P95 latency %v is outside 3-sigma range (avg: %.2fms, std: %.2fms)", wm.P95Latency, avgLat, stdLat),
-			})
+		if currentLat > avgLat+sigma*stdLat || currentLat < avgLat-sigma*stdLat {
+			// Attribute the shift to requests slower than the historical average P95
+			slow := time.Duration(avgLat) * time.Millisecond
+			contributors := ac.contributors(storage.EntryFilter{Successful: true, SlowerThan: slow})
+			e.addAnomaly(types.Anomaly{
+				Timestamp:    e.clock.Now(),
+				Type:         "Latency Anomaly",
+				Severity:     types.SeverityWarning,
+				Message:      fmt.Sprintf("P95 latency %v is outside %g-sigma range of %s (avg: %.2fms, std: %.2fms)", wm.P95Latency, sigma, label, avgLat, stdLat) + formatContributors(contributors),
+				Contributors: contributors,
+			}, ac, evidenceSlowest)
 		}
 	}
 
+	e.detectCacheHitDrops(ac)
+
 	// Baseline drift detection (simple: check if average is trending)
 	if len(e.rpsHistory) > 20 {
 		recentAvg := average(e.rpsHistory[len(e.rpsHistory)-10:])
 		olderAvg := average(e.rpsHistory[len(e.rpsHistory)-20 : len(e.rpsHistory)-10])
 		if recentAvg > olderAvg*1.2 || recentAvg < olderAvg*0.8 {
-			e.metrics.Anomalies = append(e.metrics.Anomalies, types.Anomaly{
-				Timestamp: time.Now(),
-				Type:      "Baseline Drift",
-				Message:   fmt.Sprintf("RPS baseline drift detected (recent avg: %.2f, older avg: %.2f)", recentAvg, olderAvg),
-			})
+			contributors := ac.contributors(storage.EntryFilter{})
+			e.addAnomaly(types.Anomaly{
+				Timestamp:    e.clock.Now(),
+				Type:         "Baseline Drift",
+				Severity:     types.SeverityInfo,
+				Message:      fmt.Sprintf("RPS baseline drift detected (recent avg: %.2f, older avg: %.2f)", recentAvg, olderAvg) + formatContributors(contributors),
+				Contributors: contributors,
+			}, ac, evidenceNone)
 		}
 	}
 }
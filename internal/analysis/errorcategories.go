@@ -0,0 +1,118 @@
+package analysis
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// errorCategory is a configured error category with its matchers compiled.
+type errorCategory struct {
+	config.ErrorCategoryConfig
+	status  [][2]int
+	message *regexp.Regexp // nil when the category matches by status only
+}
+
+func newErrorCategories(configs []config.ErrorCategoryConfig) ([]errorCategory, error) {
+	categories := make([]errorCategory, 0, len(configs))
+	for _, cfg := range configs {
+		c := errorCategory{ErrorCategoryConfig: cfg}
+		var err error
+		if c.status, err = cfg.StatusRanges(); err != nil {
+			return nil, fmt.Errorf("error category %s: %w", cfg.Name, err)
+		}
+		if cfg.Message != "" {
+			if c.message, err = regexp.Compile(cfg.Message); err != nil {
+				return nil, fmt.Errorf("error category %s: message: %w", cfg.Name, err)
+			}
+		}
+		categories = append(categories, c)
+	}
+	return categories, nil
+}
+
+func (c errorCategory) match(entry types.LogEntry) bool {
+	for _, r := range c.status {
+		if entry.StatusCode >= r[0] && entry.StatusCode <= r[1] {
+			return true
+		}
+	}
+	return c.message != nil && c.message.MatchString(entry.Message)
+}
+
+// classifyError sets the category of a failed entry: one with an error
+// status, or an ERROR level entry without a status.
+func (e *Engine) classifyError(entry *types.LogEntry) {
+	failed := entry.StatusCode >= 400 || (entry.StatusCode == 0 && entry.Level == types.ErrorLevel)
+	if !failed {
+		return
+	}
+	for _, c := range e.errorCategories {
+		if c.match(*entry) {
+			entry.ErrorCategory = c.Name
+			return
+		}
+	}
+}
+
+// errorCategoryStats counts a window's errors per category, busiest first.
+func errorCategoryStats(agg storage.WindowAggregate) []types.ErrorCategoryStats {
+	if len(agg.ErrorCategories) == 0 {
+		return nil
+	}
+	stats := make([]types.ErrorCategoryStats, 0, len(agg.ErrorCategories))
+	for name, errors := range agg.ErrorCategories {
+		s := types.ErrorCategoryStats{Name: name, Errors: errors}
+		if agg.Total > 0 {
+			s.Rate = float64(errors) / float64(agg.Total) * 100
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Errors != stats[j].Errors {
+			return stats[i].Errors > stats[j].Errors
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// detectErrorCategories raises an anomaly for each category whose errors
+// reach its alert rate in the 5m window.
+func (e *Engine) detectErrorCategories(ac *anomalyContext) {
+	current, ok := e.metrics.Windows["5m"]
+	if !ok {
+		return
+	}
+	for _, c := range e.errorCategories {
+		if c.AlertRate <= 0 {
+			continue
+		}
+		var stats types.ErrorCategoryStats
+		for _, s := range current.ErrorCategories {
+			if s.Name == c.Name {
+				stats = s
+				break
+			}
+		}
+		if stats.Errors < c.MinErrors || stats.Rate < c.AlertRate {
+			continue
+		}
+
+		name := c.Name
+		contributors := ac.contributors(storage.EntryFilter{Category: name})
+		evidence := ac.entries(storage.EntryFilter{Category: name}, storage.EvidenceRecent)
+		e.addAnomaly(types.Anomaly{
+			Timestamp:    e.clock.Now(),
+			Type:         "Error Category: " + name,
+			Severity:     types.SeverityWarning,
+			Message:      fmt.Sprintf("%s errors at %.2f%% of 5m requests (%d errors, threshold %g%%)", name, stats.Rate, stats.Errors, c.AlertRate) + formatContributors(contributors),
+			Contributors: contributors,
+			Evidence:     evidence,
+		}, ac, evidenceGiven)
+	}
+}
@@ -0,0 +1,72 @@
+package analysis
+
+import (
+	"log"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+const (
+	maxEvidenceEntries = 10              // Raw entries retained per anomaly
+	recordCooldown     = 1 * time.Minute // Minimum gap between persisting the same anomaly type
+	maxRecentAnomalies = 50              // Kept in memory; older ones are browsed from storage
+)
+
+// evidenceKind selects which raw entries are captured as evidence for an anomaly.
+type evidenceKind int
+
+const (
+	evidenceNone    evidenceKind = iota
+	evidenceSlowest              // Slowest successful requests
+	evidenceErrors               // Server errors first, then client errors, most recent first
+	evidenceGiven                // Set by the detector on the anomaly itself
+)
+
+// evidence returns the entries from the current window that best illustrate
+// an anomaly of the given kind.
+func (c *anomalyContext) evidence(kind evidenceKind) []types.LogEntry {
+	switch kind {
+	case evidenceSlowest:
+		return c.entries(storage.EntryFilter{HasLatency: true}, storage.EvidenceSlowest)
+	case evidenceErrors:
+		return c.entries(storage.EntryFilter{Failed: true}, storage.EvidenceWorst)
+	}
+	return nil
+}
+
+// addAnomaly records an anomaly. Anomalies re-fire on every tick while the
+// condition holds, so persistence, evidence capture, and notifications happen
+// at most once per type per recordCooldown.
+func (e *Engine) addAnomaly(a types.Anomaly, ac *anomalyContext, kind evidenceKind) {
+	if a.Severity == "" {
+		a.Severity = types.SeverityWarning
+	}
+	a.Snapshot = e.snapshot()
+	if a.Timestamp.Sub(e.lastRecorded[a.Type]) >= recordCooldown {
+		e.lastRecorded[a.Type] = a.Timestamp
+		if err := e.storage.InsertAnomaly(a); err != nil {
+			log.Printf("Error storing anomaly: %v", err)
+		}
+		e.queueNotification(a)
+		if e.dryRun != nil {
+			e.dryRun.anomaly(a, e.silenced(a.Type, e.clock.Now()))
+		}
+		if e.anomalyTopic != nil {
+			e.anomalyTopic.TryPublish(a)
+		}
+		if kind != evidenceGiven {
+			a.Evidence = ac.evidence(kind)
+		}
+		if len(a.Evidence) > 0 {
+			if err := e.storage.InsertEvidence(a); err != nil {
+				log.Printf("Error storing anomaly evidence: %v", err)
+			}
+		}
+	}
+	e.metrics.Anomalies = append(e.metrics.Anomalies, a)
+	if len(e.metrics.Anomalies) > maxRecentAnomalies {
+		e.metrics.Anomalies = e.metrics.Anomalies[len(e.metrics.Anomalies)-maxRecentAnomalies:]
+	}
+}
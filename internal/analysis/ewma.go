@@ -0,0 +1,66 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/VividCortex/ewma"
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// newEWMA builds a moving average for a smoothing factor alpha, converting it
+// to the average sample age the ewma package expects (alpha = 2 / (age + 1)).
+func newEWMA(alpha float64) ewma.MovingAverage {
+	return ewma.NewMovingAverage(2/alpha - 1)
+}
+
+// smoothedOr returns the moving average, or raw while the average is still
+// warming up and reports zero.
+func smoothedOr(ma ewma.MovingAverage, raw float64) float64 {
+	if v := ma.Value(); v != 0 {
+		return v
+	}
+	return raw
+}
+
+// detectEWMADeviations fires when the current 1m value deviates from its
+// EWMA-smoothed value by more than the configured relative threshold. It reacts
+// faster than the sigma detector because it needs no long history.
+func (e *Engine) detectEWMADeviations(current types.WindowedMetrics, ac *anomalyContext) {
+	threshold := e.Thresholds().EWMADeviation
+
+	checks := []struct {
+		kind     string
+		current  float64
+		smoothed float64
+		unit     string
+		filter   storage.EntryFilter
+		evidence evidenceKind
+	}{
+		{"RPS", current.RPS, e.rpsEWMA.Value(), "", storage.EntryFilter{}, evidenceNone},
+		{"Error Rate", current.ErrorRate, e.errorRateEWMA.Value(), "%", storage.EntryFilter{Errors: true}, evidenceErrors},
+		{"P95 Latency", float64(current.P95Latency.Milliseconds()), e.latencyEWMA.Value(), "ms", storage.EntryFilter{
+			Successful: true, SlowerThan: time.Duration(e.latencyEWMA.Value()) * time.Millisecond,
+		}, evidenceSlowest},
+	}
+
+	for _, c := range checks {
+		if c.smoothed <= 0 { // Still warming up
+			continue
+		}
+		deviation := (c.current - c.smoothed) / c.smoothed
+		if math.Abs(deviation) <= threshold {
+			continue
+		}
+		contributors := ac.contributors(c.filter)
+		e.addAnomaly(types.Anomaly{
+			Timestamp:    e.clock.Now(),
+			Type:         "EWMA " + c.kind + " Deviation",
+			Severity:     types.SeverityWarning,
+			Message:      fmt.Sprintf("%s %.2f%s deviates %+.0f%% from EWMA %.2f%s (alpha %.2f)", c.kind, c.current, c.unit, deviation*100, c.smoothed, c.unit, e.detection.EWMA.Alpha) + formatContributors(contributors),
+			Contributors: contributors,
+		}, ac, c.evidence)
+	}
+}
@@ -0,0 +1,156 @@
+package analysis
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+const (
+	explainCurrentWindow  = 1 * time.Minute
+	explainBaselineWindow = 1 * time.Hour
+	maxContributors       = 3
+)
+
+func fieldString(entry types.LogEntry, key string) string {
+	if entry.Fields == nil {
+		return ""
+	}
+	if v, ok := entry.Fields[key]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// anomalyContext breaks the current and baseline windows down by dimension
+// in SQL when an anomaly fires: the busiest values of the current window,
+// and the exact baseline counts of those values. Counts are cached per
+// filter, so several anomalies on the same tick share their queries.
+type anomalyContext struct {
+	engine *Engine
+	counts map[countsKey]dimensionCounts
+}
+
+type countsKey struct {
+	filter storage.EntryFilter
+	window time.Duration
+}
+
+type dimensionCounts struct {
+	values map[string]map[string]int // Dimension -> value -> matching entries
+	total  int
+}
+
+func (e *Engine) newAnomalyContext() *anomalyContext {
+	return &anomalyContext{engine: e, counts: make(map[countsKey]dimensionCounts)}
+}
+
+// dimensionCounts counts the entries matching f in the last window: the
+// busiest values, or with of, exactly the values in it.
+func (c *anomalyContext) dimensionCounts(f storage.EntryFilter, window time.Duration, of map[string]map[string]int) (dimensionCounts, bool) {
+	key := countsKey{f, window}
+	if dc, ok := c.counts[key]; ok {
+		return dc, true
+	}
+	since := c.engine.clock.Now().Add(-window)
+	var values map[string]map[string]int
+	var total int
+	var err error
+	if of == nil {
+		values, total, err = c.engine.storage.DimensionCounts(since, f)
+	} else {
+		values, total, err = c.engine.storage.DimensionCountsOf(since, f, of)
+	}
+	if err != nil {
+		log.Printf("Error counting entries for anomaly explanation: %v", err)
+		return dimensionCounts{}, false
+	}
+	dc := dimensionCounts{values: values, total: total}
+	c.counts[key] = dc
+	return dc, true
+}
+
+// contributors ranks the dimension values which grew the most (by share of
+// the entries matching f) in the current window versus the baseline window.
+func (c *anomalyContext) contributors(f storage.EntryFilter) []types.Contributor {
+	current, ok := c.dimensionCounts(f, explainCurrentWindow, nil)
+	if !ok {
+		return nil
+	}
+	// A value absent from the baseline's busiest isn't new to it, so the
+	// baseline counts exactly the current window's values
+	baseline, ok := c.dimensionCounts(f, explainBaselineWindow, current.values)
+	if !ok {
+		return nil
+	}
+	return topContributors(current, baseline)
+}
+
+// entries returns up to maxEvidenceEntries entries of the current window
+// matching f, in the given storage.Evidence order.
+func (c *anomalyContext) entries(f storage.EntryFilter, order string) []types.LogEntry {
+	entries, err := c.engine.storage.EvidenceEntries(c.engine.clock.Now().Add(-explainCurrentWindow), f, order, maxEvidenceEntries)
+	if err != nil {
+		log.Printf("Error loading anomaly evidence: %v", err)
+	}
+	return entries
+}
+
+func topContributors(current, baseline dimensionCounts) []types.Contributor {
+	if current.total == 0 {
+		return nil
+	}
+
+	var contributors []types.Contributor
+	for dim, values := range current.values {
+		for value, count := range values {
+			share := float64(count) / float64(current.total) * 100
+			baseShare := 0.0
+			if baseline.total > 0 {
+				baseShare = float64(baseline.values[dim][value]) / float64(baseline.total) * 100
+			}
+			if share <= baseShare {
+				continue
+			}
+			contributors = append(contributors, types.Contributor{
+				Dimension:     dim,
+				Value:         value,
+				Share:         share,
+				BaselineShare: baseShare,
+			})
+		}
+	}
+
+	sort.Slice(contributors, func(i, j int) bool {
+		di := contributors[i].Share - contributors[i].BaselineShare
+		dj := contributors[j].Share - contributors[j].BaselineShare
+		if di != dj {
+			return di > dj
+		}
+		if contributors[i].Share != contributors[j].Share {
+			return contributors[i].Share > contributors[j].Share
+		}
+		return contributors[i].Dimension+contributors[i].Value < contributors[j].Dimension+contributors[j].Value
+	})
+	if len(contributors) > maxContributors {
+		contributors = contributors[:maxContributors]
+	}
+	return contributors
+}
+
+// formatContributors renders contributors as a suffix for an anomaly message.
+func formatContributors(contributors []types.Contributor) string {
+	if len(contributors) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(contributors))
+	for _, c := range contributors {
+		parts = append(parts, fmt.Sprintf("%s=%s %.0f%% (baseline %.0f%%)", c.Dimension, c.Value, c.Share, c.BaselineShare))
+	}
+	return "; top contributors: " + strings.Join(parts, ", ")
+}
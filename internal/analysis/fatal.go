@@ -0,0 +1,66 @@
+package analysis
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// fatalAnomaly is the type of the anomaly raised for crash lines.
+const fatalAnomaly = "Fatal Log"
+
+func newFatalPatterns(cfg config.FatalConfig) ([]*regexp.Regexp, error) {
+	if cfg.Disable {
+		return nil, nil
+	}
+	patterns := make([]*regexp.Regexp, 0, len(cfg.Patterns))
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("fatal.patterns: %w", err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// checkFatal raises a critical anomaly as soon as entry matches a fatal
+// pattern, rather than waiting for the next tick's detection. Further
+// matches within the record cooldown add nothing, so a crash loop alerts
+// once a minute instead of once a line.
+func (e *Engine) checkFatal(entry types.LogEntry) {
+	if len(e.fatalPatterns) == 0 {
+		return
+	}
+	var matched *regexp.Regexp
+	for _, re := range e.fatalPatterns {
+		if re.MatchString(entry.Message) {
+			matched = re
+			break
+		}
+	}
+	if matched == nil {
+		return
+	}
+	now := e.clock.Now()
+	if now.Sub(e.lastRecorded[fatalAnomaly]) < recordCooldown {
+		return
+	}
+
+	line, _, _ := strings.Cut(entry.Message, "\n")
+	message := fmt.Sprintf("Fatal log line matching %q: %s", matched.String(), truncateLine(line))
+	if entry.Source != "" {
+		message += " (from " + entry.Source + ")"
+	}
+	e.addAnomaly(types.Anomaly{
+		Timestamp: now,
+		Type:      fatalAnomaly,
+		Severity:  types.SeverityCritical,
+		Message:   message,
+		Evidence:  []types.LogEntry{entry},
+	}, nil, evidenceGiven)
+	e.dirty = true
+}
@@ -0,0 +1,12 @@
+package analysis
+
+// FileProcessed reports whether a batch file was already read from a
+// watched directory, so it isn't read again after a restart.
+func (e *Engine) FileProcessed(path, fingerprint string) (bool, error) {
+	return e.storage.FileProcessed(path, fingerprint)
+}
+
+// MarkFileProcessed records that a batch file was read in full.
+func (e *Engine) MarkFileProcessed(path, fingerprint string, size int64, lines int) error {
+	return e.storage.MarkFileProcessed(path, fingerprint, size, lines)
+}
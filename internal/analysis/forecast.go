@@ -0,0 +1,160 @@
+package analysis
+
+import (
+	"log"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+const (
+	forecastInterval = 1 * time.Minute // Recompute the forecast at most this often
+	forecastHistory  = 24 * time.Hour  // Stored history used to fit the model
+	forecastHorizon  = 6               // Hours to project ahead
+	holtAlpha        = 0.5             // Level smoothing factor
+	holtBeta         = 0.3             // Trend smoothing factor
+)
+
+type hourBucket struct {
+	start    time.Time
+	requests int
+	errors   int
+}
+
+// updateForecast refits the forecast from stored rollups if it is stale or
+// the SLO target was changed.
+func (e *Engine) updateForecast(now time.Time) {
+	sloTarget := e.Thresholds().SLOTarget
+	if now.Sub(e.metrics.Forecast.GeneratedAt) < forecastInterval && e.metrics.Forecast.SLOTarget == sloTarget {
+		return
+	}
+
+	rollups, err := e.storage.GetRollupsSince(now.Add(-forecastHistory))
+	if err != nil {
+		log.Printf("Error loading history for forecast: %v", err)
+		return
+	}
+	e.metrics.Forecast = buildForecast(bucketByHour(rollups, now), now, sloTarget)
+}
+
+func bucketByHour(rollups []storage.Rollup, now time.Time) []hourBucket {
+	if len(rollups) == 0 {
+		return nil
+	}
+
+	first := rollups[0].Timestamp.Truncate(time.Hour)
+	last := now.Truncate(time.Hour)
+	if first.After(last) {
+		first = last
+	}
+	n := int(last.Sub(first)/time.Hour) + 1
+	buckets := make([]hourBucket, n)
+	for i := range buckets {
+		buckets[i].start = first.Add(time.Duration(i) * time.Hour)
+	}
+
+	for _, r := range rollups {
+		i := int(r.Timestamp.Truncate(time.Hour).Sub(first) / time.Hour)
+		if i < 0 || i >= n {
+			continue
+		}
+		buckets[i].requests += r.Requests
+		buckets[i].errors += r.Errors
+	}
+	return buckets
+}
+
+func buildForecast(buckets []hourBucket, now time.Time, sloTarget float64) types.Forecast {
+	f := types.Forecast{
+		GeneratedAt:          now,
+		SLOTarget:            sloTarget,
+		ErrorBudgetRemaining: 100,
+	}
+	if len(buckets) == 0 {
+		return f
+	}
+
+	rps := make([]float64, len(buckets))
+	errRate := make([]float64, len(buckets))
+	totalRequests, totalErrors := 0, 0
+	for i, b := range buckets {
+		seconds := time.Hour.Seconds()
+		if i == len(buckets)-1 {
+			// The current hour is still filling up
+			seconds = now.Sub(b.start).Seconds()
+			if seconds < 1 {
+				seconds = 1
+			}
+		}
+		rps[i] = float64(b.requests) / seconds
+		if b.requests > 0 {
+			errRate[i] = float64(b.errors) / float64(b.requests) * 100
+		}
+		totalRequests += b.requests
+		totalErrors += b.errors
+	}
+
+	allowedRate := (100 - f.SLOTarget) / 100
+	budget := allowedRate * float64(totalRequests) // Errors we may still afford
+	if budget > 0 {
+		f.ErrorBudgetRemaining = (budget - float64(totalErrors)) / budget * 100
+	}
+	remaining := budget - float64(totalErrors)
+
+	rpsForecast := holtForecast(rps, forecastHorizon)
+	errForecast := holtForecast(errRate, forecastHorizon)
+	next := now.Truncate(time.Hour)
+	for h := 0; h < forecastHorizon; h++ {
+		p := types.ForecastPoint{
+			Time:      next.Add(time.Duration(h+1) * time.Hour),
+			RPS:       clampMin(rpsForecast[h], 0),
+			ErrorRate: clamp(errForecast[h], 0, 100),
+		}
+		f.Points = append(f.Points, p)
+
+		requests := p.RPS * time.Hour.Seconds()
+		remaining += requests*allowedRate - requests*p.ErrorRate/100
+		if remaining < 0 && f.BudgetExhaustedIn == 0 {
+			f.BudgetExhaustedIn = time.Duration(h+1) * time.Hour
+		}
+	}
+	return f
+}
+
+// holtForecast applies Holt's linear (double exponential) smoothing to series
+// and returns the next horizon values.
+func holtForecast(series []float64, horizon int) []float64 {
+	out := make([]float64, horizon)
+	if len(series) == 0 {
+		return out
+	}
+
+	level, trend := series[0], 0.0
+	if len(series) > 1 {
+		trend = series[1] - series[0]
+	}
+	for _, v := range series[1:] {
+		prevLevel := level
+		level = holtAlpha*v + (1-holtAlpha)*(level+trend)
+		trend = holtBeta*(level-prevLevel) + (1-holtBeta)*trend
+	}
+	for h := range out {
+		out[h] = level + float64(h+1)*trend
+	}
+	return out
+}
+
+func clampMin(v, lo float64) float64 {
+	if v < lo {
+		return lo
+	}
+	return v
+}
+
+func clamp(v, lo, hi float64) float64 {
+	if v > hi {
+		return hi
+	}
+	return clampMin(v, lo)
+}
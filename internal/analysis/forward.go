@@ -0,0 +1,54 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/filter"
+	"github.com/nitis/pulseWatch/internal/forward"
+)
+
+// newForwarders creates a forwarder per configured rule.
+func newForwarders(rules []config.ForwardRule) ([]*forward.Forwarder, error) {
+	var forwarders []*forward.Forwarder
+	for _, r := range rules {
+		expr, err := forwardFilter(r)
+		if err != nil {
+			return nil, err
+		}
+		var sink forward.Sink
+		switch r.Type {
+		case config.ForwardLoki:
+			sink = forward.NewLoki(r.URL, r.Labels, r.Headers)
+		case config.ForwardWebhook:
+			sink = forward.NewWebhook(r.URL, r.Headers)
+		case config.ForwardPulsewatch:
+			pw := forward.NewPulsewatch(r.Address, r.Token)
+			if r.TLS.Enabled {
+				if err := pw.UseTLS(r.TLS.CA, r.TLS.Cert, r.TLS.Key); err != nil {
+					return nil, fmt.Errorf("forward %s: %w", r.Name, err)
+				}
+			}
+			sink = pw
+		case config.ForwardFile:
+			if sink, err = forward.NewFile(r.Path, r.Format); err != nil {
+				return nil, fmt.Errorf("forward %s: %w", r.Name, err)
+			}
+		}
+		forwarders = append(forwarders, forward.New(r.Name, expr, r.Sample, sink, r.BatchSize, r.FlushInterval))
+	}
+	return forwarders, nil
+}
+
+// forwardFilter parses the rule's filter; nil, matching every entry, when
+// it has none.
+func forwardFilter(r config.ForwardRule) (*filter.Expr, error) {
+	if r.Filter == "" {
+		return nil, nil
+	}
+	expr, err := filter.Parse(r.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("forward %s: %w", r.Name, err)
+	}
+	return expr, nil
+}
@@ -0,0 +1,20 @@
+package analysis
+
+import (
+	"log"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+)
+
+// liveOperationLatencies loads a GraphQL operation's latencies for a live
+// window.
+func (e *Engine) liveOperationLatencies(since time.Time, f storage.EntryFilter) func(string) []float64 {
+	return func(operation string) []float64 {
+		latencies, err := e.storage.OperationLatenciesSince(since, operation, f)
+		if err != nil {
+			log.Printf("Error loading latencies for operation %s: %v", operation, err)
+		}
+		return latencies
+	}
+}
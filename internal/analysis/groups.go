@@ -0,0 +1,38 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// topGroups ranks the window's groups (endpoints unless a grouping expression
+// is configured) and folds everything past the top N into one Other group.
+func (e *Engine) topGroups(agg storage.WindowAggregate) []types.GroupCount {
+	counts, total := agg.Endpoints, agg.EndpointsTotal
+	if e.groupBy != nil {
+		counts, total = agg.Groups, agg.GroupsTotal
+	}
+
+	groups := make([]types.GroupCount, 0, len(counts))
+	for key, count := range counts {
+		groups = append(groups, types.GroupCount{Key: key, Count: count})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	if len(groups) <= e.groupTop {
+		return groups
+	}
+
+	// The rest includes the keys past the busiest the aggregate kept
+	other := types.GroupCount{Key: "other", Count: total, Other: true}
+	for _, g := range groups[:e.groupTop] {
+		other.Count -= g.Count
+	}
+	return append(groups[:e.groupTop], other)
+}
@@ -0,0 +1,77 @@
+package analysis
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// latencyHistogram counts request latencies since the session started. It
+// has its own lock so scrapes never wait on a metrics send.
+type latencyHistogram struct {
+	mu        sync.Mutex
+	field     string // Field holding the trace ID
+	bounds    []time.Duration
+	counts    []uint64 // Per bucket, not cumulative
+	exemplars []types.Exemplar
+	sum       time.Duration
+	count     uint64
+	created   time.Time
+}
+
+func newLatencyHistogram(cfg config.HistogramConfig, created time.Time) *latencyHistogram {
+	return &latencyHistogram{
+		field:     cfg.ExemplarField,
+		bounds:    cfg.Buckets,
+		counts:    make([]uint64, len(cfg.Buckets)+1),
+		exemplars: make([]types.Exemplar, len(cfg.Buckets)+1),
+		created:   created,
+	}
+}
+
+// observe counts a request with a latency, making it its bucket's exemplar
+// if it has a trace ID.
+func (h *latencyHistogram) observe(entry types.LogEntry) {
+	if entry.Latency <= 0 {
+		return
+	}
+	// Buckets include their upper bound, as Prometheus' le does
+	i := sort.Search(len(h.bounds), func(i int) bool { return entry.Latency <= h.bounds[i] })
+	traceID := fieldString(entry, h.field)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[i]++
+	h.sum += entry.Latency
+	h.count++
+	if traceID != "" {
+		h.exemplars[i] = types.Exemplar{TraceID: traceID, Latency: entry.Latency, Timestamp: entry.Timestamp}
+	}
+}
+
+func (h *latencyHistogram) snapshot() types.LatencyHistogram {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := types.LatencyHistogram{
+		Bounds:    h.bounds,
+		Counts:    make([]uint64, len(h.counts)),
+		Exemplars: append([]types.Exemplar(nil), h.exemplars...),
+		Sum:       h.sum,
+		Count:     h.count,
+		Created:   h.created,
+	}
+	var total uint64
+	for i, n := range h.counts {
+		total += n
+		out.Counts[i] = total
+	}
+	return out
+}
+
+// LatencyHistogram returns the session's request latency histogram.
+func (e *Engine) LatencyHistogram() types.LatencyHistogram {
+	return e.histogram.snapshot()
+}
@@ -0,0 +1,78 @@
+package analysis
+
+import (
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// snapshot captures the 1m window's headline metrics, or the whole scan's
+// during an initial scan.
+func (e *Engine) snapshot() types.MetricsSnapshot {
+	wm, ok := e.metrics.Windows["1m"]
+	if !ok {
+		wm = e.metrics.Windows["all"]
+	}
+	return types.MetricsSnapshot{
+		RPS:        wm.RPS,
+		ErrorRate:  wm.ErrorRate,
+		Requests:   wm.TotalRequests,
+		Errors:     wm.TotalErrors,
+		P50Latency: wm.P50Latency,
+		P95Latency: wm.P95Latency,
+		P99Latency: wm.P99Latency,
+	}
+}
+
+// AnomalyHistory returns persisted anomalies matching f, most recent first.
+// It only reads the database and is safe to call from any goroutine.
+func (e *Engine) AnomalyHistory(f types.AnomalyFilter) ([]types.Anomaly, error) {
+	return e.storage.QueryAnomalies(f)
+}
+
+// AnomalyEvidence returns the raw entries captured when a persisted anomaly fired.
+func (e *Engine) AnomalyEvidence(a types.Anomaly) ([]types.LogEntry, error) {
+	return e.storage.GetEvidence(a.Type, a.Timestamp)
+}
+
+// Rollups returns the per-minute metric rollups between from and to. It only
+// reads the database and is safe to call from any goroutine.
+func (e *Engine) Rollups(from, to time.Time) ([]storage.Rollup, error) {
+	return e.storage.GetRollupsBetween(from, to)
+}
+
+// TopErrors returns the most frequent failing endpoint/status pairs between
+// from and to. Like Rollups, it only reads the database.
+func (e *Engine) TopErrors(from, to time.Time, limit int) ([]storage.ErrorCount, error) {
+	return e.storage.TopErrorsBetween(from, to, limit)
+}
+
+// RecordEvent stores an external event, such as a deployment, as a timeline
+// marker, and queues deploys for the regression check. It is safe to call
+// from any goroutine.
+func (e *Engine) RecordEvent(ev types.Event) error {
+	if err := e.storage.InsertEvent(ev); err != nil {
+		return err
+	}
+	e.noteDeploy(ev)
+	return nil
+}
+
+// Events returns the stored events between from and to, oldest first,
+// optionally of one type.
+func (e *Engine) Events(from, to time.Time, eventType string) ([]types.Event, error) {
+	return e.storage.GetEventsBetween(from, to, eventType)
+}
+
+// Annotate stores an operator annotation of a log line or time range. It is
+// safe to call from any goroutine.
+func (e *Engine) Annotate(a types.Annotation) error {
+	return e.storage.InsertAnnotation(a)
+}
+
+// Annotations returns the stored annotations overlapping from..to, oldest
+// first.
+func (e *Engine) Annotations(from, to time.Time) ([]types.Annotation, error) {
+	return e.storage.GetAnnotationsBetween(from, to)
+}
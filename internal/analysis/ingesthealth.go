@@ -0,0 +1,49 @@
+package analysis
+
+import (
+	"sort"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// staleInputHealth is how long an input's health is kept after its last
+// update; inputs that ended stop reporting.
+const staleInputHealth = time.Minute
+
+// SetInputHealth makes the engine report the inputs' telemetry read from
+// updates, such as ingest.Telemetry's, in its internals. Call it before
+// Start.
+func (e *Engine) SetInputHealth(updates <-chan types.InputHealth) {
+	e.inputHealth = updates
+}
+
+// updateInputHealth takes the latest health of each input from the updates
+// channel and publishes them as Metrics.Internals.Inputs.
+func (e *Engine) updateInputHealth(now time.Time) {
+	if e.inputHealth == nil {
+		return
+	}
+	if e.inputs == nil {
+		e.inputs = make(map[string]types.InputHealth)
+	}
+	for drained := false; !drained; {
+		select {
+		case h := <-e.inputHealth:
+			e.inputs[h.Name] = h
+		default:
+			drained = true
+		}
+	}
+
+	inputs := make([]types.InputHealth, 0, len(e.inputs))
+	for name, h := range e.inputs {
+		if now.Sub(h.Updated) > staleInputHealth {
+			delete(e.inputs, name)
+			continue
+		}
+		inputs = append(inputs, h)
+	}
+	sort.Slice(inputs, func(i, j int) bool { return inputs[i].Name < inputs[j].Name })
+	e.metrics.Internals.Inputs = inputs
+}
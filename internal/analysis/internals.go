@@ -0,0 +1,55 @@
+package analysis
+
+import (
+	"log"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+const (
+	internalsInterval = 10 * time.Second
+	vacuumInterval    = 24 * time.Hour // VACUUM rewrites the whole file, so run it rarely
+)
+
+// updateInternals samples storage statistics once per internalsInterval.
+func (e *Engine) updateInternals(now time.Time) {
+	if now.Sub(e.lastInternals) < internalsInterval {
+		return
+	}
+	st, err := e.storage.Stats()
+	if err != nil {
+		log.Printf("Error reading storage stats: %v", err)
+		return
+	}
+
+	rate := 0.0
+	if !e.lastInternals.IsZero() {
+		rate = float64(st.Inserts-e.metrics.Internals.Storage.Inserts) / now.Sub(e.lastInternals).Seconds()
+	}
+	e.lastInternals = now
+
+	e.metrics.Internals.Storage = types.StorageStats{
+		SizeBytes:       st.SizeBytes,
+		WALBytes:        st.WALBytes,
+		TableRows:       st.TableRows,
+		Inserts:         st.Inserts,
+		InsertRate:      rate,
+		Queries:         st.Queries,
+		AvgQueryLatency: st.AvgQueryLatency,
+		PrunedRows:      st.PrunedRows,
+		LastPrune:       st.LastPrune,
+		LastVacuum:      st.LastVacuum,
+	}
+}
+
+// maybeVacuum reclaims space freed by pruning, at most once per vacuumInterval.
+func (e *Engine) maybeVacuum(now time.Time) {
+	if now.Sub(e.lastVacuum) < vacuumInterval {
+		return
+	}
+	e.lastVacuum = now
+	if err := e.storage.Vacuum(); err != nil {
+		log.Printf("Error vacuuming DB: %v", err)
+	}
+}
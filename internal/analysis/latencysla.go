@@ -0,0 +1,115 @@
+package analysis
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// computeLatencySLA evaluates each objective over latencies in milliseconds,
+// keeping the configured order.
+func computeLatencySLA(latencies []float64, objectives []config.LatencyObjective) []types.LatencySLA {
+	slas := make([]types.LatencySLA, 0, len(objectives))
+	for _, o := range objectives {
+		limit := float64(o.Threshold) / float64(time.Millisecond)
+		within := 0
+		for _, l := range latencies {
+			if l <= limit {
+				within++
+			}
+		}
+		sla := types.LatencySLA{Threshold: o.Threshold, Requests: len(latencies), Target: o.Target, Percent: 100}
+		if len(latencies) > 0 {
+			sla.Percent = float64(within) / float64(len(latencies)) * 100
+		}
+		slas = append(slas, sla)
+	}
+	return slas
+}
+
+// liveEndpointLatencySLA computes the per-endpoint objectives for endpoints
+// seen in a live window.
+func (e *Engine) liveEndpointLatencySLA(since time.Time, f storage.EntryFilter, seen map[string]int) map[string][]types.LatencySLA {
+	result := make(map[string][]types.LatencySLA)
+	for endpoint, objectives := range e.latencySLA.Endpoints {
+		if seen[endpoint] == 0 {
+			continue
+		}
+		latencies, err := e.storage.LatenciesSince(since, endpoint, f)
+		if err != nil {
+			log.Printf("Error loading latencies for %s: %v", endpoint, err)
+			continue
+		}
+		result[endpoint] = computeLatencySLA(latencies, objectives)
+	}
+	return result
+}
+
+// scanEndpointLatencySLA computes the per-endpoint objectives from entries
+// held in memory during an initial scan.
+func (e *Engine) scanEndpointLatencySLA(entries []types.LogEntry) map[string][]types.LatencySLA {
+	result := make(map[string][]types.LatencySLA)
+	if len(e.latencySLA.Endpoints) == 0 {
+		return result
+	}
+	latencies := make(map[string][]float64)
+	for _, entry := range entries {
+		if _, ok := e.latencySLA.Endpoints[entry.Endpoint]; ok && entry.StatusCode < 400 && entry.Latency > 0 {
+			latencies[entry.Endpoint] = append(latencies[entry.Endpoint], float64(entry.Latency.Milliseconds()))
+		}
+	}
+	for endpoint, ls := range latencies {
+		result[endpoint] = computeLatencySLA(ls, e.latencySLA.Endpoints[endpoint])
+	}
+	return result
+}
+
+// detectLatencySLABreach raises an anomaly while the 5m window misses a
+// latency target, overall or for an endpoint, once it holds enough requests
+// to judge.
+func (e *Engine) detectLatencySLABreach(ac *anomalyContext) {
+	wm, ok := e.metrics.Windows["5m"]
+	if !ok {
+		return
+	}
+	var missed []string
+	var contributors []types.Contributor
+	describe := func(s types.LatencySLA) string {
+		return fmt.Sprintf("%.1f%% within %s (target %g%%)", s.Percent, s.Threshold, s.Target)
+	}
+	for _, s := range wm.LatencySLA {
+		if s.Missed() && s.Requests >= e.latencySLA.MinRequests {
+			missed = append(missed, describe(s))
+		}
+	}
+	endpoints := make([]string, 0, len(wm.EndpointLatencySLA))
+	for endpoint := range wm.EndpointLatencySLA {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	for _, endpoint := range endpoints {
+		for _, s := range wm.EndpointLatencySLA[endpoint] {
+			if s.Missed() && s.Requests >= e.latencySLA.MinRequests {
+				missed = append(missed, endpoint+" "+describe(s))
+				contributors = append(contributors, types.Contributor{Dimension: "endpoint", Value: endpoint, Share: 100})
+				break
+			}
+		}
+	}
+	if len(missed) == 0 {
+		return
+	}
+	e.addAnomaly(types.Anomaly{
+		Timestamp:    e.clock.Now(),
+		Type:         "Latency SLA Breach",
+		Severity:     types.SeverityWarning,
+		Message:      "Latency objectives missed over 5m: " + strings.Join(missed, ", "),
+		Contributors: contributors,
+	}, ac, evidenceSlowest)
+}
@@ -0,0 +1,35 @@
+package analysis
+
+import (
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// levelStats turns per-level counts into rates and shares of the window.
+func levelStats(counts map[string]int, total int, window time.Duration) map[types.LogLevel]types.LevelStats {
+	levels := make(map[types.LogLevel]types.LevelStats, len(counts))
+	for level, count := range counts {
+		ls := types.LevelStats{Count: count}
+		if window > 0 {
+			ls.RPS = float64(count) / window.Seconds()
+		}
+		if total > 0 {
+			ls.Share = float64(count) / float64(total) * 100
+		}
+		levels[types.LogLevel(level)] = ls
+	}
+	return levels
+}
+
+// levelRPS extracts the per-level rates for a trend point.
+func levelRPS(levels map[types.LogLevel]types.LevelStats) map[types.LogLevel]float64 {
+	if len(levels) == 0 {
+		return nil
+	}
+	rates := make(map[types.LogLevel]float64, len(levels))
+	for level, ls := range levels {
+		rates[level] = ls.RPS
+	}
+	return rates
+}
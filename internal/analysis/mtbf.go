@@ -0,0 +1,129 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+const (
+	failureIdleExpiry   = 24 * time.Hour // Forget endpoints without a failure for this long
+	maxFailureIntervals = 10
+)
+
+// endpointFailures tracks the time between an endpoint's server errors.
+type endpointFailures struct {
+	failures    int
+	lastFailure time.Time       // Log time of the latest error
+	received    time.Time       // Wall-clock time of the latest error, for expiry
+	gaps        []time.Duration // The latest 2*window gaps, oldest first
+	histogram   []int           // Every gap, bucketed by types.FailureGapBuckets
+}
+
+// recordFailure notes a server error of the entry's endpoint.
+func (e *Engine) recordFailure(entry types.LogEntry) {
+	f, ok := e.failures[entry.Endpoint]
+	if !ok {
+		f = &endpointFailures{histogram: make([]int, len(types.FailureGapBuckets)+1)}
+		e.failures[entry.Endpoint] = f
+	}
+	f.failures++
+	f.received = e.clock.Now()
+	if f.lastFailure.IsZero() {
+		f.lastFailure = entry.Timestamp
+		return
+	}
+	if entry.Timestamp.Before(f.lastFailure) {
+		return // Out of order; the gap it closes was already counted
+	}
+	gap := entry.Timestamp.Sub(f.lastFailure)
+	f.lastFailure = entry.Timestamp
+	f.gaps = append(f.gaps, gap)
+	if keep := 2 * e.detection.MTBF.Window; len(f.gaps) > keep {
+		f.gaps = f.gaps[len(f.gaps)-keep:]
+	}
+	f.histogram[types.FailureGapBucket(gap)]++
+}
+
+// updateFailureIntervals publishes the MTBF of endpoints with enough
+// failures, those failing more often first, and drops endpoints whose
+// failures stopped long ago.
+func (e *Engine) updateFailureIntervals(now time.Time) {
+	cfg := e.detection.MTBF
+	var intervals []types.FailureInterval
+	for endpoint, f := range e.failures {
+		if now.Sub(f.received) > failureIdleExpiry {
+			delete(e.failures, endpoint)
+			continue
+		}
+		if len(f.gaps) < cfg.Window {
+			continue
+		}
+		fi := types.FailureInterval{
+			Endpoint:    endpoint,
+			Failures:    f.failures,
+			LastFailure: f.lastFailure,
+			MTBF:        medianGap(f.gaps[len(f.gaps)-cfg.Window:]),
+			Histogram:   append([]int(nil), f.histogram...),
+		}
+		if len(f.gaps) >= 2*cfg.Window {
+			fi.PreviousMTBF = medianGap(f.gaps[len(f.gaps)-2*cfg.Window : len(f.gaps)-cfg.Window])
+			// Failures that have since stopped for longer than they used
+			// to be apart no longer count as speeding up
+			fi.Shrinking = float64(fi.MTBF)*cfg.Factor <= float64(fi.PreviousMTBF) && now.Sub(f.received) < fi.PreviousMTBF
+		}
+		intervals = append(intervals, fi)
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		if intervals[i].Shrinking != intervals[j].Shrinking {
+			return intervals[i].Shrinking
+		}
+		if intervals[i].MTBF != intervals[j].MTBF {
+			return intervals[i].MTBF < intervals[j].MTBF
+		}
+		return intervals[i].Endpoint < intervals[j].Endpoint
+	})
+	if len(intervals) > maxFailureIntervals {
+		intervals = intervals[:maxFailureIntervals]
+	}
+	e.metrics.FailureIntervals = intervals
+}
+
+func medianGap(gaps []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), gaps...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// detectShrinkingMTBF raises one anomaly listing the endpoints whose
+// failures are coming markedly closer together. The error rate can stay
+// under every threshold while this happens, e.g. a leak that crashes a
+// worker ever more often.
+func (e *Engine) detectShrinkingMTBF(ac *anomalyContext) {
+	var shrinking []string
+	var contributors []types.Contributor
+	for _, fi := range e.metrics.FailureIntervals {
+		if !fi.Shrinking {
+			continue
+		}
+		shrinking = append(shrinking, fmt.Sprintf("%s (every %s, was %s)", fi.Endpoint, fi.MTBF.Round(time.Millisecond), fi.PreviousMTBF.Round(time.Millisecond)))
+		contributors = append(contributors, types.Contributor{Dimension: "endpoint", Value: fi.Endpoint, Share: 100})
+	}
+	if len(shrinking) == 0 {
+		return
+	}
+	e.addAnomaly(types.Anomaly{
+		Timestamp:    e.clock.Now(),
+		Type:         "Shrinking MTBF",
+		Severity:     types.SeverityWarning,
+		Message:      "Failures are becoming more frequent: " + strings.Join(shrinking, ", "),
+		Contributors: contributors,
+	}, ac, evidenceErrors)
+}
@@ -0,0 +1,74 @@
+package analysis
+
+import (
+	"log"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/notify"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// maxPendingNotifications bounds the alerts waiting to be sent; more are
+// dropped so slow services never block detection.
+const maxPendingNotifications = 16
+
+// resolveInterval is how often notifiers that track firing alerts are asked
+// to resolve the ones that went quiet.
+const resolveInterval = 15 * time.Second
+
+// newNotifiers returns the configured push notification channels.
+func newNotifiers(cfg config.NotifyConfig) []notify.Notifier {
+	var notifiers []notify.Notifier
+	if cfg.Ntfy.Topic != "" {
+		notifiers = append(notifiers, notify.NewNtfy(cfg.Ntfy.Server, cfg.Ntfy.Topic, cfg.Ntfy.Token))
+	}
+	if cfg.Pushover.Token != "" {
+		notifiers = append(notifiers, notify.NewPushover(cfg.Pushover.Token, cfg.Pushover.User, cfg.Pushover.Device))
+	}
+	if am := cfg.Alertmanager; am.URL != "" {
+		notifiers = append(notifiers, notify.NewAlertmanager(am.URL, am.Username, am.Password, am.ResolveAfter, am.Labels))
+	}
+	return notifiers
+}
+
+// queueNotification hands a newly recorded anomaly to the notifier goroutine
+// if it is severe enough.
+func (e *Engine) queueNotification(a types.Anomaly) {
+	if len(e.notifiers) == 0 || types.SeverityRank(a.Severity) > types.SeverityRank(e.notifyMinSeverity) {
+		return
+	}
+	if e.silenced(a.Type, e.clock.Now()) {
+		return
+	}
+	select {
+	case e.notifyCh <- a:
+	default:
+		log.Printf("Too many pending notifications, dropping %s alert", a.Type)
+	}
+}
+
+func (e *Engine) runNotifier() {
+	ticker := e.clock.NewTicker(resolveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case a := <-e.notifyCh:
+			for _, n := range e.notifiers {
+				if err := n.Notify(a); err != nil {
+					log.Printf("Error sending %s notification: %v", n.Name(), err)
+				}
+			}
+		case <-ticker.C():
+			for _, n := range e.notifiers {
+				if r, ok := n.(notify.Resolver); ok {
+					if err := r.Resolve(e.clock.Now()); err != nil {
+						log.Printf("Error sending %s resolution: %v", n.Name(), err)
+					}
+				}
+			}
+		case <-e.doneChan:
+			return
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+const (
+	parseWindow     = 1 * time.Minute // Parse outcomes are judged per window
+	maxParseSamples = 5               // Failing lines kept per source
+)
+
+// parseCount tallies one source's parse outcomes in the current window.
+type parseCount struct {
+	lines, failed int
+	samples       []types.LogEntry // First failing lines, as messages
+}
+
+// CountParse records whether a structured parser accepted a line from
+// source (the input itself if empty). It is a no-op unless
+// detection.parse_failures is enabled.
+func (e *Engine) CountParse(source, line string, structured bool) {
+	if e.detection.ParseFailures.Threshold <= 0 {
+		return
+	}
+	if source == "" {
+		source = e.remoteWrite.Source
+	}
+	e.parseMu.Lock()
+	defer e.parseMu.Unlock()
+	if e.parseCounts == nil {
+		e.parseCounts = make(map[string]*parseCount)
+	}
+	c, ok := e.parseCounts[source]
+	if !ok {
+		c = &parseCount{}
+		e.parseCounts[source] = c
+	}
+	c.lines++
+	if structured {
+		return
+	}
+	c.failed++
+	if len(c.samples) < maxParseSamples {
+		c.samples = append(c.samples, types.LogEntry{Timestamp: e.clock.Now(), Message: line, Level: types.ErrorLevel})
+	}
+}
+
+// detectParseFailures raises one anomaly per window naming every source
+// whose share of unparsed lines exceeded the threshold, e.g. because a
+// deploy changed the log format, with sample lines as evidence. It runs
+// every tick, since input that no longer parses never marks the engine
+// dirty. Call it with e.mu held.
+func (e *Engine) detectParseFailures(now time.Time) {
+	pf := e.detection.ParseFailures
+	if pf.Threshold <= 0 || now.Sub(e.parseWindowStart) < parseWindow {
+		return
+	}
+	e.parseMu.Lock()
+	counts := e.parseCounts
+	e.parseCounts = nil
+	e.parseWindowStart = now
+	e.parseMu.Unlock()
+
+	sources := make([]string, 0, len(counts))
+	for source, c := range counts {
+		if c.lines >= pf.MinLines && float64(c.failed)/float64(c.lines)*100 > pf.Threshold {
+			sources = append(sources, source)
+		}
+	}
+	if len(sources) == 0 {
+		return
+	}
+	sort.Strings(sources)
+
+	var failing, samples []string
+	var evidence []types.LogEntry
+	for _, source := range sources {
+		c := counts[source]
+		failing = append(failing, fmt.Sprintf("%s %d of %d lines (%.1f%%)", source, c.failed, c.lines, float64(c.failed)/float64(c.lines)*100))
+		for _, s := range c.samples {
+			samples = append(samples, fmt.Sprintf("%s: %s", source, truncateLine(s.Message)))
+		}
+		evidence = append(evidence, c.samples...)
+	}
+	if len(evidence) > maxEvidenceEntries {
+		evidence = evidence[:maxEvidenceEntries]
+	}
+	e.addAnomaly(types.Anomaly{
+		Timestamp: now,
+		Type:      "Parse Failures",
+		Severity:  types.SeverityWarning,
+		Message:   fmt.Sprintf("Unparsed lines above %.1f%%: %s. Samples: %s", pf.Threshold, strings.Join(failing, ", "), strings.Join(samples, " | ")),
+		Evidence:  evidence,
+	}, nil, evidenceGiven)
+	e.dirty = true
+}
+
+// truncateLine shortens a sample line for the anomaly message, without
+// splitting a UTF-8 sequence.
+func truncateLine(line string) string {
+	const max = 200
+	if len(line) <= max {
+		return line
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut] + "…"
+}
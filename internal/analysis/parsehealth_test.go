@@ -0,0 +1,29 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateLine(t *testing.T) {
+	short := "GET /health 200"
+	if got := truncateLine(short); got != short {
+		t.Errorf("truncateLine(%q) = %q, want it unchanged", short, got)
+	}
+
+	// 199 ASCII bytes put the 200-byte limit inside the first 'é'
+	line := strings.Repeat("a", 199) + strings.Repeat("é", 10)
+	got := truncateLine(line)
+	if !utf8.ValidString(got) {
+		t.Fatalf("truncateLine split a rune: %q", got)
+	}
+	if want := strings.Repeat("a", 199) + "…"; got != want {
+		t.Errorf("truncateLine = %q, want %q", got, want)
+	}
+
+	ascii := strings.Repeat("a", 300)
+	if want := strings.Repeat("a", 200) + "…"; truncateLine(ascii) != want {
+		t.Errorf("truncateLine of 300 ASCII bytes = %q, want 200 bytes and an ellipsis", truncateLine(ascii))
+	}
+}
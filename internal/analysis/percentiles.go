@@ -0,0 +1,62 @@
+package analysis
+
+import (
+	"log"
+	"time"
+
+	"github.com/montanaflynn/stats"
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// computePercentiles evaluates each requested percentile over latencies in
+// milliseconds, keeping the configured order.
+func computePercentiles(latencies []float64, percentiles []float64) []types.PercentileValue {
+	values := make([]types.PercentileValue, 0, len(percentiles))
+	for _, p := range percentiles {
+		var latency time.Duration
+		if len(latencies) > 0 {
+			v, _ := stats.Percentile(latencies, p)
+			latency = time.Duration(v * float64(time.Millisecond))
+		}
+		values = append(values, types.PercentileValue{Percentile: p, Latency: latency})
+	}
+	return values
+}
+
+// liveEndpointPercentiles computes the per-endpoint overrides for endpoints
+// seen in a live window.
+func (e *Engine) liveEndpointPercentiles(since time.Time, f storage.EntryFilter, seen map[string]int) map[string][]types.PercentileValue {
+	result := make(map[string][]types.PercentileValue)
+	for endpoint, percentiles := range e.percentiles.Endpoints {
+		if seen[endpoint] == 0 {
+			continue
+		}
+		latencies, err := e.storage.LatenciesSince(since, endpoint, f)
+		if err != nil {
+			log.Printf("Error loading latencies for %s: %v", endpoint, err)
+			continue
+		}
+		result[endpoint] = computePercentiles(latencies, percentiles)
+	}
+	return result
+}
+
+// scanEndpointPercentiles computes the per-endpoint overrides from entries
+// held in memory during an initial scan.
+func (e *Engine) scanEndpointPercentiles(entries []types.LogEntry) map[string][]types.PercentileValue {
+	result := make(map[string][]types.PercentileValue)
+	if len(e.percentiles.Endpoints) == 0 {
+		return result
+	}
+	latencies := make(map[string][]float64)
+	for _, entry := range entries {
+		if _, ok := e.percentiles.Endpoints[entry.Endpoint]; ok && entry.StatusCode < 400 && entry.Latency > 0 {
+			latencies[entry.Endpoint] = append(latencies[entry.Endpoint], float64(entry.Latency.Milliseconds()))
+		}
+	}
+	for endpoint, ls := range latencies {
+		result[endpoint] = computePercentiles(ls, e.percentiles.Endpoints[endpoint])
+	}
+	return result
+}
@@ -0,0 +1,163 @@
+package analysis
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/locale"
+	"github.com/nitis/pulseWatch/internal/probe"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// probeCorrelationWindow is how recent a log-derived error anomaly must be
+// to count as confirming a failing probe.
+const probeCorrelationWindow = 5 * time.Minute
+
+// logErrorAnomalies are the anomaly types that show failures in the logs.
+var logErrorAnomalies = map[string]bool{
+	"Error Spike":        true,
+	"Error Rate Anomaly": true,
+	"Continuous Failure": true,
+}
+
+// probeState tracks one probe's results. It is guarded by Engine.probeMu.
+type probeState struct {
+	probe    *probe.Probe
+	failures int // Consecutive failed checks before an anomaly
+	status   types.ProbeStatus
+	recent   []types.ProbeResult // Within types.ProbeStatusWindow
+}
+
+func newProbes(probes []config.ProbeConfig) []*probeState {
+	states := make([]*probeState, len(probes))
+	for i, p := range probes {
+		states[i] = &probeState{
+			probe:    probe.New(p.Name, p.URL, p.Method, p.Headers, p.Interval, p.Timeout, p.ExpectStatus),
+			failures: p.Failures,
+			status:   types.ProbeStatus{Name: p.Name, URL: p.URL},
+		}
+	}
+	return states
+}
+
+// StartProbes runs the configured synthetic probes until the engine stops.
+// Only live watch calls it: probes say nothing about replayed or scanned
+// logs.
+func (e *Engine) StartProbes() {
+	for _, s := range e.probes {
+		p := s.probe
+		goSafe("probe", func() { p.Run(e.doneChan, e.RecordProbe) })
+	}
+}
+
+// ProbeNames lists the configured probes, in config order.
+func (e *Engine) ProbeNames() []string {
+	names := make([]string, len(e.probes))
+	for i, s := range e.probes {
+		names[i] = s.probe.Name
+	}
+	return names
+}
+
+// ProbeResults returns the stored results of the named probe between from
+// and to. Like Rollups, it only reads the database.
+func (e *Engine) ProbeResults(name string, from, to time.Time) ([]types.ProbeResult, error) {
+	return e.storage.GetProbeResultsBetween(name, from, to)
+}
+
+// RecordProbe stores a probe result and updates the probe's status, which
+// the next tick publishes.
+func (e *Engine) RecordProbe(r types.ProbeResult) {
+	if err := e.storage.InsertProbeResult(r); err != nil {
+		log.Printf("Error storing probe result: %v", err)
+	}
+	e.probeMu.Lock()
+	defer e.probeMu.Unlock()
+	for _, s := range e.probes {
+		if s.probe.Name != r.Name {
+			continue
+		}
+		s.status.Last = r
+		s.recent = append(s.recent, r)
+		if r.OK {
+			s.status.Failing = 0
+			s.status.LastSuccess = r.Timestamp
+		} else {
+			s.status.Failing++
+		}
+		e.probesChanged = true
+	}
+}
+
+// updateProbes publishes the probes' status after new results and raises a
+// "Probe Failure" anomaly while any probe has failed its configured number
+// of checks in a row, correlated with what the logs show. Probes keep
+// reporting while no logs arrive, which is when they matter most, so it runs
+// every tick. Call it with e.mu held.
+func (e *Engine) updateProbes(now time.Time) {
+	e.probeMu.Lock()
+	if !e.probesChanged {
+		e.probeMu.Unlock()
+		return
+	}
+	e.probesChanged = false
+	statuses := make([]types.ProbeStatus, len(e.probes))
+	var failing []string
+	for i, s := range e.probes {
+		keep := s.recent[:0]
+		s.status.Checks, s.status.Successes = 0, 0
+		for _, r := range s.recent {
+			if now.Sub(r.Timestamp) > types.ProbeStatusWindow {
+				continue
+			}
+			keep = append(keep, r)
+			s.status.Checks++
+			if r.OK {
+				s.status.Successes++
+			}
+		}
+		s.recent = keep
+		statuses[i] = s.status
+		if s.status.Failing >= s.failures {
+			failing = append(failing, fmt.Sprintf("%s failed %d checks in a row (%s)", s.probe.Name, s.status.Failing, s.status.Last.Error))
+		}
+	}
+	e.probeMu.Unlock()
+
+	e.metrics.Probes = statuses
+	e.dirty = true
+	if len(failing) == 0 {
+		return
+	}
+	severity, correlation := e.probeCorrelation(now)
+	e.addAnomaly(types.Anomaly{
+		Timestamp: now,
+		Type:      "Probe Failure",
+		Severity:  severity,
+		Message:   fmt.Sprintf("Probe %s %s", strings.Join(failing, "; "), correlation),
+	}, nil, evidenceNone)
+}
+
+// probeCorrelation compares failing probes with the logs: a recent error
+// anomaly confirms the outage, no logged traffic suggests requests no longer
+// reach the service, and healthy logs point at the path in between.
+func (e *Engine) probeCorrelation(now time.Time) (severity, message string) {
+	for i := len(e.metrics.Anomalies) - 1; i >= 0; i-- {
+		a := e.metrics.Anomalies[i]
+		if now.Sub(a.Timestamp) > probeCorrelationWindow {
+			break
+		}
+		if logErrorAnomalies[a.Type] {
+			return types.SeverityCritical, fmt.Sprintf("while logs show %s at %s: %s", a.Type, locale.Time(a.Timestamp), a.Message)
+		}
+	}
+	wm, ok := e.metrics.Windows["1m"]
+	if !ok || wm.TotalRequests == 0 {
+		return types.SeverityCritical, "and no requests were logged in the last minute; traffic may not be reaching the service"
+	}
+	return types.SeverityWarning, fmt.Sprintf("but logs look healthy (error rate %s over %s requests in the last minute); check the path from outside, such as DNS, TLS, or the load balancer",
+		locale.Percent(wm.ErrorRate, 1), locale.Int(int64(wm.TotalRequests)))
+}
@@ -0,0 +1,150 @@
+package analysis
+
+import (
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/topk"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// rateLimiter simulates one rate-limit policy against the entries. The
+// limited requests per key are counted in a sketch, so a flood of distinct
+// keys, e.g. spoofed client addresses, can't grow it without bound.
+type rateLimiter struct {
+	cfg         config.RateLimitConfig
+	buckets     map[string]*tokenBucket
+	limited     *topk.Sketch   // Limited requests per key
+	keyRequests map[string]int // Requests of the keys in limited since they were first limited
+	limitedKeys int            // Keys limited at least once
+	requests    int
+	rejected    int
+	latest      time.Time // Latest log time seen, for expiring idle buckets
+}
+
+// tokenBucket is one key's bucket. Requests seen since it was created are
+// counted so a key's total is known once it is first limited.
+type tokenBucket struct {
+	tokens   float64
+	last     time.Time // Log time of the latest refill
+	requests int
+	limited  bool // The key has been limited since the bucket was created
+}
+
+// newRateLimiters creates a limiter per policy, each keeping its most
+// limited maxKeys keys.
+func newRateLimiters(cfgs []config.RateLimitConfig, maxKeys int) []*rateLimiter {
+	limiters := make([]*rateLimiter, len(cfgs))
+	for i, cfg := range cfgs {
+		limiters[i] = &rateLimiter{
+			cfg:         cfg,
+			buckets:     make(map[string]*tokenBucket),
+			limited:     topk.New(maxKeys),
+			keyRequests: make(map[string]int),
+		}
+	}
+	return limiters
+}
+
+// recordRateLimits runs entry through every simulated policy. Buckets refill
+// by log time, so replays simulate the traffic as it arrived.
+func (e *Engine) recordRateLimits(entry types.LogEntry) {
+	for _, l := range e.rateLimiters {
+		key := fieldString(entry, l.cfg.Key)
+		if key == "" {
+			continue
+		}
+		l.take(key, entry.Timestamp)
+	}
+}
+
+// take spends a token of key's bucket at t, counting the request as limited
+// when the bucket is empty. Entries older than the bucket's last refill
+// don't refill it.
+func (l *rateLimiter) take(key string, t time.Time) {
+	if t.After(l.latest) {
+		l.latest = t
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(l.cfg.Burst), last: t}
+		l.buckets[key] = b
+	}
+	if elapsed := t.Sub(b.last); elapsed > 0 {
+		b.tokens = min(b.tokens+elapsed.Seconds()*l.cfg.Rate, float64(l.cfg.Burst))
+		b.last = t
+	}
+	b.requests++
+	l.requests++
+
+	_, tracked := l.keyRequests[key]
+	if tracked {
+		l.keyRequests[key]++
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return
+	}
+	l.rejected++
+	if !tracked {
+		// A key that fell out of the sketch and whose bucket has since
+		// refilled completely counts again
+		if !b.limited {
+			l.limitedKeys++
+		}
+		l.keyRequests[key] = b.requests
+	}
+	b.limited = true
+	l.limited.Add(key, 1)
+}
+
+// expire forgets buckets that have refilled completely, which behave like
+// new ones.
+func (l *rateLimiter) expire() {
+	full := time.Duration(float64(l.cfg.Burst) / l.cfg.Rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if l.latest.Sub(b.last) >= full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// updateRateLimits publishes each policy's outcome so far.
+func (e *Engine) updateRateLimits() {
+	if len(e.rateLimiters) == 0 {
+		return
+	}
+	stats := make([]types.RateLimitStats, len(e.rateLimiters))
+	for i, l := range e.rateLimiters {
+		l.expire()
+		counts := l.limited.Counts()
+		for key := range l.keyRequests {
+			if _, ok := counts[key]; !ok {
+				delete(l.keyRequests, key)
+			}
+		}
+		s := types.RateLimitStats{
+			Name:        l.cfg.Name,
+			Key:         l.cfg.Key,
+			Rate:        l.cfg.Rate,
+			Burst:       l.cfg.Burst,
+			Requests:    l.requests,
+			Limited:     l.rejected,
+			LimitedKeys: l.limitedKeys,
+		}
+		if l.requests > 0 {
+			s.LimitedPct = float64(l.rejected) / float64(l.requests) * 100
+		}
+		for _, it := range l.limited.Top(l.cfg.Top) {
+			// A key that replaced another in the sketch inherits its
+			// count, which may exceed the requests seen since
+			s.TopKeys = append(s.TopKeys, types.KeyLimited{
+				Key:      it.Key,
+				Requests: max(l.keyRequests[it.Key], it.Count),
+				Limited:  it.Count,
+			})
+		}
+		stats[i] = s
+	}
+	e.metrics.RateLimits = stats
+}
@@ -0,0 +1,29 @@
+package analysis
+
+import (
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// adaptTick measures the ingest rate since the last tick and returns the
+// refresh interval to use next. Outside adaptive mode that is always the
+// configured tick; in adaptive mode the tick is stretched in proportion to how
+// far the rate exceeds refresh.AdaptiveRate, up to refresh.MaxTick.
+func (e *Engine) adaptTick(now time.Time) time.Duration {
+	rate := 0.0
+	if elapsed := now.Sub(e.lastTick).Seconds(); elapsed > 0 {
+		rate = float64(e.ingested) / elapsed
+	}
+	e.ingested = 0
+	e.lastTick = now
+
+	tick := e.refresh.Tick
+	if e.refresh.Adaptive && rate > e.refresh.AdaptiveRate {
+		scaled := time.Duration(float64(tick) * rate / e.refresh.AdaptiveRate)
+		// Round so small rate changes don't reset the ticker every time
+		tick = min(scaled.Round(100*time.Millisecond), max(e.refresh.MaxTick, tick))
+	}
+	e.metrics.Refresh = types.RefreshStatus{Tick: tick, Adaptive: e.refresh.Adaptive, IngestRate: rate}
+	return tick
+}
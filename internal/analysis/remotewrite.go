@@ -0,0 +1,146 @@
+package analysis
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/remotewrite"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// remoteWriteDue reports whether the next remote write is due.
+func (e *Engine) remoteWriteDue(now time.Time) bool {
+	return e.remoteWriter != nil && now.Sub(e.lastRemoteWrite) >= e.remoteWrite.Interval
+}
+
+// queueRemoteWrite hands the 1m window's series to the remote-write
+// goroutine once per interval. If the previous push is still in flight the
+// new one is dropped rather than blocking the ticker.
+func (e *Engine) queueRemoteWrite(now time.Time) {
+	if !e.remoteWriteDue(now) {
+		return
+	}
+	e.lastRemoteWrite = now
+	select {
+	case e.remoteWriteCh <- e.remoteWriteSeries(now):
+	default:
+		log.Printf("Remote write still in progress, skipping this interval")
+	}
+}
+
+func (e *Engine) runRemoteWrite() {
+	for {
+		select {
+		case series := <-e.remoteWriteCh:
+			if err := e.remoteWriter.Push(series); err != nil {
+				log.Printf("Error pushing metrics via remote write: %v", err)
+			}
+		case <-e.doneChan:
+			return
+		}
+	}
+}
+
+// remoteWriteSeries converts the 1m window to series labelled with the
+// source and, for the busiest remoteWrite.Top endpoints, the endpoint.
+// Endpoint errors are counted from entries that log an HTTP method.
+func (e *Engine) remoteWriteSeries(now time.Time) []remotewrite.Series {
+	wm, ok := e.metrics.Windows["1m"]
+	if !ok {
+		return nil
+	}
+	labels := func(extra ...string) map[string]string {
+		l := map[string]string{"source": e.remoteWrite.Source}
+		for k, v := range e.remoteWrite.Labels {
+			l[k] = v
+		}
+		for i := 0; i+1 < len(extra); i += 2 {
+			l[extra[i]] = extra[i+1]
+		}
+		return l
+	}
+
+	series := []remotewrite.Series{
+		remotewrite.NewSeries("pulsewatch_requests_per_second", labels(), wm.RPS, now),
+		remotewrite.NewSeries("pulsewatch_error_ratio", labels(), wm.ErrorRate/100, now),
+	}
+	for _, p := range wm.Percentiles {
+		quantile := strconv.FormatFloat(p.Percentile/100, 'g', -1, 64)
+		series = append(series, remotewrite.NewSeries("pulsewatch_latency_seconds", labels("quantile", quantile), p.Latency.Seconds(), now))
+	}
+	for level, ls := range wm.Levels {
+		series = append(series, remotewrite.NewSeries("pulsewatch_log_entries_per_second", labels("level", strings.ToLower(string(level))), ls.RPS, now))
+	}
+	for _, s := range wm.LatencySLA {
+		series = append(series, remotewrite.NewSeries("pulsewatch_latency_within_ratio", labels("threshold", formatSeconds(s.Threshold)), s.Percent/100, now))
+	}
+	for endpoint, slas := range wm.EndpointLatencySLA {
+		for _, s := range slas {
+			series = append(series, remotewrite.NewSeries("pulsewatch_endpoint_latency_within_ratio", labels("endpoint", endpoint, "threshold", formatSeconds(s.Threshold)), s.Percent/100, now))
+		}
+	}
+
+	endpoints := make([]string, 0, len(wm.TopEndpoints))
+	for endpoint := range wm.TopEndpoints {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if wm.TopEndpoints[endpoints[i]] != wm.TopEndpoints[endpoints[j]] {
+			return wm.TopEndpoints[endpoints[i]] > wm.TopEndpoints[endpoints[j]]
+		}
+		return endpoints[i] < endpoints[j]
+	})
+	if len(endpoints) > e.remoteWrite.Top {
+		endpoints = endpoints[:e.remoteWrite.Top]
+	}
+	for _, endpoint := range endpoints {
+		requests := wm.TopEndpoints[endpoint]
+		series = append(series, remotewrite.NewSeries("pulsewatch_endpoint_requests_per_second", labels("endpoint", endpoint), float64(requests)/time.Minute.Seconds(), now))
+		errors := 0
+		for _, m := range wm.EndpointMethods[endpoint] {
+			errors += m.Errors
+		}
+		series = append(series, remotewrite.NewSeries("pulsewatch_endpoint_error_ratio", labels("endpoint", endpoint), float64(errors)/float64(requests), now))
+	}
+	return append(series, e.histogramSeries(now, labels)...)
+}
+
+// histogramSeries converts the session's latency histogram to cumulative
+// _bucket, _sum, and _count series. Each bucket exemplar is sent once.
+func (e *Engine) histogramSeries(now time.Time, labels func(extra ...string) map[string]string) []remotewrite.Series {
+	const name = "pulsewatch_request_duration_seconds"
+	h := e.histogram.snapshot()
+	if e.pushedExemplars == nil {
+		e.pushedExemplars = make([]types.Exemplar, len(h.Counts))
+	}
+	series := make([]remotewrite.Series, 0, len(h.Counts)+2)
+	for i, count := range h.Counts {
+		le := "+Inf"
+		if i < len(h.Bounds) {
+			le = formatSeconds(h.Bounds[i])
+		}
+		s := remotewrite.NewSeries(name+"_bucket", labels("le", le), float64(count), now)
+		if ex := h.Exemplars[i]; ex.TraceID != "" && ex != e.pushedExemplars[i] {
+			s.Exemplars = []remotewrite.Exemplar{{
+				Labels:    []remotewrite.Label{{Name: "trace_id", Value: ex.TraceID}},
+				Value:     ex.Latency.Seconds(),
+				Timestamp: ex.Timestamp.UnixMilli(),
+			}}
+			e.pushedExemplars[i] = ex
+		}
+		series = append(series, s)
+	}
+	series = append(series,
+		remotewrite.NewSeries(name+"_sum", labels(), h.Sum.Seconds(), now),
+		remotewrite.NewSeries(name+"_count", labels(), float64(h.Count), now),
+	)
+	return series
+}
+
+// formatSeconds writes d in seconds, like Prometheus histogram bounds.
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'g', -1, 64)
+}
@@ -0,0 +1,44 @@
+package analysis
+
+import (
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// maxReportEntries bounds the entries kept for the EOF report. Input longer
+// than this is treated as an endless stream and gets no final report.
+const maxReportEntries = 1 << 20
+
+// SetReportOnEOF makes the engine send a final historical report when the
+// input ends, for finite input such as a file piped to stdin. Call it before
+// Start.
+func (e *Engine) SetReportOnEOF(enabled bool) {
+	e.reportOnEOF = enabled
+}
+
+func (e *Engine) keepForReport(entry types.LogEntry) {
+	if !e.reportOnEOF {
+		return
+	}
+	if len(e.inputLog) >= maxReportEntries {
+		e.reportOnEOF = false
+		e.inputLog = nil
+		return
+	}
+	e.inputLog = append(e.inputLog, entry)
+}
+
+// sendFinalReport sends metrics for all input as a single "all" window,
+// marked Final.
+func (e *Engine) sendFinalReport() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.metrics.Windows = map[string]types.WindowedMetrics{
+		"all": e.computeWindowedMetrics(e.inputLog, 0),
+	}
+	e.updateRateLimits()
+	e.metrics.Final = true
+	e.inputLog = nil
+	e.dirty = false
+	e.sendMetrics()
+}
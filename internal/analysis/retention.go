@@ -0,0 +1,36 @@
+package analysis
+
+import (
+	"log"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+)
+
+const rollupInterval = 1 * time.Minute
+
+// recordRollup persists a snapshot of the 1m window once per rollupInterval.
+// Rollups outlive raw entries (see config.RetentionConfig), so long-range
+// trends and forecasts keep working after raw data is pruned.
+func (e *Engine) recordRollup(now time.Time) {
+	if now.Sub(e.lastRollup) < rollupInterval {
+		return
+	}
+	wm, ok := e.metrics.Windows["1m"]
+	if !ok {
+		return
+	}
+	e.lastRollup = now
+
+	err := e.storage.InsertRollup(storage.Rollup{
+		Timestamp: now,
+		Requests:  wm.TotalRequests,
+		Errors:    wm.TotalErrors,
+		P50:       wm.P50Latency,
+		P95:       wm.P95Latency,
+		P99:       wm.P99Latency,
+	})
+	if err != nil {
+		log.Printf("Error storing metrics rollup: %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// retrySeen remembers when a client request or idempotency key was last seen.
+type retrySeen struct {
+	last     time.Time // Log time of the latest request
+	received time.Time // Wall-clock time it was seen, for expiry
+}
+
+// recordRetry marks entry as a likely retry when its client sent the same
+// method and endpoint within the retry interval, or its idempotency key
+// was seen within the key window.
+func (e *Engine) recordRetry(entry *types.LogEntry) {
+	if e.retries.Disable {
+		return
+	}
+	now := e.clock.Now()
+	if key := fieldString(*entry, e.retries.KeyField); key != "" {
+		if seen, ok := e.retryKeys[key]; ok && absDuration(entry.Timestamp.Sub(seen.last)) <= e.retries.KeyWindow {
+			entry.Retry = true
+		}
+		e.retryKeys[key] = retrySeen{last: entry.Timestamp, received: now}
+	}
+	client := fieldString(*entry, e.retries.ClientField)
+	if client == "" || entry.Endpoint == "" {
+		return
+	}
+	key := client + " " + entry.Method + " " + entry.Endpoint
+	if seen, ok := e.retryClients[key]; ok && absDuration(entry.Timestamp.Sub(seen.last)) <= e.retries.Interval {
+		entry.Retry = true
+	}
+	e.retryClients[key] = retrySeen{last: entry.Timestamp, received: now}
+}
+
+// expireRetries forgets requests and keys too old to be retried.
+func (e *Engine) expireRetries(now time.Time) {
+	for key, seen := range e.retryClients {
+		if now.Sub(seen.received) > e.retries.Interval {
+			delete(e.retryClients, key)
+		}
+	}
+	for key, seen := range e.retryKeys {
+		if now.Sub(seen.received) > e.retries.KeyWindow {
+			delete(e.retryKeys, key)
+		}
+	}
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// retryStats summarises a window's likely retries and its e.retries.Top most
+// retried endpoints.
+func (e *Engine) retryStats(agg storage.WindowAggregate) types.RetryStats {
+	if e.retries.Disable || agg.Total == 0 {
+		return types.RetryStats{}
+	}
+	stats := types.RetryStats{
+		Requests:      agg.Total,
+		Retries:       agg.Retries,
+		Amplification: types.Amplification(agg.Total, agg.Retries),
+	}
+	for endpoint, retries := range agg.EndpointRetries {
+		requests := agg.Endpoints[endpoint]
+		stats.TopEndpoints = append(stats.TopEndpoints, types.EndpointRetries{
+			Endpoint:      endpoint,
+			Requests:      requests,
+			Retries:       retries,
+			Amplification: types.Amplification(requests, retries),
+		})
+	}
+	sort.Slice(stats.TopEndpoints, func(i, j int) bool {
+		a, b := stats.TopEndpoints[i], stats.TopEndpoints[j]
+		if a.Retries != b.Retries {
+			return a.Retries > b.Retries
+		}
+		return a.Endpoint < b.Endpoint
+	})
+	if len(stats.TopEndpoints) > e.retries.Top {
+		stats.TopEndpoints = stats.TopEndpoints[:e.retries.Top]
+	}
+	return stats
+}
+
+// detectRetryStorm raises an anomaly when retries amplify the 1m window's
+// traffic by at least the configured factor.
+func (e *Engine) detectRetryStorm(ac *anomalyContext) {
+	cfg := e.retries
+	current, ok := e.metrics.Windows["1m"]
+	if cfg.Disable || !ok || current.Retries.Requests < cfg.MinRequests || current.Retries.Amplification < cfg.Amplification {
+		return
+	}
+
+	r := current.Retries
+	message := fmt.Sprintf("Likely client retries amplify 1m traffic %.2fx (%d of %d requests, threshold %gx)", r.Amplification, r.Retries, r.Requests, cfg.Amplification)
+	if len(r.TopEndpoints) > 0 {
+		top := r.TopEndpoints[0]
+		message += fmt.Sprintf("; most retried: %s %.2fx", top.Endpoint, top.Amplification)
+	}
+	contributors := ac.contributors(storage.EntryFilter{Retries: true})
+	evidence := ac.entries(storage.EntryFilter{Retries: true}, storage.EvidenceRecent)
+	e.addAnomaly(types.Anomaly{
+		Timestamp:    e.clock.Now(),
+		Type:         "Retry Storm",
+		Severity:     types.SeverityWarning,
+		Message:      message + formatContributors(contributors),
+		Contributors: contributors,
+		Evidence:     evidence,
+	}, ac, evidenceGiven)
+}
@@ -0,0 +1,56 @@
+package analysis
+
+import (
+	"log"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// SetMetricsScope makes each tick also compute the live windows over only
+// the entries whose message or endpoint contains text, published as
+// Metrics.ScopedWindows; "" stops. Detection, trends, and exports keep
+// using every entry.
+func (e *Engine) SetMetricsScope(text string) {
+	e.scopeMu.Lock()
+	e.scope = text
+	e.scopeMu.Unlock()
+	e.markDirty()
+}
+
+// markDirty publishes the metrics, with the scoped windows, on the next tick.
+func (e *Engine) markDirty() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.dirty = true
+}
+
+// scopedWindowSet is the live windows over the entries in a scope.
+type scopedWindowSet struct {
+	scope, source string
+	windows       map[string]types.WindowedMetrics // nil without a scope
+}
+
+// scopedWindows computes the live windows over the stored entries in the
+// current scope, filtering and aggregating in SQL. It runs without e.mu.
+func (e *Engine) scopedWindows() scopedWindowSet {
+	e.scopeMu.Lock()
+	set := scopedWindowSet{scope: e.scope, source: e.scopeSource}
+	e.scopeMu.Unlock()
+	if (set.scope == "" && set.source == "") || e.initialScan {
+		return set
+	}
+
+	now := e.clock.Now()
+	f := storage.EntryFilter{Contains: set.scope, Source: set.source}
+	set.windows = make(map[string]types.WindowedMetrics, len(e.windows))
+	for key, window := range e.windows {
+		wm, err := e.liveWindow(now.Add(-window), window, f)
+		if err != nil {
+			log.Printf("Error aggregating window %s for scope %q: %v", key, set.scope, err)
+			continue
+		}
+		set.windows[key] = wm
+	}
+	return set
+}
@@ -0,0 +1,74 @@
+package analysis
+
+import (
+	"sort"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// sessionState remembers where a session was last seen.
+type sessionState struct {
+	endpoint string
+	last     time.Time // Log time of the session's latest request
+	received time.Time // Wall-clock time the session was last seen, for expiry
+}
+
+// recordSession tags entry with its session and the endpoint the session
+// visited before, unless the session was idle longer than the timeout.
+func (e *Engine) recordSession(entry *types.LogEntry) {
+	if e.session.Field == "" {
+		return
+	}
+	entry.Session = fieldString(*entry, e.session.Field)
+	if entry.Session == "" || entry.Endpoint == "" {
+		return
+	}
+
+	s, ok := e.sessions[entry.Session]
+	if ok && entry.Timestamp.Sub(s.last) <= e.session.Timeout {
+		entry.PrevEndpoint = s.endpoint
+	}
+	e.sessions[entry.Session] = sessionState{endpoint: entry.Endpoint, last: entry.Timestamp, received: e.clock.Now()}
+}
+
+// expireSessions forgets sessions idle for longer than the timeout.
+func (e *Engine) expireSessions(now time.Time) {
+	for id, s := range e.sessions {
+		if now.Sub(s.received) > e.session.Timeout {
+			delete(e.sessions, id)
+		}
+	}
+}
+
+// sessionStats summarises a window's sessions and its e.session.Top most
+// common transitions.
+func (e *Engine) sessionStats(agg storage.WindowAggregate) types.SessionStats {
+	if e.session.Field == "" || agg.Sessions == 0 {
+		return types.SessionStats{}
+	}
+
+	stats := types.SessionStats{
+		Sessions:           agg.Sessions,
+		Requests:           agg.SessionRequests,
+		RequestsPerSession: float64(agg.SessionRequests) / float64(agg.Sessions),
+	}
+	for t, count := range agg.Transitions {
+		stats.TopTransitions = append(stats.TopTransitions, types.Transition{From: t.From, To: t.To, Count: count})
+	}
+	sort.Slice(stats.TopTransitions, func(i, j int) bool {
+		a, b := stats.TopTransitions[i], stats.TopTransitions[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	if len(stats.TopTransitions) > e.session.Top {
+		stats.TopTransitions = stats.TopTransitions[:e.session.Top]
+	}
+	return stats
+}
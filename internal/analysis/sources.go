@@ -0,0 +1,43 @@
+package analysis
+
+import (
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// sourceStats summarises each input of a window; nil unless entries came
+// from more than one.
+func sourceStats(agg storage.WindowAggregate, window time.Duration) map[string]types.SourceStats {
+	if len(agg.Sources) < 2 {
+		return nil
+	}
+	stats := make(map[string]types.SourceStats, len(agg.Sources))
+	for source, a := range agg.Sources {
+		s := types.SourceStats{
+			Entries:   a.Requests,
+			Errors:    a.Errors,
+			ErrorRate: float64(a.Errors) / float64(a.Requests) * 100,
+			Share:     float64(a.Requests) / float64(agg.Total) * 100,
+		}
+		if window > 0 {
+			s.RPS = float64(a.Requests) / window.Seconds()
+		}
+		if a.LatencyCount > 0 {
+			s.AvgLatency = time.Duration(a.LatencySum / float64(a.LatencyCount) * float64(time.Millisecond))
+		}
+		stats[source] = s
+	}
+	return stats
+}
+
+// SetSourceScope makes each tick also compute the live windows over only
+// the entries read from source, published as Metrics.ScopedWindows, on top
+// of any text scope; "" stops.
+func (e *Engine) SetSourceScope(source string) {
+	e.scopeMu.Lock()
+	e.scopeSource = source
+	e.scopeMu.Unlock()
+	e.markDirty()
+}
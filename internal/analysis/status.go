@@ -0,0 +1,41 @@
+package analysis
+
+import (
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// statusInterval is how often the status is refreshed while no logs arrive,
+// so that an idle service's rate falls to zero.
+const statusInterval = 10 * time.Second
+
+// Status returns the headline metrics as of the last tick.
+func (e *Engine) Status() types.Status {
+	e.statusMu.RLock()
+	defer e.statusMu.RUnlock()
+	return e.status
+}
+
+func (e *Engine) statusDue(now time.Time) bool {
+	e.statusMu.RLock()
+	defer e.statusMu.RUnlock()
+	return now.Sub(e.status.Updated) >= statusInterval
+}
+
+// publishStatus stores the current metrics for Status. Call it with e.mu
+// held, after calculateMetrics.
+func (e *Engine) publishStatus(now time.Time) {
+	s := types.Status{Snapshot: e.snapshot(), Learning: e.metrics.Learning, Updated: now, Started: e.metrics.StartTime}
+	for _, a := range e.metrics.Anomalies {
+		if now.Sub(a.Timestamp) <= types.StatusAnomalyWindow {
+			s.Anomalies++
+			if a.Severity == types.SeverityCritical {
+				s.Critical++
+			}
+		}
+	}
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+	e.status = s
+}
@@ -0,0 +1,125 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+const (
+	streakIdleExpiry = 1 * time.Hour // Forget endpoints not seen for this long
+	maxErrorStreaks  = 10
+)
+
+// endpointStreak tracks an endpoint's run of consecutive server errors.
+type endpointStreak struct {
+	length      int       // Consecutive 5xx responses, 0 after a success
+	started     time.Time // Log time of the first error of the current streak
+	lastError   time.Time // Log time of the latest error
+	lastSuccess time.Time // Log time of the latest success
+	received    time.Time // Wall-clock time the endpoint was last seen, for expiry
+	longest     int
+}
+
+// recordStreak updates the endpoint's streak with one entry. Only server
+// errors count as failures; a 4xx is the client's fault and ends no streak.
+func (e *Engine) recordStreak(entry types.LogEntry) {
+	if entry.Endpoint == "" || entry.StatusCode == 0 {
+		return
+	}
+	s, ok := e.streaks[entry.Endpoint]
+	if !ok {
+		s = &endpointStreak{}
+		e.streaks[entry.Endpoint] = s
+	}
+	s.received = e.clock.Now()
+
+	switch {
+	case entry.StatusCode >= 500:
+		if s.length == 0 {
+			s.started = entry.Timestamp
+		}
+		s.length++
+		s.lastError = entry.Timestamp
+		s.longest = max(s.longest, s.length)
+		e.recordFailure(entry)
+	case entry.StatusCode < 400:
+		s.length = 0
+		s.lastSuccess = entry.Timestamp
+	}
+}
+
+// updateErrorStreaks publishes the active streaks, longest first, and drops
+// endpoints that have gone quiet.
+func (e *Engine) updateErrorStreaks(now time.Time) {
+	var streaks []types.ErrorStreak
+	for endpoint, s := range e.streaks {
+		if now.Sub(s.received) > streakIdleExpiry {
+			delete(e.streaks, endpoint)
+			continue
+		}
+		if s.length == 0 {
+			continue
+		}
+		streaks = append(streaks, types.ErrorStreak{
+			Endpoint:    endpoint,
+			Length:      s.length,
+			Longest:     s.longest,
+			Since:       s.started,
+			Duration:    s.lastError.Sub(s.started),
+			LastSuccess: s.lastSuccess,
+			Continuous:  e.isContinuousFailure(s),
+		})
+	}
+	sort.Slice(streaks, func(i, j int) bool {
+		if streaks[i].Length != streaks[j].Length {
+			return streaks[i].Length > streaks[j].Length
+		}
+		return streaks[i].Endpoint < streaks[j].Endpoint
+	})
+	if len(streaks) > maxErrorStreaks {
+		streaks = streaks[:maxErrorStreaks]
+	}
+	e.metrics.ErrorStreaks = streaks
+}
+
+// isContinuousFailure reports whether a streak is long enough, in both errors
+// and time, to call the endpoint down rather than flaky.
+func (e *Engine) isContinuousFailure(s *endpointStreak) bool {
+	cfg := e.detection.Streak
+	return s.length >= cfg.MinErrors && s.lastError.Sub(s.started) >= cfg.MinDuration
+}
+
+// detectContinuousFailures raises one anomaly listing every endpoint that has
+// been failing without interruption. Averaged error rates hide these when the
+// endpoint is a small share of traffic.
+func (e *Engine) detectContinuousFailures(ac *anomalyContext) {
+	var failing []string
+	var contributors []types.Contributor
+	for _, s := range e.metrics.ErrorStreaks {
+		if !s.Continuous {
+			continue
+		}
+		desc := fmt.Sprintf("%s (%d errors in a row over %s", s.Endpoint, s.Length, s.Duration.Round(time.Second))
+		if s.LastSuccess.IsZero() {
+			desc += ", no success seen)"
+		} else {
+			desc += fmt.Sprintf(", last success at %s)", s.LastSuccess.Format("15:04:05"))
+		}
+		failing = append(failing, desc)
+		contributors = append(contributors, types.Contributor{Dimension: "endpoint", Value: s.Endpoint, Share: 100})
+	}
+	if len(failing) == 0 {
+		return
+	}
+	e.addAnomaly(types.Anomaly{
+		Timestamp:    e.clock.Now(),
+		Type:         "Continuous Failure",
+		Severity:     types.SeverityCritical,
+		Message:      "Endpoints failing continuously: " + strings.Join(failing, ", "),
+		Contributors: contributors,
+	}, ac, evidenceErrors)
+}
@@ -0,0 +1,76 @@
+package analysis
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// tenantStats turns the per-tenant aggregates of a window into stats for the
+// busiest e.tenant.Top tenants. latencies supplies each top tenant's
+// successful latencies for the P95.
+func (e *Engine) tenantStats(agg storage.WindowAggregate, window time.Duration, latencies func(tenant string) []float64) map[string]types.RequestStats {
+	if e.tenant.Field == "" {
+		return nil
+	}
+	return requestStats(agg.Tenants, agg.Total, e.tenant.Top, window, latencies)
+}
+
+// requestStats turns per-key aggregates into stats for the top busiest
+// keys, with shares of total requests. latencies supplies each key's
+// successful latencies for the P95.
+func requestStats(aggs map[string]storage.RequestAggregate, total, top int, window time.Duration, latencies func(key string) []float64) map[string]types.RequestStats {
+	if len(aggs) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(aggs))
+	for key := range aggs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if aggs[keys[i]].Requests != aggs[keys[j]].Requests {
+			return aggs[keys[i]].Requests > aggs[keys[j]].Requests
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > top {
+		keys = keys[:top]
+	}
+
+	result := make(map[string]types.RequestStats, len(keys))
+	for _, key := range keys {
+		a := aggs[key]
+		rs := types.RequestStats{
+			Requests:  a.Requests,
+			Errors:    a.Errors,
+			ErrorRate: float64(a.Errors) / float64(a.Requests) * 100,
+			Share:     float64(a.Requests) / float64(total) * 100,
+		}
+		if window > 0 {
+			rs.RPS = float64(a.Requests) / window.Seconds()
+		}
+		if a.LatencyCount > 0 {
+			rs.AvgLatency = time.Duration(a.LatencySum / float64(a.LatencyCount) * float64(time.Millisecond))
+			if p := computePercentiles(latencies(key), []float64{95}); len(p) == 1 {
+				rs.P95Latency = p[0].Latency
+			}
+		}
+		result[key] = rs
+	}
+	return result
+}
+
+// liveTenantLatencies loads a tenant's latencies for a live window.
+func (e *Engine) liveTenantLatencies(since time.Time, f storage.EntryFilter) func(string) []float64 {
+	return func(tenant string) []float64 {
+		latencies, err := e.storage.TenantLatenciesSince(since, tenant, f)
+		if err != nil {
+			log.Printf("Error loading latencies for tenant %s: %v", tenant, err)
+		}
+		return latencies
+	}
+}
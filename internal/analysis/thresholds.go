@@ -0,0 +1,53 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// minSpikeErrors keeps a handful of errors on a quiet service from counting
+// as a spike.
+const minSpikeErrors = 5
+
+// Thresholds returns the detection thresholds currently in effect.
+func (e *Engine) Thresholds() types.Thresholds {
+	e.thresholdsMu.RLock()
+	defer e.thresholdsMu.RUnlock()
+	return e.thresholds
+}
+
+// SetThresholds replaces the detection thresholds. The change applies from
+// the next detection pass; a new SLO target also refits the forecast.
+func (e *Engine) SetThresholds(t types.Thresholds) {
+	e.thresholdsMu.Lock()
+	defer e.thresholdsMu.Unlock()
+	e.thresholds = t
+}
+
+// detectErrorSpike fires when the 1m error rate exceeds the 1h error rate by
+// the configured multiplier.
+func (e *Engine) detectErrorSpike(ac *anomalyContext) {
+	current, ok := e.metrics.Windows["1m"]
+	if !ok || current.TotalErrors < minSpikeErrors {
+		return
+	}
+	hour, ok := e.metrics.Windows["1h"]
+	if !ok || hour.ErrorRate == 0 {
+		return
+	}
+	multiplier := e.Thresholds().ErrorSpike
+	if current.ErrorRate <= hour.ErrorRate*multiplier {
+		return
+	}
+
+	contributors := ac.contributors(storage.EntryFilter{Errors: true})
+	e.addAnomaly(types.Anomaly{
+		Timestamp:    e.clock.Now(),
+		Type:         "Error Spike",
+		Severity:     types.SeverityCritical,
+		Message:      fmt.Sprintf("1m error rate %.2f%% is %.1fx the 1h rate of %.2f%% (threshold %gx)", current.ErrorRate, current.ErrorRate/hour.ErrorRate, hour.ErrorRate, multiplier) + formatContributors(contributors),
+		Contributors: contributors,
+	}, ac, evidenceErrors)
+}
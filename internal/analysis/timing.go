@@ -0,0 +1,35 @@
+package analysis
+
+import (
+	"time"
+
+	"github.com/montanaflynn/stats"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// timingStats summarises paired queue and service times in milliseconds.
+func timingStats(queue, service []float64) types.TimingStats {
+	if len(service) == 0 {
+		return types.TimingStats{}
+	}
+	percentile := func(data []float64, p float64) time.Duration {
+		v, _ := stats.Percentile(data, p)
+		return time.Duration(v * float64(time.Millisecond))
+	}
+
+	totalQueue, _ := stats.Sum(queue)
+	totalService, _ := stats.Sum(service)
+	share := 0.0
+	if totalQueue+totalService > 0 {
+		share = totalQueue / (totalQueue + totalService) * 100
+	}
+
+	return types.TimingStats{
+		Samples:    len(service),
+		QueueP50:   percentile(queue, 50),
+		QueueP95:   percentile(queue, 95),
+		ServiceP50: percentile(service, 50),
+		ServiceP95: percentile(service, 95),
+		QueueShare: share,
+	}
+}
@@ -0,0 +1,29 @@
+package analysis
+
+import (
+	"log"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// versionStats turns the per-version aggregates of a window into stats for
+// the busiest e.version.Top versions.
+func (e *Engine) versionStats(agg storage.WindowAggregate, window time.Duration, latencies func(version string) []float64) map[string]types.RequestStats {
+	if e.version.Field == "" {
+		return nil
+	}
+	return requestStats(agg.Versions, agg.Total, e.version.Top, window, latencies)
+}
+
+// liveVersionLatencies loads a version's latencies for a live window.
+func (e *Engine) liveVersionLatencies(since time.Time, f storage.EntryFilter) func(string) []float64 {
+	return func(version string) []float64 {
+		latencies, err := e.storage.VersionLatenciesSince(since, version, f)
+		if err != nil {
+			log.Printf("Error loading latencies for version %s: %v", version, err)
+		}
+		return latencies
+	}
+}
@@ -0,0 +1,38 @@
+package analysis
+
+import (
+	"time"
+)
+
+// updateWarmup refreshes the learning indicator and reports whether detection
+// may run. During warm-up, baselines and EWMAs keep learning but no anomalies
+// fire, so the first minute of a session doesn't produce garbage alerts.
+// Warm-up ends once either its duration has passed or enough samples were
+// seen, so a quiet service that rarely produces samples isn't left
+// unmonitored.
+func (e *Engine) updateWarmup(now time.Time) bool {
+	if !e.metrics.Learning {
+		return true
+	}
+
+	progress, conditions := 0.0, 0
+	if d := e.detection.Warmup.Duration; d > 0 {
+		progress = max(progress, float64(now.Sub(e.metrics.StartTime))/float64(d))
+		conditions++
+	}
+	if n := e.detection.Warmup.Samples; n > 0 {
+		progress = max(progress, float64(e.samplesSeen)/float64(n))
+		conditions++
+	}
+	if conditions == 0 {
+		progress = 1
+	}
+
+	if progress >= 1 {
+		e.metrics.Learning = false
+		e.metrics.WarmupProgress = 1
+		return true
+	}
+	e.metrics.WarmupProgress = progress
+	return false
+}
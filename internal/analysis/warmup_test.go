@@ -0,0 +1,58 @@
+package analysis
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/pkg/clock"
+)
+
+func newWarmupEngine(t *testing.T) *Engine {
+	t.Helper()
+	e, err := NewEngine(filepath.Join(t.TempDir(), "pulsewatch.db"), false, config.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { e.storage.Close() })
+	e.SetClock(clock.NewFake(fakeStart))
+	e.detection.Warmup = config.WarmupConfig{Duration: time.Minute, Samples: 30}
+	return e
+}
+
+func TestWarmupEndsAfterDurationWithFewSamples(t *testing.T) {
+	e := newWarmupEngine(t)
+	e.samplesSeen = 3 // A quiet service
+
+	if e.updateWarmup(fakeStart.Add(30 * time.Second)) {
+		t.Fatal("warm-up ended halfway through its duration")
+	}
+	if got := e.metrics.WarmupProgress; got != 0.5 {
+		t.Errorf("WarmupProgress = %v halfway through, want 0.5", got)
+	}
+	if !e.updateWarmup(fakeStart.Add(time.Minute)) {
+		t.Fatal("warm-up still running after its duration with few samples")
+	}
+	if e.metrics.Learning {
+		t.Error("still learning after warm-up ended")
+	}
+}
+
+func TestWarmupEndsAfterSamplesBeforeDuration(t *testing.T) {
+	e := newWarmupEngine(t)
+	e.samplesSeen = 30
+
+	if !e.updateWarmup(fakeStart.Add(10 * time.Second)) {
+		t.Fatal("warm-up still running with enough samples")
+	}
+}
+
+func TestWarmupDisabled(t *testing.T) {
+	e := newWarmupEngine(t)
+	e.detection.Warmup = config.WarmupConfig{Duration: -1, Samples: -1}
+
+	if !e.updateWarmup(fakeStart) {
+		t.Fatal("warm-up ran with both conditions disabled")
+	}
+}
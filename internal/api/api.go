@@ -0,0 +1,153 @@
+// Package api serves a running pulsewatch instance's metrics and anomalies
+// over HTTP for dashboards such as Grafana.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// Source provides the stored history the API serves and records events.
+type Source interface {
+	Rollups(from, to time.Time) ([]storage.Rollup, error)
+	AnomalyHistory(f types.AnomalyFilter) ([]types.Anomaly, error)
+	RecordEvent(ev types.Event) error
+	Events(from, to time.Time, eventType string) ([]types.Event, error)
+	Annotations(from, to time.Time) ([]types.Annotation, error)
+	Status() types.Status
+	LatencyHistogram() types.LatencyHistogram
+	ProbeNames() []string
+	ProbeResults(name string, from, to time.Time) ([]types.ProbeResult, error)
+}
+
+// Server is the HTTP API server.
+type Server struct {
+	source      Source
+	eventsToken string // Bearer token required to post events; empty allows anyone
+	srv         *http.Server
+}
+
+// NewServer creates a Server that will listen on addr, e.g. ":9100".
+// eventsToken, if set, is required as a bearer token to post events.
+func NewServer(addr, eventsToken string, source Source) *Server {
+	s := &Server{source: source, eventsToken: eventsToken}
+	mux := http.NewServeMux()
+	s.registerGrafana(mux)
+	mux.HandleFunc("GET /api/series", s.handleSeries)
+	mux.HandleFunc("GET /api/anomalies", s.handleAnomalies)
+	mux.HandleFunc("GET /api/status", s.handleStatus)
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	mux.HandleFunc("POST /api/events", s.handlePostEvent)
+	mux.HandleFunc("GET /api/annotations", s.handleAnnotations)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	s.srv = &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	return s
+}
+
+// Start binds the listen address and serves in the background.
+func (s *Server) Start() error {
+	ln, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("API server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Shutdown stops the server, waiting up to ctx for in-flight requests.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
+
+// handleSeries returns one series as a flat array of {time, value} objects,
+// for the Infinity datasource or scripts.
+// Query: target (see Targets), from and to (RFC 3339 or Unix milliseconds;
+// default the last hour), interval (e.g. 5m; default 1m).
+func (s *Server) handleSeries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	to := parseTime(q.Get("to"), time.Now())
+	from := parseTime(q.Get("from"), to.Add(-time.Hour))
+	step, _ := time.ParseDuration(q.Get("interval"))
+
+	points, err := s.series(q.Get("target"), from, to, step)
+	if err != nil {
+		httpError(w, err, http.StatusBadRequest)
+		return
+	}
+	type point struct {
+		Time  time.Time `json:"time"`
+		Value float64   `json:"value"`
+	}
+	out := make([]point, len(points))
+	for i, p := range points {
+		out[i] = point{Time: p.start, Value: p.value}
+	}
+	writeJSON(w, out)
+}
+
+// handleAnomalies lists stored anomalies, newest first.
+// Query: type, severity, since (e.g. 24h; default 24h), limit (default 100).
+func (s *Server) handleAnomalies(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := types.AnomalyFilter{Type: q.Get("type"), Severity: q.Get("severity"), Limit: 100}
+	since := 24 * time.Hour
+	if d, err := time.ParseDuration(q.Get("since")); err == nil {
+		since = d
+	}
+	f.Since = time.Now().Add(-since)
+	if n, err := strconv.Atoi(q.Get("limit")); err == nil {
+		f.Limit = n
+	}
+
+	anomalies, err := s.source.AnomalyHistory(f)
+	if err != nil {
+		httpError(w, err, http.StatusInternalServerError)
+		return
+	}
+	type anomaly struct {
+		Time     time.Time             `json:"time"`
+		Type     string                `json:"type"`
+		Severity string                `json:"severity"`
+		Message  string                `json:"message"`
+		Snapshot types.MetricsSnapshot `json:"snapshot"`
+	}
+	out := make([]anomaly, len(anomalies))
+	for i, a := range anomalies {
+		out[i] = anomaly{Time: a.Timestamp, Type: a.Type, Severity: a.Severity, Message: a.Message, Snapshot: a.Snapshot}
+	}
+	writeJSON(w, out)
+}
+
+// parseTime parses RFC 3339 or Unix milliseconds, returning def otherwise.
+func parseTime(s string, def time.Time) time.Time {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms)
+	}
+	return def
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing API response: %v", err)
+	}
+}
+
+func httpError(w http.ResponseWriter, err error, status int) {
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// maxEventBody bounds the size of a posted event.
+const maxEventBody = 64 << 10
+
+// eventRequest is the JSON body of POST /api/events. Time is RFC 3339 or
+// Unix milliseconds and defaults to now.
+type eventRequest struct {
+	Type   string          `json:"type"`
+	Title  string          `json:"title"`
+	Text   string          `json:"text"`
+	Source string          `json:"source"`
+	Time   json.RawMessage `json:"time"`
+}
+
+// handlePostEvent records an external event, e.g. from a deploy pipeline.
+func (s *Server) handlePostEvent(w http.ResponseWriter, r *http.Request) {
+	if s.eventsToken != "" {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.eventsToken)) != 1 {
+			httpError(w, fmt.Errorf("invalid or missing bearer token"), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	var req eventRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxEventBody)).Decode(&req); err != nil {
+		httpError(w, err, http.StatusBadRequest)
+		return
+	}
+	ev := types.Event{
+		Type:   strings.ToLower(strings.TrimSpace(req.Type)),
+		Title:  strings.TrimSpace(req.Title),
+		Text:   req.Text,
+		Source: req.Source,
+	}
+	if ev.Type == "" || ev.Title == "" {
+		httpError(w, fmt.Errorf("type and title are required"), http.StatusBadRequest)
+		return
+	}
+	ev.Timestamp = parseTime(strings.Trim(string(req.Time), `"`), time.Now()).Local().Round(0)
+
+	if err := s.source.RecordEvent(ev); err != nil {
+		httpError(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, eventJSON(ev))
+}
+
+// handleEvents lists stored events, oldest first.
+// Query: type, from and to (RFC 3339 or Unix milliseconds; default the last
+// 24 hours).
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	to := parseTime(q.Get("to"), time.Now())
+	from := parseTime(q.Get("from"), to.Add(-24*time.Hour))
+	events, err := s.source.Events(from.Local(), to.Local(), q.Get("type"))
+	if err != nil {
+		httpError(w, err, http.StatusInternalServerError)
+		return
+	}
+	out := make([]map[string]interface{}, len(events))
+	for i, ev := range events {
+		out[i] = eventJSON(ev)
+	}
+	writeJSON(w, out)
+}
+
+func eventJSON(ev types.Event) map[string]interface{} {
+	return map[string]interface{}{
+		"time":   ev.Timestamp,
+		"type":   ev.Type,
+		"title":  ev.Title,
+		"text":   ev.Text,
+		"source": ev.Source,
+	}
+}
+
+// handleAnnotations lists the operator annotations overlapping the range,
+// oldest first.
+// Query: from and to (RFC 3339 or Unix milliseconds; default the last 24
+// hours).
+func (s *Server) handleAnnotations(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	to := parseTime(q.Get("to"), time.Now())
+	from := parseTime(q.Get("from"), to.Add(-24*time.Hour))
+	annotations, err := s.source.Annotations(from.Local(), to.Local())
+	if err != nil {
+		httpError(w, err, http.StatusInternalServerError)
+		return
+	}
+	out := make([]map[string]interface{}, len(annotations))
+	for i, a := range annotations {
+		out[i] = map[string]interface{}{
+			"created": a.Created,
+			"start":   a.Start,
+			"end":     a.End,
+			"label":   a.Label,
+			"note":    a.Note,
+			"line":    a.Line,
+		}
+	}
+	writeJSON(w, out)
+}
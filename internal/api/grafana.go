@@ -0,0 +1,195 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// The endpoints below implement the Grafana simple JSON datasource protocol
+// (grafana-simple-json-datasource and its successors). Point the datasource
+// at http://host:port/.
+
+// anomaliesTarget is the table target listing anomalies.
+const anomaliesTarget = "anomalies"
+
+type grafanaRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+type grafanaQuery struct {
+	Range      grafanaRange `json:"range"`
+	IntervalMs int64        `json:"intervalMs"`
+	Targets    []struct {
+		Target string `json:"target"`
+		RefID  string `json:"refId"`
+		Type   string `json:"type"`
+	} `json:"targets"`
+}
+
+type grafanaAnnotationQuery struct {
+	Range      grafanaRange `json:"range"`
+	Annotation struct {
+		Name  string `json:"name"`
+		Query string `json:"query"` // Optional severity or type text to filter by
+	} `json:"annotation"`
+}
+
+func (s *Server) registerGrafana(mux *http.ServeMux) {
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+	mux.HandleFunc("POST /search", s.handleGrafanaSearch)
+	mux.HandleFunc("POST /metrics", s.handleGrafanaSearch)
+	mux.HandleFunc("POST /query", s.handleGrafanaQuery)
+	mux.HandleFunc("POST /annotations", s.handleGrafanaAnnotations)
+}
+
+// handleGrafanaSearch lists the queryable targets.
+func (s *Server) handleGrafanaSearch(w http.ResponseWriter, r *http.Request) {
+	targets := append([]string{}, Targets...)
+	for _, name := range s.source.ProbeNames() {
+		targets = append(targets, probeSuccessPrefix+name, probeLatencyPrefix+name)
+	}
+	writeJSON(w, append(targets, anomaliesTarget))
+}
+
+// handleGrafanaQuery answers time series targets with datapoints and the
+// anomalies target with a table.
+func (s *Server) handleGrafanaQuery(w http.ResponseWriter, r *http.Request) {
+	var q grafanaQuery
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		httpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	var out []interface{}
+	for _, t := range q.Targets {
+		if t.Target == anomaliesTarget {
+			table, err := s.anomalyTable(q.Range)
+			if err != nil {
+				httpError(w, err, http.StatusInternalServerError)
+				return
+			}
+			out = append(out, table)
+			continue
+		}
+
+		points, err := s.series(t.Target, q.Range.From, q.Range.To, time.Duration(q.IntervalMs)*time.Millisecond)
+		if err != nil {
+			httpError(w, err, http.StatusBadRequest)
+			return
+		}
+		datapoints := make([][2]float64, len(points))
+		for i, p := range points {
+			datapoints[i] = [2]float64{p.value, float64(p.start.UnixMilli())}
+		}
+		out = append(out, map[string]interface{}{"target": t.Target, "datapoints": datapoints})
+	}
+	writeJSON(w, out)
+}
+
+func (s *Server) anomalyTable(rng grafanaRange) (map[string]interface{}, error) {
+	anomalies, err := s.source.AnomalyHistory(types.AnomalyFilter{Since: rng.From.Local(), Until: rng.To.Local()})
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]interface{}, len(anomalies))
+	for i, a := range anomalies {
+		rows[i] = []interface{}{a.Timestamp.UnixMilli(), a.Type, a.Severity, a.Message}
+	}
+	return map[string]interface{}{
+		"type": "table",
+		"columns": []map[string]string{
+			{"text": "Time", "type": "time"},
+			{"text": "Type", "type": "string"},
+			{"text": "Severity", "type": "string"},
+			{"text": "Message", "type": "string"},
+		},
+		"rows": rows,
+	}, nil
+}
+
+// handleGrafanaAnnotations returns anomalies in the range as annotations. A
+// query of critical, warning, or info filters by severity; any other text
+// filters by type. A query of "events", or "events:<type>", returns the
+// posted external events instead, and "notes" the operator annotations as
+// regions.
+func (s *Server) handleGrafanaAnnotations(w http.ResponseWriter, r *http.Request) {
+	var q grafanaAnnotationQuery
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		httpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(q.Annotation.Query) == "notes" {
+		annotations, err := s.source.Annotations(q.Range.From.Local(), q.Range.To.Local())
+		if err != nil {
+			httpError(w, err, http.StatusInternalServerError)
+			return
+		}
+		out := make([]map[string]interface{}, len(annotations))
+		for i, a := range annotations {
+			out[i] = map[string]interface{}{
+				"annotation": q.Annotation,
+				"time":       a.Start.UnixMilli(),
+				"timeEnd":    a.End.UnixMilli(),
+				"isRegion":   a.End.After(a.Start),
+				"title":      a.Label,
+				"text":       a.Note,
+				"tags":       []string{"note", a.Label},
+			}
+		}
+		writeJSON(w, out)
+		return
+	}
+
+	if rest, ok := strings.CutPrefix(strings.TrimSpace(q.Annotation.Query), "events"); ok && (rest == "" || rest[0] == ':') {
+		events, err := s.source.Events(q.Range.From.Local(), q.Range.To.Local(), strings.TrimPrefix(rest, ":"))
+		if err != nil {
+			httpError(w, err, http.StatusInternalServerError)
+			return
+		}
+		out := make([]map[string]interface{}, len(events))
+		for i, ev := range events {
+			out[i] = map[string]interface{}{
+				"annotation": q.Annotation,
+				"time":       ev.Timestamp.UnixMilli(),
+				"title":      ev.Title,
+				"text":       ev.Text,
+				"tags":       []string{ev.Type},
+			}
+		}
+		writeJSON(w, out)
+		return
+	}
+
+	f := types.AnomalyFilter{Since: q.Range.From.Local(), Until: q.Range.To.Local()}
+	switch query := strings.TrimSpace(q.Annotation.Query); query {
+	case types.SeverityCritical, types.SeverityWarning, types.SeverityInfo:
+		f.Severity = query
+	default:
+		f.Type = query
+	}
+	anomalies, err := s.source.AnomalyHistory(f)
+	if err != nil {
+		httpError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	out := make([]map[string]interface{}, len(anomalies))
+	for i, a := range anomalies {
+		out[i] = map[string]interface{}{
+			"annotation": q.Annotation,
+			"time":       a.Timestamp.UnixMilli(),
+			"title":      a.Type,
+			"text":       a.Message,
+			"tags":       []string{a.Severity},
+		}
+	}
+	writeJSON(w, out)
+}
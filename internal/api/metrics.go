@@ -0,0 +1,76 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+const openMetricsType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// maxExemplarLabels is the OpenMetrics limit on the characters in an
+// exemplar's label names and values.
+const maxExemplarLabels = 128
+
+// handleMetrics serves the session's request latency histogram in the
+// OpenMetrics text format for Prometheus to scrape. Each bucket carries its
+// latest request with a trace ID as a trace_id exemplar.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	h := s.source.LatencyHistogram()
+	w.Header().Set("Content-Type", openMetricsType)
+	b := bufio.NewWriter(w)
+	defer b.Flush()
+
+	const name = "pulsewatch_request_duration_seconds"
+	fmt.Fprintf(b, "# TYPE %s histogram\n", name)
+	fmt.Fprintf(b, "# UNIT %s seconds\n", name)
+	fmt.Fprintf(b, "# HELP %s Latency of the requests read this session.\n", name)
+	for i, count := range h.Counts {
+		le := "+Inf"
+		if i < len(h.Bounds) {
+			le = formatSeconds(h.Bounds[i])
+		}
+		fmt.Fprintf(b, "%s_bucket{le=\"%s\"} %d", name, le, count)
+		if i < len(h.Exemplars) {
+			writeExemplar(b, h.Exemplars[i])
+		}
+		b.WriteByte('\n')
+	}
+	fmt.Fprintf(b, "%s_count %d\n", name, h.Count)
+	fmt.Fprintf(b, "%s_sum %s\n", name, formatSeconds(h.Sum))
+	fmt.Fprintf(b, "%s_created %s\n", name, unixSeconds(h.Created))
+	b.WriteString("# EOF\n")
+}
+
+// writeExemplar appends ` # {trace_id="..."} value timestamp` for ex,
+// or nothing if it has no trace ID or one too long to be valid.
+func writeExemplar(b *bufio.Writer, ex types.Exemplar) {
+	if ex.TraceID == "" || len("trace_id")+len(ex.TraceID) > maxExemplarLabels {
+		return
+	}
+	fmt.Fprintf(b, " # {trace_id=\"%s\"} %s", escapeLabel(ex.TraceID), formatSeconds(ex.Latency))
+	if !ex.Timestamp.IsZero() {
+		b.WriteString(" " + unixSeconds(ex.Timestamp))
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'g', -1, 64)
+}
+
+// unixSeconds formats t as OpenMetrics timestamps are: seconds since the
+// epoch, with the milliseconds as a fraction.
+func unixSeconds(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixMilli())/1000, 'f', -1, 64)
+}
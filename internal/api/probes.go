@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// Probe series are queried as a prefix and the probe's name, e.g.
+// probe_success:homepage.
+const (
+	probeSuccessPrefix = "probe_success:" // Percent of checks that passed
+	probeLatencyPrefix = "probe_latency:" // Average check latency in milliseconds
+)
+
+// probeSeries returns a probe's results between from and to in buckets of
+// step (at least rollupStep).
+func (s *Server) probeSeries(target string, from, to time.Time, step time.Duration) ([]seriesPoint, error) {
+	name, latency := strings.CutPrefix(target, probeLatencyPrefix)
+	if !latency {
+		name = strings.TrimPrefix(target, probeSuccessPrefix)
+	}
+	if !slices.Contains(s.source.ProbeNames(), name) {
+		return nil, fmt.Errorf("unknown probe %q (available: %v)", name, s.source.ProbeNames())
+	}
+	// Stored timestamps are local time and compared as text
+	results, err := s.source.ProbeResults(name, from.Local(), to.Local())
+	if err != nil {
+		return nil, err
+	}
+
+	step = max(step.Truncate(rollupStep), rollupStep)
+	var points []seriesPoint
+	var bucket []types.ProbeResult
+	flush := func() {
+		if len(bucket) == 0 {
+			return
+		}
+		var ok int
+		var total time.Duration
+		for _, r := range bucket {
+			if r.OK {
+				ok++
+			}
+			total += r.Latency
+		}
+		value := float64(ok) / float64(len(bucket)) * 100
+		if latency {
+			value = float64(total) / float64(len(bucket)) / float64(time.Millisecond)
+		}
+		points = append(points, seriesPoint{start: bucket[0].Timestamp.Truncate(step), value: value})
+	}
+	for _, r := range results {
+		if len(bucket) > 0 && !r.Timestamp.Truncate(step).Equal(bucket[0].Timestamp.Truncate(step)) {
+			flush()
+			bucket = bucket[:0]
+		}
+		bucket = append(bucket, r)
+	}
+	flush()
+	return points, nil
+}
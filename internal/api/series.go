@@ -0,0 +1,97 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+)
+
+// rollupStep is the resolution of the stored rollups.
+const rollupStep = time.Minute
+
+// Targets lists the series that can be queried.
+var Targets = []string{"rps", "error_rate", "requests", "errors", "p50", "p95", "p99"}
+
+type seriesPoint struct {
+	start time.Time
+	value float64
+}
+
+// series returns target between from and to in buckets of step (at least
+// rollupStep). Rates are averaged over a bucket's rollups, counts summed, and
+// latency percentiles take the bucket's worst value.
+func (s *Server) series(target string, from, to time.Time, step time.Duration) ([]seriesPoint, error) {
+	if strings.HasPrefix(target, probeSuccessPrefix) || strings.HasPrefix(target, probeLatencyPrefix) {
+		return s.probeSeries(target, from, to, step)
+	}
+	value, ok := seriesValues[target]
+	if !ok {
+		return nil, fmt.Errorf("unknown target %q (available: %v)", target, Targets)
+	}
+	// Stored timestamps are local time and compared as text
+	rollups, err := s.source.Rollups(from.Local(), to.Local())
+	if err != nil {
+		return nil, err
+	}
+
+	step = max(step.Truncate(rollupStep), rollupStep)
+	var points []seriesPoint
+	var bucket []storage.Rollup
+	flush := func() {
+		if len(bucket) > 0 {
+			points = append(points, seriesPoint{start: bucket[0].Timestamp.Truncate(step), value: value(bucket)})
+		}
+	}
+	for _, r := range rollups {
+		if len(bucket) > 0 && !r.Timestamp.Truncate(step).Equal(bucket[0].Timestamp.Truncate(step)) {
+			flush()
+			bucket = bucket[:0]
+		}
+		bucket = append(bucket, r)
+	}
+	flush()
+	return points, nil
+}
+
+var seriesValues = map[string]func([]storage.Rollup) float64{
+	"rps": func(b []storage.Rollup) float64 {
+		return float64(sumRollups(b, func(r storage.Rollup) int { return r.Requests })) / float64(len(b)) / rollupStep.Seconds()
+	},
+	"error_rate": func(b []storage.Rollup) float64 {
+		requests := sumRollups(b, func(r storage.Rollup) int { return r.Requests })
+		if requests == 0 {
+			return 0
+		}
+		return float64(sumRollups(b, func(r storage.Rollup) int { return r.Errors })) / float64(requests) * 100
+	},
+	"requests": func(b []storage.Rollup) float64 {
+		return float64(sumRollups(b, func(r storage.Rollup) int { return r.Requests }))
+	},
+	"errors": func(b []storage.Rollup) float64 {
+		return float64(sumRollups(b, func(r storage.Rollup) int { return r.Errors }))
+	},
+	"p50": maxLatency(func(r storage.Rollup) time.Duration { return r.P50 }),
+	"p95": maxLatency(func(r storage.Rollup) time.Duration { return r.P95 }),
+	"p99": maxLatency(func(r storage.Rollup) time.Duration { return r.P99 }),
+}
+
+func sumRollups(b []storage.Rollup, field func(storage.Rollup) int) int {
+	total := 0
+	for _, r := range b {
+		total += field(r)
+	}
+	return total
+}
+
+// maxLatency returns a value func giving the highest latency in milliseconds.
+func maxLatency(field func(storage.Rollup) time.Duration) func([]storage.Rollup) float64 {
+	return func(b []storage.Rollup) float64 {
+		var worst time.Duration
+		for _, r := range b {
+			worst = max(worst, field(r))
+		}
+		return float64(worst) / float64(time.Millisecond)
+	}
+}
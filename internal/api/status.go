@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StatusResponse is the body of GET /api/status: the last minute's headline
+// metrics and the anomalies of the last few minutes.
+type StatusResponse struct {
+	Time      time.Time `json:"time"` // When the metrics were computed
+	RPS       float64   `json:"rps"`
+	ErrorRate float64   `json:"error_rate"` // Percent
+	Requests  int       `json:"requests"`
+	Errors    int       `json:"errors"`
+	P50       float64   `json:"p50_ms"`
+	P95       float64   `json:"p95_ms"`
+	P99       float64   `json:"p99_ms"`
+	Anomalies int       `json:"anomalies"`
+	Critical  int       `json:"critical"`
+	Learning  bool      `json:"learning"`
+}
+
+// handleStatus returns the running instance's current StatusResponse.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	st := s.source.Status()
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	writeJSON(w, StatusResponse{
+		Time:      st.Updated,
+		RPS:       st.Snapshot.RPS,
+		ErrorRate: st.Snapshot.ErrorRate,
+		Requests:  st.Snapshot.Requests,
+		Errors:    st.Snapshot.Errors,
+		P50:       ms(st.Snapshot.P50Latency),
+		P95:       ms(st.Snapshot.P95Latency),
+		P99:       ms(st.Snapshot.P99Latency),
+		Anomalies: st.Anomalies,
+		Critical:  st.Critical,
+		Learning:  st.Learning,
+	})
+}
+
+// FetchStatus queries the API at addr, a listen address such as ":9100" or
+// a base URL, for the instance's status.
+func FetchStatus(ctx context.Context, addr string) (StatusResponse, error) {
+	var st StatusResponse
+	base := addr
+	if !strings.Contains(addr, "://") {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return st, fmt.Errorf("invalid API address %q: %w", addr, err)
+		}
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = "127.0.0.1"
+		}
+		base = "http://" + net.JoinHostPort(host, port)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(base, "/")+"/api/status", nil)
+	if err != nil {
+		return st, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return st, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return st, fmt.Errorf("API %s: %s", base, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		return st, fmt.Errorf("failed to decode status: %w", err)
+	}
+	return st, nil
+}
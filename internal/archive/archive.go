@@ -0,0 +1,135 @@
+// Package archive exports log entries that are about to be pruned to
+// compressed NDJSON files, locally or in S3, so history isn't simply destroyed
+// at the retention boundary.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/storage"
+)
+
+// Sink stores archive objects.
+type Sink interface {
+	Put(name string, data []byte) error
+}
+
+// Archiver writes pruned entries to a Sink, one object per UTC day and
+// batch.
+type Archiver struct {
+	sink    Sink
+	batches int // Archive calls so far, numbering the objects of a prune
+}
+
+// NewArchiver creates a new Archiver.
+func NewArchiver(sink Sink) *Archiver {
+	return &Archiver{sink: sink}
+}
+
+// record is the NDJSON shape of an archived entry.
+type record struct {
+	Timestamp    time.Time              `json:"timestamp"`
+	Message      string                 `json:"message"`
+	Level        string                 `json:"level"`
+	StatusCode   int                    `json:"status_code,omitempty"`
+	LatencyMs    int64                  `json:"latency_ms,omitempty"`
+	Endpoint     string                 `json:"endpoint,omitempty"`
+	Method       string                 `json:"method,omitempty"`
+	CacheStatus  string                 `json:"cache_status,omitempty"`
+	QueueMs      int64                  `json:"queue_ms,omitempty"`
+	ServiceMs    int64                  `json:"service_ms,omitempty"`
+	Tenant       string                 `json:"tenant,omitempty"`
+	GroupKey     string                 `json:"group_key,omitempty"`
+	Protocol     string                 `json:"protocol,omitempty"`
+	TLSVersion   string                 `json:"tls_version,omitempty"`
+	Session      string                 `json:"session,omitempty"`
+	PrevEndpoint string                 `json:"prev_endpoint,omitempty"`
+	Source       string                 `json:"source,omitempty"`
+	GRPCStatus   string                 `json:"grpc_status,omitempty"`
+	Operation    string                 `json:"operation,omitempty"`
+	Version      string                 `json:"version,omitempty"`
+	Retry        bool                   `json:"retry,omitempty"`
+	Category     string                 `json:"category,omitempty"`
+	ClientIP     string                 `json:"client_ip,omitempty"`
+	Timings      map[string]float64     `json:"timings,omitempty"`
+	Fields       map[string]interface{} `json:"fields,omitempty"`
+}
+
+// Archive partitions entries by UTC day and writes each partition as
+// <day>/pulsewatch-<unix>-<batch>.ndjson.gz, where at is the time of the
+// prune and batch numbers the calls. It returns an error if any partition
+// fails, in which case the caller must not delete the entries.
+func (a *Archiver) Archive(entries []storage.StoredEntry, at time.Time) error {
+	a.batches++
+	partitions := make(map[string][]storage.StoredEntry)
+	for _, entry := range entries {
+		day := entry.Timestamp.UTC().Format("2006-01-02")
+		partitions[day] = append(partitions[day], entry)
+	}
+
+	days := make([]string, 0, len(partitions))
+	for day := range partitions {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	for _, day := range days {
+		data, err := encode(partitions[day])
+		if err != nil {
+			return err
+		}
+		name := path.Join(day, fmt.Sprintf("pulsewatch-%d-%d.ndjson.gz", at.Unix(), a.batches))
+		if err := a.sink.Put(name, data); err != nil {
+			return fmt.Errorf("failed to archive %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func encode(entries []storage.StoredEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(gz)
+	for _, entry := range entries {
+		err := enc.Encode(record{
+			Timestamp:    entry.Timestamp,
+			Message:      entry.Message,
+			Level:        string(entry.Level),
+			StatusCode:   entry.StatusCode,
+			LatencyMs:    entry.Latency.Milliseconds(),
+			Endpoint:     entry.Endpoint,
+			Method:       entry.Method,
+			CacheStatus:  entry.CacheStatus,
+			QueueMs:      entry.QueueTime.Milliseconds(),
+			ServiceMs:    entry.ServiceTime.Milliseconds(),
+			Tenant:       entry.Tenant,
+			GroupKey:     entry.GroupKey,
+			Protocol:     entry.Protocol,
+			TLSVersion:   entry.TLSVersion,
+			Session:      entry.Session,
+			PrevEndpoint: entry.PrevEndpoint,
+			Source:       entry.Source,
+			GRPCStatus:   entry.GRPCStatus,
+			Operation:    entry.Operation,
+			Version:      entry.Version,
+			Retry:        entry.Retry,
+			Category:     entry.ErrorCategory,
+			ClientIP:     entry.ClientIP,
+			Timings:      entry.Timings,
+			Fields:       entry.Fields,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,88 @@
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/awssig"
+)
+
+// LocalSink writes archive objects below a directory.
+type LocalSink struct {
+	Dir string
+}
+
+// Put writes data to Dir/name, creating parent directories as needed.
+func (s *LocalSink) Put(name string, data []byte) error {
+	target := filepath.Join(s.Dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0644)
+}
+
+// S3Sink uploads archive objects to an S3 (or S3-compatible) bucket.
+type S3Sink struct {
+	Bucket   string
+	Prefix   string
+	Region   string
+	Endpoint string // Optional, e.g. "minio.local:9000"; uses path-style URLs
+	Creds    awssig.Credentials
+	Client   *http.Client
+}
+
+// NewS3Sink creates an S3Sink using credentials from the environment.
+func NewS3Sink(bucket, prefix, region, endpoint string) (*S3Sink, error) {
+	creds, err := awssig.CredentialsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if region == "" {
+		region = awssig.RegionFromEnv("us-east-1")
+	}
+	return &S3Sink{
+		Bucket:   bucket,
+		Prefix:   prefix,
+		Region:   region,
+		Endpoint: endpoint,
+		Creds:    creds,
+		Client:   &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// Put uploads data to s3://Bucket/Prefix/name.
+func (s *S3Sink) Put(name string, data []byte) error {
+	key := path.Join(s.Prefix, name)
+	url := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.Bucket, s.Region, key)
+	if s.Endpoint != "" {
+		url = fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(s.Endpoint, "/"), s.Bucket, key)
+		if !strings.Contains(s.Endpoint, "://") {
+			url = "https://" + url
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/gzip")
+	awssig.Sign(req, awssig.HashPayload(data), "s3", s.Region, s.Creds, time.Now())
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("S3 PUT %s: %s: %s", key, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+// Package bus connects the stages of the pipeline (ingestion, parsing,
+// analysis, and the dashboard or sinks) through typed publish/subscribe
+// topics, so a new consumer subscribes instead of adding another fan-out.
+package bus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// Bus holds the pipeline's topics.
+type Bus struct {
+	RawLines  *Topic[string]         // Lines as read from the input
+	Entries   *Topic[types.LogEntry] // Lines the parsers understood
+	Metrics   *Topic[types.Metrics]  // Recomputed metrics, once per tick
+	Anomalies *Topic[types.Anomaly]  // Anomalies as they are recorded
+}
+
+// New creates a Bus with empty topics.
+func New() *Bus {
+	return &Bus{
+		RawLines:  NewTopic[string]("raw_lines"),
+		Entries:   NewTopic[types.LogEntry]("entries"),
+		Metrics:   NewTopic[types.Metrics]("metrics"),
+		Anomalies: NewTopic[types.Anomaly]("anomalies"),
+	}
+}
+
+// Stats reports every subscriber's buffer and counters.
+func (b *Bus) Stats() []types.PipelineStat {
+	var stats []types.PipelineStat
+	stats = append(stats, b.RawLines.Stats()...)
+	stats = append(stats, b.Entries.Stats()...)
+	stats = append(stats, b.Metrics.Stats()...)
+	return append(stats, b.Anomalies.Stats()...)
+}
+
+// Policy decides what Publish does when a subscriber's buffer is full.
+type Policy int
+
+const (
+	Block      Policy = iota // Wait for room, slowing the publisher down
+	DropOldest               // Discard the oldest buffered value to make room
+)
+
+// Policy names, as used in the config.
+const (
+	PolicyBlock      = "block"
+	PolicyDropOldest = "drop_oldest"
+)
+
+// ParsePolicy parses a policy name; "" is Block.
+func ParsePolicy(s string) (Policy, error) {
+	switch s {
+	case "", PolicyBlock:
+		return Block, nil
+	case PolicyDropOldest:
+		return DropOldest, nil
+	}
+	return Block, fmt.Errorf("unknown policy %q (want %s or %s)", s, PolicyBlock, PolicyDropOldest)
+}
+
+func (p Policy) String() string {
+	if p == DropOldest {
+		return PolicyDropOldest
+	}
+	return PolicyBlock
+}
+
+// subscriber is one consumer of a topic.
+type subscriber[T any] struct {
+	name      string
+	ch        chan T
+	policy    Policy
+	delivered atomic.Int64
+	dropped   atomic.Int64
+}
+
+// Topic delivers every published value to every subscriber.
+type Topic[T any] struct {
+	name   string
+	mu     sync.RWMutex
+	subs   []*subscriber[T]
+	closed bool
+}
+
+// NewTopic creates a topic without subscribers.
+func NewTopic[T any](name string) *Topic[T] {
+	return &Topic[T]{name: name}
+}
+
+// Subscribe returns a channel receiving the values published from now on,
+// buffered to hold buffer values, for the consumer called name. When the
+// buffer is full, policy decides whether publishing waits or drops. The
+// channel is closed when the topic is closed.
+func (t *Topic[T]) Subscribe(name string, buffer int, policy Policy) <-chan T {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ch := make(chan T, buffer)
+	if t.closed {
+		close(ch)
+		return ch
+	}
+	t.subs = append(t.subs, &subscriber[T]{name: name, ch: ch, policy: policy})
+	return ch
+}
+
+// Publish delivers v to each subscriber in turn. Full buffers of Block
+// subscribers are waited on, so slow consumers apply backpressure; those
+// of DropOldest subscribers lose their oldest value. It returns false if
+// ctx was cancelled first.
+func (t *Topic[T]) Publish(ctx context.Context, v T) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, s := range t.subs {
+		if s.policy == DropOldest {
+			s.sendDropOldest(v)
+			continue
+		}
+		select {
+		case s.ch <- v:
+			s.delivered.Add(1)
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// sendDropOldest delivers v, discarding buffered values until it fits. An
+// unbuffered subscriber that isn't waiting misses v instead.
+func (s *subscriber[T]) sendDropOldest(v T) {
+	for {
+		select {
+		case s.ch <- v:
+			s.delivered.Add(1)
+			return
+		default:
+		}
+		if cap(s.ch) == 0 {
+			s.dropped.Add(1)
+			return
+		}
+		select {
+		case <-s.ch:
+			s.dropped.Add(1)
+		default: // The consumer made room meanwhile
+		}
+	}
+}
+
+// TryPublish delivers v to the subscribers with room for it and drops it
+// for the others, for publishers that must never block; DropOldest
+// subscribers lose their oldest value instead. It returns how many
+// subscribers received it.
+func (t *Topic[T]) TryPublish(v T) int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	delivered := 0
+	for _, s := range t.subs {
+		if s.policy == DropOldest && cap(s.ch) > 0 {
+			s.sendDropOldest(v)
+			delivered++
+			continue
+		}
+		select {
+		case s.ch <- v:
+			s.delivered.Add(1)
+			delivered++
+		default:
+			s.dropped.Add(1)
+		}
+	}
+	return delivered
+}
+
+// Stats reports each subscriber's buffer and counters.
+func (t *Topic[T]) Stats() []types.PipelineStat {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	stats := make([]types.PipelineStat, 0, len(t.subs))
+	for _, s := range t.subs {
+		stats = append(stats, types.PipelineStat{
+			Topic:     t.name,
+			Consumer:  s.name,
+			Policy:    s.policy.String(),
+			Buffer:    cap(s.ch),
+			Queued:    len(s.ch),
+			Delivered: s.delivered.Load(),
+			Dropped:   s.dropped.Load(),
+		})
+	}
+	return stats
+}
+
+// PublishAll publishes every value received from in, then closes the
+// topic. It returns early, also closing the topic, if ctx is cancelled.
+func (t *Topic[T]) PublishAll(ctx context.Context, in <-chan T) {
+	defer t.Close()
+	for {
+		select {
+		case v, ok := <-in:
+			if !ok || !t.Publish(ctx, v) {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Close closes every subscriber channel; later values are discarded.
+func (t *Topic[T]) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	t.closed = true
+	for _, s := range t.subs {
+		close(s.ch)
+	}
+	t.subs = nil
+}
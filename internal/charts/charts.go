@@ -0,0 +1,118 @@
+// Package charts renders time series as SVG or PNG line charts, e.g. for
+// embedding trend charts in postmortems.
+package charts
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Chart size and plot margins, in pixels.
+const (
+	width        = 900
+	height       = 320
+	marginLeft   = 70
+	marginRight  = 20
+	marginTop    = 40
+	marginBottom = 40
+	yTicks       = 4
+)
+
+var palette = []color.RGBA{
+	{0x7D, 0x56, 0xF4, 0xFF},
+	{0x00, 0x99, 0x66, 0xFF},
+	{0xE6, 0x4B, 0x35, 0xFF},
+	{0xF2, 0xA9, 0x00, 0xFF},
+}
+
+// markerColor draws event markers.
+var markerColor = color.RGBA{0xE0, 0x7B, 0x00, 0xFF}
+
+// Line is one named series of a chart; Values align with Chart.Times.
+type Line struct {
+	Name   string
+	Values []float64
+}
+
+// Chart is a line chart over time.
+type Chart struct {
+	Title string
+	Unit  string // Appended to y-axis labels, e.g. "ms" or "%"
+	Times []time.Time
+	Lines []Line
+
+	// Markers are drawn as labelled vertical lines, e.g. deployments.
+	Markers []Marker
+}
+
+// Marker is a labelled point in time on a chart.
+type Marker struct {
+	Time  time.Time
+	Label string
+}
+
+// yMax returns the top of the y axis: the largest value with some headroom,
+// rounded to a readable number.
+func (c Chart) yMax() float64 {
+	top := 0.0
+	for _, l := range c.Lines {
+		for _, v := range l.Values {
+			top = math.Max(top, v)
+		}
+	}
+	if top == 0 {
+		return 1
+	}
+	top *= 1.1
+	magnitude := math.Pow(10, math.Floor(math.Log10(top)))
+	return math.Ceil(top/magnitude*2) / 2 * magnitude
+}
+
+// point maps the i-th sample with value v to plot coordinates.
+func (c Chart) point(i int, v, yMax float64) (x, y float64) {
+	plotH := float64(height - marginTop - marginBottom)
+	x, _ = c.timeX(c.Times[i])
+	y = float64(marginTop) + plotH - v/yMax*plotH
+	return x, y
+}
+
+// timeX maps t to an x coordinate, reporting whether it lies on the plot.
+func (c Chart) timeX(t time.Time) (float64, bool) {
+	plotW := float64(width - marginLeft - marginRight)
+	if len(c.Times) < 2 {
+		return float64(marginLeft), len(c.Times) == 1 && t.Equal(c.Times[0])
+	}
+	first, last := c.Times[0], c.Times[len(c.Times)-1]
+	x := float64(marginLeft) + t.Sub(first).Seconds()/last.Sub(first).Seconds()*plotW
+	return x, !t.Before(first) && !t.After(last)
+}
+
+// xLabels returns the indexes of the samples whose times label the x axis.
+func (c Chart) xLabels() []int {
+	n := len(c.Times)
+	switch {
+	case n == 0:
+		return nil
+	case n < 3:
+		return []int{0, n - 1}
+	}
+	return []int{0, n / 4, n / 2, 3 * n / 4, n - 1}
+}
+
+func (c Chart) timeLabel(i int) string {
+	if c.Times[len(c.Times)-1].Sub(c.Times[0]) > 24*time.Hour {
+		return c.Times[i].Format("01-02 15:04")
+	}
+	return c.Times[i].Format("15:04")
+}
+
+func (c Chart) valueLabel(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64) + c.Unit
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
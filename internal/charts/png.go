@@ -0,0 +1,149 @@
+package charts
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+)
+
+// fontScale enlarges the 3x5 bitmap font.
+const fontScale = 2
+
+var (
+	white     = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	gridColor = color.RGBA{0xE0, 0xE0, 0xE0, 0xFF}
+	axisColor = color.RGBA{0x88, 0x88, 0x88, 0xFF}
+	textColor = color.RGBA{0x33, 0x33, 0x33, 0xFF}
+)
+
+// WritePNG renders the chart as a PNG image.
+func (c Chart) WritePNG(w io.Writer) error {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{white}, image.Point{}, draw.Src)
+	yMax := c.yMax()
+	plotBottom := height - marginBottom
+
+	drawText(img, marginLeft, 14, c.Title, textColor)
+	for i := 0; i <= yTicks; i++ {
+		v := yMax * float64(i) / yTicks
+		_, y := c.point(0, v, yMax)
+		drawLine(img, marginLeft, y, width-marginRight, y, gridColor)
+		label := c.valueLabel(v)
+		drawText(img, marginLeft-6-textWidth(label), int(y)-5*fontScale/2, label, textColor)
+	}
+	for _, i := range c.xLabels() {
+		x, _ := c.point(i, 0, yMax)
+		label := c.timeLabel(i)
+		drawText(img, int(x)-textWidth(label)/2, plotBottom+10, label, textColor)
+	}
+	drawLine(img, marginLeft, float64(plotBottom), width-marginRight, float64(plotBottom), axisColor)
+
+	for _, m := range c.Markers {
+		x, ok := c.timeX(m.Time)
+		if !ok {
+			continue
+		}
+		for y := marginTop; y < plotBottom; y += 7 {
+			drawLine(img, int(x), float64(y), int(x), float64(min(y+3, plotBottom)), markerColor)
+		}
+		drawText(img, int(x)+4, marginTop+2, m.Label, markerColor)
+	}
+
+	legendX := width - marginRight
+	for li := len(c.Lines) - 1; li >= 0; li-- {
+		l := c.Lines[li]
+		stroke := palette[li%len(palette)]
+		for i := 1; i < len(l.Values); i++ {
+			x0, y0 := c.point(i-1, l.Values[i-1], yMax)
+			x1, y1 := c.point(i, l.Values[i], yMax)
+			drawLine(img, int(x0), y0, int(x1), y1, stroke)
+		}
+
+		legendX -= textWidth(l.Name)
+		drawText(img, legendX, 14, l.Name, textColor)
+		draw.Draw(img, image.Rect(legendX-14, 14, legendX-4, 24), &image.Uniform{stroke}, image.Point{}, draw.Src)
+		legendX -= 24
+	}
+
+	return png.Encode(w, img)
+}
+
+// drawLine draws a one-pixel line from (x0, y0) to (x1, y1).
+func drawLine(img *image.RGBA, x0 int, y0f float64, x1 int, y1f float64, c color.RGBA) {
+	y0, y1 := int(math.Round(y0f)), int(math.Round(y1f))
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.SetRGBA(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		if e2 := 2 * e; e2 >= dy {
+			e += dy
+			x0 += sx
+		} else {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// glyphs is a 3x5 bitmap font; each row is three bits, most significant on
+// the left. Lower-case text is drawn in upper case.
+var glyphs = map[rune][5]uint8{
+	'0': {7, 5, 5, 5, 7}, '1': {2, 6, 2, 2, 7}, '2': {7, 1, 7, 4, 7}, '3': {7, 1, 7, 1, 7},
+	'4': {5, 5, 7, 1, 1}, '5': {7, 4, 7, 1, 7}, '6': {7, 4, 7, 5, 7}, '7': {7, 1, 1, 1, 1},
+	'8': {7, 5, 7, 5, 7}, '9': {7, 5, 7, 1, 7},
+	'A': {2, 5, 7, 5, 5}, 'B': {6, 5, 6, 5, 6}, 'C': {7, 4, 4, 4, 7}, 'D': {6, 5, 5, 5, 6},
+	'E': {7, 4, 6, 4, 7}, 'F': {7, 4, 6, 4, 4}, 'G': {7, 4, 5, 5, 7}, 'H': {5, 5, 7, 5, 5},
+	'I': {7, 2, 2, 2, 7}, 'J': {1, 1, 1, 5, 7}, 'K': {5, 5, 6, 5, 5}, 'L': {4, 4, 4, 4, 7},
+	'M': {5, 7, 7, 5, 5}, 'N': {6, 5, 5, 5, 5}, 'O': {7, 5, 5, 5, 7}, 'P': {7, 5, 7, 4, 4},
+	'Q': {7, 5, 5, 7, 1}, 'R': {7, 5, 6, 5, 5}, 'S': {7, 4, 7, 1, 7}, 'T': {7, 2, 2, 2, 2},
+	'U': {5, 5, 5, 5, 7}, 'V': {5, 5, 5, 5, 2}, 'W': {5, 5, 7, 7, 5}, 'X': {5, 5, 2, 5, 5},
+	'Y': {5, 5, 2, 2, 2}, 'Z': {7, 1, 2, 4, 7},
+	'.': {0, 0, 0, 0, 2}, ':': {0, 2, 0, 2, 0}, '-': {0, 0, 7, 0, 0}, '%': {5, 1, 2, 4, 5},
+	'/': {1, 1, 2, 4, 4}, '(': {1, 2, 2, 2, 1}, ')': {4, 2, 2, 2, 4},
+}
+
+// glyphAdvance is the width of a character including spacing.
+const glyphAdvance = 4 * fontScale
+
+func textWidth(s string) int {
+	return len(s) * glyphAdvance
+}
+
+// drawText draws s with its top-left corner at (x, y). Characters without a
+// glyph are left blank.
+func drawText(img *image.RGBA, x, y int, s string, c color.RGBA) {
+	for _, r := range strings.ToUpper(s) {
+		g := glyphs[r]
+		for row := 0; row < 5; row++ {
+			for col := 0; col < 3; col++ {
+				if g[row]&(4>>col) == 0 {
+					continue
+				}
+				rect := image.Rect(x+col*fontScale, y+row*fontScale, x+(col+1)*fontScale, y+(row+1)*fontScale)
+				draw.Draw(img, rect, &image.Uniform{c}, image.Point{}, draw.Src)
+			}
+		}
+		x += glyphAdvance
+	}
+}
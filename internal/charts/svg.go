@@ -0,0 +1,61 @@
+package charts
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// WriteSVG renders the chart as an SVG document.
+func (c Chart) WriteSVG(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	yMax := c.yMax()
+	plotBottom := height - marginBottom
+
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n", width, height, width, height)
+	fmt.Fprintf(b, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+	fmt.Fprintf(b, `<text x="%d" y="24" font-size="16" font-weight="bold">%s</text>`+"\n", marginLeft, html.EscapeString(c.Title))
+
+	for i := 0; i <= yTicks; i++ {
+		v := yMax * float64(i) / yTicks
+		_, y := c.point(0, v, yMax)
+		fmt.Fprintf(b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#e0e0e0"/>`+"\n", marginLeft, y, width-marginRight, y)
+		fmt.Fprintf(b, `<text x="%d" y="%.1f" text-anchor="end" fill="#555">%s</text>`+"\n", marginLeft-6, y+4, html.EscapeString(c.valueLabel(v)))
+	}
+	for _, i := range c.xLabels() {
+		x, _ := c.point(i, 0, yMax)
+		fmt.Fprintf(b, `<text x="%.1f" y="%d" text-anchor="middle" fill="#555">%s</text>`+"\n", x, plotBottom+18, c.timeLabel(i))
+	}
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#888"/>`+"\n", marginLeft, plotBottom, width-marginRight, plotBottom)
+
+	for _, m := range c.Markers {
+		x, ok := c.timeX(m.Time)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(b, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" stroke="%s" stroke-dasharray="4 3"/>`+"\n", x, marginTop, x, plotBottom, hex(markerColor))
+		fmt.Fprintf(b, `<text x="%.1f" y="%d" font-size="10" fill="%s">%s</text>`+"\n", x+3, marginTop+10, hex(markerColor), html.EscapeString(m.Label))
+	}
+
+	legendX := width - marginRight
+	for li := len(c.Lines) - 1; li >= 0; li-- {
+		l := c.Lines[li]
+		stroke := hex(palette[li%len(palette)])
+		points := make([]string, 0, len(l.Values))
+		for i, v := range l.Values {
+			x, y := c.point(i, v, yMax)
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+		}
+		fmt.Fprintf(b, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`+"\n", stroke, strings.Join(points, " "))
+
+		legendX -= 10 + 7*len(l.Name)
+		fmt.Fprintf(b, `<rect x="%d" y="14" width="10" height="10" fill="%s"/>`+"\n", legendX-14, stroke)
+		fmt.Fprintf(b, `<text x="%d" y="24">%s</text>`+"\n", legendX, html.EscapeString(l.Name))
+		legendX -= 14
+	}
+
+	fmt.Fprintln(b, "</svg>")
+	return b.Flush()
+}
@@ -0,0 +1,159 @@
+package clickhouse
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// DefaultColumns maps ClickHouse columns to entry fields when no mapping is
+// configured.
+var DefaultColumns = map[string]string{
+	"timestamp":  "timestamp",
+	"level":      "level",
+	"status":     "status",
+	"latency_ms": "latency_ms",
+	"endpoint":   "endpoint",
+	"method":     "method",
+	"protocol":   "protocol",
+	"tenant":     "tenant",
+	"message":    "message",
+}
+
+// fieldTypes lists the entry fields a column can map to and their ClickHouse
+// types. "fields.<name>" maps a parsed field and is stored as a String.
+var fieldTypes = map[string]string{
+	"timestamp":    "DateTime64(3, 'UTC')",
+	"message":      "String",
+	"level":        "LowCardinality(String)",
+	"status":       "UInt16",
+	"latency_ms":   "Float64",
+	"endpoint":     "String",
+	"method":       "LowCardinality(String)",
+	"cache_status": "LowCardinality(String)",
+	"queue_ms":     "Float64",
+	"service_ms":   "Float64",
+	"tenant":       "String",
+	"group":        "String",
+	"protocol":     "LowCardinality(String)",
+	"tls_version":  "LowCardinality(String)",
+	"session":      "String",
+	"source":       "LowCardinality(String)",
+	"grpc_status":  "LowCardinality(String)",
+	"operation":    "LowCardinality(String)",
+	"version":      "LowCardinality(String)",
+	"retry":        "Bool",
+	"category":     "LowCardinality(String)",
+}
+
+// ValidateField reports whether field can be mapped to a column.
+func ValidateField(field string) error {
+	if _, ok := fieldTypes[field]; ok {
+		return nil
+	}
+	if name, ok := strings.CutPrefix(field, "fields."); ok && name != "" {
+		return nil
+	}
+	return fmt.Errorf("unknown field %q", field)
+}
+
+// fieldValue extracts field from entry as a JSON-encodable value.
+func fieldValue(entry types.LogEntry, field string) interface{} {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	switch field {
+	case "timestamp":
+		return entry.Timestamp.UTC().Format("2006-01-02 15:04:05.000")
+	case "message":
+		return entry.Message
+	case "level":
+		return string(entry.Level)
+	case "status":
+		return entry.StatusCode
+	case "latency_ms":
+		return ms(entry.Latency)
+	case "endpoint":
+		return entry.Endpoint
+	case "method":
+		return entry.Method
+	case "cache_status":
+		return entry.CacheStatus
+	case "queue_ms":
+		return ms(entry.QueueTime)
+	case "service_ms":
+		return ms(entry.ServiceTime)
+	case "tenant":
+		return entry.Tenant
+	case "group":
+		return entry.GroupKey
+	case "protocol":
+		return entry.Protocol
+	case "tls_version":
+		return entry.TLSVersion
+	case "session":
+		return entry.Session
+	case "source":
+		return entry.Source
+	case "grpc_status":
+		return entry.GRPCStatus
+	case "operation":
+		return entry.Operation
+	case "version":
+		return entry.Version
+	case "retry":
+		return entry.Retry
+	case "category":
+		return entry.ErrorCategory
+	}
+	if name, ok := strings.CutPrefix(field, "fields."); ok {
+		if v, ok := entry.Fields[name]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+// sortedColumns returns the mapping's column names in a stable order.
+func sortedColumns(columns map[string]string) []string {
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// createTableSQL returns a MergeTree table definition for the mapping,
+// ordered by the column mapped to the timestamp if there is one.
+func createTableSQL(table string, columns map[string]string) string {
+	var defs []string
+	orderBy := "tuple()"
+	for _, name := range sortedColumns(columns) {
+		field := columns[name]
+		typ, ok := fieldTypes[field]
+		if !ok {
+			typ = "String"
+		}
+		defs = append(defs, fmt.Sprintf("    %s %s", quoteIdent(name), typ))
+		if field == "timestamp" {
+			orderBy = quoteIdent(name)
+		}
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n%s\n) ENGINE = MergeTree ORDER BY %s",
+		quoteTable(table), strings.Join(defs, ",\n"), orderBy)
+}
+
+func quoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
+
+// quoteTable quotes "table" or "database.table".
+func quoteTable(table string) string {
+	parts := strings.SplitN(table, ".", 2)
+	for i, p := range parts {
+		parts[i] = quoteIdent(p)
+	}
+	return strings.Join(parts, ".")
+}
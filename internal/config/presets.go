@@ -0,0 +1,44 @@
+package config
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// presetFiles holds the bundled presets, one YAML config per technology.
+// The first line of each is a comment describing it.
+//
+//go:embed presets/*.yaml
+var presetFiles embed.FS
+
+// Presets returns the names of the bundled presets, sorted.
+func Presets() []string {
+	entries, _ := presetFiles.ReadDir("presets")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Preset returns the YAML of the named preset.
+func Preset(name string) ([]byte, error) {
+	data, err := presetFiles.ReadFile("presets/" + name + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(Presets(), ", "))
+	}
+	return data, nil
+}
+
+// PresetDescription returns the one-line description of the named preset.
+func PresetDescription(name string) string {
+	data, err := Preset(name)
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	return strings.TrimSpace(strings.TrimPrefix(line, "#"))
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/nitis/pulseWatch/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Thresholds returns the runtime-adjustable detection thresholds.
+func (c *Config) Thresholds() types.Thresholds {
+	return types.Thresholds{
+		Sigma:         c.Detection.Sigma,
+		ErrorSpike:    c.Detection.ErrorSpike,
+		EWMADeviation: c.Detection.EWMA.Threshold,
+		SLOTarget:     c.SLO.Target,
+	}
+}
+
+// SaveThresholds writes t back into the config file at path. The file is
+// edited as a YAML node tree, so comments and unrelated settings survive.
+func SaveThresholds(path string, t types.Thresholds) error {
+	if path == "" {
+		return fmt.Errorf("no config file to save to; start with --config")
+	}
+
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("config %s: top level is not a mapping", path)
+	}
+
+	setFloat(root, t.Sigma, "detection", "sigma")
+	setFloat(root, t.ErrorSpike, "detection", "error_spike")
+	setFloat(root, t.EWMADeviation, "detection", "ewma", "threshold")
+	setFloat(root, t.SLOTarget, "slo", "target")
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// setFloat sets the scalar at the nested key path under m, creating
+// intermediate mappings as needed.
+func setFloat(m *yaml.Node, value float64, keys ...string) {
+	for i, key := range keys {
+		var child *yaml.Node
+		for j := 0; j+1 < len(m.Content); j += 2 {
+			if m.Content[j].Value == key {
+				child = m.Content[j+1]
+				break
+			}
+		}
+		last := i == len(keys)-1
+		if child == nil || (!last && child.Kind != yaml.MappingNode) {
+			if child == nil {
+				child = &yaml.Node{}
+				m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, child)
+			}
+			if !last {
+				*child = yaml.Node{Kind: yaml.MappingNode}
+			}
+		}
+		m = child
+	}
+	m.Kind = yaml.ScalarNode
+	m.Tag = ""
+	m.Style = 0
+	m.Value = strconv.FormatFloat(value, 'g', -1, 64)
+}
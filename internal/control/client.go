@@ -0,0 +1,120 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// errNotRunning explains a missing socket.
+var errNotRunning = errors.New("no pulsewatch daemon is listening (start one with watch --headless)")
+
+// Client calls a daemon's control API.
+type Client struct {
+	path   string
+	client *http.Client
+}
+
+// NewClient creates a Client for the socket at path.
+func NewClient(path string) *Client {
+	return &Client{
+		path: path,
+		client: &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		}},
+	}
+}
+
+// Status returns the daemon's status.
+func (c *Client) Status(ctx context.Context) (StatusResponse, error) {
+	var st StatusResponse
+	err := c.do(ctx, http.MethodGet, "/status", nil, &st)
+	return st, err
+}
+
+// Reload makes the daemon re-read its configuration.
+func (c *Client) Reload(ctx context.Context) (string, error) {
+	return c.message(ctx, http.MethodPost, "/reload", nil)
+}
+
+// RotateSession starts a new session.
+func (c *Client) RotateSession(ctx context.Context) (string, error) {
+	return c.message(ctx, http.MethodPost, "/rotate", nil)
+}
+
+// SetFilter sets the filter expression; "" clears it.
+func (c *Client) SetFilter(ctx context.Context, expr string) (string, error) {
+	return c.message(ctx, http.MethodPut, "/filter", filterRequest{Expr: expr})
+}
+
+// Silence silences notifications for anomalyType ("*" for all) for d; zero
+// lifts the silence.
+func (c *Client) Silence(ctx context.Context, anomalyType string, d time.Duration) (string, error) {
+	return c.message(ctx, http.MethodPost, "/silence", silenceRequest{Type: anomalyType, For: d})
+}
+
+// Pause pauses ingestion.
+func (c *Client) Pause(ctx context.Context) (string, error) {
+	return c.message(ctx, http.MethodPost, "/ingest/pause", nil)
+}
+
+// Resume resumes ingestion, processing the held records first.
+func (c *Client) Resume(ctx context.Context) (string, error) {
+	return c.message(ctx, http.MethodPost, "/ingest/resume", nil)
+}
+
+// Flush processes the records held so far while ingestion stays paused.
+func (c *Client) Flush(ctx context.Context) (string, error) {
+	return c.message(ctx, http.MethodPost, "/ingest/flush", nil)
+}
+
+func (c *Client) message(ctx context.Context, method, path string, body interface{}) (string, error) {
+	var resp messageResponse
+	err := c.do(ctx, method, path, body, &resp)
+	return resp.Message, err
+}
+
+// do sends body as JSON and decodes the response into out. Error responses
+// become errors carrying the daemon's message.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, "http://pulsewatch"+path, r)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED) {
+			return fmt.Errorf("%s: %w", c.path, errNotRunning)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return errors.New(strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode control response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+// Package control serves a running pulsewatch daemon's control API on a
+// unix socket, for `pulsewatch ctl` and other automation. It speaks JSON
+// over HTTP; only local users allowed to open the socket can use it.
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/filter"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// Engine is the part of the analysis engine the control API drives.
+type Engine interface {
+	Status() types.Status
+	Filter() string
+	SetFilter(expr *filter.Expr)
+	Silence(anomalyType string, d time.Duration) time.Time // Returns when the silence ends
+	Silences() map[string]time.Time
+	RotateSession() (time.Time, error)
+}
+
+// Ingestion is the ingestion gate the control API pauses and resumes.
+type Ingestion interface {
+	Pause() bool
+	Resume() int
+	Flush() int
+	State() types.IngestState
+}
+
+// StatusResponse is the body of GET /status.
+type StatusResponse struct {
+	Status   types.Status         `json:"status"`
+	PID      int                  `json:"pid"`
+	Filter   string               `json:"filter"`
+	Silences map[string]time.Time `json:"silences"` // Anomaly type ("*" for all) -> end
+	Ingest   *types.IngestState   `json:"ingest,omitempty"`
+}
+
+// Server is the control API server.
+type Server struct {
+	path      string
+	engine    Engine
+	ingestion Ingestion // nil unless SetIngestion was called
+	reload    func() (string, error)
+	srv       *http.Server
+}
+
+// NewServer creates a Server that will listen on the unix socket at path.
+// reload re-reads the configuration and describes what it applied.
+func NewServer(path string, engine Engine, reload func() (string, error)) *Server {
+	s := &Server{path: path, engine: engine, reload: reload}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.HandleFunc("POST /reload", s.handleReload)
+	mux.HandleFunc("POST /rotate", s.handleRotate)
+	mux.HandleFunc("PUT /filter", s.handleFilter)
+	mux.HandleFunc("POST /silence", s.handleSilence)
+	mux.HandleFunc("POST /ingest/pause", s.withIngestion(pauseIngestion))
+	mux.HandleFunc("POST /ingest/resume", s.withIngestion(resumeIngestion))
+	mux.HandleFunc("POST /ingest/flush", s.withIngestion(flushIngestion))
+	s.srv = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	return s
+}
+
+// SetIngestion lets the API pause, resume, and flush ingestion through i.
+// Call it before Start.
+func (s *Server) SetIngestion(i Ingestion) {
+	s.ingestion = i
+}
+
+// Start binds the socket, readable and writable by the owner only, and
+// serves in the background. A socket left by an instance that is no longer
+// running is replaced; one in use is an error.
+func (s *Server) Start() error {
+	if _, err := os.Stat(s.path); err == nil {
+		if conn, err := net.DialTimeout("unix", s.path, time.Second); err == nil {
+			conn.Close()
+			return fmt.Errorf("control socket %s is in use by another instance", s.path)
+		}
+		os.Remove(s.path)
+	}
+	ln, err := net.Listen("unix", s.path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(s.path, 0o600); err != nil {
+		ln.Close()
+		return err
+	}
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("Control server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Shutdown stops the server, waiting up to ctx for in-flight requests, and
+// removes the socket.
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.srv.Shutdown(ctx)
+	os.Remove(s.path)
+	return err
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	resp := StatusResponse{
+		Status:   s.engine.Status(),
+		PID:      os.Getpid(),
+		Filter:   s.engine.Filter(),
+		Silences: s.engine.Silences(),
+	}
+	if s.ingestion != nil {
+		st := s.ingestion.State()
+		resp.Ingest = &st
+	}
+	writeJSON(w, resp)
+}
+
+// messageResponse is the body of requests that only report what they did.
+type messageResponse struct {
+	Message string `json:"message"`
+}
+
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	msg, err := s.reload()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	writeJSON(w, messageResponse{msg})
+}
+
+func (s *Server) handleRotate(w http.ResponseWriter, r *http.Request) {
+	previous, err := s.engine.RotateSession()
+	if err != nil {
+		// The session was rotated; only its marker event is missing
+		log.Printf("Error recording session event: %v", err)
+	}
+	writeJSON(w, messageResponse{fmt.Sprintf("Started a new session; the previous one ran %s", time.Since(previous).Round(time.Second))})
+}
+
+// filterRequest is the body of PUT /filter; an empty Expr clears the filter.
+type filterRequest struct {
+	Expr string `json:"expr"`
+}
+
+func (s *Server) handleFilter(w http.ResponseWriter, r *http.Request) {
+	var req filterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Expr == "" {
+		s.engine.SetFilter(nil)
+		writeJSON(w, messageResponse{"Filter cleared; all entries are processed"})
+		return
+	}
+	expr, err := filter.Parse(req.Expr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.engine.SetFilter(expr)
+	writeJSON(w, messageResponse{"Only entries matching " + expr.String() + " are processed"})
+}
+
+// silenceRequest is the body of POST /silence. A zero For lifts the
+// silence.
+type silenceRequest struct {
+	Type string        `json:"type"` // Anomaly type, or "*" for all
+	For  time.Duration `json:"for"`
+}
+
+func (s *Server) handleSilence(w http.ResponseWriter, r *http.Request) {
+	var req silenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Type == "" || req.For < 0 {
+		http.Error(w, "type is required and for must not be negative", http.StatusBadRequest)
+		return
+	}
+	until := s.engine.Silence(req.Type, req.For)
+	name := req.Type
+	if name == "*" {
+		name = "all anomaly types"
+	}
+	if req.For == 0 {
+		writeJSON(w, messageResponse{fmt.Sprintf("Notifications for %s resumed", name)})
+		return
+	}
+	writeJSON(w, messageResponse{fmt.Sprintf("Notifications for %s silenced until %s", name, until.Format(time.DateTime))})
+}
+
+// withIngestion answers 404 when ingestion can't be controlled.
+func (s *Server) withIngestion(handle func(Ingestion) string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.ingestion == nil {
+			http.Error(w, "ingestion can't be paused in this run", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, messageResponse{handle(s.ingestion)})
+	}
+}
+
+func pauseIngestion(i Ingestion) string {
+	if !i.Pause() {
+		return fmt.Sprintf("Ingestion was already paused; %d records held", i.State().Held)
+	}
+	return "Ingestion paused; new records are held until it resumes"
+}
+
+func resumeIngestion(i Ingestion) string {
+	return fmt.Sprintf("Ingestion resumed; %d held records are being processed", i.Resume())
+}
+
+func flushIngestion(i Ingestion) string {
+	return fmt.Sprintf("%d held records are being processed; ingestion stays paused", i.Flush())
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing control response: %v", err)
+	}
+}
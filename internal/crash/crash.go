@@ -0,0 +1,221 @@
+// Package crash turns a panic in any pipeline goroutine into an orderly
+// exit: the terminal is restored, pending data is flushed, a crash report is
+// written next to the database, and the user is told where both are.
+package crash
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// hookTimeout bounds each cleanup step so a wedged one (e.g. a database
+// locked by the panicking goroutine) can't keep the process from exiting.
+const hookTimeout = 5 * time.Second
+
+type hook struct {
+	name string
+	fn   func()
+}
+
+type section struct {
+	name string
+	fn   func(io.Writer)
+}
+
+var (
+	mu         sync.Mutex
+	version    = "dev"
+	configHash string
+	dbPath     string
+	started    = time.Now()
+	terminal   func()
+	hooks      []hook
+	sections   []section
+	crashing   atomic.Bool
+)
+
+// Configure records what the crash report identifies the session by.
+func Configure(ver, cfgHash, db string) {
+	mu.Lock()
+	defer mu.Unlock()
+	version, configHash, dbPath = ver, cfgHash, db
+}
+
+// SetTerminal registers fn to hand the terminal back before anything is
+// printed, typically by stopping the dashboard and waiting for it to exit.
+func SetTerminal(fn func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	terminal = fn
+}
+
+// OnCrash registers a cleanup step, such as flushing buffered exports or
+// closing the database. Steps run in the order they were registered.
+func OnCrash(name string, fn func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	hooks = append(hooks, hook{name, fn})
+}
+
+// AddReport registers a section of the crash report, e.g. self-metrics.
+func AddReport(name string, fn func(io.Writer)) {
+	mu.Lock()
+	defer mu.Unlock()
+	sections = append(sections, section{name, fn})
+}
+
+// Recover handles a panic in the calling goroutine. Defer it in every
+// long-running goroutine after its other defers, so it runs before they
+// close channels and downstream stages mistake the crash for end of input:
+// defer crash.Recover("parser").
+func Recover(goroutine string) {
+	if r := recover(); r != nil {
+		Handle(goroutine, r, debug.Stack())
+	}
+}
+
+// Wait blocks forever if a crash is being handled, so a goroutine that
+// returns because of the cleanup (e.g. the stopped dashboard) does not exit
+// the process before the report is written.
+func Wait() {
+	if crashing.Load() {
+		select {}
+	}
+}
+
+// Handle restores the terminal, gathers the report sections, runs the
+// cleanup steps, writes the crash report, and exits with status 2. A nil
+// stack means it was already printed, e.g. by the dashboard. Only the first crash is handled; later
+// callers block until the process exits.
+func Handle(goroutine string, value any, stack []byte) {
+	if !crashing.CompareAndSwap(false, true) {
+		select {}
+	}
+	mu.Lock()
+	restore, steps, extra := terminal, hooks, sections
+	mu.Unlock()
+
+	if restore != nil {
+		run("terminal", restore)
+	}
+	// Self-metrics are gathered while the database is still open
+	var metrics bytes.Buffer
+	for _, s := range extra {
+		var b bytes.Buffer
+		fmt.Fprintf(&metrics, "\n== %s\n", s.name)
+		if err := run(s.name, func() { s.fn(&b) }); err != nil {
+			fmt.Fprintf(&metrics, "unavailable: %v\n", err)
+			continue
+		}
+		metrics.Write(b.Bytes())
+	}
+	var failed []string
+	for _, h := range steps {
+		if err := run(h.name, h.fn); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", h.name, err))
+		}
+	}
+
+	path, err := writeReport(goroutine, value, stack, failed, metrics.Bytes())
+	fmt.Fprintf(os.Stderr, "\npulsewatch crashed in %s: %v\n", goroutine, value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not write the crash report: %v\n", err)
+		if stack != nil {
+			os.Stderr.Write(stack)
+		}
+	} else {
+		fmt.Fprintf(os.Stderr, "Crash report: %s\n", path)
+	}
+	for _, f := range failed {
+		fmt.Fprintf(os.Stderr, "Cleanup failed: %s\n", f)
+	}
+	if dbPath != "" {
+		fmt.Fprintf(os.Stderr, "Session data was saved to %s; summarize it with: pulsewatch report --db-path %s\n", dbPath, dbPath)
+	}
+	fmt.Fprintln(os.Stderr, "Please attach the crash report when filing an issue.")
+	os.Exit(2)
+}
+
+// run calls fn, turning a panic or a timeout into an error.
+func run(name string, fn func()) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panicked: %v", r)
+			}
+		}()
+		fn()
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(hookTimeout):
+		return fmt.Errorf("timed out after %s", hookTimeout)
+	}
+}
+
+// writeReport writes the report beside the database, or to the temp
+// directory if that fails, and returns its path.
+func writeReport(goroutine string, value any, stack []byte, failed []string, metrics []byte) (string, error) {
+	name := "pulsewatch-crash-" + time.Now().Format("20060102-150405") + ".txt"
+	dirs := []string{os.TempDir()}
+	if dbPath != "" {
+		dirs = append([]string{filepath.Dir(dbPath)}, dirs...)
+	}
+	var err error
+	for _, dir := range dirs {
+		var f *os.File
+		path := filepath.Join(dir, name)
+		if f, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600); err != nil {
+			continue
+		}
+		writeSections(f, goroutine, value, stack, failed, metrics)
+		if err = f.Close(); err == nil {
+			return path, nil
+		}
+	}
+	return "", err
+}
+
+func writeSections(w io.Writer, goroutine string, value any, stack []byte, failed []string, metrics []byte) {
+	fmt.Fprintf(w, "pulsewatch crash report\n\n")
+	fmt.Fprintf(w, "Time:        %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(w, "Version:     %s (%s, %s/%s)\n", version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(w, "Uptime:      %s\n", time.Since(started).Round(time.Second))
+	fmt.Fprintf(w, "Config hash: %s\n", configHash)
+	fmt.Fprintf(w, "Database:    %s\n", dbPath)
+	fmt.Fprintf(w, "Goroutine:   %s\n", goroutine)
+	fmt.Fprintf(w, "Panic:       %v\n", value)
+	for _, f := range failed {
+		fmt.Fprintf(w, "Cleanup:     %s\n", f)
+	}
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	fmt.Fprintf(w, "\n== Runtime\n")
+	fmt.Fprintf(w, "Goroutines:  %d\n", runtime.NumGoroutine())
+	fmt.Fprintf(w, "Heap:        %d bytes in use, %d objects\n", ms.HeapAlloc, ms.HeapObjects)
+	fmt.Fprintf(w, "Sys:         %d bytes\n", ms.Sys)
+	fmt.Fprintf(w, "GC cycles:   %d\n", ms.NumGC)
+
+	w.Write(metrics)
+
+	fmt.Fprintf(w, "\n== Stack\n")
+	if stack != nil {
+		w.Write(stack)
+	} else {
+		fmt.Fprintln(w, "Printed to the terminal when the dashboard stopped.")
+	}
+	buf := make([]byte, 1<<20)
+	fmt.Fprintf(w, "\n== All goroutines\n%s", buf[:runtime.Stack(buf, true)])
+}
@@ -0,0 +1,160 @@
+// Package digest renders scheduled email digests of the stored metrics,
+// top errors, and anomalies.
+package digest
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/locale"
+	"github.com/nitis/pulseWatch/internal/storage"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// maxAnomalies caps the anomalies listed in one digest.
+const maxAnomalies = 50
+
+// Source provides the stored history a digest covers.
+type Source interface {
+	Rollups(from, to time.Time) ([]storage.Rollup, error)
+	AnomalyHistory(f types.AnomalyFilter) ([]types.Anomaly, error)
+	TopErrors(from, to time.Time, limit int) ([]storage.ErrorCount, error)
+}
+
+// Data is what a digest template renders.
+type Data struct {
+	Title    string
+	From, To time.Time
+	Minutes  int // Minutes of stored metrics in the period
+
+	Requests, Errors int
+	ErrorRate        float64
+	AvgRPS, PeakRPS  float64
+	P50, P95, P99    time.Duration // Worst minute
+
+	TopErrors []storage.ErrorCount // Empty once raw entries passed retention
+
+	Anomalies               []types.Anomaly // Newest first, at most maxAnomalies
+	TotalAnomalies          int
+	Critical, Warning, Info int
+}
+
+// Build loads the digest data for the period from..to.
+func Build(source Source, title string, from, to time.Time, top int) (Data, error) {
+	d := Data{Title: title, From: from, To: to}
+
+	rollups, err := source.Rollups(from, to)
+	if err != nil {
+		return d, fmt.Errorf("loading rollups: %w", err)
+	}
+	d.Minutes = len(rollups)
+	for _, r := range rollups {
+		d.Requests += r.Requests
+		d.Errors += r.Errors
+		d.PeakRPS = max(d.PeakRPS, float64(r.Requests)/time.Minute.Seconds())
+		d.P50 = max(d.P50, r.P50)
+		d.P95 = max(d.P95, r.P95)
+		d.P99 = max(d.P99, r.P99)
+	}
+	if d.Requests > 0 {
+		d.ErrorRate = float64(d.Errors) / float64(d.Requests) * 100
+	}
+	if len(rollups) > 0 {
+		d.AvgRPS = float64(d.Requests) / float64(len(rollups)) / time.Minute.Seconds()
+	}
+
+	if top > 0 {
+		if d.TopErrors, err = source.TopErrors(from, to, top); err != nil {
+			return d, fmt.Errorf("loading top errors: %w", err)
+		}
+	}
+
+	anomalies, err := source.AnomalyHistory(types.AnomalyFilter{Since: from, Until: to})
+	if err != nil {
+		return d, fmt.Errorf("loading anomalies: %w", err)
+	}
+	d.TotalAnomalies = len(anomalies)
+	for _, a := range anomalies {
+		switch a.Severity {
+		case types.SeverityCritical:
+			d.Critical++
+		case types.SeverityWarning:
+			d.Warning++
+		case types.SeverityInfo:
+			d.Info++
+		}
+	}
+	if len(anomalies) > maxAnomalies {
+		anomalies = anomalies[:maxAnomalies]
+	}
+	d.Anomalies = anomalies
+	return d, nil
+}
+
+// LoadTemplate parses the HTML template at path, or the built-in one if path
+// is empty.
+func LoadTemplate(path string) (*template.Template, error) {
+	text := defaultTemplate
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	return template.New("digest").Funcs(template.FuncMap{
+		"ms":        func(d time.Duration) string { return locale.Duration(d.Truncate(time.Millisecond)) },
+		"num":       func(n int) string { return locale.Int(int64(n)) },
+		"float":     func(f float64) string { return locale.Float(f, 2) },
+		"pct":       func(f float64) string { return locale.Percent(f, 2) },
+		"datetime":  func(t time.Time) string { return locale.Date(t) + " " + locale.ShortTime(t) },
+		"shortdate": func(t time.Time) string { return locale.ShortDate(t) + " " + locale.ShortTime(t) },
+	}).Parse(text)
+}
+
+// Render executes tmpl with d.
+func Render(tmpl *template.Template, d Data) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, d); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+const defaultTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, Helvetica, Arial, sans-serif; color: #222;">
+<h2>{{.Title}}</h2>
+<p style="color: #666;">{{.From.Format "Mon"}} {{datetime .From}} &ndash; {{.To.Format "Mon"}} {{datetime .To}} ({{num .Minutes}} minutes of data)</p>
+
+<h3>Key metrics</h3>
+<table cellpadding="6" style="border-collapse: collapse;">
+<tr><td>Requests</td><td><b>{{num .Requests}}</b></td></tr>
+<tr><td>Errors</td><td><b>{{num .Errors}}</b> ({{pct .ErrorRate}})</td></tr>
+<tr><td>RPS</td><td>{{float .AvgRPS}} avg, {{float .PeakRPS}} peak</td></tr>
+<tr><td>Worst minute P50 / P95 / P99</td><td>{{ms .P50}} / {{ms .P95}} / {{ms .P99}}</td></tr>
+</table>
+
+<h3>Top errors</h3>
+{{if .TopErrors}}
+<table cellpadding="6" style="border-collapse: collapse;">
+<tr style="background: #f0f0f0;"><th align="left">Endpoint</th><th>Status</th><th align="right">Count</th></tr>
+{{range .TopErrors}}<tr><td>{{.Endpoint}}</td><td align="center">{{.StatusCode}}</td><td align="right">{{num .Count}}</td></tr>
+{{end}}</table>
+{{else}}<p>No failed requests stored for this period.</p>{{end}}
+
+<h3>Anomalies: {{num .TotalAnomalies}}</h3>
+{{if .Anomalies}}
+<p>{{.Critical}} critical, {{.Warning}} warning, {{.Info}} info</p>
+<table cellpadding="6" style="border-collapse: collapse;">
+<tr style="background: #f0f0f0;"><th align="left">Time</th><th align="left">Severity</th><th align="left">Type</th><th align="left">Message</th></tr>
+{{range .Anomalies}}<tr><td>{{shortdate .Timestamp}}</td><td>{{if eq .Severity "critical"}}<b style="color: #c00;">{{.Severity}}</b>{{else}}{{.Severity}}{{end}}</td><td>{{.Type}}</td><td>{{.Message}}</td></tr>
+{{end}}</table>
+{{if gt .TotalAnomalies (len .Anomalies)}}<p>Showing the latest {{len .Anomalies}}.</p>{{end}}
+{{else}}<p>No anomalies detected.</p>{{end}}
+</body>
+</html>
+`
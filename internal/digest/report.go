@@ -0,0 +1,181 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/cron"
+	"github.com/nitis/pulseWatch/internal/locale"
+)
+
+// maxSlackErrors caps the top errors listed in a Slack message.
+const maxSlackErrors = 5
+
+// ReportScheduler generates a report on a cron schedule and delivers it to
+// a directory, by email, and/or to a Slack incoming webhook.
+type ReportScheduler struct {
+	cfg      config.ReportConfig
+	mail     config.DigestConfig // From and SMTP server for emailed reports
+	source   Source
+	tmpl     *template.Template
+	schedule cron.Schedule
+	client   *http.Client
+}
+
+// NewReportScheduler creates a ReportScheduler reading from source. Reports
+// use the digest's HTML template and are emailed with its from address and
+// SMTP server. It fails if the schedule or template is invalid.
+func NewReportScheduler(cfg config.ReportConfig, mail config.DigestConfig, source Source) (*ReportScheduler, error) {
+	schedule, err := cron.Parse(cfg.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("report %s: %w", cfg.Name, err)
+	}
+	tmpl, err := LoadTemplate(mail.Template)
+	if err != nil {
+		return nil, fmt.Errorf("loading digest template: %w", err)
+	}
+	return &ReportScheduler{
+		cfg:      cfg,
+		mail:     mail,
+		source:   source,
+		tmpl:     tmpl,
+		schedule: schedule,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Run delivers a report at every scheduled time until ctx is cancelled.
+func (s *ReportScheduler) Run(ctx context.Context) {
+	for {
+		next := s.schedule.Next(time.Now())
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if err := s.Deliver(next); err != nil {
+			log.Printf("Error delivering report %s: %v", s.cfg.Name, err)
+		}
+	}
+}
+
+// Deliver generates the report for the period ending at end and delivers it
+// to every configured destination, returning their errors joined.
+func (s *ReportScheduler) Deliver(end time.Time) error {
+	d, html, err := s.build(end)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if s.cfg.Dir != "" {
+		if err := s.writeFile(html, end); err != nil {
+			errs = append(errs, fmt.Errorf("writing file: %w", err))
+		}
+	}
+	if len(s.cfg.To) > 0 {
+		mail := s.mail
+		mail.To = s.cfg.To
+		subject := fmt.Sprintf("%s: %s requests, %s errors, %s anomalies", d.Title, locale.Int(int64(d.Requests)), locale.Percent(d.ErrorRate, 2), locale.Int(int64(d.TotalAnomalies)))
+		if err := sendMail(mail, subject, html); err != nil {
+			errs = append(errs, fmt.Errorf("sending email: %w", err))
+		}
+	}
+	if s.cfg.Slack != "" {
+		if err := s.postSlack(d); err != nil {
+			errs = append(errs, fmt.Errorf("posting to Slack: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Render returns the HTML of the report for the period ending at end
+// without delivering it.
+func (s *ReportScheduler) Render(end time.Time) (string, error) {
+	_, html, err := s.build(end)
+	return html, err
+}
+
+func (s *ReportScheduler) build(end time.Time) (Data, string, error) {
+	d, err := Build(s.source, s.cfg.Name, end.Add(-s.cfg.Period), end, s.cfg.Top)
+	if err != nil {
+		return d, "", err
+	}
+	html, err := Render(s.tmpl, d)
+	if err != nil {
+		return d, "", fmt.Errorf("rendering report: %w", err)
+	}
+	return d, html, nil
+}
+
+// writeFile saves the HTML as Dir/<name>-<end>.html.
+func (s *ReportScheduler) writeFile(html string, end time.Time) error {
+	if err := os.MkdirAll(s.cfg.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.html", fileSafe(s.cfg.Name), end.Format("20060102-1504"))
+	return os.WriteFile(filepath.Join(s.cfg.Dir, name), []byte(html), 0o644)
+}
+
+// fileSafe replaces the characters of name that don't belong in a file name.
+func fileSafe(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ' ' || r == ':' {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// postSlack posts a text summary of d to the Slack webhook.
+func (s *ReportScheduler) postSlack(d Data) error {
+	payload, err := json.Marshal(map[string]string{"text": slackText(d)})
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.cfg.Slack, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// slackText summarizes d in Slack's mrkdwn: the key metrics, anomaly counts,
+// and the most frequent failing endpoints.
+func slackText(d Data) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*: %s %s – %s %s (%s minutes of data)\n", d.Title, locale.Date(d.From), locale.ShortTime(d.From), locale.Date(d.To), locale.ShortTime(d.To), locale.Int(int64(d.Minutes)))
+	fmt.Fprintf(&b, "Requests: *%s* | Errors: *%s* (%s)\n", locale.Int(int64(d.Requests)), locale.Int(int64(d.Errors)), locale.Percent(d.ErrorRate, 2))
+	fmt.Fprintf(&b, "RPS: %s avg, %s peak\n", locale.Float(d.AvgRPS, 2), locale.Float(d.PeakRPS, 2))
+	fmt.Fprintf(&b, "Worst minute P50/P95/P99: %s / %s / %s\n", locale.Duration(d.P50.Truncate(time.Millisecond)), locale.Duration(d.P95.Truncate(time.Millisecond)), locale.Duration(d.P99.Truncate(time.Millisecond)))
+	fmt.Fprintf(&b, "Anomalies: *%s* (%d critical, %d warning, %d info)", locale.Int(int64(d.TotalAnomalies)), d.Critical, d.Warning, d.Info)
+	if len(d.TopErrors) > 0 {
+		b.WriteString("\nTop errors:")
+		for i, e := range d.TopErrors {
+			if i == maxSlackErrors {
+				break
+			}
+			fmt.Fprintf(&b, "\n• `%s` %d × %s", e.Endpoint, e.StatusCode, locale.Int(int64(e.Count)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,105 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/config"
+	"github.com/nitis/pulseWatch/internal/locale"
+)
+
+// Scheduler sends digests on the configured daily or weekly schedule.
+type Scheduler struct {
+	cfg    config.DigestConfig
+	source Source
+	tmpl   *template.Template
+}
+
+// NewScheduler creates a Scheduler reading from source. It fails if the
+// configured template does not parse.
+func NewScheduler(cfg config.DigestConfig, source Source) (*Scheduler, error) {
+	tmpl, err := LoadTemplate(cfg.Template)
+	if err != nil {
+		return nil, fmt.Errorf("loading digest template: %w", err)
+	}
+	return &Scheduler{cfg: cfg, source: source, tmpl: tmpl}, nil
+}
+
+// Run sends a digest at every scheduled time until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		next := Next(s.cfg, time.Now())
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if err := s.Send(next); err != nil {
+			log.Printf("Error sending %s digest: %v", s.cfg.Schedule, err)
+		}
+	}
+}
+
+// Send emails the digest for the period ending at end.
+func (s *Scheduler) Send(end time.Time) error {
+	d, err := s.build(end)
+	if err != nil {
+		return err
+	}
+	html, err := Render(s.tmpl, d)
+	if err != nil {
+		return fmt.Errorf("rendering digest: %w", err)
+	}
+	subject := fmt.Sprintf("%s: %s requests, %s errors, %s anomalies", d.Title, locale.Int(int64(d.Requests)), locale.Percent(d.ErrorRate, 2), locale.Int(int64(d.TotalAnomalies)))
+	return sendMail(s.cfg, subject, html)
+}
+
+// Render returns the HTML of the digest for the period ending at end
+// without sending it.
+func (s *Scheduler) Render(end time.Time) (string, error) {
+	d, err := s.build(end)
+	if err != nil {
+		return "", err
+	}
+	return Render(s.tmpl, d)
+}
+
+func (s *Scheduler) build(end time.Time) (Data, error) {
+	schedule := s.cfg.Schedule
+	if schedule == "" {
+		schedule = "daily"
+	}
+	title := fmt.Sprintf("%s (%s)", s.cfg.Subject, schedule)
+	return Build(s.source, title, end.Add(-Period(s.cfg)), end, s.cfg.Top)
+}
+
+// Period returns the span one digest covers.
+func Period(cfg config.DigestConfig) time.Duration {
+	if cfg.Schedule == "weekly" {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// Next returns the first scheduled send time after now, in local time.
+func Next(cfg config.DigestConfig, now time.Time) time.Time {
+	at, _ := time.Parse("15:04", cfg.At)
+	next := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+	if cfg.Schedule == "weekly" {
+		weekday, _ := config.ParseWeekday(cfg.Weekday)
+		next = next.AddDate(0, 0, (int(weekday)-int(next.Weekday())+7)%7)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 7)
+		}
+		return next
+	}
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
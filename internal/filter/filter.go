@@ -0,0 +1,318 @@
+// Package filter evaluates boolean expressions over log entries, such as
+//
+//	status >= 500 or latency > 1s
+//	method == "POST" and endpoint =~ "^/api/" and not level == "DEBUG"
+//
+// A comparison is an attribute, an operator, and a value. Attributes are
+// status, latency, queue_time, service_time, endpoint, method, level, tenant,
+// cache_status, protocol, tls_version, session, group, source, grpc_status,
+// operation, version, retry (1 for likely retries, else 0), category (the
+// configured error category), message, or any parsed field (optionally
+// written fields.<name>). Operators are ==, !=, <, <=, >, >=, =~ (regex
+// match) and !~. Values are numbers, durations (250ms, 2s; compared with the
+// timing attributes), or quoted strings. Comparisons combine with and, or, not (or
+// &&, ||, !) and parentheses.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// Expr is a parsed filter expression.
+type Expr struct {
+	source string
+	root   node
+}
+
+type node interface {
+	match(entry types.LogEntry) bool
+}
+
+type andNode struct{ left, right node }
+type orNode struct{ left, right node }
+type notNode struct{ inner node }
+
+func (n andNode) match(e types.LogEntry) bool { return n.left.match(e) && n.right.match(e) }
+func (n orNode) match(e types.LogEntry) bool  { return n.left.match(e) || n.right.match(e) }
+func (n notNode) match(e types.LogEntry) bool { return !n.inner.match(e) }
+
+// comparison compares an attribute with a literal.
+type comparison struct {
+	attr   string
+	op     string
+	text   string
+	number float64 // Valid when isNum
+	isNum  bool
+	re     *regexp.Regexp // For =~ and !~
+}
+
+// Parse compiles an expression.
+func Parse(source string) (*Expr, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", source, err)
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", source, err)
+	}
+	return &Expr{source: source, root: root}, nil
+}
+
+// String returns the source expression.
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Match reports whether entry satisfies the expression. A nil Expr matches
+// every entry.
+func (e *Expr) Match(entry types.LogEntry) bool {
+	if e == nil {
+		return true
+	}
+	return e.root.match(entry)
+}
+
+func (c comparison) match(entry types.LogEntry) bool {
+	value, num, isNum := attribute(entry, c.attr)
+	switch c.op {
+	case "=~":
+		return c.re.MatchString(value)
+	case "!~":
+		return !c.re.MatchString(value)
+	}
+
+	if c.isNum && isNum {
+		switch c.op {
+		case "==":
+			return num == c.number
+		case "!=":
+			return num != c.number
+		case "<":
+			return num < c.number
+		case "<=":
+			return num <= c.number
+		case ">":
+			return num > c.number
+		case ">=":
+			return num >= c.number
+		}
+	}
+	switch c.op {
+	case "==":
+		return value == c.text
+	case "!=":
+		return value != c.text
+	}
+	return false // Ordering needs numbers on both sides
+}
+
+// attribute returns an entry attribute as text and, when it is numeric, as
+// a number. Timing attributes are in milliseconds.
+func attribute(entry types.LogEntry, name string) (string, float64, bool) {
+	ms := func(d time.Duration) (string, float64, bool) {
+		v := float64(d) / float64(time.Millisecond)
+		return strconv.FormatFloat(v, 'f', -1, 64), v, true
+	}
+	var text string
+	switch name {
+	case "status":
+		return strconv.Itoa(entry.StatusCode), float64(entry.StatusCode), true
+	case "latency":
+		return ms(entry.Latency)
+	case "queue_time":
+		return ms(entry.QueueTime)
+	case "service_time":
+		return ms(entry.ServiceTime)
+	case "endpoint":
+		text = entry.Endpoint
+	case "method":
+		text = entry.Method
+	case "level":
+		text = string(entry.Level)
+	case "tenant":
+		text = entry.Tenant
+	case "cache_status":
+		text = entry.CacheStatus
+	case "protocol":
+		text = entry.Protocol
+	case "tls_version":
+		text = entry.TLSVersion
+	case "session":
+		text = entry.Session
+	case "group":
+		text = entry.GroupKey
+	case "source":
+		text = entry.Source
+	case "grpc_status":
+		text = entry.GRPCStatus
+	case "operation":
+		text = entry.Operation
+	case "version":
+		text = entry.Version
+	case "category":
+		text = entry.ErrorCategory
+	case "retry":
+		if entry.Retry {
+			return "1", 1, true
+		}
+		return "0", 0, true
+	case "message":
+		text = entry.Message
+	default:
+		if v, ok := entry.Fields[strings.TrimPrefix(name, "fields.")]; ok && v != nil {
+			text = fmt.Sprint(v)
+		}
+	}
+	if n, err := strconv.ParseFloat(text, 64); err == nil {
+		return text, n, true
+	}
+	return text, 0, false
+}
+
+// timingAttributes are compared in milliseconds, so durations convert to ms.
+var timingAttributes = map[string]bool{"latency": true, "queue_time": true, "service_time": true}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) next() (token, error) {
+	t, ok := p.peek()
+	if !ok {
+		return t, fmt.Errorf("unexpected end of expression")
+	}
+	p.pos++
+	return t, nil
+}
+
+func (p *parser) accept(kind tokenKind, texts ...string) bool {
+	t, ok := p.peek()
+	if !ok || t.kind != kind {
+		return false
+	}
+	for _, text := range texts {
+		if strings.EqualFold(t.text, text) {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokenIdent, "or") || p.accept(tokenOp, "||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokenIdent, "and") || p.accept(tokenOp, "&&") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.accept(tokenIdent, "not") || p.accept(tokenOp, "!") {
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner}, nil
+	}
+	if p.accept(tokenOp, "(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(tokenOp, ")") {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	attr, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if attr.kind != tokenIdent {
+		return nil, fmt.Errorf("expected an attribute, got %q", attr.text)
+	}
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch op.text {
+	case "==", "!=", "<", "<=", ">", ">=", "=~", "!~":
+	default:
+		return nil, fmt.Errorf("expected a comparison after %s, got %q", attr.text, op.text)
+	}
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if value.kind == tokenOp {
+		return nil, fmt.Errorf("expected a value after %s %s, got %q", attr.text, op.text, value.text)
+	}
+
+	c := comparison{attr: attr.text, op: op.text, text: value.text}
+	if op.text == "=~" || op.text == "!~" {
+		if c.re, err = regexp.Compile(value.text); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	if value.kind == tokenString {
+		if op.text != "==" && op.text != "!=" {
+			return nil, fmt.Errorf("%s needs a number, got %q", op.text, value.text)
+		}
+		return c, nil
+	}
+	if n, err := strconv.ParseFloat(value.text, 64); err == nil {
+		c.number, c.isNum = n, true
+	} else if d, err := time.ParseDuration(value.text); err == nil && timingAttributes[attr.text] {
+		c.number, c.isNum = float64(d)/float64(time.Millisecond), true
+	} else if op.text != "==" && op.text != "!=" {
+		return nil, fmt.Errorf("%s needs a number, got %q", op.text, value.text)
+	}
+	return c, nil
+}
@@ -0,0 +1,79 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenIdent  tokenKind = iota // Attribute, keyword, or bare value such as 500 or 2s
+	tokenString                  // Quoted string, unquoted
+	tokenOp                      // Operator or parenthesis
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// operators are matched longest first.
+var operators = []string{"==", "!=", "<=", ">=", "=~", "!~", "&&", "||", "<", ">", "!", "(", ")"}
+
+func lex(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		r := rune(s[i])
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(s) && rune(s[j]) != r; j++ {
+				if s[j] == '\\' && j+1 < len(s) && (rune(s[j+1]) == r || s[j+1] == '\\') {
+					j++
+				}
+				b.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, token{tokenString, b.String()})
+			i = j + 1
+		case isWordByte(s[i]):
+			j := i
+			for j < len(s) && isWordByte(s[j]) {
+				j++
+			}
+			tokens = append(tokens, token{tokenIdent, s[i:j]})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(s[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q", s[i])
+			}
+			tokens = append(tokens, token{tokenOp, op})
+			i += len(op)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	return tokens, nil
+}
+
+// isWordByte reports whether b can be part of an attribute or bare value,
+// e.g. fields.user_agent, 2.5, 250ms, or -1.
+func isWordByte(b byte) bool {
+	return b == '_' || b == '.' || b == '-' || b == '/' ||
+		(b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
@@ -0,0 +1,127 @@
+// Package forward ships the log entries matching a filter expression to a
+// downstream sink (Loki, a file, a webhook, or another pulsewatch), so
+// pulsewatch can act as a sampling shipper for the entries worth keeping.
+package forward
+
+import (
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/filter"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// maxPendingBatches bounds the entries kept while a sink is failing; beyond
+// it the oldest are dropped.
+const maxPendingBatches = 10
+
+// Sink receives batches of forwarded entries.
+type Sink interface {
+	Send(entries []types.LogEntry) error
+	Close() error
+}
+
+// Forwarder batches the entries a filter matches into a sink.
+type Forwarder struct {
+	name          string
+	filter        *filter.Expr
+	sample        float64 // Fraction of matching entries forwarded
+	sink          Sink
+	batchSize     int
+	flushInterval time.Duration
+
+	entries chan types.LogEntry
+	pending []types.LogEntry
+	dropped int
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// New creates a Forwarder named name. sample is the fraction of matching
+// entries forwarded, in (0, 1].
+func New(name string, expr *filter.Expr, sample float64, sink Sink, batchSize int, flushInterval time.Duration) *Forwarder {
+	return &Forwarder{
+		name:          name,
+		filter:        expr,
+		sample:        sample,
+		sink:          sink,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		entries:       make(chan types.LogEntry, batchSize*2),
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+}
+
+// Offer queues entry if it matches the filter and is sampled. It never
+// blocks; entries that don't fit the queue are dropped and counted.
+func (f *Forwarder) Offer(entry types.LogEntry) {
+	if !f.filter.Match(entry) || (f.sample < 1 && rand.Float64() >= f.sample) {
+		return
+	}
+	select {
+	case f.entries <- entry:
+	default:
+		f.dropped++ // Only touched by the caller's goroutine
+		if f.dropped%10000 == 1 {
+			log.Printf("Forwarder %s falling behind, %d entries dropped so far", f.name, f.dropped)
+		}
+	}
+}
+
+// Run sends queued entries every flush interval or batch size until Close
+// is called.
+func (f *Forwarder) Run() {
+	defer close(f.stopped)
+	ticker := time.NewTicker(f.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case entry := <-f.entries:
+			f.pending = append(f.pending, entry)
+			if len(f.pending) >= f.batchSize {
+				f.flush()
+			}
+		case <-ticker.C:
+			f.flush()
+		case <-f.done:
+			for {
+				select {
+				case entry := <-f.entries:
+					f.pending = append(f.pending, entry)
+				default:
+					f.flush()
+					if err := f.sink.Close(); err != nil {
+						log.Printf("Error closing forwarder %s: %v", f.name, err)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close flushes the queued entries and stops Run. It must be called at most
+// once, after Run was started.
+func (f *Forwarder) Close() {
+	close(f.done)
+	<-f.stopped
+}
+
+// flush sends the pending entries. On failure they are kept for the next
+// flush, up to maxPendingBatches batches.
+func (f *Forwarder) flush() {
+	if len(f.pending) == 0 {
+		return
+	}
+	if err := f.sink.Send(f.pending); err != nil {
+		log.Printf("Error forwarding %d entries to %s: %v", len(f.pending), f.name, err)
+		if limit := f.batchSize * maxPendingBatches; len(f.pending) > limit {
+			log.Printf("Forwarder %s dropping %d oldest entries", f.name, len(f.pending)-limit)
+			f.pending = append(f.pending[:0], f.pending[len(f.pending)-limit:]...)
+		}
+		return
+	}
+	f.pending = f.pending[:0]
+}
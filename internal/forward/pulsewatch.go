@@ -0,0 +1,103 @@
+package forward
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/stream"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// pulsewatchTimeout bounds connecting to the receiver and writing a batch.
+const pulsewatchTimeout = 30 * time.Second
+
+// PulsewatchSink streams parsed entries to another pulsewatch running
+// watch --pulsewatch, in the binary framing of package stream. It connects
+// on the first batch and again after a failed one.
+type PulsewatchSink struct {
+	addr      string
+	token     string
+	tlsConfig *tls.Config // nil for plain TCP
+	conn      net.Conn
+	w         *stream.Writer
+}
+
+// NewPulsewatch creates a PulsewatchSink sending to addr (host:port) and
+// presenting token, which may be empty.
+func NewPulsewatch(addr, token string) *PulsewatchSink {
+	return &PulsewatchSink{addr: addr, token: token}
+}
+
+// UseTLS makes the sink connect over TLS, verifying the receiver against
+// the CA bundle in caFile, or the system roots without one, and presenting
+// the client certificate in certFile and keyFile when set.
+func (s *PulsewatchSink) UseTLS(caFile, certFile, keyFile string) error {
+	host, _, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return err
+	}
+	config := &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates in %s", caFile)
+		}
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	s.tlsConfig = config
+	return nil
+}
+
+func (s *PulsewatchSink) Send(entries []types.LogEntry) error {
+	if s.conn == nil {
+		dialer := &net.Dialer{Timeout: pulsewatchTimeout}
+		var conn net.Conn
+		var err error
+		if s.tlsConfig != nil {
+			conn, err = tls.DialWithDialer(dialer, "tcp", s.addr, s.tlsConfig)
+		} else {
+			conn, err = dialer.Dial("tcp", s.addr)
+		}
+		if err != nil {
+			return err
+		}
+		s.conn, s.w = conn, stream.NewWriter(conn, s.token)
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(pulsewatchTimeout))
+	for _, entry := range entries {
+		if err := s.w.Write(entry); err != nil {
+			s.Close()
+			return err
+		}
+	}
+	if err := s.w.Flush(); err != nil {
+		// The receiver may have seen part of the batch; resending it
+		// duplicates those entries rather than losing the rest
+		s.Close()
+		return err
+	}
+	return nil
+}
+
+func (s *PulsewatchSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn, s.w = nil, nil
+	return err
+}
@@ -0,0 +1,152 @@
+package forward
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// entryJSON is the JSON form of a forwarded entry.
+func entryJSON(entry types.LogEntry) map[string]interface{} {
+	m := map[string]interface{}{
+		"time":    entry.Timestamp,
+		"level":   entry.Level,
+		"message": entry.Message,
+	}
+	if entry.StatusCode != 0 {
+		m["status"] = entry.StatusCode
+	}
+	if entry.Latency > 0 {
+		m["latency_ms"] = float64(entry.Latency) / float64(time.Millisecond)
+	}
+	if entry.Endpoint != "" {
+		m["endpoint"] = entry.Endpoint
+	}
+	if entry.Method != "" {
+		m["method"] = entry.Method
+	}
+	if len(entry.Fields) > 0 {
+		m["fields"] = entry.Fields
+	}
+	return m
+}
+
+// FileSink appends entries to a file, one per line: the raw line, or a JSON
+// object with format "json".
+type FileSink struct {
+	f    *os.File
+	json bool
+}
+
+// NewFile opens path for appending.
+func NewFile(path, format string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{f: f, json: format == "json"}, nil
+}
+
+func (s *FileSink) Send(entries []types.LogEntry) error {
+	w := bufio.NewWriter(s.f)
+	enc := json.NewEncoder(w)
+	for _, entry := range entries {
+		if s.json {
+			if err := enc.Encode(entryJSON(entry)); err != nil {
+				return err
+			}
+			continue
+		}
+		w.WriteString(strings.TrimRight(entry.Message, "\n"))
+		w.WriteByte('\n')
+	}
+	return w.Flush()
+}
+
+func (s *FileSink) Close() error {
+	return s.f.Close()
+}
+
+// WebhookSink posts each batch as a JSON array.
+type WebhookSink struct {
+	url     string
+	headers map[string]string
+}
+
+// NewWebhook creates a WebhookSink posting to url with extra headers.
+func NewWebhook(url string, headers map[string]string) *WebhookSink {
+	return &WebhookSink{url: url, headers: headers}
+}
+
+func (s *WebhookSink) Send(entries []types.LogEntry) error {
+	batch := make([]map[string]interface{}, len(entries))
+	for i, entry := range entries {
+		batch[i] = entryJSON(entry)
+	}
+	return postJSON(s.url, s.headers, batch)
+}
+
+func (s *WebhookSink) Close() error { return nil }
+
+// LokiSink pushes entries to Grafana Loki as one stream with fixed labels.
+type LokiSink struct {
+	url     string
+	labels  map[string]string
+	headers map[string]string // e.g. X-Scope-OrgID
+}
+
+// NewLoki creates a LokiSink for the Loki server at baseURL, e.g.
+// http://loki:3100.
+func NewLoki(baseURL string, labels, headers map[string]string) *LokiSink {
+	return &LokiSink{url: strings.TrimSuffix(baseURL, "/") + "/loki/api/v1/push", labels: labels, headers: headers}
+}
+
+func (s *LokiSink) Send(entries []types.LogEntry) error {
+	values := make([][2]string, len(entries))
+	for i, entry := range entries {
+		values[i] = [2]string{strconv.FormatInt(entry.Timestamp.UnixNano(), 10), entry.Message}
+	}
+	push := map[string]interface{}{
+		"streams": []map[string]interface{}{{"stream": s.labels, "values": values}},
+	}
+	return postJSON(s.url, s.headers, push)
+}
+
+func (s *LokiSink) Close() error { return nil }
+
+func postJSON(url string, headers map[string]string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "pulsewatch")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
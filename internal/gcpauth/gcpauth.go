@@ -0,0 +1,254 @@
+// Package gcpauth gets OAuth2 access tokens for Google Cloud APIs, enough
+// for the few Google APIs pulsewatch talks to without pulling in the SDK.
+package gcpauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTokenURL is Google's OAuth2 token endpoint.
+const defaultTokenURL = "https://oauth2.googleapis.com/token"
+
+// Source returns access tokens, fetching a new one shortly before the
+// current one expires. It is safe for concurrent use.
+type Source struct {
+	// Project is the project of the credentials, if they name one.
+	Project string
+
+	fetch  func(ctx context.Context) (string, time.Duration, error)
+	mu     sync.Mutex
+	token  string
+	expiry time.Time // Zero for a token that doesn't expire
+}
+
+// keyFile is a service account key or an authorized user's credentials,
+// as written by gcloud auth application-default login.
+type keyFile struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+	QuotaProject string `json:"quota_project_id"`
+}
+
+// FromEnv finds credentials for scope the way Google's client libraries
+// do, in order: a token in $GOOGLE_OAUTH_ACCESS_TOKEN (e.g. from gcloud auth
+// print-access-token), the key file in $GOOGLE_APPLICATION_CREDENTIALS,
+// gcloud's application default credentials, then the metadata server of
+// the Compute Engine, GKE, or Cloud Run instance pulsewatch runs on.
+func FromEnv(scope string) (*Source, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	project := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return &Source{Project: project, token: token}, nil
+	}
+
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			if adc := filepath.Join(dir, "gcloud", "application_default_credentials.json"); fileExists(adc) {
+				path = adc
+			}
+		}
+	}
+	if path != "" {
+		s, err := fromKeyFile(path, scope, client)
+		if err != nil {
+			return nil, fmt.Errorf("credentials %s: %w", path, err)
+		}
+		if project != "" {
+			s.Project = project
+		}
+		return s, nil
+	}
+
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+	return &Source{Project: project, fetch: func(ctx context.Context) (string, time.Duration, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+host+"/computeMetadata/v1/instance/service-accounts/default/token?scopes="+url.QueryEscape(scope), nil)
+		if err != nil {
+			return "", 0, err
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+		token, ttl, err := requestToken(client, req)
+		if err != nil {
+			return "", 0, fmt.Errorf("no credentials found and the metadata server failed: %w", err)
+		}
+		return token, ttl, nil
+	}}, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// fromKeyFile reads a service account key or authorized user file.
+func fromKeyFile(path, scope string, client *http.Client) (*Source, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var kf keyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, err
+	}
+	tokenURL := kf.TokenURI
+	if tokenURL == "" {
+		tokenURL = defaultTokenURL
+	}
+
+	switch kf.Type {
+	case "service_account":
+		key, err := parseKey(kf.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		return &Source{Project: kf.ProjectID, fetch: func(ctx context.Context) (string, time.Duration, error) {
+			assertion, err := signJWT(key, kf.ClientEmail, scope, tokenURL, time.Now())
+			if err != nil {
+				return "", 0, err
+			}
+			return postToken(ctx, client, tokenURL, url.Values{
+				"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+				"assertion":  {assertion},
+			})
+		}}, nil
+	case "authorized_user":
+		return &Source{Project: kf.QuotaProject, fetch: func(ctx context.Context) (string, time.Duration, error) {
+			return postToken(ctx, client, tokenURL, url.Values{
+				"grant_type":    {"refresh_token"},
+				"client_id":     {kf.ClientID},
+				"client_secret": {kf.ClientSecret},
+				"refresh_token": {kf.RefreshToken},
+			})
+		}}, nil
+	}
+	return nil, fmt.Errorf("unsupported credentials type %q", kf.Type)
+}
+
+func parseKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("private_key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if key, err1 := x509.ParsePKCS1PrivateKey(block.Bytes); err1 == nil {
+			return key, nil
+		}
+		return nil, fmt.Errorf("private_key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private_key is not an RSA key")
+	}
+	return key, nil
+}
+
+// signJWT builds the RS256-signed assertion exchanged for a token.
+func signJWT(key *rsa.PrivateKey, email, scope, audience string, now time.Time) (string, error) {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   email,
+		"scope": scope,
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+func postToken(ctx context.Context, client *http.Client, tokenURL string, form url.Values) (string, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return requestToken(client, req)
+}
+
+// requestToken sends req and decodes the token response.
+func requestToken(client *http.Client, req *http.Request) (string, time.Duration, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", 0, fmt.Errorf("token request: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var t struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return "", 0, fmt.Errorf("failed to decode token: %w", err)
+	}
+	if t.AccessToken == "" {
+		return "", 0, errors.New("token response has no access_token")
+	}
+	return t.AccessToken, time.Duration(t.ExpiresIn) * time.Second, nil
+}
+
+// Token returns a valid access token.
+func (s *Source) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && (s.expiry.IsZero() || time.Now().Before(s.expiry.Add(-time.Minute))) {
+		return s.token, nil
+	}
+	if s.fetch == nil {
+		return s.token, nil
+	}
+	token, ttl, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.token, s.expiry = token, time.Now().Add(ttl)
+	return token, nil
+}
+
+// Authorize sets req's Authorization header.
+func (s *Source) Authorize(req *http.Request) error {
+	token, err := s.Token(req.Context())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
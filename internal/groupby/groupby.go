@@ -0,0 +1,190 @@
+// Package groupby evaluates grouping-key expressions such as
+// "{method} {endpoint|segments:2}" against log entries.
+//
+// An expression is literal text with {placeholders}. A placeholder names a
+// built-in attribute (endpoint, method, status, level, tenant, cache_status,
+// source, grpc_status, operation, version, category) or any parsed field,
+// optionally followed by filters:
+//
+//	lower, upper   change case
+//	class          status code class, e.g. 404 -> 4xx
+//	segments:N     first N path segments, e.g. /api/v1/users/42 -> /api/v1
+//	default:TEXT   TEXT when the value is empty
+//
+// An entry for which every placeholder is empty has no group.
+package groupby
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// Expr is a parsed grouping expression.
+type Expr struct {
+	source string
+	parts  []part
+}
+
+type part struct {
+	literal string
+	name    string // Attribute or field; empty for literal parts
+	filters []filter
+}
+
+type filter func(string) string
+
+// Parse compiles an expression.
+func Parse(source string) (*Expr, error) {
+	e := &Expr{source: source}
+	rest := source
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			e.parts = append(e.parts, part{literal: rest})
+			break
+		}
+		if open > 0 {
+			e.parts = append(e.parts, part{literal: rest[:open]})
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed '{' in %q", source)
+		}
+		p, err := parsePlaceholder(rest[open+1 : open+end])
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", source, err)
+		}
+		e.parts = append(e.parts, p)
+		rest = rest[open+end+1:]
+	}
+
+	for _, p := range e.parts {
+		if p.name != "" {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("%q has no {placeholder}", source)
+}
+
+func parsePlaceholder(s string) (part, error) {
+	pieces := strings.Split(s, "|")
+	p := part{name: strings.TrimSpace(pieces[0])}
+	if p.name == "" {
+		return p, fmt.Errorf("empty placeholder")
+	}
+	for _, spec := range pieces[1:] {
+		f, err := parseFilter(strings.TrimSpace(spec))
+		if err != nil {
+			return p, err
+		}
+		p.filters = append(p.filters, f)
+	}
+	return p, nil
+}
+
+func parseFilter(spec string) (filter, error) {
+	name, arg, _ := strings.Cut(spec, ":")
+	switch name {
+	case "lower":
+		return strings.ToLower, nil
+	case "upper":
+		return strings.ToUpper, nil
+	case "class":
+		return func(v string) string {
+			if code, err := strconv.Atoi(v); err == nil && code >= 100 && code < 600 {
+				return strconv.Itoa(code/100) + "xx"
+			}
+			return v
+		}, nil
+	case "segments":
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("segments needs a positive count, got %q", arg)
+		}
+		return func(v string) string { return pathSegments(v, n) }, nil
+	case "default":
+		return func(v string) string {
+			if v == "" {
+				return arg
+			}
+			return v
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown filter %q", name)
+}
+
+// pathSegments keeps the first n segments of a URL path, dropping any query.
+func pathSegments(path string, n int) string {
+	path, _, _ = strings.Cut(path, "?")
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(segments) > n {
+		segments = segments[:n]
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// String returns the source expression.
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Eval returns the entry's group key, or "" when it has no group.
+func (e *Expr) Eval(entry types.LogEntry) string {
+	var b strings.Builder
+	found := false
+	for _, p := range e.parts {
+		if p.name == "" {
+			b.WriteString(p.literal)
+			continue
+		}
+		v := attribute(entry, p.name)
+		for _, f := range p.filters {
+			v = f(v)
+		}
+		if v != "" {
+			found = true
+		}
+		b.WriteString(v)
+	}
+	if !found {
+		return ""
+	}
+	return b.String()
+}
+
+func attribute(entry types.LogEntry, name string) string {
+	switch name {
+	case "endpoint":
+		return entry.Endpoint
+	case "method":
+		return entry.Method
+	case "status":
+		if entry.StatusCode == 0 {
+			return ""
+		}
+		return strconv.Itoa(entry.StatusCode)
+	case "level":
+		return string(entry.Level)
+	case "tenant":
+		return entry.Tenant
+	case "cache_status":
+		return entry.CacheStatus
+	case "source":
+		return entry.Source
+	case "grpc_status":
+		return entry.GRPCStatus
+	case "operation":
+		return entry.Operation
+	case "version":
+		return entry.Version
+	case "category":
+		return entry.ErrorCategory
+	}
+	if v, ok := entry.Fields[name]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
@@ -0,0 +1,148 @@
+package ingest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	checkpointInterval = 5 * time.Second // Minimum gap between saves while tailing
+	fingerprintBytes   = 1024            // Leading bytes hashed to recognize a file
+)
+
+// Checkpoint is how far a tailed file was read.
+type Checkpoint struct {
+	Offset int64 `json:"offset"`
+	// Fingerprint hashes the file's first bytes (up to Offset), so a file
+	// replaced by rotation isn't resumed at the old file's offset.
+	Fingerprint string    `json:"fingerprint"`
+	Updated     time.Time `json:"updated"`
+}
+
+// Checkpoints stores per-file offsets in a JSON sidecar file, so that a
+// restarted watch resumes where the last one stopped.
+type Checkpoints struct {
+	path string
+
+	mu    sync.Mutex
+	files map[string]Checkpoint // Absolute path -> checkpoint
+}
+
+// OpenCheckpoints loads the checkpoints saved at path; a missing file has
+// none.
+func OpenCheckpoints(path string) (*Checkpoints, error) {
+	c := &Checkpoints{path: path, files: make(map[string]Checkpoint)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &c.files); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Get returns the checkpoint for file, if one was saved.
+func (c *Checkpoints) Get(file string) (Checkpoint, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cp, ok := c.files[checkpointKey(file)]
+	return cp, ok
+}
+
+// Set records the checkpoint for file and saves all of them, replacing the
+// sidecar file atomically.
+func (c *Checkpoints) Set(file string, cp Checkpoint) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files[checkpointKey(file)] = cp
+	data, err := json.MarshalIndent(c.files, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+func checkpointKey(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		return abs
+	}
+	return file
+}
+
+// fingerprint hashes the first min(n, fingerprintBytes) bytes of file.
+func fingerprint(file *os.File, n int64) (string, error) {
+	buf := make([]byte, min(n, fingerprintBytes))
+	if _, err := file.ReadAt(buf, 0); err != nil && err != io.EOF {
+		return "", err
+	}
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// SetCheckpoints makes the tailer resume from the offset saved for its file
+// and save its progress there. It has no effect with InitialScan.
+func (i *FileIngester) SetCheckpoints(c *Checkpoints) {
+	i.checkpoints = c
+}
+
+// resumeOffset returns where tailing starts: the saved offset if the file
+// is the one it was saved for, the start if the file was rotated or
+// truncated since, and the end without a checkpoint.
+func (i *FileIngester) resumeOffset(file *os.File) int64 {
+	end, _ := file.Seek(0, io.SeekEnd)
+	if i.checkpoints == nil {
+		return end
+	}
+	cp, ok := i.checkpoints.Get(i.FilePath)
+	if !ok {
+		return end
+	}
+	if cp.Offset > end {
+		return 0
+	}
+	if fp, err := fingerprint(file, cp.Offset); err != nil || fp != cp.Fingerprint {
+		return 0
+	}
+	return cp.Offset
+}
+
+// SaveCheckpoint records how far the file was read. The tailer calls it
+// periodically; call it on shutdown to save the final offset.
+func (i *FileIngester) SaveCheckpoint() {
+	if i.checkpoints == nil || i.InitialScan {
+		return
+	}
+	i.mu.Lock()
+	offset := i.offset
+	i.mu.Unlock()
+
+	file, err := os.Open(i.FilePath)
+	if err != nil {
+		return // Removed; there is nothing to resume
+	}
+	defer file.Close()
+	fp, err := fingerprint(file, offset)
+	if err == nil {
+		err = i.checkpoints.Set(i.FilePath, Checkpoint{Offset: offset, Fingerprint: fp, Updated: time.Now()})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving checkpoint: %v\n", err)
+	}
+}
@@ -0,0 +1,229 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/awssig"
+	"github.com/nitis/pulseWatch/internal/crash"
+)
+
+// cloudWatchLateArrival is how far behind the newest event each poll starts
+// again, since CloudWatch may return events a little after their
+// timestamp. Events seen before are skipped by ID.
+const cloudWatchLateArrival = 30 * time.Second
+
+// CloudWatchIngester reads a CloudWatch Logs group through the
+// FilterLogEvents API. With InitialScan it reads the events since Since
+// and stops; otherwise it polls for new events.
+type CloudWatchIngester struct {
+	Group        string
+	StreamPrefix string // Only read streams whose name starts with this
+	Pattern      string // Optional CloudWatch filter pattern
+	InitialScan  bool
+	// Since is how far back reading starts; 0 reads the group's whole
+	// retention with InitialScan and only new events when following.
+	Since        time.Duration
+	PollInterval time.Duration
+	region       string
+	endpoint     string
+	creds        awssig.Credentials
+	client       *http.Client
+	guard        *Guard
+}
+
+// NewCloudWatchIngester creates a CloudWatchIngester for group. An empty
+// region comes from the environment, defaulting to us-east-1; endpoint
+// overrides the regional endpoint, e.g. for LocalStack. Credentials come
+// from the standard AWS_* variables.
+func NewCloudWatchIngester(group, streamPrefix, pattern, region, endpoint string, initialScan bool, since, pollInterval time.Duration, guard *Guard) (*CloudWatchIngester, error) {
+	if group == "" {
+		return nil, fmt.Errorf("no CloudWatch log group; set ingest.cloudwatch.group or pass --log-group")
+	}
+	creds, err := awssig.CredentialsFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("CloudWatch Logs: %w", err)
+	}
+	if region == "" {
+		region = awssig.RegionFromEnv("us-east-1")
+	}
+	if endpoint == "" {
+		endpoint = "https://logs." + region + ".amazonaws.com"
+	} else if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	return &CloudWatchIngester{
+		Group:        group,
+		StreamPrefix: streamPrefix,
+		Pattern:      pattern,
+		InitialScan:  initialScan,
+		Since:        since,
+		PollInterval: pollInterval,
+		region:       region,
+		endpoint:     strings.TrimSuffix(endpoint, "/"),
+		creds:        creds,
+		client:       &http.Client{Timeout: 30 * time.Second},
+		guard:        guard,
+	}, nil
+}
+
+// cloudWatchEvent is the part of a FilterLogEvents event that is used.
+type cloudWatchEvent struct {
+	EventID   string `json:"eventId"`
+	Stream    string `json:"logStreamName"`
+	Timestamp int64  `json:"timestamp"` // Unix milliseconds
+	Message   string `json:"message"`
+}
+
+// Ingest streams the group's log lines without their metadata.
+func (i *CloudWatchIngester) Ingest(ctx context.Context) (<-chan string, error) {
+	records, err := i.IngestRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return recordLines(records), nil
+}
+
+// IngestRecords streams the group's events, oldest first, each tagged with
+// the log_stream field. It fails if the group can't be read.
+func (i *CloudWatchIngester) IngestRecords(ctx context.Context) (<-chan Record, error) {
+	end := time.Now()
+	start := end.Add(-i.Since)
+	if i.InitialScan && i.Since == 0 {
+		start = time.UnixMilli(0)
+	}
+	// Read the first page up front so a wrong group or credentials fail now
+	events, token, err := i.filter(ctx, start, end, "")
+	if err != nil {
+		return nil, err
+	}
+
+	records := make(chan Record, 1000)
+	go func() {
+		defer close(records)
+		defer crash.Recover("cloudwatch reader")
+		seen := make(map[string]int64) // Event ID -> timestamp, for events in the late-arrival window
+		from := start                  // Never look back past where reading began
+		newest := start.UnixMilli()
+		for {
+			for _, ev := range events {
+				if _, dup := seen[ev.EventID]; dup {
+					continue
+				}
+				seen[ev.EventID] = ev.Timestamp
+				newest = max(newest, ev.Timestamp)
+				if !i.send(ctx, ev, records) {
+					return
+				}
+			}
+			if token != "" {
+				events, token, err = i.filter(ctx, start, end, token)
+			} else {
+				if i.InitialScan {
+					return
+				}
+				select {
+				case <-time.After(i.PollInterval):
+				case <-ctx.Done():
+					return
+				}
+				start = time.UnixMilli(newest).Add(-cloudWatchLateArrival)
+				if start.Before(from) {
+					start = from
+				}
+				end = time.Now()
+				for id, ts := range seen {
+					if ts < start.UnixMilli() {
+						delete(seen, id)
+					}
+				}
+				events, token, err = i.filter(ctx, start, end, "")
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				fmt.Fprintf(os.Stderr, "Error reading CloudWatch log group %s: %v\n", i.Group, err)
+				events, token = nil, ""
+			}
+		}
+	}()
+	return records, nil
+}
+
+// send passes an event's lines (multi-line messages are split) to records.
+// It returns false once ctx is done.
+func (i *CloudWatchIngester) send(ctx context.Context, ev cloudWatchEvent, records chan<- Record) bool {
+	fields := map[string]string{"log_stream": ev.Stream}
+	ok := true
+	i.guard.ScanLines(strings.NewReader(ev.Message), func(line string) bool {
+		select {
+		case records <- Record{Line: line, Fields: fields}:
+			return true
+		case <-ctx.Done():
+			ok = false
+			return false
+		}
+	})
+	return ok
+}
+
+// filter fetches one page of events between start and end.
+func (i *CloudWatchIngester) filter(ctx context.Context, start, end time.Time, token string) ([]cloudWatchEvent, string, error) {
+	query := map[string]interface{}{
+		"logGroupName": i.Group,
+		"startTime":    start.UnixMilli(),
+		"endTime":      end.UnixMilli(),
+	}
+	if i.StreamPrefix != "" {
+		query["logStreamNamePrefix"] = i.StreamPrefix
+	}
+	if i.Pattern != "" {
+		query["filterPattern"] = i.Pattern
+	}
+	if token != "" {
+		query["nextToken"] = token
+	}
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Logs_20140328.FilterLogEvents")
+	awssig.Sign(req, awssig.HashPayload(body), "logs", i.region, i.creds, time.Now())
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var awsErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&awsErr) == nil && awsErr.Type != "" {
+			return nil, "", fmt.Errorf("%s: %s: %s", resp.Status, awsErr.Type[strings.LastIndex(awsErr.Type, "#")+1:], awsErr.Message)
+		}
+		return nil, "", fmt.Errorf("FilterLogEvents: %s", resp.Status)
+	}
+	var page struct {
+		Events    []cloudWatchEvent `json:"events"`
+		NextToken string            `json:"nextToken"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, "", fmt.Errorf("failed to decode log events: %w", err)
+	}
+	return page.Events, page.NextToken, nil
+}
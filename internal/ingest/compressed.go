@@ -0,0 +1,70 @@
+package ingest
+
+import (
+	"compress/bzip2"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compressed reports whether path names a file OpenLog decompresses: .gz,
+// .zst, or .bz2, as left by logrotate.
+func Compressed(path string) bool {
+	for _, ext := range []string{".gz", ".zst", ".bz2"} {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenLog opens a log file for reading from the start, decompressing it if
+// its name ends in .gz, .zst, or .bz2.
+func OpenLog(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return decompress(path, file)
+}
+
+// decompress wraps r in the decompressor its name calls for. r is closed
+// with the result, or right away if the data isn't valid.
+func decompress(name string, r io.ReadCloser) (io.ReadCloser, error) {
+	switch {
+	case strings.HasSuffix(name, ".gz"):
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		return &decompressed{Reader: gz, close: gz.Close, source: r}, nil
+	case strings.HasSuffix(name, ".zst"):
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		return &decompressed{Reader: zr, close: func() error { zr.Close(); return nil }, source: r}, nil
+	case strings.HasSuffix(name, ".bz2"):
+		return &decompressed{Reader: bzip2.NewReader(r), source: r}, nil
+	}
+	return r, nil
+}
+
+// decompressed reads through a decompressor and closes it with its source.
+type decompressed struct {
+	io.Reader
+	close  func() error
+	source io.Closer
+}
+
+func (d *decompressed) Close() error {
+	if d.close != nil {
+		d.close()
+	}
+	return d.source.Close()
+}
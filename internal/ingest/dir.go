@@ -0,0 +1,204 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/crash"
+)
+
+// ProcessedFiles remembers which batch files were read in full, so that a
+// restarted watch doesn't read them again. The engine stores them in its
+// database.
+type ProcessedFiles interface {
+	FileProcessed(path, fingerprint string) (bool, error)
+	MarkFileProcessed(path, fingerprint string, size int64, lines int) error
+}
+
+// DirIngester reads the files dropped into a directory, such as hourly batch
+// exports, each once and in full. A file counts as complete once it has gone
+// unmodified for Settle; hidden files, which writers often rename into
+// place, are skipped. Files ending in .gz, .zst, or .bz2 are decompressed.
+// With InitialScan it reads the files present and stops; otherwise it polls
+// the directory for new ones.
+type DirIngester struct {
+	Dir          string
+	Pattern      string // Glob the file names must match
+	Settle       time.Duration
+	PollInterval time.Duration
+	InitialScan  bool
+	guard        *Guard
+	tracker      ProcessedFiles
+	ready        chan struct{} // Closed by SetTracker
+}
+
+// NewDirIngester creates a DirIngester for dir. Reading starts once
+// SetTracker is called.
+func NewDirIngester(dir, pattern string, settle, pollInterval time.Duration, initialScan bool, guard *Guard) *DirIngester {
+	return &DirIngester{
+		Dir:          dir,
+		Pattern:      pattern,
+		Settle:       settle,
+		PollInterval: pollInterval,
+		InitialScan:  initialScan,
+		guard:        guard,
+		ready:        make(chan struct{}),
+	}
+}
+
+// SetTracker sets where processed files are recorded and starts reading.
+// It must be called once.
+func (i *DirIngester) SetTracker(t ProcessedFiles) {
+	i.tracker = t
+	close(i.ready)
+}
+
+// dirFile is a candidate file and the state it was listed in.
+type dirFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Ingest streams the files' lines without their names.
+func (i *DirIngester) Ingest(ctx context.Context) (<-chan string, error) {
+	records, err := i.IngestRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return recordLines(records), nil
+}
+
+// IngestRecords streams the files' lines, each tagged with the file field
+// of the file it came from, relative to Dir. Files are read one at a time,
+// oldest first. It fails if Dir isn't a readable directory.
+func (i *DirIngester) IngestRecords(ctx context.Context) (<-chan Record, error) {
+	if _, err := i.list(time.Now()); err != nil {
+		return nil, err
+	}
+
+	records := make(chan Record, 1000)
+	go func() {
+		defer close(records)
+		defer crash.Recover("directory reader")
+		select {
+		case <-i.ready:
+		case <-ctx.Done():
+			return
+		}
+		// Files already read or skipped this run, so they aren't hashed again
+		// on every poll
+		done := make(map[dirFile]bool)
+		for {
+			files, err := i.list(time.Now())
+			if err != nil && ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "Error listing %s: %v\n", i.Dir, err)
+			}
+			for _, f := range files {
+				if done[f] {
+					continue
+				}
+				done[f] = true
+				if err := i.read(ctx, f, records); err != nil && ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", f.path, err)
+				}
+				if ctx.Err() != nil {
+					return
+				}
+			}
+			if i.InitialScan {
+				return
+			}
+
+			select {
+			case <-time.After(i.PollInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return records, nil
+}
+
+// list returns the complete files in Dir matching Pattern, oldest first.
+// With InitialScan every file present counts as complete.
+func (i *DirIngester) list(now time.Time) ([]dirFile, error) {
+	entries, err := os.ReadDir(i.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []dirFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if ok, _ := filepath.Match(i.Pattern, name); !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.Size() == 0 {
+			continue
+		}
+		if !i.InitialScan && now.Sub(info.ModTime()) < i.Settle {
+			continue
+		}
+		files = append(files, dirFile{path: filepath.Join(i.Dir, name), size: info.Size(), modTime: info.ModTime()})
+	}
+	sort.Slice(files, func(a, b int) bool {
+		if !files[a].modTime.Equal(files[b].modTime) {
+			return files[a].modTime.Before(files[b].modTime)
+		}
+		return files[a].path < files[b].path
+	})
+	return files, nil
+}
+
+// read streams f's lines to records unless the tracker has it as
+// processed, and records it once every line was sent.
+func (i *DirIngester) read(ctx context.Context, f dirFile, records chan<- Record) error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	sum, err := fingerprint(file, f.size)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	key := checkpointKey(f.path)
+	if processed, err := i.tracker.FileProcessed(key, sum); err != nil || processed {
+		file.Close()
+		return err
+	}
+
+	body, err := decompress(f.path, file)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	name, err := filepath.Rel(i.Dir, f.path)
+	if err != nil {
+		name = f.path
+	}
+	fields := map[string]string{"file": name}
+	lines := 0
+	err = i.guard.ScanLines(body, func(line string) bool {
+		select {
+		case records <- Record{Line: line, Fields: fields}:
+			lines++
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+	if err != nil || ctx.Err() != nil {
+		return err
+	}
+	return i.tracker.MarkFileProcessed(key, sum, f.size, lines)
+}
@@ -0,0 +1,331 @@
+package ingest
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/crash"
+)
+
+// dockerPollInterval is how often the container list is checked for newly
+// started matches while following.
+const dockerPollInterval = 5 * time.Second
+
+// DockerIngester streams the logs of the containers selected by name and
+// label from the Docker Engine API, tagging each line with the container it
+// came from.
+type DockerIngester struct {
+	Names       []string // Container name filters; a container matching any is read
+	Labels      []string // Label filters, "key" or "key=value"; a container must match all
+	InitialScan bool     // Read the containers' existing logs and stop instead of following
+	host        string
+	client      *http.Client
+	base        string
+	guard       *Guard
+	reconnects  atomic.Int64 // Streams restarted for a container read before
+}
+
+// NewDockerIngester creates a DockerIngester for the daemon at host, e.g.
+// unix:///var/run/docker.sock or tcp://127.0.0.1:2375. An empty host uses
+// $DOCKER_HOST, then the default socket.
+func NewDockerIngester(host string, names, labels []string, initialScan bool, guard *Guard) (*DockerIngester, error) {
+	if host == "" {
+		host = os.Getenv("DOCKER_HOST")
+	}
+	if host == "" {
+		host = "unix:///var/run/docker.sock"
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", host, err)
+	}
+
+	i := &DockerIngester{Names: names, Labels: labels, InitialScan: initialScan, host: host, guard: guard}
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		i.client = &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}}
+		i.base = "http://docker"
+	case "tcp", "http":
+		i.client = &http.Client{}
+		i.base = "http://" + u.Host
+	default:
+		return nil, fmt.Errorf("docker host %q: scheme must be unix, tcp, or http", host)
+	}
+	return i, nil
+}
+
+// dockerContainer is the part of a container listing that is used.
+type dockerContainer struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Image  string            `json:"Image"`
+	Labels map[string]string `json:"Labels"`
+}
+
+// name returns the container's primary name without the leading slash.
+func (c dockerContainer) name() string {
+	if len(c.Names) == 0 {
+		return c.ID
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
+
+// Ingest streams the selected containers' log lines without their metadata.
+func (i *DockerIngester) Ingest(ctx context.Context) (<-chan string, error) {
+	records, err := i.IngestRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return recordLines(records), nil
+}
+
+// IngestRecords streams the selected containers' log lines, each tagged with
+// the container, container_id, image, and stream (stdout or stderr) fields.
+// It fails if the daemon can't be reached or nothing matches; when
+// following, containers started later are picked up as they appear.
+func (i *DockerIngester) IngestRecords(ctx context.Context) (<-chan Record, error) {
+	containers, err := i.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(containers) == 0 && i.InitialScan {
+		return nil, fmt.Errorf("no running container matches the docker selection")
+	}
+
+	records := make(chan Record, 1000)
+	var wg sync.WaitGroup
+	streaming := make(map[string]bool)
+	streamed := make(map[string]bool)
+	var mu sync.Mutex
+	start := func(c dockerContainer) {
+		mu.Lock()
+		defer mu.Unlock()
+		if streaming[c.ID] {
+			return
+		}
+		streaming[c.ID] = true
+		if streamed[c.ID] {
+			i.reconnects.Add(1)
+		}
+		streamed[c.ID] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer crash.Recover("docker container " + c.name())
+			if err := i.stream(ctx, c, records); err != nil && ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "Error reading logs of container %s: %v\n", c.name(), err)
+			}
+			mu.Lock()
+			delete(streaming, c.ID)
+			mu.Unlock()
+		}()
+	}
+	for _, c := range containers {
+		start(c)
+	}
+
+	go func() {
+		defer close(records)
+		defer crash.Recover("docker watcher")
+		if !i.InitialScan {
+			ticker := time.NewTicker(dockerPollInterval)
+			defer ticker.Stop()
+			for done := false; !done; {
+				select {
+				case <-ticker.C:
+					found, err := i.list(ctx)
+					if err != nil {
+						if ctx.Err() == nil {
+							fmt.Fprintf(os.Stderr, "Error listing containers: %v\n", err)
+						}
+						continue
+					}
+					for _, c := range found {
+						start(c)
+					}
+				case <-ctx.Done():
+					done = true
+				}
+			}
+		}
+		wg.Wait()
+	}()
+	return records, nil
+}
+
+// Health reports how often a container's log stream had to be restarted.
+func (i *DockerIngester) Health() Health {
+	return Health{LagBytes: -1, Reconnects: i.reconnects.Load()}
+}
+
+// list returns the running containers matching the name and label filters.
+func (i *DockerIngester) list(ctx context.Context) ([]dockerContainer, error) {
+	filters := map[string][]string{}
+	if len(i.Names) > 0 {
+		filters["name"] = i.Names
+	}
+	if len(i.Labels) > 0 {
+		filters["label"] = i.Labels
+	}
+	query := url.Values{}
+	if len(filters) > 0 {
+		data, err := json.Marshal(filters)
+		if err != nil {
+			return nil, err
+		}
+		query.Set("filters", string(data))
+	}
+
+	resp, err := i.get(ctx, "/containers/json?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var containers []dockerContainer
+	if err := json.NewDecoder(resp.Body).Decode(&containers); err != nil {
+		return nil, fmt.Errorf("failed to decode container list: %w", err)
+	}
+	return containers, nil
+}
+
+// stream copies one container's log lines to records until its log ends.
+func (i *DockerIngester) stream(ctx context.Context, c dockerContainer, records chan<- Record) error {
+	tty, err := i.hasTTY(ctx, c.ID)
+	if err != nil {
+		return err
+	}
+	query := url.Values{"stdout": {"1"}, "stderr": {"1"}}
+	if i.InitialScan {
+		query.Set("tail", "all")
+	} else {
+		query.Set("follow", "1")
+		query.Set("tail", "0")
+	}
+	resp, err := i.get(ctx, "/containers/"+c.ID+"/logs?"+query.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	fields := func(stream string) map[string]string {
+		id := c.ID
+		if len(id) > 12 {
+			id = id[:12]
+		}
+		return map[string]string{"container": c.name(), "container_id": id, "image": c.Image, "stream": stream}
+	}
+	send := func(fields map[string]string) func(string) bool {
+		return func(line string) bool {
+			select {
+			case records <- Record{Line: line, Fields: fields}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+	}
+
+	// A TTY's output is a single raw stream; otherwise stdout and stderr
+	// arrive multiplexed in frames
+	if tty {
+		return i.guard.ScanLines(resp.Body, send(fields("stdout")))
+	}
+	stdoutR, stdoutW := io.Pipe()
+	stderrR, stderrW := io.Pipe()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer crash.Recover("docker stdout reader")
+		i.guard.ScanLines(stdoutR, send(fields("stdout")))
+		stdoutR.CloseWithError(io.ErrClosedPipe)
+	}()
+	go func() {
+		defer wg.Done()
+		defer crash.Recover("docker stderr reader")
+		i.guard.ScanLines(stderrR, send(fields("stderr")))
+		stderrR.CloseWithError(io.ErrClosedPipe)
+	}()
+	err = demuxDockerStream(resp.Body, stdoutW, stderrW)
+	stdoutW.Close()
+	stderrW.Close()
+	wg.Wait()
+	return err
+}
+
+// demuxDockerStream splits Docker's multiplexed log stream: each frame is an
+// 8-byte header (stream type, three zero bytes, big-endian payload length)
+// followed by the payload.
+func demuxDockerStream(r io.Reader, stdout, stderr io.Writer) error {
+	var header [8]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		w := stdout
+		if header[0] == 2 {
+			w = stderr
+		}
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(w, r, size); err != nil {
+			if err == io.ErrClosedPipe {
+				return nil // The reader stopped, e.g. on shutdown
+			}
+			return err
+		}
+	}
+}
+
+func (i *DockerIngester) hasTTY(ctx context.Context, id string) (bool, error) {
+	resp, err := i.get(ctx, "/containers/"+id+"/json")
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	var info struct {
+		Config struct {
+			Tty bool `json:"Tty"`
+		} `json:"Config"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return false, fmt.Errorf("failed to decode container %s: %w", id, err)
+	}
+	return info.Config.Tty, nil
+}
+
+func (i *DockerIngester) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, i.base+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("docker API at %s: %w", i.host, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("docker API %s: %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
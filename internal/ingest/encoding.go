@@ -0,0 +1,90 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"unicode/utf16"
+)
+
+// Encoding is the character encoding of an input. Lines reach the parsers
+// as UTF-8 whatever it is.
+type Encoding int
+
+const (
+	// EncodingUTF8 is UTF-8 or ASCII. Lines that aren't valid UTF-8 are
+	// read as Latin-1 (ISO-8859-1) unless they look binary.
+	EncodingUTF8 Encoding = iota
+	// EncodingUTF16LE and EncodingUTF16BE are announced by a byte order
+	// mark, as IIS and many Windows applications write them.
+	EncodingUTF16LE
+	EncodingUTF16BE
+)
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// sniffBOM returns the encoding that a byte order mark at the start of data
+// announces and the mark's length: UTF-8 and 0 without one.
+func sniffBOM(data []byte) (Encoding, int) {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return EncodingUTF8, len(bomUTF8)
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return EncodingUTF16LE, len(bomUTF16LE)
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return EncodingUTF16BE, len(bomUTF16BE)
+	}
+	return EncodingUTF8, 0
+}
+
+// readBOM consumes a byte order mark at the start of r and returns the
+// encoding of the rest.
+func readBOM(r *bufio.Reader) Encoding {
+	start, _ := r.Peek(len(bomUTF8))
+	enc, n := sniffBOM(start)
+	r.Discard(n)
+	return enc
+}
+
+// fileEncoding returns the encoding that the byte order mark of file
+// announces and the mark's length.
+func fileEncoding(file *os.File) (Encoding, int) {
+	start := make([]byte, len(bomUTF8))
+	n, _ := file.ReadAt(start, 0)
+	return sniffBOM(start[:n])
+}
+
+// utf16LineEnd reports whether the 0x0A byte ending the first n bytes of a
+// UTF-16 line is a newline rather than half of another character.
+func utf16LineEnd(enc Encoding, n int) bool {
+	if enc == EncodingUTF16LE {
+		return n%2 == 1
+	}
+	return n%2 == 0
+}
+
+// decodeUTF16 converts UTF-16 text to UTF-8, ignoring a trailing odd byte.
+func decodeUTF16(data []byte, enc Encoding) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		lo, hi := data[2*i], data[2*i+1]
+		if enc == EncodingUTF16BE {
+			lo, hi = hi, lo
+		}
+		units[i] = uint16(lo) | uint16(hi)<<8
+	}
+	return string(utf16.Decode(units))
+}
+
+// latin1 converts Latin-1 text to UTF-8.
+func latin1(s string) string {
+	runes := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		runes[i] = rune(s[i])
+	}
+	return string(runes)
+}
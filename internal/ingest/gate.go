@@ -0,0 +1,141 @@
+package ingest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/crash"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// Gate lets ingestion be paused and resumed at runtime, e.g. to freeze the
+// dashboard while investigating. While paused, records are held, up to the
+// buffer size; then the inputs are no longer read, so files and sockets
+// keep the rest until it resumes.
+type Gate struct {
+	buffer int
+
+	mu      sync.Mutex
+	paused  bool
+	since   time.Time
+	held    []Record
+	release int           // Held records to pass on although paused
+	wake    chan struct{} // Signals a change to Run
+}
+
+// NewGate creates a Gate holding up to buffer records while paused.
+func NewGate(buffer int) *Gate {
+	return &Gate{buffer: buffer, wake: make(chan struct{}, 1)}
+}
+
+// Run passes records from in on until in closes and nothing is held, or
+// ctx is cancelled.
+func (g *Gate) Run(ctx context.Context, in <-chan Record) <-chan Record {
+	out := make(chan Record, 1000)
+	go func() {
+		defer close(out)
+		defer crash.Recover("ingestion gate")
+		for {
+			g.mu.Lock()
+			if len(g.held) > 0 && (!g.paused || g.release > 0) {
+				r := g.held[0]
+				g.held = g.held[1:]
+				if len(g.held) == 0 {
+					g.held = nil
+				}
+				g.release = max(g.release-1, 0)
+				g.mu.Unlock()
+				select {
+				case out <- r:
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+			paused, full, empty := g.paused, len(g.held) >= g.buffer, len(g.held) == 0
+			g.mu.Unlock()
+			if in == nil && empty {
+				return
+			}
+
+			source := in
+			if paused && full {
+				source = nil
+			}
+			select {
+			case r, ok := <-source:
+				if !ok {
+					in = nil
+					continue
+				}
+				g.mu.Lock()
+				if g.paused {
+					g.held = append(g.held, r)
+					g.mu.Unlock()
+					continue
+				}
+				g.mu.Unlock()
+				select {
+				case out <- r:
+				case <-ctx.Done():
+					return
+				}
+			case <-g.wake:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Pause holds records from now on. It returns false if already paused.
+func (g *Gate) Pause() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused {
+		return false
+	}
+	g.paused, g.since = true, time.Now()
+	g.signal()
+	return true
+}
+
+// Resume passes the held records on, then every new one. It returns how
+// many were held.
+func (g *Gate) Resume() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.paused, g.release = false, 0
+	g.signal()
+	return len(g.held)
+}
+
+// Flush passes the records held so far on but stays paused. It returns how
+// many there were.
+func (g *Gate) Flush() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.release = len(g.held)
+	g.signal()
+	return g.release
+}
+
+// State reports whether the gate is paused and what it holds.
+func (g *Gate) State() types.IngestState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	st := types.IngestState{Paused: g.paused, Held: len(g.held), Full: g.paused && len(g.held) >= g.buffer}
+	if g.paused {
+		st.Since = g.since
+	}
+	return st
+}
+
+func (g *Gate) signal() {
+	select {
+	case g.wake <- struct{}{}:
+	default:
+	}
+}
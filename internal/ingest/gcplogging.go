@@ -0,0 +1,331 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/crash"
+	"github.com/nitis/pulseWatch/internal/gcpauth"
+)
+
+// gcpLoggingScope is the OAuth2 scope for reading log entries.
+const gcpLoggingScope = "https://www.googleapis.com/auth/logging.read"
+
+// gcpLateArrival is how far behind the newest entry each poll starts again,
+// since Cloud Logging may return entries a little after their timestamp.
+// Entries seen before are skipped by insert ID.
+const gcpLateArrival = 30 * time.Second
+
+// GCPLoggingIngester reads Google Cloud Logging entries matching a filter
+// through the entries.list API. With InitialScan it reads the entries since
+// Since and stops; otherwise it polls for new entries.
+type GCPLoggingIngester struct {
+	Project     string
+	Filter      string // Logging query language, e.g. resource.type="cloud_run_revision"
+	InitialScan bool
+	// Since is how far back reading starts; 0 reads the API's default of
+	// the last 24 hours with InitialScan and only new entries when following.
+	Since        time.Duration
+	PollInterval time.Duration
+	endpoint     string
+	auth         *gcpauth.Source
+	client       *http.Client
+	guard        *Guard
+}
+
+// NewGCPLoggingIngester creates a GCPLoggingIngester. An empty project
+// comes from the credentials or $GOOGLE_CLOUD_PROJECT; endpoint overrides
+// logging.googleapis.com. Credentials are found as gcpauth.FromEnv does.
+func NewGCPLoggingIngester(project, filter, endpoint string, initialScan bool, since, pollInterval time.Duration, guard *Guard) (*GCPLoggingIngester, error) {
+	auth, err := gcpauth.FromEnv(gcpLoggingScope)
+	if err != nil {
+		return nil, fmt.Errorf("Cloud Logging: %w", err)
+	}
+	if project == "" {
+		project = auth.Project
+	}
+	if project == "" {
+		return nil, fmt.Errorf("no Google Cloud project; set ingest.gcp.project or pass --gcp-project")
+	}
+	if endpoint == "" {
+		endpoint = "https://logging.googleapis.com"
+	} else if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	return &GCPLoggingIngester{
+		Project:      project,
+		Filter:       filter,
+		InitialScan:  initialScan,
+		Since:        since,
+		PollInterval: pollInterval,
+		endpoint:     strings.TrimSuffix(endpoint, "/"),
+		auth:         auth,
+		client:       &http.Client{Timeout: 30 * time.Second},
+		guard:        guard,
+	}, nil
+}
+
+// gcpEntry is the part of a Cloud Logging LogEntry that is used.
+type gcpEntry struct {
+	LogName     string                 `json:"logName"`
+	InsertID    string                 `json:"insertId"`
+	Timestamp   time.Time              `json:"timestamp"`
+	Severity    string                 `json:"severity"`
+	TextPayload string                 `json:"textPayload"`
+	JSONPayload map[string]interface{} `json:"jsonPayload"`
+	Proto       map[string]interface{} `json:"protoPayload"`
+	Resource    struct {
+		Type string `json:"type"`
+	} `json:"resource"`
+	HTTPRequest *struct {
+		Method      string `json:"requestMethod"`
+		URL         string `json:"requestUrl"`
+		Status      int    `json:"status"`
+		Latency     string `json:"latency"` // e.g. "0.123s"
+		Protocol    string `json:"protocol"`
+		UserAgent   string `json:"userAgent"`
+		RemoteIP    string `json:"remoteIp"`
+		CacheHit    bool   `json:"cacheHit"`
+		CacheLookup bool   `json:"cacheLookup"`
+	} `json:"httpRequest"`
+}
+
+// Ingest streams the entries as JSON lines without their metadata.
+func (i *GCPLoggingIngester) Ingest(ctx context.Context) (<-chan string, error) {
+	records, err := i.IngestRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return recordLines(records), nil
+}
+
+// IngestRecords streams the matching entries, oldest first, as JSON lines
+// for the JSON parser, each tagged with the log_name and resource_type
+// fields. It fails if the entries can't be listed.
+func (i *GCPLoggingIngester) IngestRecords(ctx context.Context) (<-chan Record, error) {
+	end := time.Now()
+	var start time.Time
+	if i.Since > 0 || !i.InitialScan {
+		start = end.Add(-i.Since)
+	}
+	// List the first page up front so a wrong filter or credentials fail now
+	entries, token, err := i.list(ctx, start, end, "")
+	if err != nil {
+		return nil, err
+	}
+
+	records := make(chan Record, 1000)
+	go func() {
+		defer close(records)
+		defer crash.Recover("cloud logging reader")
+		seen := make(map[string]time.Time) // Log name and insert ID -> timestamp, for entries in the late-arrival window
+		from := start                      // Never look back past where reading began
+		newest := start
+		for {
+			for _, entry := range entries {
+				key := entry.LogName + "\x00" + entry.InsertID
+				if _, dup := seen[key]; dup {
+					continue
+				}
+				seen[key] = entry.Timestamp
+				if entry.Timestamp.After(newest) {
+					newest = entry.Timestamp
+				}
+				rec, ok := i.record(entry)
+				if !ok {
+					continue
+				}
+				select {
+				case records <- rec:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if token != "" {
+				entries, token, err = i.list(ctx, start, end, token)
+			} else {
+				if i.InitialScan {
+					return
+				}
+				select {
+				case <-time.After(i.PollInterval):
+				case <-ctx.Done():
+					return
+				}
+				start = newest.Add(-gcpLateArrival)
+				if start.Before(from) {
+					start = from
+				}
+				end = time.Now()
+				for key, ts := range seen {
+					if ts.Before(start) {
+						delete(seen, key)
+					}
+				}
+				entries, token, err = i.list(ctx, start, end, "")
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				fmt.Fprintf(os.Stderr, "Error reading Cloud Logging entries of %s: %v\n", i.Project, err)
+				entries, token = nil, ""
+			}
+		}
+	}()
+	return records, nil
+}
+
+// record maps entry onto the fields the JSON parser reads: timestamp,
+// level, message, and for HTTP requests method, path, status, latency,
+// protocol, and cache status. Other jsonPayload fields are kept as they are.
+func (i *GCPLoggingIngester) record(entry gcpEntry) (Record, bool) {
+	out := make(map[string]interface{}, len(entry.JSONPayload)+8)
+	for k, v := range entry.JSONPayload {
+		out[k] = v
+	}
+	out["timestamp"] = entry.Timestamp.Format(time.RFC3339Nano)
+	out["level"] = gcpLevel(entry.Severity)
+	out["severity"] = entry.Severity
+	message := gcpMessage(entry)
+	if req := entry.HTTPRequest; req != nil {
+		out["method"] = req.Method
+		out["path"] = req.URL
+		if u, err := url.Parse(req.URL); err == nil && u.Path != "" {
+			out["path"] = u.Path
+		}
+		if req.Status != 0 {
+			out["status"] = req.Status
+		}
+		if d, err := time.ParseDuration(req.Latency); err == nil {
+			out["latency"] = float64(d) / float64(time.Millisecond)
+		}
+		if req.Protocol != "" {
+			out["protocol"] = req.Protocol
+		}
+		if req.CacheLookup {
+			out["cache_status"] = "MISS"
+			if req.CacheHit {
+				out["cache_status"] = "HIT"
+			}
+		}
+		for key, value := range map[string]string{"user_agent": req.UserAgent, "remote_ip": req.RemoteIP} {
+			if value != "" {
+				out[key] = value
+			}
+		}
+		if message == "" {
+			message = strings.TrimSpace(fmt.Sprintf("%s %s %d", req.Method, req.URL, req.Status))
+		}
+	}
+	out["message"] = i.guard.Clip(message)
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return Record{}, false
+	}
+	logName := entry.LogName
+	if at := strings.LastIndex(logName, "/logs/"); at >= 0 {
+		logName, _ = url.PathUnescape(logName[at+len("/logs/"):])
+	}
+	return Record{Line: string(data), Fields: map[string]string{"log_name": logName, "resource_type": entry.Resource.Type}}, true
+}
+
+// gcpMessage picks an entry's message from its payload.
+func gcpMessage(entry gcpEntry) string {
+	if entry.TextPayload != "" {
+		return entry.TextPayload
+	}
+	for _, key := range []string{"message", "msg"} {
+		if s, ok := entry.JSONPayload[key].(string); ok {
+			return s
+		}
+	}
+	if entry.Proto != nil {
+		// Audit logs: the API method and the resource it acted on
+		method, _ := entry.Proto["methodName"].(string)
+		resource, _ := entry.Proto["resourceName"].(string)
+		return strings.TrimSpace(method + " " + resource)
+	}
+	return ""
+}
+
+// gcpLevel maps a Cloud Logging severity onto the levels the JSON parser
+// knows.
+func gcpLevel(severity string) string {
+	switch severity {
+	case "ERROR", "CRITICAL", "ALERT", "EMERGENCY":
+		return "error"
+	case "WARNING":
+		return "warning"
+	case "DEBUG":
+		return "debug"
+	}
+	return "info"
+}
+
+// list fetches one page of entries between start (unbounded if zero) and
+// end.
+func (i *GCPLoggingIngester) list(ctx context.Context, start, end time.Time, token string) ([]gcpEntry, string, error) {
+	filter := fmt.Sprintf(`timestamp <= "%s"`, end.UTC().Format(time.RFC3339Nano))
+	if !start.IsZero() {
+		filter = fmt.Sprintf(`timestamp >= "%s" AND %s`, start.UTC().Format(time.RFC3339Nano), filter)
+	}
+	if i.Filter != "" {
+		filter = "(" + i.Filter + ") AND " + filter
+	}
+	query := map[string]interface{}{
+		"resourceNames": []string{"projects/" + i.Project},
+		"filter":        filter,
+		"orderBy":       "timestamp asc",
+		"pageSize":      1000,
+	}
+	if token != "" {
+		query["pageToken"] = token
+	}
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.endpoint+"/v2/entries:list", bytes.NewReader(body))
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := i.auth.Authorize(req); err != nil {
+		return nil, "", err
+	}
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&apiErr) == nil && apiErr.Error.Message != "" {
+			return nil, "", fmt.Errorf("entries.list: %s: %s", resp.Status, apiErr.Error.Message)
+		}
+		return nil, "", fmt.Errorf("entries.list: %s", resp.Status)
+	}
+	var page struct {
+		Entries       []gcpEntry `json:"entries"`
+		NextPageToken string     `json:"nextPageToken"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, "", fmt.Errorf("failed to decode log entries: %w", err)
+	}
+	return page.Entries, page.NextPageToken, nil
+}
@@ -0,0 +1,272 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/crash"
+)
+
+const (
+	// maxGELFChunks is the most chunks a GELF message may be split into.
+	maxGELFChunks = 128
+	// gelfChunkTimeout is how long the chunks of a message are kept waiting
+	// for the rest, as in the GELF specification.
+	gelfChunkTimeout = 5 * time.Second
+	// maxGELFPending bounds the messages being reassembled at once.
+	maxGELFPending = 1000
+	// maxGELFMessage bounds a decompressed message; larger ones are dropped.
+	maxGELFMessage = 8 << 20
+)
+
+// gelfChunkMagic starts every chunk of a chunked GELF message.
+var gelfChunkMagic = []byte{0x1e, 0x0f}
+
+// GELFIngester receives Graylog Extended Log Format messages over UDP, as
+// sent by Docker's gelf log driver, Graylog sidecars, and most logging
+// libraries. Chunked messages are reassembled and gzip or zlib compressed
+// ones decompressed.
+type GELFIngester struct {
+	Addr  string
+	JSON  bool // Hand the parsers a JSON line instead of short_message
+	guard *Guard
+}
+
+// NewGELFIngester creates a GELFIngester listening on addr. With json, each
+// message becomes a JSON line with its timestamp, level name, host, message,
+// and custom fields without their leading underscore, so _status and
+// _latency are read like any JSON log. Otherwise short_message goes to the
+// parsers and the other fields are attached to the entry.
+func NewGELFIngester(addr string, json bool, guard *Guard) *GELFIngester {
+	return &GELFIngester{Addr: addr, JSON: json, guard: guard}
+}
+
+// Ingest receives GELF messages without their fields.
+func (i *GELFIngester) Ingest(ctx context.Context) (<-chan string, error) {
+	records, err := i.IngestRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return recordLines(records), nil
+}
+
+// IngestRecords listens on Addr and streams the messages it receives. The
+// channel closes when ctx is cancelled.
+func (i *GELFIngester) IngestRecords(ctx context.Context) (<-chan Record, error) {
+	conn, err := net.ListenPacket("udp", i.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("gelf: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	records := make(chan Record, 1000)
+	go func() {
+		defer close(records)
+		defer crash.Recover("gelf listener")
+		chunks := newGELFChunks()
+		buf := make([]byte, 65536)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+					fmt.Fprintf(os.Stderr, "Error receiving GELF: %v\n", err)
+					continue
+				}
+				return
+			}
+			packet := buf[:n]
+			if bytes.HasPrefix(packet, gelfChunkMagic) {
+				if packet = chunks.add(packet, time.Now()); packet == nil {
+					continue
+				}
+			}
+			rec, err := i.record(packet)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading GELF from %s: %v\n", addr, err)
+				continue
+			}
+			if rec.Line == "" {
+				continue
+			}
+			select {
+			case records <- rec:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return records, nil
+}
+
+// record decompresses and decodes a complete message. Messages that the
+// guard filters out come back with an empty line.
+func (i *GELFIngester) record(payload []byte) (Record, error) {
+	data, err := gelfDecompress(payload)
+	if err != nil {
+		return Record{}, err
+	}
+	var msg map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&msg); err != nil {
+		return Record{}, fmt.Errorf("decoding message: %w", err)
+	}
+	short, _ := msg["short_message"].(string)
+	if short == "" {
+		return Record{}, fmt.Errorf("message has no short_message")
+	}
+	short = i.guard.Clip(short)
+	if !i.guard.Accept(short) {
+		return Record{}, nil
+	}
+
+	level := "info"
+	if l, ok := msg["level"].(json.Number); ok {
+		level = journalLevel(l.String())
+	}
+	if !i.JSON {
+		fields := map[string]string{"level": level}
+		for key, value := range msg {
+			if name, ok := gelfField(key); ok {
+				fields[name] = fmt.Sprint(value)
+			}
+		}
+		if host, ok := msg["host"].(string); ok {
+			fields["host"] = host
+		}
+		return Record{Line: short, Fields: fields}, nil
+	}
+
+	out := map[string]interface{}{}
+	for key, value := range msg {
+		if name, ok := gelfField(key); ok {
+			out[name] = value
+		}
+	}
+	out["message"] = short
+	out["level"] = level
+	if host, ok := msg["host"].(string); ok {
+		out["host"] = host
+	}
+	if full, ok := msg["full_message"].(string); ok {
+		out["full_message"] = i.guard.Clip(full)
+	}
+	if ts, ok := msg["timestamp"].(json.Number); ok {
+		if secs, err := strconv.ParseFloat(ts.String(), 64); err == nil {
+			out["timestamp"] = time.UnixMicro(int64(secs * 1e6)).UTC().Format(time.RFC3339Nano)
+		}
+	}
+	line, err := json.Marshal(out)
+	if err != nil {
+		return Record{}, err
+	}
+	return Record{Line: string(line)}, nil
+}
+
+// gelfField returns the name of a custom field ("_status" is status). _id
+// is reserved by GELF and skipped.
+func gelfField(key string) (string, bool) {
+	name, ok := strings.CutPrefix(key, "_")
+	if !ok || name == "" || name == "id" {
+		return "", false
+	}
+	return name, true
+}
+
+// gelfDecompress returns the JSON of a message, which may be gzip or zlib
+// compressed or sent as is.
+func gelfDecompress(payload []byte) ([]byte, error) {
+	var r io.ReadCloser
+	var err error
+	switch {
+	case len(payload) >= 2 && payload[0] == 0x1f && payload[1] == 0x8b:
+		r, err = gzip.NewReader(bytes.NewReader(payload))
+	case len(payload) >= 2 && payload[0] == 0x78 && binary.BigEndian.Uint16(payload)%31 == 0:
+		r, err = zlib.NewReader(bytes.NewReader(payload))
+	default:
+		return payload, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decompressing message: %w", err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(io.LimitReader(r, maxGELFMessage+1))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing message: %w", err)
+	}
+	if len(data) > maxGELFMessage {
+		return nil, fmt.Errorf("message is over %d bytes", maxGELFMessage)
+	}
+	return data, nil
+}
+
+// gelfChunks reassembles chunked messages. Each chunk is the magic bytes,
+// an 8-byte message ID, its sequence number, the sequence count, and part
+// of the payload.
+type gelfChunks struct {
+	pending map[[8]byte]*gelfMessage
+}
+
+type gelfMessage struct {
+	parts    [][]byte
+	received int
+	first    time.Time
+}
+
+func newGELFChunks() *gelfChunks {
+	return &gelfChunks{pending: make(map[[8]byte]*gelfMessage)}
+}
+
+// add stores a chunk and returns the whole payload once every chunk of its
+// message has arrived, or nil. Messages still incomplete after
+// gelfChunkTimeout are dropped.
+func (c *gelfChunks) add(chunk []byte, now time.Time) []byte {
+	for id, m := range c.pending {
+		if now.Sub(m.first) > gelfChunkTimeout {
+			delete(c.pending, id)
+		}
+	}
+	if len(chunk) < 12 {
+		return nil
+	}
+	var id [8]byte
+	copy(id[:], chunk[2:10])
+	seq, count := int(chunk[10]), int(chunk[11])
+	if count == 0 || count > maxGELFChunks || seq >= count {
+		return nil
+	}
+
+	m, ok := c.pending[id]
+	if !ok {
+		if len(c.pending) >= maxGELFPending {
+			return nil
+		}
+		m = &gelfMessage{parts: make([][]byte, count), first: now}
+		c.pending[id] = m
+	}
+	if len(m.parts) != count || m.parts[seq] != nil {
+		return nil
+	}
+	m.parts[seq] = append([]byte(nil), chunk[12:]...)
+	m.received++
+	if m.received < count {
+		return nil
+	}
+	delete(c.pending, id)
+	return bytes.Join(m.parts, nil)
+}
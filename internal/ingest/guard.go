@@ -0,0 +1,192 @@
+package ingest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// binaryControlRatio is the share of control characters and invalid UTF-8
+// above which a line is treated as binary data.
+const binaryControlRatio = 0.1
+
+// Guard keeps very long lines and binary data away from the parsers: lines
+// are cut at a maximum length instead of failing the read, and lines that
+// look binary are skipped. It counts both so they can be reported.
+type Guard struct {
+	maxLineLength int
+
+	mu         sync.Mutex
+	truncated  int
+	binary     int
+	transcoded int // Lines converted to UTF-8 from UTF-16 or Latin-1
+}
+
+// NewGuard creates a Guard that cuts lines at maxLineLength bytes.
+func NewGuard(maxLineLength int) *Guard {
+	return &Guard{maxLineLength: maxLineLength}
+}
+
+// ReadLine reads the next line from r without its line ending, converting
+// it from enc to UTF-8. Bytes beyond the maximum line length are discarded
+// and the line counted as truncated. n is the number of bytes consumed. If
+// no line ending was found, err is non-nil (io.EOF at the end of the input)
+// and line holds the partial line.
+func (g *Guard) ReadLine(r *bufio.Reader, enc Encoding) (line string, n int, err error) {
+	var buf []byte
+	truncated := false
+	for {
+		chunk, readErr := r.ReadSlice('\n')
+		n += len(chunk)
+		more := readErr == bufio.ErrBufferFull
+		lineEnd := false
+		if readErr == nil {
+			if enc == EncodingUTF8 || utf16LineEnd(enc, n) {
+				chunk = chunk[:len(chunk)-1]
+				lineEnd = true
+			} else {
+				more = true
+			}
+		}
+		if room := g.maxLineLength - len(buf); len(chunk) > room {
+			cut := max(room, 0)
+			if enc == EncodingUTF8 {
+				// Don't split a multi-byte character
+				for cut > 0 && !utf8.RuneStart(chunk[cut]) {
+					cut--
+				}
+			} else {
+				cut -= (len(buf) + cut) % 2
+			}
+			chunk = chunk[:cut]
+			truncated = true
+		}
+		buf = append(buf, chunk...)
+		if lineEnd && enc == EncodingUTF16LE {
+			// The newline's second byte follows
+			if _, readErr = r.ReadByte(); readErr == nil {
+				n++
+			}
+		}
+
+		if more {
+			continue
+		}
+		err = readErr
+		break
+	}
+
+	line = string(buf)
+	transcoded := false
+	if enc != EncodingUTF8 {
+		line, transcoded = decodeUTF16(buf, enc), true
+	} else if !utf8.ValidString(line) {
+		// Binary data stays as it is for Accept to reject
+		if decoded := latin1(line); !isBinary(decoded) {
+			line, transcoded = decoded, true
+		}
+	}
+	if err == nil && (truncated || transcoded) {
+		g.mu.Lock()
+		if truncated {
+			g.truncated++
+		}
+		if transcoded {
+			g.transcoded++
+		}
+		g.mu.Unlock()
+	}
+	return strings.TrimSuffix(line, "\r"), n, err
+}
+
+// Clip cuts line to the maximum line length, counting it as truncated if it
+// was longer, for input that arrives already split into records.
+func (g *Guard) Clip(line string) string {
+	if len(line) <= g.maxLineLength {
+		return line
+	}
+	cut := g.maxLineLength
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	g.mu.Lock()
+	g.truncated++
+	g.mu.Unlock()
+	return line[:cut]
+}
+
+// Accept reports whether line is text worth parsing. Binary lines are
+// counted and rejected.
+func (g *Guard) Accept(line string) bool {
+	if !isBinary(line) {
+		return true
+	}
+	g.mu.Lock()
+	g.binary++
+	g.mu.Unlock()
+	return false
+}
+
+// Stats returns how many lines were truncated and how many skipped as binary.
+func (g *Guard) Stats() (truncated, binary int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.truncated, g.binary
+}
+
+// Summary describes the lines the guard altered or skipped; empty if none.
+func (g *Guard) Summary() string {
+	truncated, binary := g.Stats()
+	g.mu.Lock()
+	transcoded := g.transcoded
+	g.mu.Unlock()
+	if truncated == 0 && binary == 0 && transcoded == 0 {
+		return ""
+	}
+	summary := fmt.Sprintf("Input guard: %d lines truncated to %d bytes, %d binary lines skipped", truncated, g.maxLineLength, binary)
+	if transcoded > 0 {
+		summary += fmt.Sprintf(", %d lines converted to UTF-8", transcoded)
+	}
+	return summary
+}
+
+// ScanLines passes each accepted line of r to send until r ends or send
+// returns false. A byte order mark at the start of r selects its encoding.
+func (g *Guard) ScanLines(r io.Reader, send func(string) bool) error {
+	reader := bufio.NewReader(r)
+	enc := readBOM(reader)
+	for {
+		line, n, err := g.ReadLine(reader, enc)
+		if n > 0 && (err == nil || err == io.EOF) && g.Accept(line) {
+			if !send(line) {
+				return nil
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// isBinary reports whether line contains a NUL byte or mostly looks like
+// non-text data.
+func isBinary(line string) bool {
+	if strings.IndexByte(line, 0) >= 0 {
+		return true
+	}
+	control := 0
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if (r == utf8.RuneError && size == 1) || (r < 0x20 && r != '\t') || r == 0x7f {
+			control++
+		}
+		i += size
+	}
+	return float64(control) > float64(len(line))*binaryControlRatio
+}
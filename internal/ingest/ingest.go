@@ -1,11 +1,13 @@
 package ingest
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"os"
+	"sync"
 	"time"
+
+	"github.com/nitis/pulseWatch/internal/crash"
 )
 
 // Ingester is the interface for log ingestion.
@@ -17,11 +19,20 @@ type Ingester interface {
 type FileIngester struct {
 	FilePath    string
 	InitialScan bool
+	guard       *Guard
+	checkpoints *Checkpoints // nil unless resuming
+
+	mu           sync.Mutex // Guards the tailer progress below, read by Status
+	offset       int64
+	truncations  int
+	lastTruncate time.Time
+	rotations    int
+	lastRotate   time.Time
 }
 
 // NewFileIngester creates a new FileIngester.
-func NewFileIngester(filePath string, initialScan bool) *FileIngester {
-	return &FileIngester{FilePath: filePath, InitialScan: initialScan}
+func NewFileIngester(filePath string, initialScan bool, guard *Guard) *FileIngester {
+	return &FileIngester{FilePath: filePath, InitialScan: initialScan, guard: guard}
 }
 
 // Ingest starts tailing the file and returns a channel of log lines.
@@ -30,7 +41,7 @@ func (i *FileIngester) Ingest(ctx context.Context) (<-chan string, error) {
 
 	// One-shot read (if initialScan is true)
 	if i.InitialScan {
-		file, err := os.Open(i.FilePath)
+		file, err := OpenLog(i.FilePath)
 		if err != nil {
 			close(lines) // Ensure channel is closed on error
 			return nil, err
@@ -39,16 +50,17 @@ func (i *FileIngester) Ingest(ctx context.Context) (<-chan string, error) {
 		go func() {
 			defer file.Close()
 			defer close(lines)
+			defer crash.Recover("file reader")
 
-			scanner := bufio.NewScanner(file)
-			for scanner.Scan() {
+			err := i.guard.ScanLines(file, func(line string) bool {
 				select {
-				case lines <- scanner.Text():
+				case lines <- line:
+					return true
 				case <-ctx.Done():
-					return
+					return false
 				}
-			}
-			if err := scanner.Err(); err != nil {
+			})
+			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
 			}
 		}()
@@ -56,69 +68,55 @@ func (i *FileIngester) Ingest(ctx context.Context) (<-chan string, error) {
 	}
 
 	// Dynamic Tailing (if initialScan is false, i.e., default behavior)
+	if Compressed(i.FilePath) {
+		close(lines)
+		return nil, fmt.Errorf("%s is compressed and can't be tailed; read it with --initial-scan or replay it", i.FilePath)
+	}
 	file, err := os.Open(i.FilePath)
 	if err != nil {
 		close(lines)
 		return nil, err
 	}
 
+	offset := i.resumeOffset(file)
+	i.setOffset(offset)
+
 	go func() {
-		defer file.Close()
 		defer close(lines)
-
-		file.Seek(0, 2)
-		currentSize, _ := file.Seek(0, 1)
-		ticker := time.NewTicker(1 * time.Second)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				stat, err := file.Stat()
-				if err != nil {
-					continue
-				}
-				if stat.Size() > currentSize {
-					file.Seek(currentSize, 0)
-					scanner := bufio.NewScanner(file)
-					for scanner.Scan() {
-						select {
-						case lines <- scanner.Text():
-						case <-ctx.Done():
-							return
-						}
-					}
-					currentSize = stat.Size()
-				}
-			case <-ctx.Done():
-				return
-			}
-		}
+		defer crash.Recover("file tailer")
+		i.follow(ctx, file, offset, lines)
 	}()
 
 	return lines, nil
 }
 
 // StdinIngester reads from standard input.
-type StdinIngester struct{}
+type StdinIngester struct {
+	guard *Guard
+}
 
 // NewStdinIngester creates a new StdinIngester.
-func NewStdinIngester() *StdinIngester {
-	return &StdinIngester{}
+func NewStdinIngester(guard *Guard) *StdinIngester {
+	return &StdinIngester{guard: guard}
 }
 
 // Ingest starts reading from stdin and returns a channel of log lines.
 func (i *StdinIngester) Ingest(ctx context.Context) (<-chan string, error) {
 	lines := make(chan string)
-	scanner := bufio.NewScanner(os.Stdin)
 
 	go func() {
 		defer close(lines)
-		for scanner.Scan() {
+		defer crash.Recover("stdin reader")
+		err := i.guard.ScanLines(os.Stdin, func(line string) bool {
 			select {
-			case lines <- scanner.Text():
+			case lines <- line:
+				return true
 			case <-ctx.Done():
-				return
+				return false
 			}
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
 		}
 	}()
 
@@ -0,0 +1,166 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/crash"
+)
+
+// JournaldIngester reads the systemd journal through journalctl, so services
+// that only log to the journal can be watched.
+type JournaldIngester struct {
+	Units       []string // Units to follow; empty for the whole journal
+	Priority    string   // journalctl -p filter, e.g. "warning" or "err..warning"
+	JSON        bool     // Emit JSON lines with time, level, and unit instead of the bare message
+	InitialScan bool     // Read the matching journal from the start and stop at its end
+	guard       *Guard
+}
+
+// NewJournaldIngester creates a JournaldIngester.
+func NewJournaldIngester(units []string, priority string, jsonOutput, initialScan bool, guard *Guard) *JournaldIngester {
+	return &JournaldIngester{Units: units, Priority: priority, JSON: jsonOutput, InitialScan: initialScan, guard: guard}
+}
+
+// journalRecord holds the journal fields used. MESSAGE is a string, or an
+// array of bytes when it is not valid UTF-8.
+type journalRecord struct {
+	Message    json.RawMessage `json:"MESSAGE"`
+	Priority   string          `json:"PRIORITY"`
+	Realtime   string          `json:"__REALTIME_TIMESTAMP"` // Microseconds since the epoch
+	Unit       string          `json:"_SYSTEMD_UNIT"`
+	Identifier string          `json:"SYSLOG_IDENTIFIER"`
+	Hostname   string          `json:"_HOSTNAME"`
+	PID        string          `json:"_PID"`
+}
+
+func (i *JournaldIngester) args() []string {
+	args := []string{"--output=json", "--no-pager"}
+	if !i.InitialScan {
+		args = append(args, "--follow", "--lines=0")
+	}
+	for _, unit := range i.Units {
+		args = append(args, "--unit="+unit)
+	}
+	if i.Priority != "" {
+		args = append(args, "--priority="+i.Priority)
+	}
+	return args
+}
+
+// Ingest starts journalctl and returns a channel of log lines. The channel
+// closes when journalctl exits or ctx is cancelled.
+func (i *JournaldIngester) Ingest(ctx context.Context) (<-chan string, error) {
+	cmd := exec.CommandContext(ctx, "journalctl", i.args()...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start journalctl: %w", err)
+	}
+
+	lines := make(chan string, 1000)
+	go func() {
+		defer close(lines)
+		defer crash.Recover("journald reader")
+		dec := json.NewDecoder(stdout)
+		for {
+			var rec journalRecord
+			if err := dec.Decode(&rec); err != nil {
+				if err != io.EOF && ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "Error reading journal: %v\n", err)
+				}
+				break
+			}
+			line, ok := i.line(rec)
+			if !ok {
+				continue
+			}
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				cmd.Wait()
+				return
+			}
+		}
+		io.Copy(io.Discard, stdout)
+		if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "journalctl: %v: %s\n", err, strings.TrimSpace(stderr.String()))
+		}
+	}()
+	return lines, nil
+}
+
+// line turns a journal record into the line handed to the parsers. Records
+// without a usable message are skipped.
+func (i *JournaldIngester) line(rec journalRecord) (string, bool) {
+	message, ok := journalMessage(rec.Message)
+	if !ok {
+		return "", false
+	}
+	message = i.guard.Clip(message)
+	if !i.guard.Accept(message) {
+		return "", false
+	}
+	if !i.JSON {
+		return message, true
+	}
+
+	out := map[string]string{
+		"message": message,
+		"level":   journalLevel(rec.Priority),
+	}
+	if usec, err := strconv.ParseInt(rec.Realtime, 10, 64); err == nil {
+		out["timestamp"] = time.UnixMicro(usec).Format(time.RFC3339Nano)
+	}
+	for key, value := range map[string]string{"unit": rec.Unit, "identifier": rec.Identifier, "hostname": rec.Hostname, "pid": rec.PID} {
+		if value != "" {
+			out[key] = value
+		}
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+func journalMessage(raw json.RawMessage) (string, bool) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, s != ""
+	}
+	var b []byte
+	var ints []int
+	if err := json.Unmarshal(raw, &ints); err != nil || len(ints) == 0 {
+		return "", false // Missing, or null for messages over journalctl's size limit
+	}
+	for _, v := range ints {
+		b = append(b, byte(v))
+	}
+	return string(b), true
+}
+
+// journalLevel maps a syslog priority onto the levels the JSON parser knows.
+func journalLevel(priority string) string {
+	switch priority {
+	case "0", "1", "2", "3":
+		return "error"
+	case "4":
+		return "warning"
+	case "7":
+		return "debug"
+	}
+	return "info"
+}
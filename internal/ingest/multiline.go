@@ -0,0 +1,113 @@
+package ingest
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/crash"
+)
+
+// Multiline joins continuation lines, such as the frames of a Java or
+// Python stack trace, onto the line that started the record, so each
+// record reaches the parsers as one multi-line entry.
+type Multiline struct {
+	start    *regexp.Regexp // A line matching this starts a new record
+	timeout  time.Duration  // A pending record is passed on after this long without a continuation
+	maxLines int            // Longer records are cut into several
+}
+
+// NewMultiline creates a Multiline. Lines matching start begin a record;
+// any other line continues the record before it.
+func NewMultiline(start string, timeout time.Duration, maxLines int) (*Multiline, error) {
+	re, err := regexp.Compile(start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid multiline start pattern: %w", err)
+	}
+	return &Multiline{start: re, timeout: timeout, maxLines: maxLines}, nil
+}
+
+// pendingRecord is a record waiting for more continuation lines.
+type pendingRecord struct {
+	lines  []string
+	fields map[string]string
+	last   time.Time // When the latest line arrived
+}
+
+// Assemble returns records with continuation lines joined by newlines.
+// Records from different sources, e.g. Docker containers, are assembled
+// separately. The returned channel closes once records does and the last
+// pending records are passed on.
+func (m *Multiline) Assemble(records <-chan Record) <-chan Record {
+	out := make(chan Record, 1000)
+	go func() {
+		defer close(out)
+		defer crash.Recover("multiline assembler")
+		pending := make(map[string]*pendingRecord)
+		flush := func(key string) {
+			p := pending[key]
+			delete(pending, key)
+			out <- Record{Line: strings.Join(p.lines, "\n"), Fields: p.fields}
+		}
+		ticker := time.NewTicker(max(m.timeout/4, 10*time.Millisecond))
+		defer ticker.Stop()
+		for {
+			select {
+			case rec, ok := <-records:
+				if !ok {
+					keys := make([]string, 0, len(pending))
+					for key := range pending {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					for _, key := range keys {
+						flush(key)
+					}
+					return
+				}
+				if rec.Entry != nil {
+					out <- rec // Parsed upstream, continuation lines included
+					continue
+				}
+				key := sourceKey(rec.Fields)
+				p, ok := pending[key]
+				if ok && (m.start.MatchString(rec.Line) || len(p.lines) >= m.maxLines) {
+					flush(key)
+					ok = false
+				}
+				if !ok {
+					p = &pendingRecord{fields: rec.Fields}
+					pending[key] = p
+				}
+				p.lines = append(p.lines, rec.Line)
+				p.last = time.Now()
+			case now := <-ticker.C:
+				for key, p := range pending {
+					if now.Sub(p.last) >= m.timeout {
+						flush(key)
+					}
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// sourceKey identifies the source of a record by its fields.
+func sourceKey(fields map[string]string) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k + "=" + fields[k] + "\x00")
+	}
+	return b.String()
+}
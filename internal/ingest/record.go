@@ -0,0 +1,95 @@
+package ingest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nitis/pulseWatch/internal/crash"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// Record is a line of input with metadata about where it came from, such as
+// the container that wrote it. The fields are added to the parsed entry.
+type Record struct {
+	Line   string
+	Fields map[string]string
+	Source string          // Name of the input, set by Merge
+	Entry  *types.LogEntry // Already parsed, by an upstream pulsewatch; Line is its message
+}
+
+// RecordIngester is an Ingester whose lines carry source metadata.
+type RecordIngester interface {
+	Ingester
+	IngestRecords(ctx context.Context) (<-chan Record, error)
+}
+
+// Records starts i and returns its records: tagged ones if i is a
+// RecordIngester, otherwise its lines without fields.
+func Records(ctx context.Context, i Ingester) (<-chan Record, error) {
+	if ri, ok := i.(RecordIngester); ok {
+		return ri.IngestRecords(ctx)
+	}
+	lines, err := i.Ingest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return LineRecords(lines), nil
+}
+
+// LineRecords wraps untagged lines as records. The returned channel closes
+// when lines does.
+func LineRecords(lines <-chan string) <-chan Record {
+	records := make(chan Record)
+	go func() {
+		defer close(records)
+		defer crash.Recover("line reader")
+		for line := range lines {
+			records <- Record{Line: line}
+		}
+	}()
+	return records
+}
+
+// Input is one of the inputs of a run and the name its records are tagged
+// with.
+type Input struct {
+	Name    string
+	Records <-chan Record
+}
+
+// Merge reads all inputs at once, tagging each record with the name of its
+// input. The returned channel closes when every input has.
+func Merge(inputs []Input) <-chan Record {
+	merged := make(chan Record, 1000)
+	var wg sync.WaitGroup
+	for _, in := range inputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer crash.Recover("input " + in.Name)
+			for r := range in.Records {
+				r.Source = in.Name
+				merged <- r
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+	return merged
+}
+
+// recordLines drops the metadata of records, for the plain Ingester
+// interface.
+func recordLines(records <-chan Record) <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		defer crash.Recover("record reader")
+		for r := range records {
+			lines <- r.Line
+		}
+	}()
+	return lines
+}
@@ -0,0 +1,279 @@
+package ingest
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/awssig"
+	"github.com/nitis/pulseWatch/internal/crash"
+)
+
+// S3Ingester reads the log objects under an S3 bucket prefix, such as ALB or
+// CloudFront access logs, streaming them without saving them to disk.
+// Objects ending in .gz, .zst, or .bz2 are decompressed. With InitialScan it
+// reads the objects present and stops; otherwise it polls the prefix and
+// reads objects as they appear, like tailing a file.
+type S3Ingester struct {
+	Bucket      string
+	Prefix      string
+	InitialScan bool
+	// Since limits the objects present at start to those modified within
+	// this long; 0 reads them all with InitialScan and none when following.
+	Since        time.Duration
+	PollInterval time.Duration
+	region       string
+	endpoint     string // Optional, e.g. "minio.local:9000"; uses path-style URLs
+	creds        awssig.Credentials
+	signed       bool // False without credentials, for public buckets
+	client       *http.Client
+	guard        *Guard
+}
+
+// IsS3URL reports whether s names an S3 location, s3://bucket/prefix.
+func IsS3URL(s string) bool {
+	return strings.HasPrefix(s, "s3://")
+}
+
+// NewS3Ingester creates an S3Ingester for location, s3://bucket/prefix.
+// An empty region comes from the environment, defaulting to us-east-1;
+// endpoint selects an S3-compatible store such as MinIO. Credentials come
+// from the standard AWS_* variables; without them requests are unsigned,
+// for public buckets.
+func NewS3Ingester(location, region, endpoint string, initialScan bool, since, pollInterval time.Duration, guard *Guard) (*S3Ingester, error) {
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(location, "s3://"), "/")
+	if !IsS3URL(location) || bucket == "" {
+		return nil, fmt.Errorf("invalid S3 location %q: use s3://bucket/prefix", location)
+	}
+	if region == "" {
+		region = awssig.RegionFromEnv("us-east-1")
+	}
+	if endpoint == "" && strings.Contains(bucket, ".") {
+		// Dotted bucket names don't match the wildcard certificate of
+		// virtual-hosted URLs
+		endpoint = "s3." + region + ".amazonaws.com"
+	}
+	creds, err := awssig.CredentialsFromEnv()
+	return &S3Ingester{
+		Bucket:       bucket,
+		Prefix:       prefix,
+		InitialScan:  initialScan,
+		Since:        since,
+		PollInterval: pollInterval,
+		region:       region,
+		endpoint:     strings.TrimSuffix(endpoint, "/"),
+		creds:        creds,
+		signed:       err == nil,
+		client:       &http.Client{},
+		guard:        guard,
+	}, nil
+}
+
+// s3Object is the part of an object listing that is used.
+type s3Object struct {
+	Key          string    `xml:"Key"`
+	LastModified time.Time `xml:"LastModified"`
+}
+
+// Ingest streams the objects' log lines without their metadata.
+func (i *S3Ingester) Ingest(ctx context.Context) (<-chan string, error) {
+	records, err := i.IngestRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return recordLines(records), nil
+}
+
+// IngestRecords streams the objects' log lines, each tagged with the
+// s3_key field of the object it came from. Objects are read one at a time,
+// oldest first. It fails if the prefix can't be listed, or with InitialScan
+// if nothing under it is selected.
+func (i *S3Ingester) IngestRecords(ctx context.Context) (<-chan Record, error) {
+	start := time.Now()
+	objects, err := i.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(objects))
+	var pending []s3Object
+	for _, o := range objects {
+		seen[o.Key] = true
+		if i.Since > 0 && o.LastModified.Before(start.Add(-i.Since)) {
+			continue
+		}
+		if i.InitialScan || i.Since > 0 {
+			pending = append(pending, o)
+		}
+	}
+	if len(pending) == 0 && i.InitialScan {
+		return nil, fmt.Errorf("no objects to read under s3://%s/%s", i.Bucket, i.Prefix)
+	}
+
+	records := make(chan Record, 1000)
+	go func() {
+		defer close(records)
+		defer crash.Recover("s3 reader")
+		for {
+			sortObjects(pending)
+			for _, o := range pending {
+				if err := i.read(ctx, o.Key, records); err != nil && ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "Error reading s3://%s/%s: %v\n", i.Bucket, o.Key, err)
+				}
+				if ctx.Err() != nil {
+					return
+				}
+			}
+			if i.InitialScan {
+				return
+			}
+
+			select {
+			case <-time.After(i.PollInterval):
+			case <-ctx.Done():
+				return
+			}
+			found, err := i.list(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "Error listing s3://%s/%s: %v\n", i.Bucket, i.Prefix, err)
+				}
+				pending = nil
+				continue
+			}
+			pending = pending[:0]
+			for _, o := range found {
+				if !seen[o.Key] {
+					seen[o.Key] = true
+					pending = append(pending, o)
+				}
+			}
+		}
+	}()
+	return records, nil
+}
+
+// sortObjects orders objects oldest first, then by key.
+func sortObjects(objects []s3Object) {
+	sort.Slice(objects, func(a, b int) bool {
+		if !objects[a].LastModified.Equal(objects[b].LastModified) {
+			return objects[a].LastModified.Before(objects[b].LastModified)
+		}
+		return objects[a].Key < objects[b].Key
+	})
+}
+
+// list returns the objects under the prefix, following continuation tokens.
+func (i *S3Ingester) list(ctx context.Context) ([]s3Object, error) {
+	var objects []s3Object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {i.Prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := i.get(ctx, "", query)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Contents              []s3Object `xml:"Contents"`
+			IsTruncated           bool       `xml:"IsTruncated"`
+			NextContinuationToken string     `xml:"NextContinuationToken"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode object list: %w", err)
+		}
+		for _, o := range page.Contents {
+			if !strings.HasSuffix(o.Key, "/") { // Folder placeholders
+				objects = append(objects, o)
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// read streams one object's lines to records.
+func (i *S3Ingester) read(ctx context.Context, key string, records chan<- Record) error {
+	resp, err := i.get(ctx, key, nil)
+	if err != nil {
+		return err
+	}
+	body, err := decompress(key, resp.Body)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	fields := map[string]string{"s3_key": key}
+	return i.guard.ScanLines(body, func(line string) bool {
+		select {
+		case records <- Record{Line: line, Fields: fields}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+}
+
+// get requests key (the bucket itself if empty) with query, signed when
+// credentials are set.
+func (i *S3Ingester) get(ctx context.Context, key string, query url.Values) (*http.Response, error) {
+	target := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", i.Bucket, i.region, escapeKey(key))
+	if i.endpoint != "" {
+		target = fmt.Sprintf("%s/%s/%s", i.endpoint, i.Bucket, escapeKey(key))
+		if !strings.Contains(i.endpoint, "://") {
+			target = "https://" + target
+		}
+	}
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if i.signed {
+		awssig.Sign(req, awssig.HashPayload(nil), "s3", i.region, i.creds, time.Now())
+	}
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var s3err struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+		if xml.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&s3err) == nil && s3err.Code != "" {
+			return nil, fmt.Errorf("S3 GET %s: %s: %s: %s", key, resp.Status, s3err.Code, s3err.Message)
+		}
+		return nil, fmt.Errorf("S3 GET %s: %s", key, resp.Status)
+	}
+	return resp, nil
+}
+
+// escapeKey percent-encodes an object key the way SigV4 canonicalizes
+// paths: every byte but unreserved characters and slashes.
+func escapeKey(key string) string {
+	var b strings.Builder
+	for _, c := range []byte(key) {
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package ingest
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// maxPendingScan bounds how much unread data Status reads to count pending
+// lines; beyond it the count is extrapolated.
+const maxPendingScan = 8 << 20
+
+// Status reports how far the tailer is behind the file. It stats and reads
+// the file, so call it off the UI goroutine.
+func (i *FileIngester) Status() types.SourceStatus {
+	i.mu.Lock()
+	status := types.SourceStatus{
+		Path:         i.FilePath,
+		Offset:       i.offset,
+		Truncations:  i.truncations,
+		LastTruncate: i.lastTruncate,
+		Rotations:    i.rotations,
+		LastRotate:   i.lastRotate,
+	}
+	i.mu.Unlock()
+	status.LongLines, status.BinaryLines = i.guard.Stats()
+
+	stat, err := os.Stat(i.FilePath)
+	if err != nil {
+		status.Err = err.Error()
+		return status
+	}
+	status.Size = stat.Size()
+	status.LastWrite = stat.ModTime()
+	if status.Size <= status.Offset {
+		return status
+	}
+	status.PendingBytes = status.Size - status.Offset
+
+	lines, err := countLines(i.FilePath, status.Offset, status.PendingBytes)
+	if err != nil {
+		status.Err = err.Error()
+	}
+	status.PendingLines = lines
+	return status
+}
+
+// Health reports the unread bytes of the file and how often it was reopened
+// after rotation.
+func (i *FileIngester) Health() Health {
+	i.mu.Lock()
+	offset, rotations := i.offset, i.rotations
+	i.mu.Unlock()
+	h := Health{LagBytes: -1, Reconnects: int64(rotations)}
+	if stat, err := os.Stat(i.FilePath); err == nil {
+		h.LagBytes = max(stat.Size()-offset, 0)
+	}
+	return h
+}
+
+// countLines counts the newlines in the n bytes at offset, extrapolating
+// from the first maxPendingScan bytes when n is larger.
+func countLines(path string, offset, n int64) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scan := min(n, maxPendingScan)
+	buf := make([]byte, 64<<10)
+	r := io.NewSectionReader(file, offset, scan)
+	lines := 0
+	for {
+		k, err := r.Read(buf)
+		lines += bytes.Count(buf[:k], []byte{'\n'})
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return lines, err
+		}
+	}
+	if scan < n {
+		lines = int(float64(lines) * float64(n) / float64(scan))
+	}
+	return lines, nil
+}
+
+func (i *FileIngester) setOffset(offset int64) {
+	i.mu.Lock()
+	i.offset = offset
+	i.mu.Unlock()
+}
+
+func (i *FileIngester) recordTruncate() {
+	i.mu.Lock()
+	i.offset = 0
+	i.truncations++
+	i.lastTruncate = time.Now()
+	i.mu.Unlock()
+}
+
+func (i *FileIngester) recordRotate() {
+	i.mu.Lock()
+	i.offset = 0
+	i.rotations++
+	i.lastRotate = time.Now()
+	i.mu.Unlock()
+}
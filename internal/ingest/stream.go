@@ -0,0 +1,185 @@
+package ingest
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/crash"
+	"github.com/nitis/pulseWatch/internal/stream"
+)
+
+// StreamIngester receives the parsed entries other pulsewatch instances
+// forward to it over TCP (forward type "pulsewatch"), so an edge box can
+// feed a laptop without a message broker in between. Entries arrive parsed
+// and skip the parsers; each is tagged with an upstream field holding the
+// sender's address.
+type StreamIngester struct {
+	Addr       string
+	token      string
+	tlsConfig  *tls.Config  // nil for plain TCP
+	maxConns   int          // 0 for no limit
+	reconnects atomic.Int64 // Connections from hosts that had connected before
+}
+
+// streamHandshakeTimeout bounds a sender's TLS handshake and stream header,
+// so connections that never send one don't hold a slot.
+const streamHandshakeTimeout = 10 * time.Second
+
+// maxStreamSenders bounds the senders remembered for counting reconnects;
+// past it the set starts over.
+const maxStreamSenders = 10000
+
+// NewStreamIngester creates a StreamIngester listening on addr, refusing
+// connections past maxConns (0 for no limit). With token, senders must
+// present it in the stream header. With certFile and keyFile, senders
+// connect over TLS; clientCA and clientNames verify their certificates as
+// for NewSyslogIngester.
+func NewStreamIngester(addr, token, certFile, keyFile, clientCA string, clientNames []string, maxConns int) (*StreamIngester, error) {
+	i := &StreamIngester{Addr: addr, token: token, maxConns: maxConns}
+	if certFile == "" && keyFile == "" && clientCA == "" {
+		return i, nil
+	}
+	tlsConfig, err := serverTLSConfig(certFile, keyFile, clientCA, clientNames)
+	if err != nil {
+		return nil, fmt.Errorf("stream: %w", err)
+	}
+	i.tlsConfig = tlsConfig
+	return i, nil
+}
+
+// Secured reports whether senders must present a token or connect over
+// TLS.
+func (i *StreamIngester) Secured() bool {
+	return i.token != "" || i.tlsConfig != nil
+}
+
+// Ingest receives the messages of the forwarded entries.
+func (i *StreamIngester) Ingest(ctx context.Context) (<-chan string, error) {
+	records, err := i.IngestRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return recordLines(records), nil
+}
+
+// IngestRecords listens on Addr and streams the entries of every sender.
+// The channel closes when ctx is cancelled.
+func (i *StreamIngester) IngestRecords(ctx context.Context) (<-chan Record, error) {
+	ln, err := net.Listen("tcp", i.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("stream: %w", err)
+	}
+	if i.tlsConfig != nil {
+		ln = tls.NewListener(ln, i.tlsConfig)
+	}
+
+	records := make(chan Record, 1000)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	conns := make(map[net.Conn]bool)
+	senders := make(map[string]bool)
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+		mu.Lock()
+		for conn := range conns {
+			conn.Close()
+		}
+		mu.Unlock()
+	}()
+
+	go func() {
+		defer close(records)
+		defer crash.Recover("stream listener")
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+					fmt.Fprintf(os.Stderr, "Error accepting stream connection: %v\n", err)
+					continue
+				}
+				break
+			}
+			mu.Lock()
+			full := i.maxConns > 0 && len(conns) >= i.maxConns
+			if !full {
+				conns[conn] = true
+			}
+			mu.Unlock()
+			if full {
+				fmt.Fprintf(os.Stderr, "Refused stream connection from %s: %d senders are connected\n", conn.RemoteAddr(), i.maxConns)
+				conn.Close()
+				continue
+			}
+			host, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+			if senders[host] {
+				i.reconnects.Add(1)
+			} else if len(senders) >= maxStreamSenders {
+				senders = make(map[string]bool)
+			}
+			senders[host] = true
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer crash.Recover("stream connection")
+				if err := i.serve(ctx, conn, host, records); err != nil && ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "Error reading stream from %s: %v\n", conn.RemoteAddr(), err)
+				}
+				conn.Close()
+				mu.Lock()
+				delete(conns, conn)
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+	}()
+	return records, nil
+}
+
+// Health reports how many connections came from hosts that had connected
+// before.
+func (i *StreamIngester) Health() Health {
+	return Health{LagBytes: -1, Reconnects: i.reconnects.Load()}
+}
+
+// serve reads one sender's entries until it disconnects.
+func (i *StreamIngester) serve(ctx context.Context, conn net.Conn, host string, records chan<- Record) error {
+	r := stream.NewReader(conn)
+	// The TLS handshake happens with the first read
+	conn.SetReadDeadline(time.Now().Add(streamHandshakeTimeout))
+	token, err := r.Header()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(i.token)) != 1 {
+		return fmt.Errorf("wrong or missing token")
+	}
+	conn.SetReadDeadline(time.Time{})
+	for {
+		entry, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		select {
+		// Each record gets its own fields, so no two records share a map
+		case records <- Record{Line: entry.Message, Fields: map[string]string{"upstream": host}, Entry: &entry}:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/crash"
+	"github.com/nitis/pulseWatch/internal/parser"
+)
+
+// maxSyslogFrame bounds the MSG-LEN of an octet-counted frame. Messages are
+// still clipped to the guard's maximum line length; frames announcing more
+// than this are treated as a broken stream.
+const maxSyslogFrame = 1 << 20
+
+// syslogHandshakeTimeout bounds a client's TLS handshake, so connections that
+// never complete one don't hold a slot.
+const syslogHandshakeTimeout = 10 * time.Second
+
+// maxSyslogHosts bounds the hosts remembered for counting reconnects; past
+// it the set starts over.
+const maxSyslogHosts = 10000
+
+// SyslogIngester receives syslog messages over TLS as in RFC 5425, so
+// production hosts can ship their logs to pulsewatch directly. Frames are
+// octet-counted ("MSG-LEN SP SYSLOG-MSG") or, as many relays send them,
+// newline-terminated. RFC 5424 and RFC 3164 headers are both understood;
+// the message text goes to the parsers.
+type SyslogIngester struct {
+	Addr        string
+	tlsConfig   *tls.Config // nil when insecure
+	guard       *Guard
+	maxClients  int           // 0 for no limit
+	idleTimeout time.Duration // 0 for none
+	reconnects  atomic.Int64  // Connections from hosts that had connected before
+}
+
+// NewSyslogIngester creates a SyslogIngester listening on addr with the
+// server certificate and key in certFile and keyFile. With clientCA, clients
+// must present a certificate signed by it, and with clientNames also one
+// whose common name or a DNS name is in the list. insecure receives plain
+// TCP instead, as in RFC 6587, and needs none of the files.
+func NewSyslogIngester(addr, certFile, keyFile, clientCA string, clientNames []string, insecure bool, guard *Guard) (*SyslogIngester, error) {
+	i := &SyslogIngester{Addr: addr, guard: guard}
+	if insecure {
+		if certFile != "" || clientCA != "" {
+			return nil, fmt.Errorf("syslog: tls files are set but insecure is on")
+		}
+		return i, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("syslog: tls.cert and tls.key are required (or set insecure for plain TCP)")
+	}
+	tlsConfig, err := serverTLSConfig(certFile, keyFile, clientCA, clientNames)
+	if err != nil {
+		return nil, fmt.Errorf("syslog: %w", err)
+	}
+	i.tlsConfig = tlsConfig
+	return i, nil
+}
+
+// SetLimits caps the concurrent connections, refusing more, and closes
+// connections idle for idleTimeout; 0 turns either off.
+func (i *SyslogIngester) SetLimits(maxClients int, idleTimeout time.Duration) {
+	i.maxClients = maxClients
+	i.idleTimeout = idleTimeout
+}
+
+// Ingest receives syslog messages without their metadata.
+func (i *SyslogIngester) Ingest(ctx context.Context) (<-chan string, error) {
+	records, err := i.IngestRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return recordLines(records), nil
+}
+
+// IngestRecords listens on Addr and streams the messages of every client,
+// each tagged with the hostname, app_name, procid, msgid, facility, and
+// severity fields of its header and its structured data parameters, where
+// present. The channel closes when ctx is cancelled.
+func (i *SyslogIngester) IngestRecords(ctx context.Context) (<-chan Record, error) {
+	ln, err := net.Listen("tcp", i.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("syslog: %w", err)
+	}
+	if i.tlsConfig != nil {
+		ln = tls.NewListener(ln, i.tlsConfig)
+	}
+
+	records := make(chan Record, 1000)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	conns := make(map[net.Conn]bool)
+	clients := make(map[string]bool)
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+		mu.Lock()
+		for conn := range conns {
+			conn.Close()
+		}
+		mu.Unlock()
+	}()
+
+	go func() {
+		defer close(records)
+		defer crash.Recover("syslog listener")
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+					fmt.Fprintf(os.Stderr, "Error accepting syslog connection: %v\n", err)
+					continue
+				}
+				break
+			}
+			mu.Lock()
+			full := i.maxClients > 0 && len(conns) >= i.maxClients
+			if !full {
+				conns[conn] = true
+			}
+			mu.Unlock()
+			if full {
+				fmt.Fprintf(os.Stderr, "Refused syslog connection from %s: %d clients are connected\n", conn.RemoteAddr(), i.maxClients)
+				conn.Close()
+				continue
+			}
+			host, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+			if clients[host] {
+				i.reconnects.Add(1)
+			} else if len(clients) >= maxSyslogHosts {
+				clients = make(map[string]bool)
+			}
+			clients[host] = true
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer crash.Recover("syslog connection")
+				if err := i.serve(ctx, conn, records); err != nil && ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "Error reading syslog from %s: %v\n", conn.RemoteAddr(), err)
+				}
+				conn.Close()
+				mu.Lock()
+				delete(conns, conn)
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+	}()
+	return records, nil
+}
+
+// Health reports how many connections came from hosts that had connected
+// before.
+func (i *SyslogIngester) Health() Health {
+	return Health{LagBytes: -1, Reconnects: i.reconnects.Load()}
+}
+
+// serve reads one client's frames until it disconnects.
+func (i *SyslogIngester) serve(ctx context.Context, conn net.Conn, records chan<- Record) error {
+	if tc, ok := conn.(*tls.Conn); ok {
+		hctx, cancel := context.WithTimeout(ctx, syslogHandshakeTimeout)
+		err := tc.HandshakeContext(hctx)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+	r := bufio.NewReader(conn)
+	for {
+		if i.idleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(i.idleTimeout))
+		}
+		frame, err := i.readFrame(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line, fields := parseSyslog(frame)
+		line = i.guard.Clip(line)
+		if line == "" || !i.guard.Accept(line) {
+			continue
+		}
+		select {
+		case records <- Record{Line: line, Fields: fields}:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// readFrame returns the next message: octet-counted if it starts with a
+// digit, otherwise up to the next newline.
+func (i *SyslogIngester) readFrame(r *bufio.Reader) (string, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return "", err
+	}
+	if first[0] < '0' || first[0] > '9' {
+		line, _, err := i.guard.ReadLine(r, EncodingUTF8)
+		if err == io.EOF && line != "" {
+			return line, nil
+		}
+		return line, err
+	}
+
+	prefix, err := r.ReadString(' ')
+	if err != nil {
+		return "", fmt.Errorf("reading frame length: %w", err)
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(prefix, " "))
+	if err != nil || n < 1 || n > maxSyslogFrame {
+		return "", fmt.Errorf("invalid frame length %q", strings.TrimSpace(prefix))
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", fmt.Errorf("reading frame: %w", err)
+	}
+	return strings.TrimRight(string(buf), "\r\n"), nil
+}
+
+// syslogHeader parses the header of received messages. It holds no state,
+// so the connections share it.
+var syslogHeader = parser.NewSyslogParser()
+
+// parseSyslog splits a syslog message into its text and header fields with
+// the syslog line parser; a message it doesn't parse is passed on whole.
+func parseSyslog(msg string) (string, map[string]string) {
+	entry, ok := syslogHeader.Parse(msg)
+	if !ok {
+		return msg, nil
+	}
+	fields := make(map[string]string, len(entry.Fields))
+	for key, value := range entry.Fields {
+		if s, ok := value.(string); ok {
+			fields[key] = s
+		}
+	}
+	return entry.Message, fields
+}
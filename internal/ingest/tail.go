@@ -0,0 +1,151 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// tailPollInterval is how often the file is checked without a change
+// notification, which network filesystems and some editors never send.
+const tailPollInterval = 1 * time.Second
+
+// tailer follows a file by path across the common rotation schemes:
+//   - copytruncate: the file shrinks in place and is read again from the start;
+//   - rename (logrotate's default create): the rest of the old file is read,
+//     then the new file at the path is opened;
+//   - recreate: the file is removed and later created again, and is opened
+//     once it appears.
+//
+// Changes are noticed through fsnotify on the file's directory, so renames
+// and creations are seen, with a poll as a fallback.
+type tailer struct {
+	i      *FileIngester
+	path   string
+	file   *os.File // The file being read; may no longer be at path
+	reader *bufio.Reader
+	offset int64
+	enc    Encoding // From the file's byte order mark
+}
+
+// follow tails file from offset, sending lines until ctx is done.
+func (i *FileIngester) follow(ctx context.Context, file *os.File, offset int64, lines chan<- string) {
+	t := &tailer{i: i, path: filepath.Clean(i.FilePath), file: file, reader: bufio.NewReader(file), offset: offset}
+	t.enc, _ = fileEncoding(file)
+	defer func() { t.file.Close() }()
+
+	var events <-chan fsnotify.Event
+	var watchErrors <-chan error
+	if watcher, err := fsnotify.NewWatcher(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: can't watch %s for changes, polling instead: %v\n", t.path, err)
+	} else {
+		defer watcher.Close()
+		if err := watcher.Add(filepath.Dir(t.path)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: can't watch %s for changes, polling instead: %v\n", t.path, err)
+		}
+		events, watchErrors = watcher.Events, watcher.Errors
+	}
+
+	ticker := time.NewTicker(tailPollInterval)
+	defer ticker.Stop()
+	saved, lastSave := offset, time.Now()
+	for {
+		if t.offset != saved && time.Since(lastSave) >= checkpointInterval {
+			i.SaveCheckpoint()
+			saved, lastSave = t.offset, time.Now()
+		}
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if filepath.Clean(ev.Name) != t.path {
+				continue // Another file in the directory
+			}
+		case err, ok := <-watchErrors:
+			if !ok {
+				watchErrors = nil
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Warning: watching %s: %v\n", t.path, err)
+			continue
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		if !t.check(ctx, lines) {
+			return
+		}
+	}
+}
+
+// check reads what was appended and switches to a new file at the path
+// after rotation. It returns false once ctx is done.
+func (t *tailer) check(ctx context.Context, lines chan<- string) bool {
+	if !t.read(ctx, lines) {
+		return false
+	}
+	current, err := t.file.Stat()
+	if err != nil {
+		return true
+	}
+	replacement, err := os.Stat(t.path)
+	if err != nil || os.SameFile(current, replacement) {
+		return true // Removed or renamed without a replacement yet, or unchanged
+	}
+	next, err := os.Open(t.path)
+	if err != nil {
+		return true // Retried on the next change or poll
+	}
+	t.file.Close()
+	t.file, t.offset = next, 0
+	t.reader.Reset(next)
+	t.i.recordRotate()
+	return t.read(ctx, lines)
+}
+
+// read sends the complete lines appended since the last read, starting over
+// if the file was truncated. It returns false once ctx is done.
+func (t *tailer) read(ctx context.Context, lines chan<- string) bool {
+	stat, err := t.file.Stat()
+	if err != nil {
+		return true
+	}
+	if stat.Size() < t.offset {
+		// Truncated in place (e.g. copytruncate rotation): start over
+		t.offset = 0
+		t.i.recordTruncate()
+	}
+	if t.offset == 0 {
+		var bom int
+		t.enc, bom = fileEncoding(t.file)
+		t.offset = int64(bom)
+	}
+	if stat.Size() <= t.offset {
+		return true
+	}
+	t.file.Seek(t.offset, io.SeekStart)
+	t.reader.Reset(t.file)
+	for {
+		line, n, err := t.i.guard.ReadLine(t.reader, t.enc)
+		if err != nil {
+			return true // A partial last line is read again once it is complete
+		}
+		if t.i.guard.Accept(line) {
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return false
+			}
+		}
+		t.offset += int64(n)
+		t.i.setOffset(t.offset)
+	}
+}
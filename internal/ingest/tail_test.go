@@ -0,0 +1,156 @@
+package ingest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// startTail tails path from its end, as watch does, until the test ends.
+func startTail(t *testing.T, path string) (*FileIngester, <-chan string) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	i := NewFileIngester(path, false, NewGuard(64<<10))
+	lines, err := i.Ingest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return i, lines
+}
+
+// expectLines waits for want, in order, with nothing in between.
+func expectLines(t *testing.T, lines <-chan string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		select {
+		case got, ok := <-lines:
+			if !ok {
+				t.Fatalf("tailer stopped, want %q", w)
+			}
+			if got != w {
+				t.Fatalf("got line %q, want %q", got, w)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %q", w)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTailStartsAtEnd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	writeFile(t, path, "before\n")
+	_, lines := startTail(t, path)
+
+	appendFile(t, path, "after\npartial")
+	expectLines(t, lines, "after")
+	appendFile(t, path, " line\n")
+	expectLines(t, lines, "partial line")
+}
+
+func TestTailRenameRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	writeFile(t, path, "")
+	i, lines := startTail(t, path)
+
+	appendFile(t, path, "one\n")
+	expectLines(t, lines, "one")
+
+	// logrotate's create: the old file is renamed, written to a last time by
+	// the application, and a new file is created at the path
+	rotated := filepath.Join(dir, "app.log.1")
+	if err := os.Rename(path, rotated); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, rotated, "two\n")
+	writeFile(t, path, "three\n")
+	expectLines(t, lines, "two", "three")
+
+	appendFile(t, path, "four\n")
+	expectLines(t, lines, "four")
+	if st := i.Status(); st.Rotations != 1 {
+		t.Errorf("Rotations = %d, want 1", st.Rotations)
+	}
+}
+
+func TestTailTruncateToShorter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	writeFile(t, path, "")
+	i, lines := startTail(t, path)
+
+	appendFile(t, path, "a long line written before the truncation\n")
+	expectLines(t, lines, "a long line written before the truncation")
+
+	// copytruncate: the file shrinks in place and is written from the start,
+	// ending up shorter than what was already read
+	writeFile(t, path, "short\n")
+	expectLines(t, lines, "short")
+
+	appendFile(t, path, "next\n")
+	expectLines(t, lines, "next")
+	st := i.Status()
+	if st.Truncations != 1 || st.Rotations != 0 {
+		t.Errorf("Truncations, Rotations = %d, %d, want 1, 0", st.Truncations, st.Rotations)
+	}
+}
+
+func TestTailRecreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	writeFile(t, path, "")
+	i, lines := startTail(t, path)
+
+	appendFile(t, path, "one\n")
+	expectLines(t, lines, "one")
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing at the path for a while: the tailer keeps waiting for it
+	time.Sleep(2 * tailPollInterval)
+	writeFile(t, path, "two\n")
+	expectLines(t, lines, "two")
+
+	if st := i.Status(); st.Rotations != 1 {
+		t.Errorf("Rotations = %d, want 1", st.Rotations)
+	}
+}
+
+func TestTailRecreateBeforeFinalWriteRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	writeFile(t, path, "")
+	_, lines := startTail(t, path)
+
+	appendFile(t, path, "one\n")
+	expectLines(t, lines, "one")
+
+	// The old file's last line and its replacement land together, before the
+	// tailer has read the last line; it must not be lost in the switch
+	appendFile(t, path, "last of old\n")
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, path, "first of new\n")
+	expectLines(t, lines, "last of old", "first of new")
+}
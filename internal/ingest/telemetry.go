@@ -0,0 +1,100 @@
+package ingest
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// Health is what an ingester knows about its source's state.
+type Health struct {
+	LagBytes   int64 // Written to the source but not read yet; -1 if unknown
+	Reconnects int64
+}
+
+// HealthReporter is implemented by ingesters that can tell how far behind
+// their source they are or how often they had to reconnect to it.
+type HealthReporter interface {
+	Health() Health
+}
+
+// Telemetry measures the throughput of every input and publishes a
+// types.InputHealth for each on one shared channel.
+type Telemetry struct {
+	Interval time.Duration
+	updates  chan types.InputHealth
+}
+
+// NewTelemetry creates a Telemetry publishing each input's health every
+// interval.
+func NewTelemetry(interval time.Duration) *Telemetry {
+	return &Telemetry{Interval: interval, updates: make(chan types.InputHealth, 64)}
+}
+
+// Updates returns the channel the inputs' health is published on. Updates
+// are dropped while it is full, so a slow reader sees the latest ones late
+// rather than stalling ingestion.
+func (t *Telemetry) Updates() <-chan types.InputHealth {
+	return t.updates
+}
+
+// Measure passes records through, counting their lines and bytes, and
+// publishes the health of the input named name every Interval until ctx is
+// cancelled, and once more when records closes. Lag and reconnects come from ingester when it is a
+// HealthReporter.
+func (t *Telemetry) Measure(ctx context.Context, name string, ingester Ingester, records <-chan Record) <-chan Record {
+	var lines, bytes atomic.Int64
+	out := make(chan Record, 1000)
+	done := make(chan struct{})
+	go func() {
+		defer close(out)
+		defer close(done)
+		for r := range records {
+			lines.Add(1)
+			bytes.Add(int64(len(r.Line)) + 1)
+			select {
+			case out <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	reporter, _ := ingester.(HealthReporter)
+	go func() {
+		ticker := time.NewTicker(t.Interval)
+		defer ticker.Stop()
+		last := time.Now()
+		var lastLines, lastBytes int64
+		publish := func(now time.Time) {
+			h := types.InputHealth{Name: name, Lines: lines.Load(), Bytes: bytes.Load(), LagBytes: -1, Updated: now}
+			if secs := now.Sub(last).Seconds(); secs > 0 {
+				h.LinesPerSec = float64(h.Lines-lastLines) / secs
+				h.BytesPerSec = float64(h.Bytes-lastBytes) / secs
+			}
+			last, lastLines, lastBytes = now, h.Lines, h.Bytes
+			if reporter != nil {
+				health := reporter.Health()
+				h.LagBytes, h.Reconnects = health.LagBytes, health.Reconnects
+			}
+			select {
+			case t.updates <- h:
+			default:
+			}
+		}
+		for {
+			select {
+			case <-done:
+				publish(time.Now()) // Final totals of an input that ended
+				return
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				publish(now)
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,66 @@
+package ingest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// serverTLSConfig is the TLS configuration of a receiver with the
+// certificate and key in certFile and keyFile. With clientCA, clients must
+// present a certificate signed by it, and with clientNames also one whose
+// common name or a DNS name is in the list.
+func serverTLSConfig(certFile, keyFile, clientCA string, clientNames []string) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("tls.cert and tls.key are both required")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+	if clientCA == "" {
+		if len(clientNames) > 0 {
+			return nil, fmt.Errorf("tls.client_names needs tls.client_ca")
+		}
+		return config, nil
+	}
+	pem, err := os.ReadFile(clientCA)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates in %s", clientCA)
+	}
+	config.ClientCAs = pool
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	if len(clientNames) > 0 {
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) > 0 && certNameAllowed(cs.PeerCertificates[0], clientNames) {
+				return nil
+			}
+			return fmt.Errorf("client certificate is not one of tls.client_names")
+		}
+	}
+	return config, nil
+}
+
+// certNameAllowed reports whether the certificate's common name or one of
+// its DNS names is in names.
+func certNameAllowed(cert *x509.Certificate, names []string) bool {
+	for _, name := range names {
+		if strings.EqualFold(cert.Subject.CommonName, name) {
+			return true
+		}
+		for _, dns := range cert.DNSNames {
+			if strings.EqualFold(dns, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
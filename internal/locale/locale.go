@@ -0,0 +1,249 @@
+// Package locale formats numbers, durations, dates, and times for the
+// dashboard and reports: digit grouping, the decimal mark, 12- or 24-hour
+// clocks, and the order of day, month, and year.
+package locale
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Date orders.
+const (
+	YMD = "ymd"
+	DMY = "dmy"
+	MDY = "mdy"
+)
+
+// Locale describes how values are written.
+type Locale struct {
+	Name      string
+	Thousands string // Digit group separator; empty for no grouping
+	Decimal   string
+	Clock12   bool   // 3:04 PM instead of 15:04
+	DateOrder string // YMD, DMY, or MDY
+	DateSep   string
+}
+
+// Default is the built-in format: no digit grouping, a decimal point, a
+// 24-hour clock, and ISO dates. It is used unless a locale is configured.
+var Default = Locale{Decimal: ".", DateOrder: YMD, DateSep: "-"}
+
+// known maps locale names to their conventions.
+var known = map[string]Locale{
+	"en-US": {Thousands: ",", Decimal: ".", Clock12: true, DateOrder: MDY, DateSep: "/"},
+	"en-GB": {Thousands: ",", Decimal: ".", DateOrder: DMY, DateSep: "/"},
+	"en-AU": {Thousands: ",", Decimal: ".", Clock12: true, DateOrder: DMY, DateSep: "/"},
+	"en-CA": {Thousands: ",", Decimal: ".", Clock12: true, DateOrder: YMD, DateSep: "-"},
+	"de-DE": {Thousands: ".", Decimal: ",", DateOrder: DMY, DateSep: "."},
+	"fr-FR": {Thousands: " ", Decimal: ",", DateOrder: DMY, DateSep: "/"},
+	"es-ES": {Thousands: ".", Decimal: ",", DateOrder: DMY, DateSep: "/"},
+	"it-IT": {Thousands: ".", Decimal: ",", DateOrder: DMY, DateSep: "/"},
+	"nl-NL": {Thousands: ".", Decimal: ",", DateOrder: DMY, DateSep: "-"},
+	"pt-BR": {Thousands: ".", Decimal: ",", DateOrder: DMY, DateSep: "/"},
+	"sv-SE": {Thousands: " ", Decimal: ",", DateOrder: YMD, DateSep: "-"},
+	"pl-PL": {Thousands: " ", Decimal: ",", DateOrder: DMY, DateSep: "."},
+	"ru-RU": {Thousands: " ", Decimal: ",", DateOrder: DMY, DateSep: "."},
+	"ja-JP": {Thousands: ",", Decimal: ".", DateOrder: YMD, DateSep: "/"},
+	"zh-CN": {Thousands: ",", Decimal: ".", DateOrder: YMD, DateSep: "/"},
+	"hi-IN": {Thousands: ",", Decimal: ".", Clock12: true, DateOrder: DMY, DateSep: "/"},
+}
+
+// Names returns the known locale names, sorted.
+func Names() []string {
+	names := make([]string, 0, len(known))
+	for name := range known {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the locale for name, accepting POSIX forms such as
+// de_DE.UTF-8 and bare languages such as de. "C", "POSIX", and "" give
+// Default.
+func Lookup(name string) (Locale, error) {
+	tag := name
+	if i := strings.IndexAny(tag, ".@"); i >= 0 {
+		tag = tag[:i]
+	}
+	tag = strings.ReplaceAll(tag, "_", "-")
+	if tag == "" || tag == "C" || tag == "POSIX" {
+		return Default, nil
+	}
+	lang, region, _ := strings.Cut(tag, "-")
+	tag = strings.ToLower(lang)
+	if region != "" {
+		tag += "-" + strings.ToUpper(region)
+	}
+	if l, ok := known[tag]; ok {
+		l.Name = tag
+		return l, nil
+	}
+	if region == "" {
+		for _, candidate := range Names() {
+			if strings.HasPrefix(candidate, tag+"-") {
+				l := known[candidate]
+				l.Name = candidate
+				return l, nil
+			}
+		}
+	}
+	return Locale{}, fmt.Errorf("unknown locale %q (known: %s)", name, strings.Join(Names(), ", "))
+}
+
+// FromEnv returns the locale named by the environment: LC_ALL, LC_TIME, or
+// LANG, in that order.
+func FromEnv() string {
+	for _, key := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+var (
+	mu      sync.RWMutex
+	current = Default
+)
+
+// Set makes l the locale used by the package-level functions.
+func Set(l Locale) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = l
+}
+
+// Current returns the locale set with Set, or Default.
+func Current() Locale {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Int formats n with digit grouping.
+func (l Locale) Int(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	if strings.HasPrefix(s, "-") {
+		return "-" + l.group(s[1:])
+	}
+	return l.group(s)
+}
+
+// Float formats f with prec decimals.
+func (l Locale) Float(f float64, prec int) string {
+	s := strconv.FormatFloat(f, 'f', prec, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, ok := strings.Cut(s, ".")
+	if !ok {
+		return sign + l.group(whole)
+	}
+	return sign + l.group(whole) + l.Decimal + frac
+}
+
+// Percent formats f, already in percent, with prec decimals and a % sign.
+func (l Locale) Percent(f float64, prec int) string {
+	return l.Float(f, prec) + "%"
+}
+
+// Duration formats d like time.Duration.String, with the locale's decimal
+// mark, e.g. 1,25s.
+func (l Locale) Duration(d time.Duration) string {
+	return strings.Replace(d.String(), ".", l.Decimal, 1)
+}
+
+// Time formats the time of day with seconds.
+func (l Locale) Time(t time.Time) string {
+	if l.Clock12 {
+		return t.Format("3:04:05 PM")
+	}
+	return t.Format("15:04:05")
+}
+
+// ShortTime formats the time of day without seconds.
+func (l Locale) ShortTime(t time.Time) string {
+	if l.Clock12 {
+		return t.Format("3:04 PM")
+	}
+	return t.Format("15:04")
+}
+
+// Date formats the date with the year.
+func (l Locale) Date(t time.Time) string {
+	sep := l.DateSep
+	switch l.DateOrder {
+	case DMY:
+		return t.Format("02" + sep + "01" + sep + "2006")
+	case MDY:
+		return t.Format("01" + sep + "02" + sep + "2006")
+	}
+	return t.Format("2006" + sep + "01" + sep + "02")
+}
+
+// ShortDate formats the day and month.
+func (l Locale) ShortDate(t time.Time) string {
+	if l.DateOrder == DMY {
+		return t.Format("02" + l.DateSep + "01")
+	}
+	return t.Format("01" + l.DateSep + "02")
+}
+
+// DateTime formats the date and time of day with seconds.
+func (l Locale) DateTime(t time.Time) string {
+	return l.Date(t) + " " + l.Time(t)
+}
+
+// group inserts the thousands separator into a string of digits.
+func (l Locale) group(digits string) string {
+	if l.Thousands == "" || len(digits) <= 3 {
+		return digits
+	}
+	var b strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(l.Thousands)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+// Int formats n in the current locale.
+func Int(n int64) string { return Current().Int(n) }
+
+// Float formats f in the current locale.
+func Float(f float64, prec int) string { return Current().Float(f, prec) }
+
+// Percent formats a percentage in the current locale.
+func Percent(f float64, prec int) string { return Current().Percent(f, prec) }
+
+// Duration formats d in the current locale.
+func Duration(d time.Duration) string { return Current().Duration(d) }
+
+// Time formats the time of day in the current locale.
+func Time(t time.Time) string { return Current().Time(t) }
+
+// ShortTime formats the time of day without seconds in the current locale.
+func ShortTime(t time.Time) string { return Current().ShortTime(t) }
+
+// Date formats the date in the current locale.
+func Date(t time.Time) string { return Current().Date(t) }
+
+// ShortDate formats the day and month in the current locale.
+func ShortDate(t time.Time) string { return Current().ShortDate(t) }
+
+// DateTime formats the date and time in the current locale.
+func DateTime(t time.Time) string { return Current().DateTime(t) }
@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// amAlert is an alert in the Alertmanager v2 API.
+type amAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"`
+}
+
+// Alertmanager posts alerts to a Prometheus Alertmanager, which then routes,
+// groups, silences, and delivers them. An anomaly type fires an alert that
+// is renewed while it keeps firing and resolved once it has been quiet for
+// the resolve timeout.
+type Alertmanager struct {
+	url          string
+	username     string
+	password     string
+	resolveAfter time.Duration
+	labels       map[string]string
+	mu           sync.Mutex
+	firing       map[string]*amAlert // By anomaly type and severity
+}
+
+// NewAlertmanager creates an Alertmanager notifier for the Alertmanager at
+// url, e.g. http://alertmanager:9093. Alerts carry the anomaly type and
+// severity, an instance label with the host name, and labels.
+func NewAlertmanager(url, username, password string, resolveAfter time.Duration, labels map[string]string) *Alertmanager {
+	l := map[string]string{}
+	if host, err := os.Hostname(); err == nil {
+		l["instance"] = host
+	}
+	for k, v := range labels {
+		l[k] = v
+	}
+	return &Alertmanager{
+		url:          strings.TrimSuffix(url, "/") + "/api/v2/alerts",
+		username:     username,
+		password:     password,
+		resolveAfter: resolveAfter,
+		labels:       l,
+		firing:       make(map[string]*amAlert),
+	}
+}
+
+func (n *Alertmanager) Name() string { return "alertmanager" }
+
+// Notify fires or renews the alert for a's type and severity.
+func (n *Alertmanager) Notify(a types.Anomaly) error {
+	key := a.Type + "\x00" + a.Severity
+	n.mu.Lock()
+	alert, ok := n.firing[key]
+	if !ok {
+		labels := map[string]string{
+			"alertname":    alertName(a.Type),
+			"anomaly_type": a.Type,
+			"severity":     a.Severity,
+		}
+		for k, v := range n.labels {
+			labels[k] = v
+		}
+		alert = &amAlert{Labels: labels, StartsAt: a.Timestamp}
+		n.firing[key] = alert
+	}
+	alert.Annotations = map[string]string{"summary": title(a), "description": body(a)}
+	alert.EndsAt = a.Timestamp.Add(n.resolveAfter)
+	payload := *alert
+	n.mu.Unlock()
+	return n.post([]amAlert{payload})
+}
+
+// Resolve sends the alerts whose anomaly has not fired for the resolve
+// timeout as resolved.
+func (n *Alertmanager) Resolve(now time.Time) error {
+	var resolved []amAlert
+	n.mu.Lock()
+	for key, alert := range n.firing {
+		if !now.Before(alert.EndsAt) {
+			resolved = append(resolved, *alert)
+			delete(n.firing, key)
+		}
+	}
+	n.mu.Unlock()
+	if len(resolved) == 0 {
+		return nil
+	}
+	return n.post(resolved)
+}
+
+func (n *Alertmanager) post(alerts []amAlert) error {
+	data, err := json.Marshal(alerts)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, n.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.username != "" {
+		req.SetBasicAuth(n.username, n.password)
+	}
+	return send(req)
+}
+
+// alertName turns an anomaly type such as "Error Spike" into an alert name
+// in Prometheus style, "ErrorSpike".
+func alertName(anomalyType string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(anomalyType, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
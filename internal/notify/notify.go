@@ -0,0 +1,59 @@
+// Package notify sends anomaly alerts to push notification services.
+package notify
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// Notifier delivers an anomaly alert.
+type Notifier interface {
+	Name() string
+	Notify(a types.Anomaly) error
+}
+
+// Resolver is a Notifier that also reports when alerts stop firing.
+type Resolver interface {
+	Notifier
+	Resolve(now time.Time) error
+}
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// title is the short headline of an alert.
+func title(a types.Anomaly) string {
+	return fmt.Sprintf("pulsewatch: %s (%s)", a.Type, a.Severity)
+}
+
+// body is the alert text: the anomaly message and its top contributors.
+func body(a types.Anomaly) string {
+	var b strings.Builder
+	b.WriteString(a.Message)
+	for i, c := range a.Contributors {
+		if i == 3 {
+			break
+		}
+		fmt.Fprintf(&b, "\n%s=%s: %.0f%% (baseline %.0f%%)", c.Dimension, c.Value, c.Share, c.BaselineShare)
+	}
+	return b.String()
+}
+
+// send performs req and turns non-2xx responses into errors.
+func send(req *http.Request) error {
+	req.Header.Set("User-Agent", "pulsewatch")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// DefaultNtfyServer is the public ntfy instance.
+const DefaultNtfyServer = "https://ntfy.sh"
+
+// Ntfy publishes alerts to an ntfy topic.
+type Ntfy struct {
+	url   string
+	token string
+}
+
+// NewNtfy creates an Ntfy notifier publishing to topic on server. token is
+// an access token for protected topics and may be empty.
+func NewNtfy(server, topic, token string) *Ntfy {
+	if server == "" {
+		server = DefaultNtfyServer
+	}
+	return &Ntfy{url: strings.TrimSuffix(server, "/") + "/" + topic, token: token}
+}
+
+func (n *Ntfy) Name() string { return "ntfy" }
+
+func (n *Ntfy) Notify(a types.Anomaly) error {
+	req, err := http.NewRequest(http.MethodPost, n.url, strings.NewReader(body(a)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", title(a))
+	switch a.Severity {
+	case types.SeverityCritical:
+		req.Header.Set("Priority", "urgent")
+		req.Header.Set("Tags", "rotating_light")
+	case types.SeverityWarning:
+		req.Header.Set("Priority", "high")
+		req.Header.Set("Tags", "warning")
+	}
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+	return send(req)
+}
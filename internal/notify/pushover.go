@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+const pushoverURL = "https://api.pushover.net/1/messages.json"
+
+// Pushover sends alerts through the Pushover API.
+type Pushover struct {
+	token  string // Application API token
+	user   string // User or group key
+	device string // Optional device name; empty sends to all devices
+}
+
+// NewPushover creates a Pushover notifier.
+func NewPushover(token, user, device string) *Pushover {
+	return &Pushover{token: token, user: user, device: device}
+}
+
+func (p *Pushover) Name() string { return "pushover" }
+
+func (p *Pushover) Notify(a types.Anomaly) error {
+	priority := "-1"
+	switch a.Severity {
+	case types.SeverityCritical:
+		priority = "1"
+	case types.SeverityWarning:
+		priority = "0"
+	}
+	form := url.Values{
+		"token":     {p.token},
+		"user":      {p.user},
+		"title":     {title(a)},
+		"message":   {body(a)},
+		"priority":  {priority},
+		"timestamp": {strconv.FormatInt(a.Timestamp.Unix(), 10)},
+	}
+	if p.device != "" {
+		form.Set("device", p.device)
+	}
+	req, err := http.NewRequest(http.MethodPost, pushoverURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return send(req)
+}
@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+var (
+	graphQLNameFields  = []string{"operationName", "operation_name", "graphql_operation", "graphql.operation.name"}
+	graphQLTypeFields  = []string{"operationType", "operation_type", "graphql.operation.type"}
+	graphQLQueryFields = []string{"query", "graphql_query", "graphql.document", "request_body", "body"}
+	graphQLErrorFields = []string{"graphql_errors", "graphql.errors", "errors"}
+
+	// graphQLOperation matches the start of a GraphQL document: an operation
+	// keyword with an optional name, or the shorthand "{ field ... }" query.
+	graphQLOperation = regexp.MustCompile(`^\s*(?:(query|mutation|subscription)\b\s*([_A-Za-z][_0-9A-Za-z]*)?|\{\s*[_A-Za-z])`)
+)
+
+// parseJSONGraphQL names the GraphQL operation of a request, e.g. "GetUser",
+// or "anonymous mutation" for unnamed ones, from an operation name field or
+// the document in a query or request body field. A request that succeeded over
+// HTTP but reports GraphQL errors counts as a 500, since servers answer
+// resolver failures with 200.
+func parseJSONGraphQL(entry *types.LogEntry, raw map[string]interface{}) {
+	name := firstString(raw, graphQLNameFields)
+	kind := strings.ToLower(firstString(raw, graphQLTypeFields))
+	for _, key := range graphQLQueryFields {
+		text, ok := raw[key].(string)
+		if !ok {
+			continue
+		}
+		// A request body carries the document and name as JSON
+		var body struct {
+			Query         string `json:"query"`
+			OperationName string `json:"operationName"`
+		}
+		if json.Unmarshal([]byte(text), &body) == nil && body.Query != "" {
+			text = body.Query
+			if name == "" {
+				name = body.OperationName
+			}
+		}
+		m := graphQLOperation.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		if kind == "" {
+			kind = m[1]
+		}
+		if kind == "" {
+			kind = "query"
+		}
+		if name == "" {
+			name = m[2]
+		}
+		break
+	}
+
+	switch {
+	case name != "":
+		entry.Operation = name
+	case kind != "":
+		entry.Operation = "anonymous " + kind
+	default:
+		return
+	}
+	if entry.StatusCode < 400 && hasGraphQLErrors(raw) {
+		entry.StatusCode = 500
+	}
+}
+
+// hasGraphQLErrors reports whether an error count or errors list is
+// non-empty.
+func hasGraphQLErrors(raw map[string]interface{}) bool {
+	for _, key := range graphQLErrorFields {
+		switch v := raw[key].(type) {
+		case float64:
+			return v > 0
+		case []interface{}:
+			return len(v) > 0
+		}
+	}
+	return false
+}
+
+func firstString(raw map[string]interface{}, keys []string) string {
+	for _, key := range keys {
+		if v, ok := raw[key].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// GRPCCodes are the names of gRPC status codes 0-16, as Envoy's
+// %GRPC_STATUS% and most interceptors log them.
+var GRPCCodes = []string{
+	"OK", "Canceled", "Unknown", "InvalidArgument", "DeadlineExceeded", "NotFound",
+	"AlreadyExists", "PermissionDenied", "ResourceExhausted", "FailedPrecondition",
+	"Aborted", "OutOfRange", "Unimplemented", "Internal", "Unavailable", "DataLoss",
+	"Unauthenticated",
+}
+
+// GRPCHTTPStatus is the HTTP status each gRPC code counts as, following the
+// mapping of google.rpc.Code. Codes that map to 400 or above are errors.
+var GRPCHTTPStatus = map[string]int{
+	"OK":                 200,
+	"Canceled":           499,
+	"Unknown":            500,
+	"InvalidArgument":    400,
+	"DeadlineExceeded":   504,
+	"NotFound":           404,
+	"AlreadyExists":      409,
+	"PermissionDenied":   403,
+	"ResourceExhausted":  429,
+	"FailedPrecondition": 400,
+	"Aborted":            409,
+	"OutOfRange":         400,
+	"Unimplemented":      501,
+	"Internal":           500,
+	"Unavailable":        503,
+	"DataLoss":           500,
+	"Unauthenticated":    401,
+}
+
+var (
+	grpcStatusFields = []string{"grpc_status", "grpc-status", "grpc.code", "grpc_code", "grpc.status"}
+	grpcMethodFields = []string{"grpc.full_method", "grpc_full_method", "grpc_method"}
+)
+
+// NormalizeGRPCStatus maps a gRPC status code, as a number or a name in
+// any case with or without underscores ("DEADLINE_EXCEEDED", "Cancelled"),
+// onto one of GRPCCodes.
+func NormalizeGRPCStatus(v string) (string, bool) {
+	s := strings.TrimSpace(v)
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 0 || n >= len(GRPCCodes) {
+			return "", false
+		}
+		return GRPCCodes[n], true
+	}
+	s = strings.ReplaceAll(s, "_", "")
+	if strings.EqualFold(s, "Cancelled") {
+		return "Canceled", true
+	}
+	for _, code := range GRPCCodes {
+		if strings.EqualFold(s, code) {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// parseJSONGRPC reads the gRPC status and method from Envoy and interceptor
+// fields. The status replaces the HTTP status, which is 200 for most failed
+// calls, with the one GRPCHTTPStatus maps it to; the full method
+// ("/package.Service/Method") becomes the endpoint unless one is logged.
+func parseJSONGRPC(entry *types.LogEntry, raw map[string]interface{}) {
+	for _, key := range grpcStatusFields {
+		var text string
+		switch v := raw[key].(type) {
+		case string:
+			text = v
+		case float64:
+			text = strconv.Itoa(int(v))
+		default:
+			continue
+		}
+		if code, ok := NormalizeGRPCStatus(text); ok {
+			entry.GRPCStatus = code
+			entry.StatusCode = GRPCHTTPStatus[code]
+		}
+		break
+	}
+	if entry.GRPCStatus == "" {
+		return
+	}
+
+	if entry.Endpoint == "" {
+		for _, key := range grpcMethodFields {
+			if method, ok := raw[key].(string); ok && strings.HasPrefix(method, "/") {
+				entry.Endpoint = method
+				break
+			}
+		}
+	}
+	// grpc-ecosystem interceptors log the service and method separately
+	service, _ := raw["grpc.service"].(string)
+	method, _ := raw["grpc.method"].(string)
+	if entry.Endpoint == "" && service != "" && method != "" {
+		entry.Endpoint = "/" + service + "/" + method
+	}
+	if ms, ok := raw["grpc.time_ms"].(float64); ok && entry.Latency == 0 {
+		entry.Latency = time.Duration(ms * float64(time.Millisecond))
+	}
+}
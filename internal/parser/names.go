@@ -0,0 +1,65 @@
+package parser
+
+import "fmt"
+
+// Names lists the parsers that can be selected by name. "auto" is the
+// configured chain that watch and replay use.
+var Names = []string{"auto", "json", "nginx", "apache", "syslog", "line"}
+
+// DefaultOrder is the parser chain used when the config doesn't set one.
+var DefaultOrder = []string{"json", "nginx", "line"}
+
+// NewChain returns a MultiParser that tries the named parsers in order.
+// Names may also refer to the custom parsers given.
+func NewChain(names []string, custom ...RegexSpec) (*MultiParser, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no parsers enabled")
+	}
+	parsers := make([]Parser, 0, len(names))
+	for _, name := range names {
+		if name == "auto" {
+			return nil, fmt.Errorf("parser %q cannot be part of a chain", name)
+		}
+		p, err := Find(name, custom)
+		if err != nil {
+			return nil, err
+		}
+		parsers = append(parsers, p)
+	}
+	return NewMultiParser(parsers...), nil
+}
+
+// ByName returns the parser with the given name, one of Names. "auto"
+// returns the default chain.
+func ByName(name string) (Parser, error) {
+	switch name {
+	case "auto":
+		return NewChain(DefaultOrder)
+	case "json":
+		return &JSONParser{}, nil
+	case "nginx":
+		return NewNginxParser(), nil
+	case "apache":
+		return NewApacheParser(), nil
+	case "syslog":
+		return NewSyslogParser(), nil
+	case "line":
+		return &LineParser{}, nil
+	}
+	return nil, fmt.Errorf("unknown parser %q (available: %v)", name, Names)
+}
+
+// Find returns the custom parser with the given name, or else the built-in
+// one.
+func Find(name string, custom []RegexSpec) (Parser, error) {
+	for _, spec := range custom {
+		if spec.Name == name {
+			p, err := NewRegexParser(spec)
+			if err != nil {
+				return nil, fmt.Errorf("parser %s: %w", name, err)
+			}
+			return p, nil
+		}
+	}
+	return ByName(name)
+}
@@ -36,6 +36,24 @@ func (p *MultiParser) Parse(line string) (types.LogEntry, bool) {
 	return types.LogEntry{}, false
 }
 
+// ParseWith is Parse that also returns the parser that accepted the line,
+// or nil if none did.
+func (p *MultiParser) ParseWith(line string) (types.LogEntry, Parser) {
+	for _, parser := range p.parsers {
+		if entry, ok := parser.Parse(line); ok {
+			return entry, parser
+		}
+	}
+	return types.LogEntry{}, nil
+}
+
+// Structured reports whether p extracts fields from lines, i.e. it is set
+// and isn't the line fallback that keeps any line as a message.
+func Structured(p Parser) bool {
+	_, fallback := p.(*LineParser)
+	return p != nil && !fallback
+}
+
 // JSONParser parses JSON log lines.
 type JSONParser struct{}
 
@@ -110,6 +128,34 @@ func (p *JSONParser) Parse(line string) (types.LogEntry, bool) {
 		}
 	}
 
+	// Look for common method fields
+	for _, key := range []string{"method", "http_method", "request_method"} {
+		if method, ok := raw[key].(string); ok {
+			entry.Method = strings.ToUpper(method)
+			break
+		}
+	}
+
+	// Look for common cache status fields (nginx, Varnish, CloudFront)
+	for _, key := range cacheStatusFields {
+		if status, ok := raw[key].(string); ok {
+			entry.CacheStatus = normalizeCacheStatus(status)
+			break
+		}
+	}
+
+	// Look for queueing delay and service time
+	parseJSONTiming(&entry, raw)
+
+	// Look for HTTP and TLS versions
+	parseJSONProtocol(&entry, raw)
+
+	// Look for gRPC status codes and method names
+	parseJSONGRPC(&entry, raw)
+
+	// Look for GraphQL operations
+	parseJSONGraphQL(&entry, raw)
+
 	// Add all raw fields to the entry's Fields map
 	for k, v := range raw {
 		entry.Fields[k] = v
@@ -137,8 +183,9 @@ func NewApacheParser() *ApacheParser {
 
 // NewNginxParser creates a new NginxParser.
 func NewNginxParser() *NginxParser {
-	// A common Nginx log format regex
-	re := regexp.MustCompile(`(?P<remote_addr>\S+) - (?P<remote_user>\S+) \[(?P<time_local>.+)\] "(?P<request>\S+ \S+ \S+)" (?P<status>\d{3}) (?P<body_bytes_sent>\d+) "(?P<http_referer>[^"]*)" "(?P<http_user_agent>[^"]*)" (?P<request_time>\S+)`)
+	// A common Nginx log format regex, optionally followed by $upstream_response_time
+	// and $upstream_cache_status
+	re := regexp.MustCompile(`(?P<remote_addr>\S+) - (?P<remote_user>\S+) \[(?P<time_local>.+)\] "(?P<request>\S+ \S+ \S+)" (?P<status>\d{3}) (?P<body_bytes_sent>\d+) "(?P<http_referer>[^"]*)" "(?P<http_user_agent>[^"]*)" (?P<request_time>\S+)(?: (?P<upstream_response_time>[\d.]+|-))?(?: "?(?P<cache_status>[A-Za-z]+)"?)?`)
 	return &NginxParser{regex: re}
 }
 
@@ -168,6 +215,11 @@ func (p *ApacheParser) Parse(line string) (types.LogEntry, bool) {
 	if len(requestParts) > 1 {
 		endpoint = requestParts[1]
 	}
+	method := strings.ToUpper(requestParts[0])
+	var protocol string
+	if len(requestParts) > 2 {
+		protocol = normalizeProtocol(requestParts[2])
+	}
 
 	ua := user_agent.New(result["http_user_agent"])
 	browserName, browserVersion := ua.Browser()
@@ -177,6 +229,8 @@ func (p *ApacheParser) Parse(line string) (types.LogEntry, bool) {
 		Message:    line,
 		StatusCode: status,
 		Endpoint:   endpoint,
+		Method:     method,
+		Protocol:   protocol,
 		Fields: map[string]interface{}{
 			"remote_addr":      result["remote_addr"],
 			"request":          result["request"],
@@ -223,6 +277,11 @@ func (p *NginxParser) Parse(line string) (types.LogEntry, bool) {
 	if len(requestParts) > 1 {
 		endpoint = requestParts[1]
 	}
+	method := strings.ToUpper(requestParts[0])
+	var protocol string
+	if len(requestParts) > 2 {
+		protocol = normalizeProtocol(requestParts[2])
+	}
 
 	latency := 0.0
 	if rt, err := strconv.ParseFloat(result["request_time"], 64); err == nil {
@@ -233,11 +292,14 @@ func (p *NginxParser) Parse(line string) (types.LogEntry, bool) {
 	browserName, browserVersion := ua.Browser()
 
 	entry := types.LogEntry{
-		Timestamp:  ts,
-		Message:    line,
-		StatusCode: status,
-		Endpoint:   endpoint,
-		Latency:    time.Duration(latency * float64(time.Second)),
+		Timestamp:   ts,
+		Message:     line,
+		StatusCode:  status,
+		Endpoint:    endpoint,
+		Method:      method,
+		Protocol:    protocol,
+		Latency:     time.Duration(latency * float64(time.Second)),
+		CacheStatus: normalizeCacheStatus(result["cache_status"]),
 		Fields: map[string]interface{}{
 			"remote_addr":      result["remote_addr"],
 			"request":          result["request"],
@@ -249,6 +311,11 @@ func (p *NginxParser) Parse(line string) (types.LogEntry, bool) {
 		},
 	}
 
+	if rt, err := strconv.ParseFloat(result["upstream_response_time"], 64); err == nil {
+		entry.ServiceTime = time.Duration(rt * float64(time.Second))
+		deriveTiming(&entry)
+	}
+
 	if status >= 400 {
 		entry.Level = types.ErrorLevel
 	} else {
@@ -265,20 +332,24 @@ type LineParser struct{}
 
 // Parse treats the entire line as a message.
 func (p *LineParser) Parse(line string) (types.LogEntry, bool) {
-	level := types.InfoLevel
-	if strings.Contains(strings.ToLower(line), "error") {
-		level = types.ErrorLevel
-	} else if strings.Contains(strings.ToLower(line), "warn") {
-		level = types.WarnLevel
-	}
-
 	return types.LogEntry{
 		Timestamp: time.Now(),
 		Message:   line,
-		Level:     level,
+		Level:     lineLevel(line),
 	}, true
 }
 
+// lineLevel guesses the level of free text from the words "error" and
+// "warn".
+func lineLevel(line string) types.LogLevel {
+	if strings.Contains(strings.ToLower(line), "error") {
+		return types.ErrorLevel
+	} else if strings.Contains(strings.ToLower(line), "warn") {
+		return types.WarnLevel
+	}
+	return types.InfoLevel
+}
+
 func parseTimestamp(ts interface{}) time.Time {
 	switch v := ts.(type) {
 	case string:
@@ -304,6 +375,29 @@ func parseTimestamp(ts interface{}) time.Time {
 	return time.Now()
 }
 
+// cacheStatusFields are the JSON keys checked for a cache status, in order.
+var cacheStatusFields = []string{"cache_status", "upstream_cache_status", "x_cache", "x-cache", "x_edge_result_type", "x-edge-result-type"}
+
+// normalizeCacheStatus maps the cache results of nginx ($upstream_cache_status),
+// Varnish (X-Cache: "HIT", "MISS, HIT") and CloudFront (x-edge-result-type:
+// "Hit", "RefreshHit", "Miss", ...) onto upper-case statuses with HIT and
+// MISS as the common denominators.
+func normalizeCacheStatus(status string) string {
+	s := strings.ToUpper(strings.TrimSpace(status))
+	if i := strings.LastIndex(s, ","); i >= 0 { // Chained caches: the edge result comes last
+		s = strings.TrimSpace(s[i+1:])
+	}
+	switch {
+	case s == "" || s == "-":
+		return ""
+	case strings.Contains(s, "HIT"), s == "REVALIDATED", s == "STALE", s == "UPDATING":
+		return types.CacheHit
+	case strings.Contains(s, "MISS"), s == "EXPIRED":
+		return "MISS"
+	}
+	return s
+}
+
 func parseLevel(level string) types.LogLevel {
 	l := strings.ToUpper(level)
 	switch l {
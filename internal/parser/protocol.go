@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+var (
+	protocolFields   = []string{"protocol", "http_version", "server_protocol", "request_protocol"}
+	tlsVersionFields = []string{"tls_version", "ssl_protocol", "tls_protocol"}
+)
+
+// parseJSONProtocol reads the HTTP and TLS versions from common JSON fields.
+func parseJSONProtocol(entry *types.LogEntry, raw map[string]interface{}) {
+	for _, key := range protocolFields {
+		if v, ok := raw[key].(string); ok {
+			entry.Protocol = normalizeProtocol(v)
+			break
+		}
+	}
+	for _, key := range tlsVersionFields {
+		if v, ok := raw[key].(string); ok {
+			entry.TLSVersion = normalizeTLSVersion(v)
+			break
+		}
+	}
+}
+
+// normalizeProtocol maps spellings such as "HTTP/2.0", "h2" and "2" onto
+// HTTP/1.0, HTTP/1.1, HTTP/2 and HTTP/3.
+func normalizeProtocol(v string) string {
+	s := strings.ToUpper(strings.TrimSpace(v))
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "HTTP/"), "H")
+	switch s {
+	case "":
+		return ""
+	case "1.0", "1":
+		return "HTTP/1.0"
+	case "1.1":
+		return "HTTP/1.1"
+	case "2", "2.0", "2C":
+		return "HTTP/2"
+	case "3", "3.0":
+		return "HTTP/3"
+	}
+	return strings.ToUpper(strings.TrimSpace(v))
+}
+
+// normalizeTLSVersion maps spellings such as "TLSv1.2" (nginx), "TLS 1.3"
+// and "tls1.3" onto TLSv1.x; "-" means no TLS.
+func normalizeTLSVersion(v string) string {
+	s := strings.ToUpper(strings.TrimSpace(v))
+	if s == "" || s == "-" {
+		return ""
+	}
+	if strings.HasPrefix(s, "SSL") {
+		return "SSLv" + strings.TrimLeft(s[3:], "V ")
+	}
+	s = strings.TrimLeft(strings.TrimPrefix(s, "TLS"), "V _")
+	return "TLSv" + s
+}
@@ -0,0 +1,233 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// RegexSpec defines a custom parser in the config file: a regular
+// expression whose named groups become entry attributes or fields.
+type RegexSpec struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+	// Fields maps group names to targets: an entry attribute (timestamp,
+	// level, message, status, latency, endpoint, method, cache_status,
+	// queue_time, service_time, protocol, tls_version, grpc_status,
+	// operation) or any other name for a parsed field. Unmapped groups keep
+	// their own name.
+	Fields map[string]string `yaml:"fields"`
+	// Types are conversion hints per group: int, float, string,
+	// duration[:unit], or time:<layout>.
+	Types map[string]string `yaml:"types"`
+}
+
+// durationUnits are the units of bare numbers in duration hints.
+var durationUnits = map[string]time.Duration{"ns": time.Nanosecond, "us": time.Microsecond, "ms": time.Millisecond, "s": time.Second}
+
+// RegexParser is a parser defined by a RegexSpec.
+type RegexParser struct {
+	regex  *regexp.Regexp
+	groups []regexGroup // Indexed by submatch; unnamed groups have no target
+}
+
+// regexGroup is a named group with its target and conversion.
+type regexGroup struct {
+	target string
+	kind   string        // int, float, string, duration, or time; "" for the target's default
+	unit   time.Duration // Of bare numbers, for durations
+	layout string        // For times
+}
+
+// NewRegexParser compiles spec.
+func NewRegexParser(spec RegexSpec) (*RegexParser, error) {
+	re, err := regexp.Compile(spec.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("pattern: %w", err)
+	}
+	p := &RegexParser{regex: re, groups: make([]regexGroup, len(re.SubexpNames()))}
+	named := make(map[string]bool)
+	for i, name := range re.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		named[name] = true
+		g := regexGroup{target: name, unit: time.Second}
+		if target, ok := spec.Fields[name]; ok {
+			if target == "" {
+				return nil, fmt.Errorf("fields: group %s has no target", name)
+			}
+			g.target = target
+		}
+		if hint, ok := spec.Types[name]; ok {
+			kind, arg, _ := strings.Cut(hint, ":")
+			switch kind {
+			case "int", "float", "string":
+			case "duration":
+				if arg != "" {
+					if g.unit, ok = durationUnits[arg]; !ok {
+						return nil, fmt.Errorf("types: group %s: unknown duration unit %q (use ns, us, ms, or s)", name, arg)
+					}
+				}
+			case "time":
+				if arg == "" {
+					return nil, fmt.Errorf("types: group %s: time needs a layout, e.g. time:2006-01-02 15:04:05", name)
+				}
+				g.layout = arg
+			default:
+				return nil, fmt.Errorf("types: group %s: unknown type %q (use int, float, string, duration, or time:<layout>)", name, hint)
+			}
+			g.kind = kind
+		}
+		p.groups[i] = g
+	}
+	if len(named) == 0 {
+		return nil, fmt.Errorf("pattern has no named groups, e.g. (?P<status>\\d+)")
+	}
+	for name := range spec.Fields {
+		if !named[name] {
+			return nil, fmt.Errorf("fields: pattern has no group %s", name)
+		}
+	}
+	for name := range spec.Types {
+		if !named[name] {
+			return nil, fmt.Errorf("types: pattern has no group %s", name)
+		}
+	}
+	return p, nil
+}
+
+// Parse attempts to parse a line with the parser's pattern. Lines without a
+// timestamp group are stamped with the current time, without a message
+// group keep the whole line as the message, and without a level group get
+// ERROR for statuses of 400 and above and otherwise INFO.
+func (p *RegexParser) Parse(line string) (types.LogEntry, bool) {
+	match := p.regex.FindStringSubmatch(line)
+	if match == nil {
+		return types.LogEntry{}, false
+	}
+
+	entry := types.LogEntry{Message: line, Fields: make(map[string]interface{})}
+	var hasTime, hasLevel bool
+	for i, g := range p.groups {
+		if g.target == "" {
+			continue // Unnamed group
+		}
+		value := match[i]
+		switch g.target {
+		case "timestamp":
+			if value == "" {
+				continue
+			}
+			if g.kind == "time" {
+				ts, err := time.ParseInLocation(g.layout, value, time.Local)
+				if err != nil {
+					continue
+				}
+				entry.Timestamp = ts
+			} else {
+				entry.Timestamp = parseTimestamp(value)
+			}
+			hasTime = true
+		case "level":
+			if value != "" {
+				entry.Level = regexLevel(value)
+				hasLevel = true
+			}
+		case "message":
+			entry.Message = value
+		case "status":
+			entry.StatusCode, _ = strconv.Atoi(value)
+		case "latency":
+			entry.Latency = g.duration(value)
+		case "queue_time":
+			entry.QueueTime = g.duration(value)
+		case "service_time":
+			entry.ServiceTime = g.duration(value)
+		case "endpoint":
+			entry.Endpoint = value
+		case "method":
+			entry.Method = strings.ToUpper(value)
+		case "cache_status":
+			entry.CacheStatus = normalizeCacheStatus(value)
+		case "protocol":
+			entry.Protocol = normalizeProtocol(value)
+		case "tls_version":
+			entry.TLSVersion = value
+		case "grpc_status":
+			entry.GRPCStatus = value
+		case "operation":
+			entry.Operation = value
+		default:
+			if v, ok := g.convert(value); ok && value != "" {
+				entry.Fields[g.target] = v
+			}
+		}
+	}
+
+	if !hasTime {
+		entry.Timestamp = time.Now()
+	}
+	if !hasLevel {
+		switch {
+		case entry.StatusCode >= 400:
+			entry.Level = types.ErrorLevel
+		case entry.StatusCode > 0:
+			entry.Level = types.InfoLevel
+		default:
+			entry.Level = lineLevel(entry.Message)
+		}
+	}
+	deriveTiming(&entry)
+	return entry, true
+}
+
+// duration converts a latency: Go syntax such as "12ms", or a bare number
+// in the group's unit (seconds by default).
+func (g regexGroup) duration(value string) time.Duration {
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(f * float64(g.unit))
+	}
+	d, _ := time.ParseDuration(value)
+	return d
+}
+
+// convert applies a field's type hint; fields without one stay strings.
+func (g regexGroup) convert(value string) (interface{}, bool) {
+	switch g.kind {
+	case "int":
+		n, err := strconv.ParseInt(value, 10, 64)
+		return float64(n), err == nil
+	case "float":
+		f, err := strconv.ParseFloat(value, 64)
+		return f, err == nil
+	case "duration":
+		// Fields hold numbers as JSON does, so durations are milliseconds
+		return float64(g.duration(value)) / float64(time.Millisecond), value != ""
+	case "time":
+		ts, err := time.ParseInLocation(g.layout, value, time.Local)
+		return ts.Format(time.RFC3339Nano), err == nil
+	}
+	return value, true
+}
+
+// regexLevel reads a level group, also understanding the severities of
+// loggers like Postgres (LOG, FATAL, PANIC) and syslog (CRIT, NOTICE).
+func regexLevel(value string) types.LogLevel {
+	if level := parseLevel(value); level != types.UnknownLevel {
+		return level
+	}
+	switch strings.ToUpper(value) {
+	case "FATAL", "PANIC", "CRITICAL", "CRIT", "EMERG", "ALERT", "SEVERE":
+		return types.ErrorLevel
+	case "LOG", "NOTICE":
+		return types.InfoLevel
+	case "TRACE":
+		return types.DebugLevel
+	}
+	return types.UnknownLevel
+}
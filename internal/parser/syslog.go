@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// syslogSeverities are the RFC 5424 severity names, indexed by severity.
+var syslogSeverities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// SyslogParser parses syslog lines in RFC 5424 ("<PRI>1 TIMESTAMP HOSTNAME
+// APP-NAME PROCID MSGID SD MSG") or RFC 3164 ("<PRI>Mmm dd hh:mm:ss HOSTNAME
+// TAG[PID]: MSG") format. The priority may be missing from RFC 3164 lines,
+// as in the files rsyslog writes.
+type SyslogParser struct {
+	now func() time.Time
+}
+
+// NewSyslogParser creates a new SyslogParser.
+func NewSyslogParser() *SyslogParser {
+	return &SyslogParser{now: time.Now}
+}
+
+// Parse attempts to parse a line as a syslog message. The header becomes
+// the entry's fields, the severity its level, and each structured data
+// parameter a field named "<sd-id>.<name>", e.g. "meta.request_id".
+func (p *SyslogParser) Parse(line string) (types.LogEntry, bool) {
+	rest := line
+	pri := -1
+	if strings.HasPrefix(line, "<") {
+		end := strings.IndexByte(line, '>')
+		if end < 2 || end > 4 {
+			return types.LogEntry{}, false
+		}
+		n, err := strconv.Atoi(line[1:end])
+		if err != nil || n < 0 || n > 191 {
+			return types.LogEntry{}, false
+		}
+		pri = n
+		rest = line[end+1:]
+	}
+
+	var entry types.LogEntry
+	var ok bool
+	if pri >= 0 && strings.HasPrefix(rest, "1 ") {
+		entry, ok = p.parse5424(rest[2:])
+	} else {
+		entry, ok = p.parse3164(rest)
+	}
+	if !ok {
+		return types.LogEntry{}, false
+	}
+
+	if pri >= 0 {
+		severity := pri % 8
+		entry.Fields["facility"] = strconv.Itoa(pri / 8)
+		entry.Fields["severity"] = syslogSeverities[severity]
+		entry.Level = severityLevel(severity)
+	} else {
+		entry.Level = lineLevel(entry.Message)
+	}
+	return entry, true
+}
+
+// parse5424 parses what follows "<PRI>1 ": TIMESTAMP HOSTNAME APP-NAME
+// PROCID MSGID, then the structured data and the message.
+func (p *SyslogParser) parse5424(rest string) (types.LogEntry, bool) {
+	parts := strings.SplitN(rest, " ", 6)
+	if len(parts) < 6 {
+		return types.LogEntry{}, false
+	}
+	entry := types.LogEntry{Timestamp: p.now(), Fields: make(map[string]interface{})}
+	if parts[0] != "-" {
+		ts, err := time.Parse(time.RFC3339Nano, parts[0])
+		if err != nil {
+			return types.LogEntry{}, false
+		}
+		entry.Timestamp = ts
+	}
+	for i, key := range []string{"hostname", "app_name", "procid", "msgid"} {
+		if value := parts[i+1]; value != "-" {
+			entry.Fields[key] = value
+		}
+	}
+
+	msg := parts[5]
+	if strings.HasPrefix(msg, "-") {
+		msg = msg[1:]
+	} else {
+		for strings.HasPrefix(msg, "[") {
+			end := sdElementEnd(msg)
+			if end < 0 {
+				return types.LogEntry{}, false
+			}
+			parseSDElement(msg[1:end], entry.Fields)
+			msg = msg[end+1:]
+		}
+	}
+	entry.Message = strings.TrimPrefix(strings.TrimPrefix(msg, " "), "\ufeff")
+	return entry, true
+}
+
+// parse3164 parses a BSD syslog line after its priority: a 15-character
+// timestamp, the hostname, and a tag ending in a colon.
+func (p *SyslogParser) parse3164(rest string) (types.LogEntry, bool) {
+	if len(rest) < 17 || rest[15] != ' ' {
+		return types.LogEntry{}, false
+	}
+	ts, err := time.ParseInLocation(time.Stamp, rest[:15], time.Local)
+	if err != nil {
+		return types.LogEntry{}, false
+	}
+	// The timestamp has no year: take the current one, or last year's for a
+	// December line read in January.
+	now := p.now()
+	ts = ts.AddDate(now.Year(), 0, 0)
+	if ts.After(now.Add(24 * time.Hour)) {
+		ts = ts.AddDate(-1, 0, 0)
+	}
+
+	host, body, ok := strings.Cut(rest[16:], " ")
+	if !ok || host == "" {
+		return types.LogEntry{}, false
+	}
+	entry := types.LogEntry{Timestamp: ts, Fields: map[string]interface{}{"hostname": host}}
+	if tag, text, ok := strings.Cut(body, ": "); ok && tag != "" && !strings.Contains(tag, " ") {
+		if open := strings.IndexByte(tag, '['); open > 0 && strings.HasSuffix(tag, "]") {
+			entry.Fields["procid"] = tag[open+1 : len(tag)-1]
+			tag = tag[:open]
+		}
+		entry.Fields["app_name"] = tag
+		body = text
+	}
+	entry.Message = body
+	return entry, true
+}
+
+// sdElementEnd returns the index of the "]" closing the structured data
+// element at the start of s, skipping quoted and escaped characters, or -1.
+func sdElementEnd(s string) int {
+	quoted := false
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == ']' && !quoted:
+			return i
+		}
+	}
+	return -1
+}
+
+// parseSDElement adds the parameters of one structured data element
+// (`id name="value" ...`, without the brackets) to fields. The enterprise
+// number is dropped from the ID, so "origin@32473" params become "origin.*".
+func parseSDElement(element string, fields map[string]interface{}) {
+	id, params, _ := strings.Cut(element, " ")
+	if at := strings.IndexByte(id, '@'); at > 0 {
+		id = id[:at]
+	}
+	for params != "" {
+		params = strings.TrimLeft(params, " ")
+		name, value, ok := strings.Cut(params, `="`)
+		if !ok {
+			return
+		}
+		var b strings.Builder
+		i := 0
+		for ; i < len(value) && value[i] != '"'; i++ {
+			if value[i] == '\\' && i+1 < len(value) {
+				i++
+			}
+			b.WriteByte(value[i])
+		}
+		fields[id+"."+name] = b.String()
+		if i >= len(value) {
+			return
+		}
+		params = value[i+1:]
+	}
+}
+
+// severityLevel maps a syslog severity onto a log level: emerg through err
+// are errors, warning a warning, debug debug, and the rest info.
+func severityLevel(severity int) types.LogLevel {
+	switch {
+	case severity <= 3:
+		return types.ErrorLevel
+	case severity == 4:
+		return types.WarnLevel
+	case severity == 7:
+		return types.DebugLevel
+	}
+	return types.InfoLevel
+}
@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// parseJSONTiming fills in queueing delay and service time from JSON fields:
+// explicit durations (queue_ms, service_ms in milliseconds; nginx-style
+// upstream_response_time in seconds) or arrival/start timestamps. When only
+// one of the two is known, the other is derived from the total latency.
+func parseJSONTiming(entry *types.LogEntry, raw map[string]interface{}) {
+	if ms, ok := numberField(raw, "queue_ms", "queue_time"); ok {
+		entry.QueueTime = time.Duration(ms * float64(time.Millisecond))
+	} else if arrival, ok := raw["arrival_time"]; ok {
+		for _, key := range []string{"start_time", "started_at"} {
+			if start, ok := raw[key]; ok {
+				if d := parseTimestamp(start).Sub(parseTimestamp(arrival)); d > 0 {
+					entry.QueueTime = d
+				}
+				break
+			}
+		}
+	}
+
+	if ms, ok := numberField(raw, "service_ms", "service_time"); ok {
+		entry.ServiceTime = time.Duration(ms * float64(time.Millisecond))
+	} else if s, ok := numberField(raw, "upstream_response_time"); ok {
+		entry.ServiceTime = time.Duration(s * float64(time.Second))
+	}
+
+	deriveTiming(entry)
+}
+
+// deriveTiming completes the queue/service split from the total latency when
+// only one half was logged.
+func deriveTiming(entry *types.LogEntry) {
+	if entry.Latency <= 0 {
+		return
+	}
+	switch {
+	case entry.ServiceTime > 0 && entry.QueueTime == 0 && entry.Latency > entry.ServiceTime:
+		entry.QueueTime = entry.Latency - entry.ServiceTime
+	case entry.QueueTime > 0 && entry.ServiceTime == 0 && entry.Latency > entry.QueueTime:
+		entry.ServiceTime = entry.Latency - entry.QueueTime
+	}
+}
+
+// numberField returns the first of keys holding a number or numeric string.
+func numberField(raw map[string]interface{}, keys ...string) (float64, bool) {
+	for _, key := range keys {
+		switch v := raw[key].(type) {
+		case float64:
+			return v, true
+		case string:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// totalTimingFields are _ms fields holding a request's whole duration or
+// its queue/service split rather than one component of it.
+var totalTimingFields = map[string]bool{
+	"latency_ms": true, "duration_ms": true, "elapsed_ms": true, "total_ms": true, "took_ms": true,
+	"time_ms": true, "request_time_ms": true, "response_time_ms": true, "grpc.time_ms": true,
+	"queue_ms": true, "service_ms": true,
+}
+
+// ComponentTimings returns the milliseconds logged in the given timing
+// fields, keyed by field name without its _ms suffix (db_ms is db). Without
+// fields, every numeric field ending in _ms is used except the totals.
+func ComponentTimings(raw map[string]interface{}, fields []string) map[string]float64 {
+	var timings map[string]float64
+	add := func(field string) {
+		ms, ok := numberField(raw, field)
+		if !ok || ms < 0 {
+			return
+		}
+		if timings == nil {
+			timings = make(map[string]float64)
+		}
+		timings[strings.TrimSuffix(field, "_ms")] = ms
+	}
+	if len(fields) > 0 {
+		for _, field := range fields {
+			add(field)
+		}
+		return timings
+	}
+	for key := range raw {
+		if strings.HasSuffix(key, "_ms") && !totalTimingFields[key] {
+			add(key)
+		}
+	}
+	return timings
+}
@@ -0,0 +1,86 @@
+// Package patterns groups log lines that differ only in variable parts such
+// as numbers, IDs, addresses, and timestamps.
+package patterns
+
+import (
+	"regexp"
+	"sort"
+)
+
+// masks replace the variable parts of a line, most specific first.
+var masks = []struct {
+	re          *regexp.Regexp
+	placeholder string
+}{
+	{regexp.MustCompile(`\[\d{2}/\w{3}/\d{4}(:\d{2}){3} [+-]\d{4}\]`), "[<time>]"},
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`), "<time>"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}(:\d+)?\b`), "<ip>"},
+	{regexp.MustCompile(`(?i)\b(0x)?[0-9a-f]*\d[0-9a-f]*[a-f][0-9a-f]*\b|\b[0-9a-f]*[a-f][0-9a-f]*\d[0-9a-f]*\b`), "<hex>"},
+	{regexp.MustCompile(`\?[^\s"]*`), "?<query>"},
+	{regexp.MustCompile(`\d+(\.\d+)?`), "<num>"},
+}
+
+// Of returns line's pattern: line with its variable parts replaced by
+// placeholders such as <num>, <ip>, and <time>.
+func Of(line string) string {
+	for _, m := range masks {
+		line = m.re.ReplaceAllString(line, m.placeholder)
+	}
+	return line
+}
+
+// Sample is one pattern seen during an interval.
+type Sample struct {
+	Pattern string
+	Line    string // The first line of the interval with this pattern
+	Count   int
+}
+
+// Sampler keeps one representative line per pattern until flushed.
+type Sampler struct {
+	max     int
+	samples map[string]*Sample
+	lines   int
+	dropped int // Lines whose pattern didn't fit under max
+}
+
+// NewSampler creates a Sampler tracking at most max patterns per interval.
+func NewSampler(max int) *Sampler {
+	return &Sampler{max: max, samples: make(map[string]*Sample)}
+}
+
+// Add counts line under its pattern.
+func (s *Sampler) Add(line string) {
+	s.lines++
+	pattern := Of(line)
+	if sample, ok := s.samples[pattern]; ok {
+		sample.Count++
+		return
+	}
+	if len(s.samples) >= s.max {
+		s.dropped++
+		return
+	}
+	s.samples[pattern] = &Sample{Pattern: pattern, Line: line, Count: 1}
+}
+
+// Flush returns the interval's samples, most frequent first, with the
+// number of lines added and how many of them fell outside the tracked
+// patterns, and starts a new interval.
+func (s *Sampler) Flush() (samples []Sample, lines, dropped int) {
+	samples = make([]Sample, 0, len(s.samples))
+	for _, sample := range s.samples {
+		samples = append(samples, *sample)
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].Count != samples[j].Count {
+			return samples[i].Count > samples[j].Count
+		}
+		return samples[i].Pattern < samples[j].Pattern
+	})
+	lines, dropped = s.lines, s.dropped
+	s.samples = make(map[string]*Sample)
+	s.lines, s.dropped = 0, 0
+	return samples, lines, dropped
+}
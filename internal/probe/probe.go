@@ -0,0 +1,107 @@
+// Package probe runs synthetic HTTP checks against a service, so that what
+// its logs show can be confirmed from the outside.
+package probe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// maxBodyRead bounds how much of a response is read before it is closed,
+// so that the connection can be reused.
+const maxBodyRead = 64 << 10
+
+// Probe requests a URL on an interval.
+type Probe struct {
+	Name         string
+	URL          string
+	Method       string
+	Headers      map[string]string
+	Interval     time.Duration
+	ExpectStatus int // 0 accepts any status below 400
+	client       *http.Client
+}
+
+// New creates a Probe. Redirects are not followed, so a redirect counts as
+// the status it returns.
+func New(name, url, method string, headers map[string]string, interval, timeout time.Duration, expectStatus int) *Probe {
+	return &Probe{
+		Name:         name,
+		URL:          url,
+		Method:       method,
+		Headers:      headers,
+		Interval:     interval,
+		ExpectStatus: expectStatus,
+		client: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Run checks the URL right away and then every Interval, passing each
+// result to report, until done is closed.
+func (p *Probe) Run(done <-chan struct{}, report func(types.ProbeResult)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-done
+		cancel()
+	}()
+
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	for {
+		r := p.Check(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		report(r)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Check performs one request. Latency is measured until the response
+// headers arrive.
+func (p *Probe) Check(ctx context.Context) types.ProbeResult {
+	r := types.ProbeResult{Name: p.Name, Timestamp: time.Now()}
+	req, err := http.NewRequestWithContext(ctx, p.Method, p.URL, nil)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	req.Header.Set("User-Agent", "pulsewatch-probe")
+	for k, v := range p.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := p.client.Do(req)
+	r.Latency = time.Since(r.Timestamp)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodyRead))
+	resp.Body.Close()
+
+	r.StatusCode = resp.StatusCode
+	switch {
+	case p.ExpectStatus != 0 && resp.StatusCode != p.ExpectStatus:
+		r.Error = fmt.Sprintf("status %d, want %d", resp.StatusCode, p.ExpectStatus)
+	case p.ExpectStatus == 0 && resp.StatusCode >= 400:
+		r.Error = fmt.Sprintf("status %d", resp.StatusCode)
+	default:
+		r.OK = true
+	}
+	return r
+}
@@ -0,0 +1,71 @@
+package remotewrite
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// The remote-write payload is a prometheus.WriteRequest protobuf:
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; repeated Exemplar exemplars = 3; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+//	Exemplar     { repeated Label labels = 1; double value = 2; int64 timestamp = 3; }
+//
+// It is small enough to encode by hand rather than pull in protobuf.
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+func appendTag(b []byte, field, wire int) []byte {
+	return binary.AppendUvarint(b, uint64(field<<3|wire))
+}
+
+func appendLabel(b []byte, field int, l Label) []byte {
+	var msg []byte
+	msg = appendBytesField(msg, 1, []byte(l.Name))
+	msg = appendBytesField(msg, 2, []byte(l.Value))
+	return appendBytesField(b, field, msg)
+}
+
+func appendBytesField(b []byte, field int, v []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// marshalWriteRequest encodes series as a WriteRequest.
+func marshalWriteRequest(series []Series) []byte {
+	var out, ts, msg []byte
+	for _, s := range series {
+		ts = ts[:0]
+		for _, l := range s.Labels {
+			ts = appendLabel(ts, 1, l)
+		}
+		for _, sample := range s.Samples {
+			msg = msg[:0]
+			msg = appendTag(msg, 1, wireFixed64)
+			msg = binary.LittleEndian.AppendUint64(msg, math.Float64bits(sample.Value))
+			msg = appendTag(msg, 2, wireVarint)
+			msg = binary.AppendUvarint(msg, uint64(sample.Timestamp))
+			ts = appendBytesField(ts, 2, msg)
+		}
+		for _, ex := range s.Exemplars {
+			msg = msg[:0]
+			for _, l := range ex.Labels {
+				msg = appendLabel(msg, 1, l)
+			}
+			msg = appendTag(msg, 2, wireFixed64)
+			msg = binary.LittleEndian.AppendUint64(msg, math.Float64bits(ex.Value))
+			msg = appendTag(msg, 3, wireVarint)
+			msg = binary.AppendUvarint(msg, uint64(ex.Timestamp))
+			ts = appendBytesField(ts, 3, msg)
+		}
+		out = appendBytesField(out, 1, ts)
+	}
+	return out
+}
@@ -1,47 +1,61 @@
 package replay
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"os"
 	"time"
+
+	"github.com/nitis/pulseWatch/internal/crash"
+	"github.com/nitis/pulseWatch/internal/ingest"
+	"github.com/nitis/pulseWatch/pkg/clock"
 )
 
 // Replayer reads a log file and sends entries to a channel at a specified speed.
 type Replayer struct {
 	filePath string
 	speed    float64
+	guard    *ingest.Guard
+	clock    clock.Clock
 }
 
 // NewReplayer creates a new Replayer.
-func NewReplayer(filePath string, speed float64) *Replayer {
+func NewReplayer(filePath string, speed float64, guard *ingest.Guard) *Replayer {
 	return &Replayer{
 		filePath: filePath,
 		speed:    speed,
+		guard:    guard,
+		clock:    clock.Real(),
 	}
 }
 
-// Replay reads the log file and sends log entries to the output channel.
+// SetClock paces the replay with c instead of the system clock. Call it
+// before Replay.
+func (r *Replayer) SetClock(c clock.Clock) {
+	r.clock = c
+}
+
+// Replay reads the log file, decompressing .gz, .zst, and .bz2 files, and
+// sends log entries to the output channel.
 func (r *Replayer) Replay(ctx context.Context) (<-chan string, error) {
-	file, err := os.Open(r.filePath)
+	file, err := ingest.OpenLog(r.filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 
 	outChan := make(chan string)
-	scanner := bufio.NewScanner(file)
 
 	go func() {
 		defer file.Close()
 		defer close(outChan)
+		defer crash.Recover("replayer")
 
 		var lines []string
-		for scanner.Scan() {
-			lines = append(lines, scanner.Text())
-		}
-
-		if err := scanner.Err(); err != nil {
+		err := r.guard.ScanLines(file, func(line string) bool {
+			lines = append(lines, line)
+			return true
+		})
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "error reading file: %v\n", err)
 			return
 		}
@@ -53,7 +67,11 @@ func (r *Replayer) Replay(ctx context.Context) (<-chan string, error) {
 			case <-ctx.Done():
 				return
 			case outChan <- line:
-				time.Sleep(delay)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.clock.After(delay):
 			}
 		}
 	}()
@@ -0,0 +1,69 @@
+package replay
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/ingest"
+	"github.com/nitis/pulseWatch/pkg/clock"
+)
+
+func TestReplayPacedByFakeClock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	if err := os.WriteFile(path, []byte("one\ntwo\nthree\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fake := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	r := NewReplayer(path, 2, ingest.NewGuard(64*1024))
+	r.SetClock(fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lines, err := r.Replay(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next := func() (string, bool) {
+		select {
+		case line := <-lines:
+			return line, true
+		case <-time.After(50 * time.Millisecond):
+			return "", false
+		}
+	}
+	// waitForDelay blocks until the replayer is waiting out its delay
+	waitForDelay := func() {
+		deadline := time.Now().Add(5 * time.Second)
+		for fake.Waiters() == 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("replayer never waited on the clock")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if line, ok := next(); !ok || line != "one" {
+		t.Fatalf("first line %q, %v; want it at once", line, ok)
+	}
+	for _, want := range []string{"two", "three"} {
+		waitForDelay()
+		// At 2x speed lines are 500ms apart
+		fake.Advance(499 * time.Millisecond)
+		if line, ok := next(); ok {
+			t.Fatalf("got %q before the delay had passed", line)
+		}
+		fake.Advance(time.Millisecond)
+		if line, ok := next(); !ok || line != want {
+			t.Fatalf("got %q, %v after the delay; want %q", line, ok, want)
+		}
+	}
+	waitForDelay()
+	fake.Advance(time.Second)
+	if line, ok := <-lines; ok {
+		t.Fatalf("got %q after the last line, want the channel closed", line)
+	}
+}
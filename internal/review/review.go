@@ -0,0 +1,147 @@
+// Package review posts pulsewatch reports as GitHub pull request or GitLab
+// merge request comments.
+package review
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Marker is embedded in every posted comment so later runs update it
+// instead of adding another one.
+const Marker = "<!-- pulsewatch-report -->"
+
+// Default API base URLs.
+const (
+	GitHubAPI = "https://api.github.com"
+	GitLabAPI = "https://gitlab.com/api/v4"
+)
+
+// Poster creates or updates the pulsewatch comment on a pull/merge request.
+type Poster interface {
+	Post(body string) error
+}
+
+// New returns the Poster for provider ("github" or "gitlab"). repo is
+// "owner/name" on GitHub and the project path or ID on GitLab; number is the
+// PR number or MR IID. An empty apiURL uses the provider's public API.
+func New(provider, apiURL, token, repo string, number int) (Poster, error) {
+	if token == "" {
+		return nil, fmt.Errorf("no %s token given", provider)
+	}
+	if repo == "" || number <= 0 {
+		return nil, fmt.Errorf("a repository and a pull/merge request number are required")
+	}
+	c := client{http: &http.Client{Timeout: 30 * time.Second}}
+	switch provider {
+	case "github":
+		if apiURL == "" {
+			apiURL = GitHubAPI
+		}
+		c.base = fmt.Sprintf("%s/repos/%s/issues", strings.TrimSuffix(apiURL, "/"), repo)
+		c.headers = map[string]string{"Accept": "application/vnd.github+json", "Authorization": "Bearer " + token}
+		return &gitHub{client: c, number: number}, nil
+	case "gitlab":
+		if apiURL == "" {
+			apiURL = GitLabAPI
+		}
+		c.base = fmt.Sprintf("%s/projects/%s/merge_requests/%d/notes", strings.TrimSuffix(apiURL, "/"), url.PathEscape(repo), number)
+		c.headers = map[string]string{"PRIVATE-TOKEN": token}
+		return &gitLab{client: c}, nil
+	}
+	return nil, fmt.Errorf("unknown provider %q: use github or gitlab", provider)
+}
+
+type comment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+type gitHub struct {
+	client
+	number int
+}
+
+func (g *gitHub) Post(body string) error {
+	body = Marker + "\n" + body
+	var existing []comment
+	if err := g.do(http.MethodGet, fmt.Sprintf("%s/%d/comments?per_page=100", g.base, g.number), nil, &existing); err != nil {
+		return err
+	}
+	if id, ok := findMarked(existing); ok {
+		return g.do(http.MethodPatch, fmt.Sprintf("%s/comments/%d", g.base, id), comment{Body: body}, nil)
+	}
+	return g.do(http.MethodPost, fmt.Sprintf("%s/%d/comments", g.base, g.number), comment{Body: body}, nil)
+}
+
+type gitLab struct {
+	client
+}
+
+func (g *gitLab) Post(body string) error {
+	body = Marker + "\n" + body
+	var existing []comment
+	if err := g.do(http.MethodGet, g.base+"?per_page=100", nil, &existing); err != nil {
+		return err
+	}
+	if id, ok := findMarked(existing); ok {
+		return g.do(http.MethodPut, fmt.Sprintf("%s/%d", g.base, id), comment{Body: body}, nil)
+	}
+	return g.do(http.MethodPost, g.base, comment{Body: body}, nil)
+}
+
+func findMarked(comments []comment) (int64, bool) {
+	for _, c := range comments {
+		if strings.Contains(c.Body, Marker) {
+			return c.ID, true
+		}
+	}
+	return 0, false
+}
+
+// client sends authenticated JSON requests to a provider's API.
+type client struct {
+	base    string
+	headers map[string]string // Authentication and provider-specific headers
+	http    *http.Client
+}
+
+func (c client) do(method, target string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "pulsewatch")
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,609 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// WindowAggregate is the SQL-side summary of the entries in a time window.
+type WindowAggregate struct {
+	Total       int
+	Errors      int            // status_code >= 400
+	StatusCodes map[int]int    // Exact status code -> count
+	Endpoints   map[string]int // Busiest endpoints -> count, empty excluded; see SetTopKeys
+	Latencies   []float64      // Milliseconds, successful requests with a latency only
+
+	Methods         map[string]types.MethodStats            // Method -> counts, empty methods excluded
+	EndpointMethods map[string]map[string]types.MethodStats // Endpoint -> method -> counts
+
+	CacheStatuses map[string]int              // Cache status -> count, empty statuses excluded
+	EndpointCache map[string]types.CacheStats // Endpoint -> cache lookups and hits
+
+	// Milliseconds, paired by index, for entries with a known service time
+	QueueTimes   []float64
+	ServiceTimes []float64
+
+	// ComponentTimes holds the milliseconds of each timing component, e.g.
+	// db, per endpoint ("" for entries without one), and ComponentRequests
+	// the requests per endpoint that logged any component.
+	ComponentTimes    map[string]map[string][]float64
+	ComponentRequests map[string]int
+
+	Tenants    map[string]RequestAggregate // Tenant -> counts, empty tenants excluded
+	Sources    map[string]RequestAggregate // Input -> counts, empty sources excluded
+	Operations map[string]RequestAggregate // GraphQL operation -> counts, empty excluded
+	Versions   map[string]RequestAggregate // Version -> counts, empty versions excluded
+	Groups     map[string]int              // Busiest group keys -> count, empty excluded; see SetTopKeys
+
+	// Entries with an endpoint or group key, including those past the
+	// busiest kept
+	EndpointsTotal int
+	GroupsTotal    int
+
+	Protocols   map[string]int // HTTP version -> count, empty excluded
+	TLSVersions map[string]int // TLS version -> count, empty excluded
+	Levels      map[string]int // Log level -> count, empty excluded
+
+	ErrorCategories map[string]int // Configured error category -> errors, uncategorised excluded
+
+	GRPCStatuses map[string]int                   // gRPC status -> calls
+	GRPCMethods  map[string]types.GRPCMethodStats // Full method -> calls
+
+	Retries         int            // Likely client retries
+	EndpointRetries map[string]int // Endpoint -> likely retries, empty endpoints excluded
+
+	Sessions        int                // Distinct sessions
+	SessionRequests int                // Requests that carried a session
+	Transitions     map[Transition]int // Endpoint-to-endpoint steps within a session
+}
+
+// Transition is one step of a session from one endpoint to the next.
+type Transition struct {
+	From, To string
+}
+
+// RequestAggregate counts the requests of one tenant, source, operation, or
+// version. Latency covers successful requests with a latency only, like
+// WindowAggregate.Latencies.
+type RequestAggregate struct {
+	Requests     int
+	Errors       int
+	LatencySum   float64 // Milliseconds
+	LatencyCount int
+}
+
+// NewWindowAggregate returns an empty aggregate with its maps allocated.
+func NewWindowAggregate() WindowAggregate {
+	return WindowAggregate{
+		StatusCodes:     make(map[int]int),
+		Endpoints:       make(map[string]int),
+		Methods:         make(map[string]types.MethodStats),
+		EndpointMethods: make(map[string]map[string]types.MethodStats),
+		CacheStatuses:   make(map[string]int),
+		EndpointCache:   make(map[string]types.CacheStats),
+		Tenants:         make(map[string]RequestAggregate),
+		Sources:         make(map[string]RequestAggregate),
+		Operations:      make(map[string]RequestAggregate),
+		Versions:        make(map[string]RequestAggregate),
+		Groups:          make(map[string]int),
+		Protocols:       make(map[string]int),
+		TLSVersions:     make(map[string]int),
+		Levels:          make(map[string]int),
+		ErrorCategories: make(map[string]int),
+		GRPCStatuses:    make(map[string]int),
+		GRPCMethods:     make(map[string]types.GRPCMethodStats),
+		EndpointRetries: make(map[string]int),
+		Transitions:     make(map[Transition]int),
+
+		ComponentTimes:    make(map[string]map[string][]float64),
+		ComponentRequests: make(map[string]int),
+	}
+}
+
+// AddCacheStatus counts requests with a cache status.
+func (a *WindowAggregate) AddCacheStatus(endpoint, status string, count int) {
+	if status == "" {
+		return
+	}
+	a.CacheStatuses[status] += count
+	if endpoint == "" {
+		return
+	}
+	c := a.EndpointCache[endpoint]
+	c.Lookups += count
+	if status == types.CacheHit {
+		c.Hits += count
+	}
+	a.EndpointCache[endpoint] = c
+}
+
+// AddMethod counts one request for the method breakdowns.
+func (a *WindowAggregate) AddMethod(endpoint, method string, requests, errors int) {
+	if method == "" {
+		return
+	}
+	m := a.Methods[method]
+	m.Requests += requests
+	m.Errors += errors
+	a.Methods[method] = m
+
+	if endpoint == "" {
+		return
+	}
+	if a.EndpointMethods[endpoint] == nil {
+		a.EndpointMethods[endpoint] = make(map[string]types.MethodStats)
+	}
+	em := a.EndpointMethods[endpoint][method]
+	em.Requests += requests
+	em.Errors += errors
+	a.EndpointMethods[endpoint][method] = em
+}
+
+// AddTimings records one request's component timings.
+func (a *WindowAggregate) AddTimings(endpoint string, timings map[string]float64) {
+	if len(timings) == 0 {
+		return
+	}
+	a.ComponentRequests[endpoint]++
+	if a.ComponentTimes[endpoint] == nil {
+		a.ComponentTimes[endpoint] = make(map[string][]float64)
+	}
+	for component, ms := range timings {
+		a.ComponentTimes[endpoint][component] = append(a.ComponentTimes[endpoint][component], ms)
+	}
+}
+
+// AddRetries counts likely client retries.
+func (a *WindowAggregate) AddRetries(endpoint string, count int) {
+	a.Retries += count
+	if endpoint != "" {
+		a.EndpointRetries[endpoint] += count
+	}
+}
+
+// AddGRPC counts gRPC calls for the status and method breakdowns.
+func (a *WindowAggregate) AddGRPC(method, status string, calls, errors int) {
+	if status == "" {
+		return
+	}
+	a.GRPCStatuses[status] += calls
+	m := a.GRPCMethods[method]
+	if m.Statuses == nil {
+		m.Statuses = make(map[string]int)
+	}
+	m.Calls += calls
+	m.Errors += errors
+	m.Statuses[status] += calls
+	a.GRPCMethods[method] = m
+}
+
+// AggregateSince summarises entries with timestamp >= since without loading
+// whole rows into Go.
+func (s *Storage) AggregateSince(since time.Time) (WindowAggregate, error) {
+	return s.AggregateMatching(since, EntryFilter{})
+}
+
+// AggregateMatching is AggregateSince over only the entries that match f.
+func (s *Storage) AggregateMatching(since time.Time, f EntryFilter) (WindowAggregate, error) {
+	defer s.observeQuery(time.Now())
+	agg := NewWindowAggregate()
+	m := matchSince(since, f)
+
+	err := s.readDB.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN status_code >= 400 THEN 1 ELSE 0 END), 0)
+		FROM log_entries
+		WHERE `+m.where, m.args...).Scan(&agg.Total, &agg.Errors)
+	if err != nil {
+		return agg, err
+	}
+	if agg.Total == 0 {
+		return agg, nil
+	}
+
+	err = s.queryGrouped(`
+		SELECT status_code, COUNT(*) FROM log_entries
+		WHERE `+m.where+`
+		GROUP BY status_code`, m.args, func(rows *sql.Rows) error {
+		var code, count int
+		if err := rows.Scan(&code, &count); err != nil {
+			return err
+		}
+		agg.StatusCodes[code] = count
+		return nil
+	})
+	if err != nil {
+		return agg, err
+	}
+
+	if agg.Endpoints, agg.EndpointsTotal, err = s.topBy("endpoint", m); err != nil {
+		return agg, err
+	}
+
+	err = s.queryGrouped(`
+		SELECT endpoint, method, COUNT(*), SUM(CASE WHEN status_code >= 400 THEN 1 ELSE 0 END)
+		FROM log_entries
+		WHERE `+m.where+` AND method != ''
+		GROUP BY endpoint, method`, m.args, func(rows *sql.Rows) error {
+		var endpoint, method string
+		var requests, errors int
+		if err := rows.Scan(&endpoint, &method, &requests, &errors); err != nil {
+			return err
+		}
+		agg.AddMethod(endpoint, method, requests, errors)
+		return nil
+	})
+	if err != nil {
+		return agg, err
+	}
+
+	err = s.queryGrouped(`
+		SELECT endpoint, grpc_status, COUNT(*), SUM(CASE WHEN status_code >= 400 THEN 1 ELSE 0 END)
+		FROM log_entries
+		WHERE `+m.where+` AND grpc_status != ''
+		GROUP BY endpoint, grpc_status`, m.args, func(rows *sql.Rows) error {
+		var method, status string
+		var calls, errors int
+		if err := rows.Scan(&method, &status, &calls, &errors); err != nil {
+			return err
+		}
+		agg.AddGRPC(method, status, calls, errors)
+		return nil
+	})
+	if err != nil {
+		return agg, err
+	}
+
+	err = s.queryGrouped(`
+		SELECT endpoint, cache_status, COUNT(*) FROM log_entries
+		WHERE `+m.where+` AND cache_status != ''
+		GROUP BY endpoint, cache_status`, m.args, func(rows *sql.Rows) error {
+		var endpoint, status string
+		var count int
+		if err := rows.Scan(&endpoint, &status, &count); err != nil {
+			return err
+		}
+		agg.AddCacheStatus(endpoint, status, count)
+		return nil
+	})
+	if err != nil {
+		return agg, err
+	}
+
+	err = s.queryGrouped(`
+		SELECT queue_ms, service_ms FROM log_entries
+		WHERE `+m.where+` AND service_ms > 0`, m.args, func(rows *sql.Rows) error {
+		var queue, service float64
+		if err := rows.Scan(&queue, &service); err != nil {
+			return err
+		}
+		agg.QueueTimes = append(agg.QueueTimes, queue)
+		agg.ServiceTimes = append(agg.ServiceTimes, service)
+		return nil
+	})
+	if err != nil {
+		return agg, err
+	}
+
+	err = s.queryGrouped(`
+		SELECT endpoint, timings FROM log_entries
+		WHERE `+m.where+` AND timings != ''`, m.args, func(rows *sql.Rows) error {
+		var endpoint, timings string
+		if err := rows.Scan(&endpoint, &timings); err != nil {
+			return err
+		}
+		agg.AddTimings(endpoint, decodeTimings(timings))
+		return nil
+	})
+	if err != nil {
+		return agg, err
+	}
+
+	if err := s.aggregateBy("tenant", m, agg.Tenants); err != nil {
+		return agg, err
+	}
+	if err := s.aggregateBy("source", m, agg.Sources); err != nil {
+		return agg, err
+	}
+	if err := s.aggregateBy("operation", m, agg.Operations); err != nil {
+		return agg, err
+	}
+	if err := s.aggregateBy("version", m, agg.Versions); err != nil {
+		return agg, err
+	}
+	if agg.Groups, agg.GroupsTotal, err = s.topBy("group_key", m); err != nil {
+		return agg, err
+	}
+	if err := s.countBy("protocol", m, agg.Protocols); err != nil {
+		return agg, err
+	}
+	if err := s.countBy("tls_version", m, agg.TLSVersions); err != nil {
+		return agg, err
+	}
+	if err := s.countBy("level", m, agg.Levels); err != nil {
+		return agg, err
+	}
+	if err := s.countBy("error_category", m, agg.ErrorCategories); err != nil {
+		return agg, err
+	}
+
+	err = s.queryGrouped(`
+		SELECT endpoint, COUNT(*) FROM log_entries
+		WHERE `+m.where+` AND retry = 1
+		GROUP BY endpoint`, m.args, func(rows *sql.Rows) error {
+		var endpoint string
+		var count int
+		if err := rows.Scan(&endpoint, &count); err != nil {
+			return err
+		}
+		agg.AddRetries(endpoint, count)
+		return nil
+	})
+	if err != nil {
+		return agg, err
+	}
+
+	err = s.readDB.QueryRow(`
+		SELECT COUNT(DISTINCT session), COUNT(*) FROM log_entries
+		WHERE `+m.where+` AND session != ''`, m.args...).Scan(&agg.Sessions, &agg.SessionRequests)
+	if err != nil {
+		return agg, err
+	}
+	if agg.SessionRequests > 0 {
+		err = s.queryGrouped(`
+			SELECT prev_endpoint, endpoint, COUNT(*) FROM log_entries
+			WHERE `+m.where+` AND prev_endpoint != ''
+			GROUP BY prev_endpoint, endpoint`, m.args, func(rows *sql.Rows) error {
+			var t Transition
+			var count int
+			if err := rows.Scan(&t.From, &t.To, &count); err != nil {
+				return err
+			}
+			agg.Transitions[t] = count
+			return nil
+		})
+		if err != nil {
+			return agg, err
+		}
+	}
+
+	err = s.queryGrouped(`
+		SELECT latency_ms FROM log_entries
+		WHERE `+m.where+` AND status_code < 400 AND latency_ms > 0`, m.args, func(rows *sql.Rows) error {
+		var ms float64
+		if err := rows.Scan(&ms); err != nil {
+			return err
+		}
+		agg.Latencies = append(agg.Latencies, ms)
+		return nil
+	})
+	return agg, err
+}
+
+// countBy counts rows per non-empty value of column. column is always a
+// constant from this package.
+func (s *Storage) countBy(column string, m entryMatch, counts map[string]int) error {
+	return s.queryGrouped(`
+		SELECT `+column+`, COUNT(*) FROM log_entries
+		WHERE `+m.where+` AND `+column+` != ''
+		GROUP BY `+column, m.args, func(rows *sql.Rows) error {
+		var value string
+		var count int
+		if err := rows.Scan(&value, &count); err != nil {
+			return err
+		}
+		counts[value] = count
+		return nil
+	})
+}
+
+// topBy counts the non-empty values of a high-cardinality column like
+// countBy, but returns only the busiest s.keyLimit(), so a window with
+// millions of distinct values doesn't hold them all in memory, along with
+// the entries that have any value.
+func (s *Storage) topBy(column string, m entryMatch) (map[string]int, int, error) {
+	counts := make(map[string]int)
+	total := 0
+	args := append(append([]interface{}{}, m.args...), s.keyLimit())
+	err := s.queryGrouped(`
+		SELECT `+column+`, COUNT(*), SUM(COUNT(*)) OVER () FROM log_entries
+		WHERE `+m.where+` AND `+column+` != ''
+		GROUP BY `+column+`
+		ORDER BY COUNT(*) DESC, `+column+`
+		LIMIT ?`, args, func(rows *sql.Rows) error {
+		var value string
+		var count int
+		if err := rows.Scan(&value, &count, &total); err != nil {
+			return err
+		}
+		counts[value] = count
+		return nil
+	})
+	return counts, total, err
+}
+
+// aggregateBy counts requests, errors, and successful latencies per
+// non-empty value of column. column is always a constant from this package.
+func (s *Storage) aggregateBy(column string, m entryMatch, into map[string]RequestAggregate) error {
+	return s.queryGrouped(`
+		SELECT `+column+`, COUNT(*),
+			SUM(CASE WHEN status_code >= 400 THEN 1 ELSE 0 END),
+			COALESCE(SUM(CASE WHEN status_code < 400 AND latency_ms > 0 THEN latency_ms END), 0),
+			SUM(CASE WHEN status_code < 400 AND latency_ms > 0 THEN 1 ELSE 0 END)
+		FROM log_entries
+		WHERE `+m.where+` AND `+column+` != ''
+		GROUP BY `+column, m.args, func(rows *sql.Rows) error {
+		var value string
+		var a RequestAggregate
+		if err := rows.Scan(&value, &a.Requests, &a.Errors, &a.LatencySum, &a.LatencyCount); err != nil {
+			return err
+		}
+		into[value] = a
+		return nil
+	})
+}
+
+// entryMatch is a condition on log_entries with its arguments.
+type entryMatch struct {
+	where string
+	args  []interface{}
+}
+
+// matchSince matches the entries with timestamp >= since that match f.
+func matchSince(since time.Time, f EntryFilter) entryMatch {
+	where, args := f.where()
+	return entryMatch{where: "timestamp >= ? AND " + where, args: append([]interface{}{since}, args...)}
+}
+
+func (s *Storage) queryGrouped(query string, args []interface{}, scan func(*sql.Rows) error) error {
+	rows, err := s.readDB.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// LatenciesSince returns the latencies in milliseconds of successful requests
+// to endpoint with timestamp >= since that match f.
+func (s *Storage) LatenciesSince(since time.Time, endpoint string, f EntryFilter) ([]float64, error) {
+	return s.latenciesWhere("endpoint", endpoint, matchSince(since, f))
+}
+
+// TenantLatenciesSince returns the latencies in milliseconds of the tenant's
+// successful requests with timestamp >= since that match f.
+func (s *Storage) TenantLatenciesSince(since time.Time, tenant string, f EntryFilter) ([]float64, error) {
+	return s.latenciesWhere("tenant", tenant, matchSince(since, f))
+}
+
+// OperationLatenciesSince returns the latencies in milliseconds of the
+// GraphQL operation's successful requests with timestamp >= since that
+// match f.
+func (s *Storage) OperationLatenciesSince(since time.Time, operation string, f EntryFilter) ([]float64, error) {
+	return s.latenciesWhere("operation", operation, matchSince(since, f))
+}
+
+// VersionLatenciesSince returns the latencies in milliseconds of the
+// version's successful requests with timestamp >= since that match f.
+func (s *Storage) VersionLatenciesSince(since time.Time, version string, f EntryFilter) ([]float64, error) {
+	return s.latenciesWhere("version", version, matchSince(since, f))
+}
+
+// latenciesWhere loads successful latencies for rows matching m whose column
+// equals value. column is always a constant from this package.
+func (s *Storage) latenciesWhere(column, value string, m entryMatch) ([]float64, error) {
+	defer s.observeQuery(time.Now())
+	rows, err := s.readDB.Query(`
+		SELECT latency_ms FROM log_entries
+		WHERE `+column+` = ? AND `+m.where+` AND status_code < 400 AND latency_ms > 0`, append([]interface{}{value}, m.args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var latencies []float64
+	for rows.Next() {
+		var ms float64
+		if err := rows.Scan(&ms); err != nil {
+			return nil, err
+		}
+		latencies = append(latencies, ms)
+	}
+	return latencies, rows.Err()
+}
+
+// RequestSample is the part of an entry needed to build a time series.
+type RequestSample struct {
+	Timestamp  time.Time
+	StatusCode int
+	LatencyMs  float64
+}
+
+// EndpointSamplesSince returns the endpoint's entries with timestamp >= since,
+// oldest first.
+func (s *Storage) EndpointSamplesSince(since time.Time, endpoint string) ([]RequestSample, error) {
+	defer s.observeQuery(time.Now())
+	rows, err := s.readDB.Query(`
+		SELECT timestamp, status_code, latency_ms FROM log_entries
+		WHERE endpoint = ? AND timestamp >= ?
+		ORDER BY timestamp ASC`, endpoint, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []RequestSample
+	for rows.Next() {
+		var r RequestSample
+		if err := rows.Scan(&r.Timestamp, &r.StatusCode, &r.LatencyMs); err != nil {
+			return nil, err
+		}
+		samples = append(samples, r)
+	}
+	return samples, rows.Err()
+}
+
+// EndpointSamplesBetween returns the entries with from <= timestamp < to,
+// by endpoint, oldest first.
+func (s *Storage) EndpointSamplesBetween(from, to time.Time) (map[string][]RequestSample, error) {
+	defer s.observeQuery(time.Now())
+	rows, err := s.readDB.Query(`
+		SELECT endpoint, timestamp, status_code, latency_ms FROM log_entries
+		WHERE timestamp >= ? AND timestamp < ? AND endpoint != ''
+		ORDER BY timestamp ASC`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	samples := make(map[string][]RequestSample)
+	for rows.Next() {
+		var endpoint string
+		var r RequestSample
+		if err := rows.Scan(&endpoint, &r.Timestamp, &r.StatusCode, &r.LatencyMs); err != nil {
+			return nil, err
+		}
+		samples[endpoint] = append(samples[endpoint], r)
+	}
+	return samples, rows.Err()
+}
+
+// ErrorCount is the number of failed requests (status >= 400) for one
+// endpoint and status code.
+type ErrorCount struct {
+	Endpoint   string
+	StatusCode int
+	Count      int
+}
+
+// TopErrorsBetween returns the most frequent endpoint/status pairs among
+// failed requests with from <= timestamp <= to, most frequent first.
+func (s *Storage) TopErrorsBetween(from, to time.Time, limit int) ([]ErrorCount, error) {
+	defer s.observeQuery(time.Now())
+	rows, err := s.readDB.Query(`
+		SELECT endpoint, status_code, COUNT(*) AS n FROM log_entries
+		WHERE timestamp >= ? AND timestamp <= ? AND status_code >= 400
+		GROUP BY endpoint, status_code
+		ORDER BY n DESC, endpoint ASC
+		LIMIT ?`, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []ErrorCount
+	for rows.Next() {
+		var c ErrorCount
+		if err := rows.Scan(&c.Endpoint, &c.StatusCode, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
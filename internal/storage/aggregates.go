@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// Rollup is a per-minute snapshot of the 1m window, kept far longer than raw
+// entries so long-range trends survive raw retention.
+type Rollup struct {
+	Timestamp time.Time
+	Requests  int
+	Errors    int
+	P50       time.Duration
+	P95       time.Duration
+	P99       time.Duration
+}
+
+// InsertRollup stores a metrics rollup.
+func (s *Storage) InsertRollup(r Rollup) error {
+	_, err := s.db.Exec(`
+		INSERT INTO metric_rollups (timestamp, requests, errors, p50_ms, p95_ms, p99_ms)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		r.Timestamp, r.Requests, r.Errors, r.P50.Milliseconds(), r.P95.Milliseconds(), r.P99.Milliseconds())
+	return err
+}
+
+// GetRollupsSince returns rollups with timestamp >= since, oldest first.
+func (s *Storage) GetRollupsSince(since time.Time) ([]Rollup, error) {
+	return s.queryRollups(`
+		SELECT timestamp, requests, errors, p50_ms, p95_ms, p99_ms
+		FROM metric_rollups
+		WHERE timestamp >= ?
+		ORDER BY timestamp ASC`, since)
+}
+
+// GetRollupsBetween returns rollups with from <= timestamp <= to, oldest first.
+func (s *Storage) GetRollupsBetween(from, to time.Time) ([]Rollup, error) {
+	return s.queryRollups(`
+		SELECT timestamp, requests, errors, p50_ms, p95_ms, p99_ms
+		FROM metric_rollups
+		WHERE timestamp >= ? AND timestamp <= ?
+		ORDER BY timestamp ASC`, from, to)
+}
+
+func (s *Storage) queryRollups(query string, args ...interface{}) ([]Rollup, error) {
+	rows, err := s.readDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rollups []Rollup
+	for rows.Next() {
+		var r Rollup
+		var p50, p95, p99 int64
+		if err := rows.Scan(&r.Timestamp, &r.Requests, &r.Errors, &p50, &p95, &p99); err != nil {
+			return nil, err
+		}
+		r.P50 = time.Duration(p50) * time.Millisecond
+		r.P95 = time.Duration(p95) * time.Millisecond
+		r.P99 = time.Duration(p99) * time.Millisecond
+		rollups = append(rollups, r)
+	}
+	return rollups, rows.Err()
+}
+
+// InsertAnomaly persists an anomaly (without its evidence, see InsertEvidence).
+// Timestamps are stored without their monotonic clock reading so GetEvidence
+// can match them exactly.
+func (s *Storage) InsertAnomaly(a types.Anomaly) error {
+	contributors, err := json.Marshal(a.Contributors)
+	if err != nil {
+		return err
+	}
+	snapshot, err := json.Marshal(a.Snapshot)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO anomalies (timestamp, type, severity, message, contributors, snapshot)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		a.Timestamp.Round(0), a.Type, a.Severity, a.Message, string(contributors), string(snapshot))
+	return err
+}
+
+// QueryAnomalies returns persisted anomalies matching f, most recent first.
+// Evidence is not loaded; see GetEvidence.
+func (s *Storage) QueryAnomalies(f types.AnomalyFilter) ([]types.Anomaly, error) {
+	defer s.observeQuery(time.Now())
+
+	var where []string
+	var args []interface{}
+	if f.Type != "" {
+		where = append(where, "type LIKE ? ESCAPE '\\'")
+		args = append(args, "%"+escapeLike(f.Type)+"%")
+	}
+	if f.Severity != "" {
+		where = append(where, "severity = ?")
+		args = append(args, f.Severity)
+	}
+	if !f.Since.IsZero() {
+		where = append(where, "timestamp >= ?")
+		args = append(args, f.Since)
+	}
+	if !f.Until.IsZero() {
+		where = append(where, "timestamp < ?")
+		args = append(args, f.Until)
+	}
+	query := "SELECT timestamp, type, severity, message, contributors, snapshot FROM anomalies"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY timestamp DESC, id DESC"
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	rows, err := s.readDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var anomalies []types.Anomaly
+	for rows.Next() {
+		var a types.Anomaly
+		var contributors, snapshot sql.NullString
+		if err := rows.Scan(&a.Timestamp, &a.Type, &a.Severity, &a.Message, &contributors, &snapshot); err != nil {
+			return nil, err
+		}
+		if contributors.String != "" {
+			json.Unmarshal([]byte(contributors.String), &a.Contributors)
+		}
+		if snapshot.String != "" {
+			json.Unmarshal([]byte(snapshot.String), &a.Snapshot)
+		}
+		anomalies = append(anomalies, a)
+	}
+	return anomalies, rows.Err()
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// PruneAggregates deletes rollups, anomalies, events, annotations, and
+// probe results older than olderThan.
+func (s *Storage) PruneAggregates(olderThan time.Time) error {
+	if _, err := s.db.Exec("DELETE FROM metric_rollups WHERE timestamp < ?", olderThan); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec("DELETE FROM anomalies WHERE timestamp < ?", olderThan); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec("DELETE FROM events WHERE timestamp < ?", olderThan); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec("DELETE FROM annotations WHERE end_time < ?", olderThan); err != nil {
+		return err
+	}
+	_, err := s.db.Exec("DELETE FROM probe_results WHERE timestamp < ?", olderThan)
+	return err
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// InsertAnnotation stores an operator annotation.
+func (s *Storage) InsertAnnotation(a types.Annotation) error {
+	_, err := s.db.Exec(`
+		INSERT INTO annotations (created, start_time, end_time, label, note, line)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		a.Created, a.Start, a.End, a.Label, a.Note, a.Line)
+	return err
+}
+
+// GetAnnotationsBetween returns the annotations overlapping from..to,
+// oldest first.
+func (s *Storage) GetAnnotationsBetween(from, to time.Time) ([]types.Annotation, error) {
+	defer s.observeQuery(time.Now())
+	rows, err := s.readDB.Query(`
+		SELECT created, start_time, end_time, label, note, line FROM annotations
+		WHERE end_time >= ? AND start_time <= ?
+		ORDER BY start_time ASC, id ASC`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var annotations []types.Annotation
+	for rows.Next() {
+		var a types.Annotation
+		if err := rows.Scan(&a.Created, &a.Start, &a.End, &a.Label, &a.Note, &a.Line); err != nil {
+			return nil, err
+		}
+		annotations = append(annotations, a)
+	}
+	return annotations, rows.Err()
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"bytes"
+	"database/sql/driver"
+
+	"github.com/klauspost/compress/zstd"
+	"modernc.org/sqlite"
+)
+
+// zstdMagic prefixes every zstd frame, letting reads tell compressed values
+// apart from plain text written before compression was enabled.
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+func init() {
+	// decode_column(x) is decodeColumn in SQL, so queries can match text in
+	// compressed columns
+	sqlite.MustRegisterDeterministicScalarFunction("decode_column", 1, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		switch v := args[0].(type) {
+		case []byte:
+			return decodeColumn(v), nil
+		case string:
+			return v, nil
+		}
+		return "", nil
+	})
+}
+
+// encodeColumn returns the value to store for a text column: a zstd frame when
+// compression is enabled, otherwise the text itself.
+func (s *Storage) encodeColumn(text string) interface{} {
+	if !s.compress || text == "" {
+		return text
+	}
+	return zstdEncoder.EncodeAll([]byte(text), nil)
+}
+
+// decodeColumn reverses encodeColumn, passing plain text through unchanged.
+func decodeColumn(raw []byte) string {
+	if !bytes.HasPrefix(raw, zstdMagic) {
+		return string(raw)
+	}
+	out, err := zstdDecoder.DecodeAll(raw, nil)
+	if err != nil {
+		return string(raw)
+	}
+	return string(out)
+}
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// EntryFilter selects the log entries an anomaly is explained by. The zero
+// value matches every entry; set fields narrow it down.
+type EntryFilter struct {
+	Errors     bool          // status_code >= 400
+	Failed     bool          // status_code >= 400 or an ERROR level
+	Successful bool          // status_code < 400
+	SlowerThan time.Duration // Latency above this, if set
+	HasLatency bool          // Any latency logged
+	CacheMiss  bool          // A cache lookup that wasn't a hit
+	Category   string        // Error category, if set
+	Retries    bool          // Likely client retries
+	Contains   string        // Text in the message or endpoint, if set
+	Source     string        // Name of the input read from, if set
+}
+
+// where returns f as a SQL condition on log_entries, with its arguments.
+func (f EntryFilter) where() (string, []interface{}) {
+	conds := []string{"1 = 1"}
+	var args []interface{}
+	if f.Errors {
+		conds = append(conds, "status_code >= 400")
+	}
+	if f.Failed {
+		conds = append(conds, "(status_code >= 400 OR level = ?)")
+		args = append(args, string(types.ErrorLevel))
+	}
+	if f.Successful {
+		conds = append(conds, "status_code < 400")
+	}
+	if f.SlowerThan > 0 {
+		conds = append(conds, "latency_ms > ?")
+		args = append(args, f.SlowerThan.Milliseconds())
+	}
+	if f.HasLatency {
+		conds = append(conds, "latency_ms > 0")
+	}
+	if f.CacheMiss {
+		conds = append(conds, "cache_status != '' AND cache_status != ?")
+		args = append(args, types.CacheHit)
+	}
+	if f.Category != "" {
+		conds = append(conds, "error_category = ?")
+		args = append(args, f.Category)
+	}
+	if f.Retries {
+		conds = append(conds, "retry = 1")
+	}
+	if f.Contains != "" {
+		conds = append(conds, "(instr(endpoint, ?) > 0 OR instr(decode_column(message), ?) > 0)")
+		args = append(args, f.Contains, f.Contains)
+	}
+	if f.Source != "" {
+		conds = append(conds, "source = ?")
+		args = append(args, f.Source)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// contributorDimensions are the columns anomalies are broken down by, with
+// the condition under which an entry carries a value.
+var contributorDimensions = []struct {
+	name, column, present string
+}{
+	{"endpoint", "endpoint", "endpoint != ''"},
+	{"status", "status_code", "status_code != 0"},
+	{"method", "method", "method != ''"},
+	{"tenant", "tenant", "tenant != ''"},
+	{"category", "error_category", "error_category != ''"},
+	{"client_ip", "client_ip", "client_ip != ''"},
+	{"source", "source", "source != ''"},
+}
+
+// DimensionCounts counts the entries since since that match f, in total
+// and per value of each contributor dimension (endpoint, status, method,
+// tenant, category, client_ip, source). Only the busiest values of each
+// dimension are kept; see SetTopKeys.
+func (s *Storage) DimensionCounts(since time.Time, f EntryFilter) (map[string]map[string]int, int, error) {
+	return s.dimensionCounts(since, f, nil)
+}
+
+// DimensionCountsOf is DimensionCounts for the values in of alone, e.g. the
+// busiest of another window, which are counted exactly whether or not they
+// are among the busiest of this one.
+func (s *Storage) DimensionCountsOf(since time.Time, f EntryFilter, of map[string]map[string]int) (map[string]map[string]int, int, error) {
+	return s.dimensionCounts(since, f, of)
+}
+
+func (s *Storage) dimensionCounts(since time.Time, f EntryFilter, of map[string]map[string]int) (map[string]map[string]int, int, error) {
+	defer s.observeQuery(time.Now())
+	where, args := f.where()
+	args = append([]interface{}{since}, args...)
+
+	var total int
+	if err := s.readDB.QueryRow(`SELECT COUNT(*) FROM log_entries WHERE timestamp >= ? AND `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	counts := make(map[string]map[string]int)
+	if total == 0 {
+		return counts, 0, nil
+	}
+	for _, d := range contributorDimensions {
+		query := `
+			SELECT ` + d.column + `, COUNT(*) FROM log_entries
+			WHERE timestamp >= ? AND ` + where + ` AND ` + d.present
+		queryArgs := append([]interface{}{}, args...)
+		if of == nil {
+			query += `
+			GROUP BY ` + d.column + `
+			ORDER BY COUNT(*) DESC, ` + d.column + `
+			LIMIT ?`
+			queryArgs = append(queryArgs, s.keyLimit())
+		} else {
+			if len(of[d.name]) == 0 {
+				continue
+			}
+			// status_code's integer affinity applies to the listed values
+			query += ` AND ` + d.column + ` IN (?` + strings.Repeat(", ?", len(of[d.name])-1) + `)
+			GROUP BY ` + d.column
+			for value := range of[d.name] {
+				queryArgs = append(queryArgs, value)
+			}
+		}
+		rows, err := s.readDB.Query(query, queryArgs...)
+		if err != nil {
+			return nil, 0, err
+		}
+		for rows.Next() {
+			var value string
+			var count int
+			if err := rows.Scan(&value, &count); err != nil {
+				rows.Close()
+				return nil, 0, err
+			}
+			if counts[d.name] == nil {
+				counts[d.name] = make(map[string]int)
+			}
+			counts[d.name][value] = count
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, 0, err
+		}
+	}
+	return counts, total, nil
+}
+
+// Evidence order for EvidenceEntries.
+const (
+	EvidenceRecent  = "timestamp DESC"
+	EvidenceSlowest = "latency_ms DESC"
+	// Server errors before client errors, then most recent first
+	EvidenceWorst = "status_code / 100 DESC, timestamp DESC"
+)
+
+// EvidenceEntries returns up to limit entries since since that match f, in
+// the given Evidence order.
+func (s *Storage) EvidenceEntries(since time.Time, f EntryFilter, order string, limit int) ([]types.LogEntry, error) {
+	switch order {
+	case EvidenceRecent, EvidenceSlowest, EvidenceWorst:
+	default:
+		return nil, fmt.Errorf("unknown evidence order %q", order)
+	}
+	where, args := f.where()
+	args = append([]interface{}{since}, args...)
+	return s.queryLogEntries(`
+		SELECT `+entryColumns+`
+		FROM log_entries
+		WHERE timestamp >= ? AND `+where+`
+		ORDER BY `+order+`
+		LIMIT ?`, append(args, limit)...)
+}
+
+// clientIP is the client address of entry for the client_ip column, from
+// the remote_addr field the access log parsers set.
+func clientIP(entry types.LogEntry) string {
+	if v, ok := entry.Fields["remote_addr"]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
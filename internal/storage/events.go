@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// InsertEvent stores an external event.
+func (s *Storage) InsertEvent(ev types.Event) error {
+	_, err := s.db.Exec(`
+		INSERT INTO events (timestamp, type, title, text, source)
+		VALUES (?, ?, ?, ?, ?)`,
+		ev.Timestamp, ev.Type, ev.Title, ev.Text, ev.Source)
+	return err
+}
+
+// GetEventsBetween returns events with from <= timestamp <= to, oldest
+// first. A non-empty eventType keeps only events of that type.
+func (s *Storage) GetEventsBetween(from, to time.Time, eventType string) ([]types.Event, error) {
+	defer s.observeQuery(time.Now())
+	rows, err := s.readDB.Query(`
+		SELECT timestamp, type, title, text, source FROM events
+		WHERE timestamp >= ? AND timestamp <= ? AND (? = '' OR type = ?)
+		ORDER BY timestamp ASC`, from, to, eventType, eventType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []types.Event
+	for rows.Next() {
+		var ev types.Event
+		if err := rows.Scan(&ev.Timestamp, &ev.Type, &ev.Title, &ev.Text, &ev.Source); err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// FileProcessed reports whether the file at path with the given fingerprint
+// was already read from a watched directory.
+func (s *Storage) FileProcessed(path, fingerprint string) (bool, error) {
+	var one int
+	err := s.db.QueryRow(`SELECT 1 FROM processed_files WHERE path = ? AND fingerprint = ?`, path, fingerprint).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// MarkFileProcessed records that the file at path was read in full.
+func (s *Storage) MarkFileProcessed(path, fingerprint string, size int64, lines int) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO processed_files (path, fingerprint, size, lines, processed_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		path, fingerprint, size, lines, s.clock.Now())
+	return err
+}
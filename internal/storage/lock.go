@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrLocked is returned by NewStorage when another pulsewatch process holds
+// the database.
+var ErrLocked = errors.New("database is in use by another pulsewatch process")
+
+// dbLock is an advisory lock on <db>.lock held for the lifetime of a Storage.
+// The OS releases it if the process dies, so a crash never leaves a stale lock.
+type dbLock struct {
+	file *os.File
+}
+
+func acquireLock(dbPath string) (*dbLock, error) {
+	path := dbPath + ".lock"
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := lockFile(f); err != nil {
+		holder := readHolder(f)
+		f.Close()
+		return nil, fmt.Errorf("%w (%s%s); use --db-path to run a second instance against a separate database", ErrLocked, path, holder)
+	}
+
+	// Record our PID so a second instance can say who holds the lock
+	f.Truncate(0)
+	f.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	return &dbLock{file: f}, nil
+}
+
+func readHolder(f *os.File) string {
+	buf := make([]byte, 32)
+	n, _ := f.ReadAt(buf, 0)
+	pid := strings.TrimSpace(string(buf[:n]))
+	if pid == "" {
+		return ""
+	}
+	return ", held by pid " + pid
+}
+
+func (l *dbLock) release() error {
+	unlockFile(l.file)
+	return l.file.Close()
+}
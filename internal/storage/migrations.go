@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migrations are applied in order; each runs at most once per database and
+// its index+1 is recorded in schema_migrations. Append only - never edit or
+// reorder an existing migration. The early ones use IF NOT EXISTS so
+// databases created before migrations were tracked upgrade cleanly.
+var migrations = []string{
+	// 1: initial schema
+	`
+	CREATE TABLE IF NOT EXISTS log_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME NOT NULL,
+		message TEXT,
+		level TEXT,
+		status_code INTEGER,
+		latency_ms INTEGER,
+		endpoint TEXT,
+		fields TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_timestamp ON log_entries(timestamp);
+	`,
+	// 2: seasonal baselines
+	`
+	CREATE TABLE IF NOT EXISTS baselines (
+		weekday INTEGER NOT NULL,
+		hour INTEGER NOT NULL,
+		metric TEXT NOT NULL,
+		count INTEGER NOT NULL,
+		mean REAL NOT NULL,
+		m2 REAL NOT NULL,
+		PRIMARY KEY (weekday, hour, metric)
+	);
+	`,
+	// 3: anomaly evidence
+	`
+	CREATE TABLE IF NOT EXISTS anomaly_evidence (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		anomaly_time DATETIME NOT NULL,
+		anomaly_type TEXT NOT NULL,
+		timestamp DATETIME NOT NULL,
+		message TEXT,
+		level TEXT,
+		status_code INTEGER,
+		latency_ms INTEGER,
+		endpoint TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_evidence_anomaly ON anomaly_evidence(anomaly_time, anomaly_type);
+	`,
+	// 4: secondary indexes for per-endpoint windows and error queries
+	`
+	CREATE INDEX IF NOT EXISTS idx_endpoint_timestamp ON log_entries(endpoint, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_status_timestamp ON log_entries(status_code, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_level ON log_entries(level);
+	`,
+	// 5: long-lived aggregates and anomalies, retained independently of raw entries
+	`
+	CREATE TABLE metric_rollups (
+		timestamp DATETIME NOT NULL,
+		requests INTEGER NOT NULL,
+		errors INTEGER NOT NULL,
+		p50_ms INTEGER NOT NULL,
+		p95_ms INTEGER NOT NULL,
+		p99_ms INTEGER NOT NULL
+	);
+	CREATE INDEX idx_rollups_timestamp ON metric_rollups(timestamp);
+	CREATE TABLE anomalies (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME NOT NULL,
+		type TEXT NOT NULL,
+		message TEXT,
+		contributors TEXT
+	);
+	CREATE INDEX idx_anomalies_timestamp ON anomalies(timestamp);
+	`,
+	// 6: HTTP method per entry; rows written before this are left empty
+	`
+	ALTER TABLE log_entries ADD COLUMN method TEXT NOT NULL DEFAULT '';
+	`,
+	// 7: CDN/proxy cache status per entry
+	`
+	ALTER TABLE log_entries ADD COLUMN cache_status TEXT NOT NULL DEFAULT '';
+	`,
+	// 8: queueing delay and service time per entry
+	`
+	ALTER TABLE log_entries ADD COLUMN queue_ms INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE log_entries ADD COLUMN service_ms INTEGER NOT NULL DEFAULT 0;
+	`,
+	// 9: tenant dimension, taken from the configured tenant field at insert time
+	`
+	ALTER TABLE log_entries ADD COLUMN tenant TEXT NOT NULL DEFAULT '';
+	CREATE INDEX idx_tenant_timestamp ON log_entries(tenant, timestamp);
+	`,
+	// 10: key of the configured grouping expression, evaluated at insert time
+	`
+	ALTER TABLE log_entries ADD COLUMN group_key TEXT NOT NULL DEFAULT '';
+	`,
+	// 11: HTTP and TLS versions
+	`
+	ALTER TABLE log_entries ADD COLUMN protocol TEXT NOT NULL DEFAULT '';
+	ALTER TABLE log_entries ADD COLUMN tls_version TEXT NOT NULL DEFAULT '';
+	`,
+	// 12: session and the session's previous endpoint, recorded at insert time
+	`
+	ALTER TABLE log_entries ADD COLUMN session TEXT NOT NULL DEFAULT '';
+	ALTER TABLE log_entries ADD COLUMN prev_endpoint TEXT NOT NULL DEFAULT '';
+	`,
+	// 13: anomaly severity and the metrics snapshot at firing time
+	`
+	ALTER TABLE anomalies ADD COLUMN severity TEXT NOT NULL DEFAULT '';
+	ALTER TABLE anomalies ADD COLUMN snapshot TEXT NOT NULL DEFAULT '';
+	CREATE INDEX idx_anomalies_type_timestamp ON anomalies(type, timestamp);
+	`,
+	// 14: external events (deploys, flag flips, incidents) shown as timeline markers
+	`
+	CREATE TABLE events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME NOT NULL,
+		type TEXT NOT NULL,
+		title TEXT NOT NULL,
+		text TEXT NOT NULL DEFAULT '',
+		source TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX idx_events_timestamp ON events(timestamp);
+	`,
+	// 15: synthetic probe results
+	`
+	CREATE TABLE probe_results (
+		timestamp DATETIME NOT NULL,
+		name TEXT NOT NULL,
+		ok INTEGER NOT NULL,
+		status_code INTEGER NOT NULL,
+		latency_ms INTEGER NOT NULL,
+		error TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX idx_probe_results_name_timestamp ON probe_results(name, timestamp);
+	`,
+	// 16: input an entry was read from, for runs combining several inputs
+	`
+	ALTER TABLE log_entries ADD COLUMN source TEXT NOT NULL DEFAULT '';
+	CREATE INDEX idx_source_timestamp ON log_entries(source, timestamp);
+	`,
+	// 17: gRPC status code of gRPC calls
+	`
+	ALTER TABLE log_entries ADD COLUMN grpc_status TEXT NOT NULL DEFAULT '';
+	`,
+	// 18: GraphQL operation of GraphQL requests
+	`
+	ALTER TABLE log_entries ADD COLUMN operation TEXT NOT NULL DEFAULT '';
+	CREATE INDEX idx_operation_timestamp ON log_entries(operation, timestamp);
+	`,
+	// 19: value of the configured version field
+	`
+	ALTER TABLE log_entries ADD COLUMN version TEXT NOT NULL DEFAULT '';
+	CREATE INDEX idx_version_timestamp ON log_entries(version, timestamp);
+	`,
+	// 20: whether an entry looks like a client retry
+	`
+	ALTER TABLE log_entries ADD COLUMN retry INTEGER NOT NULL DEFAULT 0;
+	`,
+	// 21: component timings (JSON object of milliseconds), empty for none
+	`
+	ALTER TABLE log_entries ADD COLUMN timings TEXT NOT NULL DEFAULT '';
+	`,
+	// 22: batch files read from watched directories, so each is read once
+	`
+	CREATE TABLE processed_files (
+		path TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		lines INTEGER NOT NULL,
+		processed_at DATETIME NOT NULL,
+		PRIMARY KEY (path, fingerprint)
+	);
+	`,
+	// 23: configured error category of failed entries
+	`
+	ALTER TABLE log_entries ADD COLUMN error_category TEXT NOT NULL DEFAULT '';
+	`,
+	// 24: operator annotations of log lines and time ranges
+	`
+	CREATE TABLE annotations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created DATETIME NOT NULL,
+		start_time DATETIME NOT NULL,
+		end_time DATETIME NOT NULL,
+		label TEXT NOT NULL,
+		note TEXT NOT NULL DEFAULT '',
+		line TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX idx_annotations_start ON annotations(start_time);
+	`,
+	// 25: client address, so anomalies can be broken down by it in SQL
+	`
+	ALTER TABLE log_entries ADD COLUMN client_ip TEXT NOT NULL DEFAULT '';
+	`,
+}
+
+// migrate brings the schema up to date.
+func migrate(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return err
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+
+	for i := current; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, i+1); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
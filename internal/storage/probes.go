@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// InsertProbeResult stores the outcome of a synthetic probe check.
+func (s *Storage) InsertProbeResult(r types.ProbeResult) error {
+	_, err := s.db.Exec(`
+		INSERT INTO probe_results (timestamp, name, ok, status_code, latency_ms, error)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		r.Timestamp, r.Name, r.OK, r.StatusCode, r.Latency.Milliseconds(), r.Error)
+	return err
+}
+
+// GetProbeResultsBetween returns the named probe's results with
+// from <= timestamp <= to, oldest first.
+func (s *Storage) GetProbeResultsBetween(name string, from, to time.Time) ([]types.ProbeResult, error) {
+	defer s.observeQuery(time.Now())
+	rows, err := s.readDB.Query(`
+		SELECT timestamp, name, ok, status_code, latency_ms, error FROM probe_results
+		WHERE name = ? AND timestamp >= ? AND timestamp <= ?
+		ORDER BY timestamp ASC`, name, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []types.ProbeResult
+	for rows.Next() {
+		var r types.ProbeResult
+		var latencyMs int64
+		if err := rows.Scan(&r.Timestamp, &r.Name, &r.OK, &r.StatusCode, &latencyMs, &r.Error); err != nil {
+			return nil, err
+		}
+		r.Latency = time.Duration(latencyMs) * time.Millisecond
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
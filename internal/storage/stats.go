@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// statsTables are the tables whose row counts are reported by Stats.
+var statsTables = []string{"log_entries", "metric_rollups", "anomalies", "anomaly_evidence", "baselines"}
+
+// counters are updated on the hot path and read by Stats.
+type counters struct {
+	inserts    atomic.Int64
+	queries    atomic.Int64
+	queryNanos atomic.Int64
+	lastPrune  atomic.Int64 // Unix nanoseconds
+	lastVacuum atomic.Int64 // Unix nanoseconds
+	prunedRows atomic.Int64
+}
+
+// Stats describes the database for the internals view.
+type Stats struct {
+	SizeBytes       int64 // Main database file
+	WALBytes        int64
+	TableRows       map[string]int64
+	Inserts         int64 // Log entries inserted since startup
+	Queries         int64 // Read queries since startup
+	AvgQueryLatency time.Duration
+	PrunedRows      int64
+	LastPrune       time.Time
+	LastVacuum      time.Time
+}
+
+// observeQuery records the latency of a read query started at start.
+func (s *Storage) observeQuery(start time.Time) {
+	s.counters.queries.Add(1)
+	s.counters.queryNanos.Add(int64(time.Since(start)))
+}
+
+// Stats gathers file sizes, row counts and runtime counters.
+func (s *Storage) Stats() (Stats, error) {
+	st := Stats{
+		TableRows:  make(map[string]int64),
+		Inserts:    s.counters.inserts.Load(),
+		Queries:    s.counters.queries.Load(),
+		PrunedRows: s.counters.prunedRows.Load(),
+	}
+	if st.Queries > 0 {
+		st.AvgQueryLatency = time.Duration(s.counters.queryNanos.Load() / st.Queries)
+	}
+	if ns := s.counters.lastPrune.Load(); ns > 0 {
+		st.LastPrune = time.Unix(0, ns)
+	}
+	if ns := s.counters.lastVacuum.Load(); ns > 0 {
+		st.LastVacuum = time.Unix(0, ns)
+	}
+
+	if fi, err := os.Stat(s.path); err == nil {
+		st.SizeBytes = fi.Size()
+	}
+	if fi, err := os.Stat(s.path + "-wal"); err == nil {
+		st.WALBytes = fi.Size()
+	}
+
+	for _, table := range statsTables {
+		var n int64
+		if err := s.readDB.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+			return st, err
+		}
+		st.TableRows[table] = n
+	}
+	return st, nil
+}
+
+// Vacuum checkpoints the WAL and rebuilds the database file, returning space
+// freed by pruning to the filesystem. It blocks writers while it runs.
+func (s *Storage) Vacuum() error {
+	if _, err := s.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec("VACUUM"); err != nil {
+		return err
+	}
+	s.counters.lastVacuum.Store(s.clock.Now().UnixNano())
+	return nil
+}
+
+// UsedBytes returns the space the data occupies in the database file: the
+// pages in use, not counting pages freed by deletes, which SQLite reuses.
+// The WAL is left out; it is checkpointed back at a few MB.
+func (s *Storage) UsedBytes() (int64, error) {
+	var pages, free, pageSize int64
+	if err := s.db.QueryRow("PRAGMA page_count").Scan(&pages); err != nil {
+		return 0, err
+	}
+	if err := s.db.QueryRow("PRAGMA freelist_count").Scan(&free); err != nil {
+		return 0, err
+	}
+	if err := s.db.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, err
+	}
+	return (pages - free) * pageSize, nil
+}
+
+// Checkpoint copies the WAL into the database and truncates it.
+func (s *Storage) Checkpoint() error {
+	_, err := s.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	return err
+}
+
+// CountLogEntries returns the number of stored raw entries.
+func (s *Storage) CountLogEntries() (int64, error) {
+	var n int64
+	err := s.db.QueryRow("SELECT COUNT(*) FROM log_entries").Scan(&n)
+	return n, err
+}
+
+// LogEntryTimeAt returns the timestamp of the entry with n older ones
+// before it, i.e. the cutoff that prunes the n oldest entries.
+func (s *Storage) LogEntryTimeAt(n int64) (time.Time, error) {
+	var t time.Time
+	err := s.db.QueryRow("SELECT timestamp FROM log_entries ORDER BY timestamp LIMIT 1 OFFSET ?", n).Scan(&t)
+	return t, err
+}
@@ -3,47 +3,137 @@ package storage
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/nitis/pulseWatch/internal/topk"
 	"github.com/nitis/pulseWatch/internal/types"
+	"github.com/nitis/pulseWatch/pkg/clock"
 	_ "modernc.org/sqlite"
 )
 
+const busyTimeoutMs = 5000
+
+// Storage persists log entries in SQLite. Writes go through a single
+// connection; reads use a separate pool so window queries never queue behind
+// inserts (WAL mode lets readers run concurrently with the writer).
 type Storage struct {
-	db *sql.DB
+	db       *sql.DB // Writer, limited to one connection
+	readDB   *sql.DB
+	compress bool
+	lock     *dbLock
+	path     string
+	counters counters
+	topKeys  int         // Values kept per high-cardinality column
+	clock    clock.Clock // Windows and prune and vacuum times; query latencies use the system clock
+}
+
+// SetClock makes the store read the time from c instead of the system
+// clock, as Engine.SetClock does.
+func (s *Storage) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// SetTopKeys sets how many endpoints and group keys AggregateSince keeps,
+// and values per dimension DimensionCounts keeps; 0, the default, means
+// topk.DefaultCapacity.
+func (s *Storage) SetTopKeys(n int) {
+	s.topKeys = n
 }
 
-func NewStorage(dbPath string) (*Storage, error) {
-	db, err := sql.Open("sqlite", dbPath)
+// keyLimit is the number of values kept per high-cardinality column.
+func (s *Storage) keyLimit() int {
+	if s.topKeys > 0 {
+		return s.topKeys
+	}
+	return topk.DefaultCapacity
+}
+
+// Options controls optional storage behaviour.
+type Options struct {
+	Compress bool // zstd-compress the message and fields columns
+}
+
+func NewStorage(dbPath string, opts Options) (*Storage, error) {
+	lock, err := acquireLock(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", dsn(dbPath, false, "journal_mode(WAL)", "synchronous(NORMAL)"))
 	if err != nil {
+		lock.release()
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		lock.release()
 		return nil, err
 	}
 
-	// Create table if not exists
-	createTableSQL := `
-	CREATE TABLE IF NOT EXISTS log_entries (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		timestamp DATETIME NOT NULL,
-		message TEXT,
-		level TEXT,
-		status_code INTEGER,
-		latency_ms INTEGER,
-		endpoint TEXT,
-		fields TEXT
-	);
-	CREATE INDEX IF NOT EXISTS idx_timestamp ON log_entries(timestamp);
-	`
-	_, err = db.Exec(createTableSQL)
+	readDB, err := sql.Open("sqlite", dsn(dbPath, true))
 	if err != nil {
+		db.Close()
+		lock.release()
 		return nil, err
 	}
 
-	return &Storage{db: db}, nil
+	return &Storage{db: db, readDB: readDB, compress: opts.Compress, lock: lock, path: dbPath, clock: clock.Real()}, nil
+}
+
+// dsn builds the SQLite URI for dbPath with a busy timeout and pragmas.
+// The path is escaped, so "?", "#" and "%" in it name the file rather than
+// start the parameters.
+func dsn(dbPath string, readOnly bool, pragmas ...string) string {
+	q := url.Values{"_pragma": append([]string{fmt.Sprintf("busy_timeout(%d)", busyTimeoutMs)}, pragmas...)}
+	if readOnly {
+		q.Set("mode", "ro")
+	}
+	// A relative path would be read as the URI's host
+	if abs, err := filepath.Abs(dbPath); err == nil {
+		dbPath = abs
+	}
+	path := filepath.ToSlash(dbPath)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // Windows drive paths, file:///C:/...
+	}
+	u := url.URL{Scheme: "file", Path: path, RawQuery: q.Encode()}
+	return u.String()
+}
+
+// OpenReadOnly opens an existing database for queries only. It takes no
+// lock and runs no migrations, so it can be used while another pulsewatch
+// process is writing. Write methods must not be called.
+func OpenReadOnly(dbPath string) (*Storage, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, err
+	}
+	readDB, err := sql.Open("sqlite", dsn(dbPath, true))
+	if err != nil {
+		return nil, err
+	}
+	return &Storage{readDB: readDB, path: dbPath, clock: clock.Real()}, nil
 }
 
 func (s *Storage) Close() error {
-	return s.db.Close()
+	readErr := s.readDB.Close()
+	if s.db == nil {
+		return readErr
+	}
+	err := s.db.Close()
+	s.lock.release()
+	if err != nil {
+		return err
+	}
+	return readErr
 }
 
 func (s *Storage) InsertLogEntry(entry types.LogEntry) error {
@@ -54,18 +144,30 @@ func (s *Storage) InsertLogEntry(entry types.LogEntry) error {
 	}
 
 	_, err = s.db.Exec(`
-		INSERT INTO log_entries (timestamp, message, level, status_code, latency_ms, endpoint, fields)
-		VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		entry.Timestamp, entry.Message, string(entry.Level), entry.StatusCode, entry.Latency.Milliseconds(), entry.Endpoint, string(fieldsJSON))
+		INSERT INTO log_entries (timestamp, message, level, status_code, latency_ms, endpoint, method, cache_status, queue_ms, service_ms, tenant, group_key, protocol, tls_version, session, prev_endpoint, source, grpc_status, operation, version, retry, error_category, client_ip, timings, fields)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.Timestamp, s.encodeColumn(entry.Message), string(entry.Level), entry.StatusCode, entry.Latency.Milliseconds(), entry.Endpoint, entry.Method, entry.CacheStatus,
+		entry.QueueTime.Milliseconds(), entry.ServiceTime.Milliseconds(), entry.Tenant, entry.GroupKey, entry.Protocol, entry.TLSVersion, entry.Session, entry.PrevEndpoint, entry.Source, entry.GRPCStatus, entry.Operation, entry.Version, entry.Retry, entry.ErrorCategory, clientIP(entry), encodeTimings(entry.Timings), s.encodeColumn(string(fieldsJSON)))
+	if err == nil {
+		s.counters.inserts.Add(1)
+	}
 	return err
 }
 
 func (s *Storage) GetLogEntriesSince(since time.Time) ([]types.LogEntry, error) {
-	rows, err := s.db.Query(`
-		SELECT timestamp, message, level, status_code, latency_ms, endpoint, fields
+	return s.queryLogEntries(`
+		SELECT `+entryColumns+`
 		FROM log_entries
 		WHERE timestamp >= ?
 		ORDER BY timestamp ASC`, since)
+}
+
+// entryColumns are the log_entries columns queryLogEntries scans.
+const entryColumns = "timestamp, message, level, status_code, latency_ms, endpoint, method, cache_status, queue_ms, service_ms, tenant, group_key, protocol, tls_version, session, prev_endpoint, source, grpc_status, operation, version, retry, error_category, timings, fields"
+
+func (s *Storage) queryLogEntries(query string, args ...interface{}) ([]types.LogEntry, error) {
+	defer s.observeQuery(time.Now())
+	rows, err := s.readDB.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -73,37 +175,278 @@ func (s *Storage) GetLogEntriesSince(since time.Time) ([]types.LogEntry, error)
 
 	var entries []types.LogEntry
 	for rows.Next() {
-		var ts time.Time
-		var message, level, endpoint, fieldsStr string
-		var statusCode, latencyMs int
-		err := rows.Scan(&ts, &message, &level, &statusCode, &latencyMs, &endpoint, &fieldsStr)
+		entry, err := scanLogEntry(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		var fields map[string]interface{}
-		json.Unmarshal([]byte(fieldsStr), &fields)
-
-		entry := types.LogEntry{
-			Timestamp:  ts,
-			Message:    message,
-			Level:      types.LogLevel(level),
-			StatusCode: statusCode,
-			Latency:    time.Duration(latencyMs) * time.Millisecond,
-			Endpoint:   endpoint,
-			Fields:     fields,
-		}
 		entries = append(entries, entry)
 	}
 	return entries, nil
 }
 
-func (s *Storage) PruneOldEntries(olderThan time.Time) error {
-	_, err := s.db.Exec("DELETE FROM log_entries WHERE timestamp < ?", olderThan)
+// scanLogEntry scans a row of entryColumns, preceded by the columns extra
+// is scanned into.
+func scanLogEntry(rows *sql.Rows, extra ...interface{}) (types.LogEntry, error) {
+	var ts time.Time
+	var level, endpoint, method, cacheStatus, tenant, groupKey, protocol, tlsVersion, session, prevEndpoint, source, grpcStatus, operation, version, errorCategory, timings string
+	var message, fieldsRaw []byte
+	var statusCode, latencyMs, queueMs, serviceMs int
+	var retry bool
+	dest := append(extra, &ts, &message, &level, &statusCode, &latencyMs, &endpoint, &method, &cacheStatus, &queueMs, &serviceMs, &tenant, &groupKey, &protocol, &tlsVersion, &session, &prevEndpoint, &source, &grpcStatus, &operation, &version, &retry, &errorCategory, &timings, &fieldsRaw)
+	if err := rows.Scan(dest...); err != nil {
+		return types.LogEntry{}, err
+	}
+
+	var fields map[string]interface{}
+	json.Unmarshal([]byte(decodeColumn(fieldsRaw)), &fields)
+
+	return types.LogEntry{
+		Timestamp:     ts,
+		Message:       decodeColumn(message),
+		Level:         types.LogLevel(level),
+		StatusCode:    statusCode,
+		Latency:       time.Duration(latencyMs) * time.Millisecond,
+		Endpoint:      endpoint,
+		Method:        method,
+		CacheStatus:   cacheStatus,
+		QueueTime:     time.Duration(queueMs) * time.Millisecond,
+		ServiceTime:   time.Duration(serviceMs) * time.Millisecond,
+		Tenant:        tenant,
+		GroupKey:      groupKey,
+		Protocol:      protocol,
+		TLSVersion:    tlsVersion,
+		Session:       session,
+		PrevEndpoint:  prevEndpoint,
+		Source:        source,
+		GRPCStatus:    grpcStatus,
+		Operation:     operation,
+		Version:       version,
+		Retry:         retry,
+		ErrorCategory: errorCategory,
+		Timings:       decodeTimings(timings),
+		Fields:        fields,
+	}, nil
+}
+
+// encodeTimings stores component timings as a JSON object, or "" for none.
+func encodeTimings(timings map[string]float64) string {
+	if len(timings) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(timings)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func decodeTimings(raw string) map[string]float64 {
+	if raw == "" {
+		return nil
+	}
+	var timings map[string]float64
+	json.Unmarshal([]byte(raw), &timings)
+	return timings
+}
+
+// pruneBatch is the most entries PruneOldEntries reads and deletes at once.
+const pruneBatch = 10000
+
+// StoredEntry is a log entry as stored, with the columns derived from it
+// when it was inserted.
+type StoredEntry struct {
+	types.LogEntry
+	ClientIP string
+}
+
+// PruneOldEntries deletes the entries older than olderThan, and the anomaly
+// evidence captured before it. With archive, each batch of entries is
+// passed to it first, in the transaction that deletes them, so entries
+// written meanwhile are neither lost nor deleted unarchived; a batch that
+// fails to archive is kept, and the error returned.
+func (s *Storage) PruneOldEntries(olderThan time.Time, archive func([]StoredEntry) error) error {
+	for {
+		n, err := s.pruneEntryBatch(olderThan, archive)
+		if err != nil {
+			return err
+		}
+		s.counters.prunedRows.Add(n)
+		if n < pruneBatch {
+			break
+		}
+	}
+	s.counters.lastPrune.Store(s.clock.Now().UnixNano())
+	_, err := s.db.Exec("DELETE FROM anomaly_evidence WHERE anomaly_time < ?", olderThan)
 	return err
 }
 
+// pruneEntryBatch archives and deletes up to pruneBatch of the oldest-written
+// entries older than olderThan, returning how many it deleted. The delete is
+// bounded by the largest rowid read, so it only removes rows archive saw.
+func (s *Storage) pruneEntryBatch(olderThan time.Time, archive func([]StoredEntry) error) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var maxRowID int64
+	var batch []StoredEntry
+	if archive != nil {
+		rows, err := tx.Query(`
+			SELECT rowid, client_ip, `+entryColumns+`
+			FROM log_entries
+			WHERE timestamp < ?
+			ORDER BY rowid
+			LIMIT ?`, olderThan, pruneBatch)
+		if err != nil {
+			return 0, err
+		}
+		for rows.Next() {
+			var e StoredEntry
+			if e.LogEntry, err = scanLogEntry(rows, &maxRowID, &e.ClientIP); err != nil {
+				rows.Close()
+				return 0, err
+			}
+			batch = append(batch, e)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+	} else {
+		err := tx.QueryRow(`
+			SELECT COALESCE(MAX(rowid), 0) FROM (
+				SELECT rowid FROM log_entries WHERE timestamp < ? ORDER BY rowid LIMIT ?
+			)`, olderThan, pruneBatch).Scan(&maxRowID)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if maxRowID == 0 {
+		return 0, nil
+	}
+	if archive != nil {
+		if err := archive(batch); err != nil {
+			return 0, err
+		}
+	}
+
+	res, err := tx.Exec("DELETE FROM log_entries WHERE timestamp < ? AND rowid <= ?", olderThan, maxRowID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
 func (s *Storage) GetEntriesInWindow(window time.Duration) ([]types.LogEntry, error) {
-	since := time.Now().Add(-window)
+	since := s.clock.Now().Add(-window)
 	return s.GetLogEntriesSince(since)
-}
\ No newline at end of file
+}
+
+// Baseline holds running statistics for one metric in one day-of-week/hour-of-day bucket.
+type Baseline struct {
+	Count int
+	Mean  float64
+	M2    float64 // Sum of squared deviations from the mean (Welford)
+}
+
+// Std returns the sample standard deviation of the baseline.
+func (b Baseline) Std() float64 {
+	if b.Count < 2 {
+		return 0
+	}
+	return math.Sqrt(b.M2 / float64(b.Count-1))
+}
+
+// GetBaseline returns the baseline for a bucket, or a zero Baseline if none has been recorded.
+func (s *Storage) GetBaseline(weekday time.Weekday, hour int, metric string) (Baseline, error) {
+	return getBaseline(s.readDB, weekday, hour, metric)
+}
+
+func getBaseline(db *sql.DB, weekday time.Weekday, hour int, metric string) (Baseline, error) {
+	var b Baseline
+	err := db.QueryRow(`
+		SELECT count, mean, m2 FROM baselines
+		WHERE weekday = ? AND hour = ? AND metric = ?`, int(weekday), hour, metric).Scan(&b.Count, &b.Mean, &b.M2)
+	if err == sql.ErrNoRows {
+		return Baseline{}, nil
+	}
+	return b, err
+}
+
+// UpdateBaseline folds a new sample into the bucket's running statistics.
+func (s *Storage) UpdateBaseline(weekday time.Weekday, hour int, metric string, value float64) error {
+	// Read through the writer so the read-modify-write sees its own last update
+	b, err := getBaseline(s.db, weekday, hour, metric)
+	if err != nil {
+		return err
+	}
+
+	b.Count++
+	delta := value - b.Mean
+	b.Mean += delta / float64(b.Count)
+	b.M2 += delta * (value - b.Mean)
+
+	_, err = s.db.Exec(`
+		INSERT INTO baselines (weekday, hour, metric, count, mean, m2)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (weekday, hour, metric) DO UPDATE SET count = excluded.count, mean = excluded.mean, m2 = excluded.m2`,
+		int(weekday), hour, metric, b.Count, b.Mean, b.M2)
+	return err
+}
+
+// InsertEvidence stores the raw entries captured for an anomaly.
+func (s *Storage) InsertEvidence(anomaly types.Anomaly) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, entry := range anomaly.Evidence {
+		_, err := tx.Exec(`
+			INSERT INTO anomaly_evidence (anomaly_time, anomaly_type, timestamp, message, level, status_code, latency_ms, endpoint)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			anomaly.Timestamp.Round(0), anomaly.Type, entry.Timestamp, entry.Message, string(entry.Level), entry.StatusCode, entry.Latency.Milliseconds(), entry.Endpoint)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetEvidence returns the entries captured for the anomaly of the given type fired at the given time.
+func (s *Storage) GetEvidence(anomalyType string, anomalyTime time.Time) ([]types.LogEntry, error) {
+	rows, err := s.readDB.Query(`
+		SELECT timestamp, message, level, status_code, latency_ms, endpoint
+		FROM anomaly_evidence
+		WHERE anomaly_type = ? AND anomaly_time = ?
+		ORDER BY id ASC`, anomalyType, anomalyTime.Round(0))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []types.LogEntry
+	for rows.Next() {
+		var ts time.Time
+		var message, level, endpoint string
+		var statusCode, latencyMs int
+		if err := rows.Scan(&ts, &message, &level, &statusCode, &latencyMs, &endpoint); err != nil {
+			return nil, err
+		}
+		entries = append(entries, types.LogEntry{
+			Timestamp:  ts,
+			Message:    message,
+			Level:      types.LogLevel(level),
+			StatusCode: statusCode,
+			Latency:    time.Duration(latencyMs) * time.Millisecond,
+			Endpoint:   endpoint,
+		})
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// The secondary indexes added by migration 4. The benchmarks run with and
+// without them to measure what they cost inserts and save queries.
+var secondaryIndexes = []string{"idx_endpoint_timestamp", "idx_status_timestamp", "idx_level"}
+
+const (
+	benchEndpoints = 200
+	benchRows      = 50000
+	benchSpan      = 2 * time.Hour
+)
+
+// newBenchStorage creates a store in a temp dir, dropping migration 4's
+// indexes unless indexed is set.
+func newBenchStorage(b *testing.B, indexed bool) *Storage {
+	b.Helper()
+	s, err := NewStorage(filepath.Join(b.TempDir(), "bench.db"), Options{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { s.Close() })
+	if !indexed {
+		for _, idx := range secondaryIndexes {
+			if _, err := s.db.Exec(`DROP INDEX ` + idx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	return s
+}
+
+// benchEntry is the i-th of a stream spread over benchSpan before now: one
+// request in 20 a server error, one in 50 a client error.
+func benchEntry(i int, now time.Time) types.LogEntry {
+	entry := types.LogEntry{
+		Timestamp:  now.Add(-benchSpan + time.Duration(i)*benchSpan/benchRows),
+		Message:    "request handled",
+		Level:      types.InfoLevel,
+		StatusCode: 200,
+		Latency:    time.Duration(5+i%250) * time.Millisecond,
+		Endpoint:   fmt.Sprintf("/api/v1/resource/%d", i%benchEndpoints),
+		Method:     "GET",
+	}
+	switch {
+	case i%20 == 0:
+		entry.StatusCode, entry.Level = 503, types.ErrorLevel
+	case i%50 == 0:
+		entry.StatusCode = 404
+	}
+	return entry
+}
+
+func benchVariants(b *testing.B, run func(b *testing.B, indexed bool)) {
+	b.Run("without-indexes", func(b *testing.B) { run(b, false) })
+	b.Run("with-indexes", func(b *testing.B) { run(b, true) })
+}
+
+func BenchmarkInsertLogEntry(b *testing.B) {
+	benchVariants(b, func(b *testing.B, indexed bool) {
+		s := newBenchStorage(b, indexed)
+		now := time.Now()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := s.InsertLogEntry(benchEntry(i%benchRows, now)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// seededBenchStorage is newBenchStorage holding benchRows entries.
+func seededBenchStorage(b *testing.B, indexed bool) (*Storage, time.Time) {
+	b.Helper()
+	s := newBenchStorage(b, indexed)
+	now := time.Now()
+	tx, err := s.db.Begin()
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < benchRows; i++ {
+		e := benchEntry(i, now)
+		if _, err := tx.Exec(`
+			INSERT INTO log_entries (timestamp, message, level, status_code, latency_ms, endpoint, method)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			e.Timestamp, e.Message, string(e.Level), e.StatusCode, e.Latency.Milliseconds(), e.Endpoint, e.Method); err != nil {
+			tx.Rollback()
+			b.Fatal(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := s.db.Exec(`ANALYZE`); err != nil {
+		b.Fatal(err)
+	}
+	return s, now
+}
+
+func BenchmarkAggregateSince(b *testing.B) {
+	benchVariants(b, func(b *testing.B, indexed bool) {
+		s, now := seededBenchStorage(b, indexed)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := s.AggregateSince(now.Add(-5 * time.Minute)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkEndpointLatencies(b *testing.B) {
+	benchVariants(b, func(b *testing.B, indexed bool) {
+		s, now := seededBenchStorage(b, indexed)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			endpoint := fmt.Sprintf("/api/v1/resource/%d", i%benchEndpoints)
+			if _, err := s.LatenciesSince(now.Add(-time.Hour), endpoint, EntryFilter{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkTopErrors(b *testing.B) {
+	benchVariants(b, func(b *testing.B, indexed bool) {
+		s, now := seededBenchStorage(b, indexed)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := s.TopErrorsBetween(now.Add(-time.Hour), now, 10); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
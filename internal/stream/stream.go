@@ -0,0 +1,330 @@
+// Package stream is the binary framing one pulsewatch uses to pass its
+// parsed entries to another over TCP. A connection starts with a header
+// (the magic "PWST", a version byte, and the sender's token as a
+// length-prefixed string, empty without one), followed by one frame per
+// entry: the payload length as a uvarint, then the entry's timestamp,
+// request attributes, and parsed fields in a fixed order. Strings are
+// length-prefixed and integers varints, so a typical access log entry takes
+// well under its text form.
+package stream
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// Version is the framing version written in the header.
+const Version = 2
+
+// maxToken bounds the token in the header.
+const maxToken = 1 << 10
+
+// MaxFrame bounds a frame; longer ones are treated as a broken stream.
+const MaxFrame = 16 << 20
+
+var magic = []byte("PWST")
+
+// Field value tags.
+const (
+	tagNil byte = iota
+	tagString
+	tagFloat
+	tagBool
+	tagInt
+	tagJSON // Anything else, e.g. nested objects, as JSON
+)
+
+// Writer encodes entries onto a connection. Frames are buffered until
+// Flush.
+type Writer struct {
+	w      *bufio.Writer
+	token  string
+	header bool
+	buf    []byte
+}
+
+// NewWriter creates a Writer on w presenting token to the receiver. The
+// header is written with the first entry.
+func NewWriter(w io.Writer, token string) *Writer {
+	return &Writer{w: bufio.NewWriter(w), token: token}
+}
+
+// Write encodes entry as one frame.
+func (w *Writer) Write(entry types.LogEntry) error {
+	if !w.header {
+		w.w.Write(magic)
+		w.w.WriteByte(Version)
+		w.w.Write(appendString(nil, w.token))
+		w.header = true
+	}
+	payload, err := appendEntry(w.buf[:0], entry)
+	if err != nil {
+		return err
+	}
+	w.buf = payload
+	var size [binary.MaxVarintLen64]byte
+	w.w.Write(size[:binary.PutUvarint(size[:], uint64(len(payload)))])
+	_, err = w.w.Write(payload)
+	return err
+}
+
+// Flush writes the buffered frames.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Reader decodes the entries of a connection.
+type Reader struct {
+	r      *bufio.Reader
+	header bool
+	buf    []byte
+}
+
+// NewReader creates a Reader on r. The header is checked by Header or the
+// first Read.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Header reads and checks the header, returning the sender's token.
+func (r *Reader) Header() (string, error) {
+	header := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(r.r, header); err != nil {
+		return "", err
+	}
+	if string(header[:len(magic)]) != string(magic) {
+		return "", fmt.Errorf("not a pulsewatch stream")
+	}
+	if header[len(magic)] != Version {
+		return "", fmt.Errorf("unsupported stream version %d (want %d)", header[len(magic)], Version)
+	}
+	size, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return "", noEOF(err)
+	}
+	if size > maxToken {
+		return "", fmt.Errorf("token of %d bytes exceeds %d", size, maxToken)
+	}
+	token := make([]byte, size)
+	if _, err := io.ReadFull(r.r, token); err != nil {
+		return "", noEOF(err)
+	}
+	r.header = true
+	return string(token), nil
+}
+
+// Read decodes the next entry. It returns io.EOF when the stream ends
+// between frames.
+func (r *Reader) Read() (types.LogEntry, error) {
+	if !r.header {
+		if _, err := r.Header(); err != nil {
+			return types.LogEntry{}, err
+		}
+	}
+	size, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return types.LogEntry{}, err
+	}
+	if size > MaxFrame {
+		return types.LogEntry{}, fmt.Errorf("frame of %d bytes exceeds %d", size, MaxFrame)
+	}
+	if cap(r.buf) < int(size) {
+		r.buf = make([]byte, size)
+	}
+	r.buf = r.buf[:size]
+	if _, err := io.ReadFull(r.r, r.buf); err != nil {
+		return types.LogEntry{}, noEOF(err)
+	}
+	d := decoder{buf: r.buf}
+	entry := d.entry()
+	if d.err != nil {
+		return types.LogEntry{}, d.err
+	}
+	return entry, nil
+}
+
+// noEOF reports a stream cut off inside a frame as unexpected.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func appendEntry(b []byte, e types.LogEntry) ([]byte, error) {
+	var ts int64 // 0 for a zero timestamp
+	if !e.Timestamp.IsZero() {
+		ts = e.Timestamp.UnixNano()
+	}
+	b = binary.AppendVarint(b, ts)
+	b = appendString(b, string(e.Level))
+	b = appendString(b, e.Message)
+	b = binary.AppendUvarint(b, uint64(max(e.StatusCode, 0)))
+	b = binary.AppendVarint(b, int64(e.Latency))
+	b = appendString(b, e.Endpoint)
+	b = appendString(b, e.Method)
+	b = appendString(b, e.CacheStatus)
+	b = binary.AppendVarint(b, int64(e.QueueTime))
+	b = binary.AppendVarint(b, int64(e.ServiceTime))
+	b = appendString(b, e.Protocol)
+	b = appendString(b, e.TLSVersion)
+	b = appendString(b, e.GRPCStatus)
+	b = appendString(b, e.Operation)
+	b = binary.AppendUvarint(b, uint64(len(e.Fields)))
+	for k, v := range e.Fields {
+		b = appendString(b, k)
+		switch v := v.(type) {
+		case nil:
+			b = append(b, tagNil)
+		case string:
+			b = appendString(append(b, tagString), v)
+		case float64:
+			b = binary.LittleEndian.AppendUint64(append(b, tagFloat), math.Float64bits(v))
+		case bool:
+			if v {
+				b = append(b, tagBool, 1)
+			} else {
+				b = append(b, tagBool, 0)
+			}
+		case int:
+			b = binary.AppendVarint(append(b, tagInt), int64(v))
+		case int64:
+			b = binary.AppendVarint(append(b, tagInt), v)
+		default:
+			raw, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", k, err)
+			}
+			b = appendString(append(b, tagJSON), string(raw))
+		}
+	}
+	return b, nil
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+var errShortFrame = errors.New("frame ends early")
+
+// decoder reads a frame's payload, remembering the first error.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) entry() types.LogEntry {
+	var e types.LogEntry
+	if ts := d.varint(); ts != 0 {
+		e.Timestamp = time.Unix(0, ts)
+	}
+	e.Level = types.LogLevel(d.string())
+	e.Message = d.string()
+	e.StatusCode = int(d.uvarint())
+	e.Latency = time.Duration(d.varint())
+	e.Endpoint = d.string()
+	e.Method = d.string()
+	e.CacheStatus = d.string()
+	e.QueueTime = time.Duration(d.varint())
+	e.ServiceTime = time.Duration(d.varint())
+	e.Protocol = d.string()
+	e.TLSVersion = d.string()
+	e.GRPCStatus = d.string()
+	e.Operation = d.string()
+	if n := d.uvarint(); n > 0 && d.err == nil {
+		if n > uint64(len(d.buf)) {
+			d.err = errShortFrame
+			return e
+		}
+		e.Fields = make(map[string]interface{}, n)
+		for ; n > 0 && d.err == nil; n-- {
+			k := d.string()
+			e.Fields[k] = d.value()
+		}
+	}
+	return e
+}
+
+func (d *decoder) value() interface{} {
+	tag := d.bytes(1)
+	if d.err != nil {
+		return nil
+	}
+	switch tag[0] {
+	case tagNil:
+		return nil
+	case tagString:
+		return d.string()
+	case tagFloat:
+		if b := d.bytes(8); d.err == nil {
+			return math.Float64frombits(binary.LittleEndian.Uint64(b))
+		}
+	case tagBool:
+		if b := d.bytes(1); d.err == nil {
+			return b[0] == 1
+		}
+	case tagInt:
+		return d.varint()
+	case tagJSON:
+		var v interface{}
+		if err := json.Unmarshal([]byte(d.string()), &v); err != nil && d.err == nil {
+			d.err = err
+		}
+		return v
+	default:
+		d.err = fmt.Errorf("unknown field tag %d", tag[0])
+	}
+	return nil
+}
+
+func (d *decoder) bytes(n uint64) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > uint64(len(d.buf)) {
+		d.err = errShortFrame
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes(d.uvarint()))
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.err = errShortFrame
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.err = errShortFrame
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
@@ -0,0 +1,131 @@
+// Package topk finds the most frequent keys of a stream, such as endpoints
+// or client IPs, in bounded memory with the Space-Saving algorithm (Metwally,
+// Agrawal, and El Abbadi, 2005).
+package topk
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// DefaultCapacity is the number of keys a sketch tracks unless configured
+// otherwise.
+const DefaultCapacity = 1000
+
+// Item is a tracked key. Count may overestimate the key's true count by up
+// to Error, the count it inherited when it replaced another key.
+type Item struct {
+	Key   string
+	Count int
+	Error int
+}
+
+// Sketch counts keys, tracking at most its capacity. Until more keys than
+// that are seen the counts are exact. After that a new key replaces the
+// least frequent one and inherits its count, so every key seen more than
+// Total/capacity times is kept, and the counts still sum to Total.
+type Sketch struct {
+	capacity int
+	items    minHeap
+	index    map[string]int // Key -> position in items
+	total    int
+}
+
+// New creates a Sketch tracking up to capacity keys, or DefaultCapacity if
+// capacity is not positive.
+func New(capacity int) *Sketch {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	s := &Sketch{capacity: capacity, index: make(map[string]int)}
+	s.items.index = s.index
+	return s
+}
+
+// Add counts n occurrences of key.
+func (s *Sketch) Add(key string, n int) {
+	if n <= 0 {
+		return
+	}
+	s.total += n
+	if i, ok := s.index[key]; ok {
+		s.items.items[i].Count += n
+		heap.Fix(&s.items, i)
+		return
+	}
+	if len(s.items.items) < s.capacity {
+		heap.Push(&s.items, Item{Key: key, Count: n})
+		return
+	}
+	least := s.items.items[0]
+	delete(s.index, least.Key)
+	s.items.items[0] = Item{Key: key, Count: least.Count + n, Error: least.Count}
+	s.index[key] = 0
+	heap.Fix(&s.items, 0)
+}
+
+// Total returns the sum of everything added, tracked or not.
+func (s *Sketch) Total() int {
+	return s.total
+}
+
+// Top returns the n most frequent keys, most frequent first and ties by
+// key, or all tracked keys if n is not positive.
+func (s *Sketch) Top(n int) []Item {
+	top := append([]Item(nil), s.items.items...)
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Key < top[j].Key
+	})
+	if n > 0 && len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// Counts returns the tracked keys and their counts.
+func (s *Sketch) Counts() map[string]int {
+	counts := make(map[string]int, len(s.items.items))
+	for _, it := range s.items.items {
+		counts[it.Key] = it.Count
+	}
+	return counts
+}
+
+// Count returns key's count, or 0 if it isn't tracked.
+func (s *Sketch) Count(key string) int {
+	if i, ok := s.index[key]; ok {
+		return s.items.items[i].Count
+	}
+	return 0
+}
+
+// minHeap orders items by count, keeping index in step.
+type minHeap struct {
+	items []Item
+	index map[string]int
+}
+
+func (h minHeap) Len() int           { return len(h.items) }
+func (h minHeap) Less(i, j int) bool { return h.items[i].Count < h.items[j].Count }
+
+func (h minHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.index[h.items[i].Key] = i
+	h.index[h.items[j].Key] = j
+}
+
+func (h *minHeap) Push(x interface{}) {
+	it := x.(Item)
+	h.index[it.Key] = len(h.items)
+	h.items = append(h.items, it)
+}
+
+func (h *minHeap) Pop() interface{} {
+	it := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	delete(h.index, it.Key)
+	return it
+}
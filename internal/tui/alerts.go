@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// flashDuration is how long a critical anomaly's banner flashes.
+const flashDuration = 30 * time.Second
+
+type alertMsg struct{ anomaly types.Anomaly }
+type flashTickMsg struct{ seq int }
+
+// alertFlash is the banner of the latest critical anomaly.
+type alertFlash struct {
+	anomaly types.Anomaly
+	until   time.Time
+	on      bool // Alternates every half second
+	seq     int
+}
+
+// SetAlerts makes the dashboard show anomalies the moment the engine
+// records them, instead of at the next metrics refresh, and flash a banner
+// for critical ones such as fatal log lines. Call it before the program
+// starts.
+func (m *Model) SetAlerts(alerts <-chan types.Anomaly) {
+	m.alertsCh = alerts
+}
+
+func (m Model) waitForAlerts() tea.Msg {
+	if m.alertsCh == nil {
+		return nil
+	}
+	a, ok := <-m.alertsCh
+	if !ok {
+		return nil
+	}
+	return alertMsg{a}
+}
+
+// showAlert adds a to the recent anomalies and flashes it when critical.
+func (m *Model) showAlert(a types.Anomaly) tea.Cmd {
+	m.metrics.Anomalies = append(m.metrics.Anomalies, a)
+	if a.Severity != types.SeverityCritical {
+		return nil
+	}
+	m.flash.seq++
+	m.flash.anomaly = a
+	m.flash.until = time.Now().Add(flashDuration)
+	m.flash.on = true
+	return m.flash.tick()
+}
+
+func (f alertFlash) tick() tea.Cmd {
+	seq := f.seq
+	return tea.Tick(500*time.Millisecond, func(time.Time) tea.Msg { return flashTickMsg{seq} })
+}
+
+// updateFlash alternates the banner until it expires.
+func (m *Model) updateFlash(msg flashTickMsg) tea.Cmd {
+	if msg.seq != m.flash.seq || m.flash.until.IsZero() {
+		return nil
+	}
+	if time.Now().After(m.flash.until) {
+		m.flash.until = time.Time{}
+		return nil
+	}
+	m.flash.on = !m.flash.on
+	return m.flash.tick()
+}
+
+// renderFlash shows the flashing banner of a recent critical anomaly.
+func (m Model) renderFlash() string {
+	if m.flash.until.IsZero() {
+		return ""
+	}
+	style := lipgloss.NewStyle().Bold(true).Width(m.width).Foreground(lipgloss.Color("#FAFAFA")).Background(lipgloss.Color("#B00020"))
+	if !m.flash.on {
+		style = style.Foreground(lipgloss.Color("#B00020")).Background(lipgloss.Color("#FAFAFA"))
+	}
+	a := m.flash.anomaly
+	text := " " + a.Timestamp.Format("15:04:05") + " " + a.Type + ": " + a.Message
+	return style.Render(truncate(text, max(m.width, 20))) + "\n"
+}
@@ -0,0 +1,167 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/locale"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// Annotator stores operator annotations, e.g. the engine.
+type Annotator interface {
+	Annotate(types.Annotation) error
+}
+
+type annotationSavedMsg struct {
+	annotation types.Annotation
+	err        error
+}
+
+// annotationPrompt asks for the label and note of a tagged log line or
+// time range.
+type annotationPrompt struct {
+	annotator  Annotator
+	open       bool
+	draft      types.Annotation
+	noteStep   bool      // The label is entered; asking for the note
+	rangeStart time.Time // Start of the time range being marked, zero if none
+	input      textinput.Model
+}
+
+func newAnnotationPrompt() annotationPrompt {
+	ti := textinput.New()
+	ti.CharLimit = 512
+	ti.Width = 60
+	return annotationPrompt{input: ti}
+}
+
+// SetAnnotator lets ctrl+t tag the selected log line and ctrl+r a time
+// range with a label and note, stored through a. Call it before the
+// program starts.
+func (m *Model) SetAnnotator(a Annotator) {
+	m.annotate.annotator = a
+}
+
+// tagLine opens the prompt for the log line at the bottom of the log pane.
+func (m *Model) tagLine() tea.Cmd {
+	if m.annotate.annotator == nil {
+		return nil
+	}
+	last := min(m.logScrollPane.YOffset+m.logScrollPane.Height, len(m.filteredLogs)) - 1
+	if last < 0 {
+		return nil
+	}
+	now := time.Now()
+	return m.annotate.start(types.Annotation{Start: now, End: now, Line: m.filteredLogs[last]})
+}
+
+// tagRange starts marking a time range, or ends it and opens the prompt.
+func (m *Model) tagRange() tea.Cmd {
+	if m.annotate.annotator == nil {
+		return nil
+	}
+	if m.annotate.rangeStart.IsZero() {
+		m.annotate.rangeStart = time.Now()
+		m.logs = append(m.logs, fmt.Sprintf("Time range started at %s (ctrl+r ends it)", locale.Time(m.annotate.rangeStart)))
+		m.applyFilter()
+		return nil
+	}
+	a := types.Annotation{Start: m.annotate.rangeStart, End: time.Now()}
+	m.annotate.rangeStart = time.Time{}
+	return m.annotate.start(a)
+}
+
+func (p *annotationPrompt) start(a types.Annotation) tea.Cmd {
+	p.open = true
+	p.draft = a
+	p.noteStep = false
+	p.input.Prompt = "Label: "
+	p.input.Placeholder = "e.g. root-cause"
+	p.input.SetValue("")
+	return p.input.Focus()
+}
+
+// update handles a key while the prompt is open. It returns the command
+// storing the annotation once the note is entered.
+func (p *annotationPrompt) update(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		p.open = false
+		p.input.Blur()
+		return nil
+	case "enter":
+		value := strings.TrimSpace(p.input.Value())
+		if !p.noteStep {
+			if value == "" {
+				return nil
+			}
+			p.draft.Label = value
+			p.noteStep = true
+			p.input.Prompt = "Note: "
+			p.input.Placeholder = "optional"
+			p.input.SetValue("")
+			return nil
+		}
+		p.draft.Note = value
+		p.open = false
+		p.input.Blur()
+		return p.save(p.draft)
+	}
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	return cmd
+}
+
+func (p annotationPrompt) save(a types.Annotation) tea.Cmd {
+	annotator := p.annotator
+	return func() tea.Msg {
+		a.Created = time.Now()
+		return annotationSavedMsg{annotation: a, err: annotator.Annotate(a)}
+	}
+}
+
+// showAnnotationSaved confirms a stored annotation in the log pane.
+func (m *Model) showAnnotationSaved(msg annotationSavedMsg) {
+	if msg.err != nil {
+		m.logs = append(m.logs, fmt.Sprintf("Annotation not saved: %v", msg.err))
+	} else {
+		m.logs = append(m.logs, fmt.Sprintf("Annotated %s: %s", annotationTime(msg.annotation), msg.annotation.Label))
+	}
+	m.applyFilter()
+}
+
+func annotationTime(a types.Annotation) string {
+	if a.Line != "" {
+		return "line at " + locale.Time(a.Start)
+	}
+	return locale.Time(a.Start) + " - " + locale.Time(a.End)
+}
+
+func (p annotationPrompt) view() string {
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render("Annotate " + annotationTime(p.draft)))
+	b.WriteString("\n\n")
+	if p.draft.Line != "" {
+		b.WriteString(p.draft.Line + "\n\n")
+	}
+	if p.noteStep {
+		b.WriteString("Label: " + p.draft.Label + "\n")
+	}
+	b.WriteString(p.input.View())
+	b.WriteString("\n\nenter: next/save | esc: cancel\n")
+	return lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(1).Render(b.String())
+}
+
+// renderAnnotation notes in the header that a time range is being marked.
+func (m Model) renderAnnotation() string {
+	if m.annotate.rangeStart.IsZero() {
+		return ""
+	}
+	text := fmt.Sprintf("MARKING since %s (ctrl+r to annotate)", locale.Time(m.annotate.rangeStart))
+	return "  " + lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#00BFFF")).Render(text)
+}
@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/locale"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// SetBaseline shows every live metric card with its change from a saved
+// session. Call it before the program starts.
+func (m *Model) SetBaseline(b *types.SessionBaseline) {
+	m.baseline = b
+}
+
+// renderBaselineHeader describes the session the cards are compared with.
+func (m Model) renderBaselineHeader() string {
+	b := m.baseline
+	return fmt.Sprintf("Compared with baseline %s: %s minutes, %s %s - %s %s (RPS %s, errors %s)\n",
+		b.Source, locale.Int(int64(b.Minutes)), locale.Date(b.From), locale.ShortTime(b.From), locale.Date(b.To), locale.ShortTime(b.To),
+		locale.Float(b.RPS, 2), locale.Percent(b.ErrorRate, 2))
+}
+
+// renderCardMetrics renders a live card's rates and percentiles, each
+// followed by its change from the baseline when one is set.
+func (m Model) renderCardMetrics(wm types.WindowedMetrics) string {
+	if m.baseline == nil {
+		return fmt.Sprintf("RPS: %s\nErrors: %s\nRequests: %s\n\n%s",
+			locale.Float(wm.RPS, 2),
+			locale.Percent(wm.ErrorRate, 2),
+			locale.Int(int64(wm.TotalRequests)),
+			types.FormatPercentiles(wm.Percentiles, "\n"))
+	}
+	b := m.baseline
+	lines := []string{
+		"RPS: " + locale.Float(wm.RPS, 2) + " " + relativeDelta(wm.RPS, b.RPS, true),
+		"Errors: " + locale.Percent(wm.ErrorRate, 2) + " " + pointDelta(wm.ErrorRate, b.ErrorRate),
+		"Requests: " + locale.Int(int64(wm.TotalRequests)),
+		"",
+	}
+	for _, p := range wm.Percentiles {
+		line := fmt.Sprintf("%s: %s", p.Label(), locale.Duration(p.Latency.Truncate(time.Millisecond)))
+		for _, bp := range b.Percentiles {
+			if bp.Percentile == p.Percentile {
+				line += " " + relativeDelta(float64(p.Latency), float64(bp.Latency), false)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// relativeDelta renders the change of value from baseline in percent with
+// an arrow, green when it is an improvement: a rise if higherIsBetter,
+// otherwise a fall.
+func relativeDelta(value, baseline float64, higherIsBetter bool) string {
+	if baseline == 0 {
+		return ""
+	}
+	change := (value/baseline - 1) * 100
+	return delta(change, fmt.Sprintf("%+.0f%%", change), higherIsBetter)
+}
+
+// pointDelta renders the change of an error rate from baseline in
+// percentage points; a fall is an improvement.
+func pointDelta(rate, baseline float64) string {
+	change := rate - baseline
+	return delta(change, fmt.Sprintf("%+.2fpp", change), false)
+}
+
+// delta renders a change with an arrow, or "=" when it rounds to nothing.
+func delta(change float64, text string, higherIsBetter bool) string {
+	if strings.Trim(text, "+-0.%p") == "" {
+		return "= " + strings.TrimLeft(text, "+-")
+	}
+	arrow := "▲"
+	if change < 0 {
+		arrow = "▼"
+	}
+	color := "#FF0000"
+	if (change > 0) == higherIsBetter {
+		color = "#00FF00"
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Render(arrow + " " + text)
+}
@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/locale"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+const breakdownBarWidth = 40
+
+// componentColors tell the components of a stacked bar apart, in order of
+// their overall share.
+var componentColors = []string{"#FF8C00", "#00BFFF", "#7CFC00", "#FF69B4", "#FFD700", "#9370DB", "#40E0D0", "#CD5C5C"}
+
+// renderBreakdown shows where request time goes by component, from timing
+// fields such as db_ms and render_ms: each component's average, P95, and
+// share for the widest window, then a stacked bar of the time per request
+// of each of the top endpoints, so the slow component stands out.
+func (m Model) renderBreakdown() string {
+	var widest string
+	var bd types.TimingBreakdown
+	for _, window := range []string{"1m", "5m", "1h", "all"} {
+		if wm, ok := m.metrics.Windows[window]; ok && len(wm.Breakdown.Components) > 0 {
+			widest, bd = window, wm.Breakdown
+		}
+	}
+	if widest == "" {
+		return "No component timings (fields such as db_ms or render_ms) seen yet.\n"
+	}
+
+	colors := make(map[string]lipgloss.Style, len(bd.Components))
+	for i, c := range bd.Components {
+		colors[c.Component] = lipgloss.NewStyle().Foreground(lipgloss.Color(componentColors[i%len(componentColors)]))
+	}
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("Request time by component (%s)", widest)))
+	b.WriteString("\n")
+	b.WriteString(renderComponents(bd.Components, colors))
+
+	if len(bd.Endpoints) > 0 {
+		longest := time.Duration(0)
+		for _, e := range bd.Endpoints {
+			longest = max(longest, perRequest(e.Components))
+		}
+		b.WriteString("\n")
+		b.WriteString(lipgloss.NewStyle().Bold(true).Render("Time per request by endpoint"))
+		b.WriteString("\n")
+		for _, e := range bd.Endpoints {
+			b.WriteString(fmt.Sprintf("%-30s %s %s\n", truncate(e.Endpoint, 30), stackedBar(e.Components, longest, colors), locale.Duration(perRequest(e.Components).Truncate(time.Millisecond))))
+			for _, c := range e.Components {
+				b.WriteString(fmt.Sprintf("  %s %-12s avg %-8s p95 %-8s %5.1f%%\n", colors[c.Component].Render("█"), truncate(c.Component, 12), locale.Duration(c.Avg.Truncate(time.Millisecond)), locale.Duration(c.P95.Truncate(time.Millisecond)), c.Share))
+			}
+		}
+	}
+
+	boxStyle := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	return boxStyle.Render(strings.TrimSuffix(b.String(), "\n")) + "\n"
+}
+
+// renderComponents lists each component's average, P95, and share.
+func renderComponents(components []types.ComponentTiming, colors map[string]lipgloss.Style) string {
+	var b strings.Builder
+	for _, c := range components {
+		b.WriteString(fmt.Sprintf("%s %-12s %s %5.1f%% | avg %s | p95 %s | %d requests\n",
+			colors[c.Component].Render("█"), truncate(c.Component, 12), drawBar(c.Share, 100, 20), c.Share,
+			locale.Duration(c.Avg.Truncate(time.Millisecond)), locale.Duration(c.P95.Truncate(time.Millisecond)), c.Samples))
+	}
+	return b.String()
+}
+
+// perRequest is the summed component time of an average request.
+func perRequest(components []types.ComponentTiming) time.Duration {
+	var total time.Duration
+	for _, c := range components {
+		total += c.PerRequest
+	}
+	return total
+}
+
+// stackedBar draws each component's time per request as a colored segment,
+// scaled so that longest fills the bar.
+func stackedBar(components []types.ComponentTiming, longest time.Duration, colors map[string]lipgloss.Style) string {
+	if longest <= 0 {
+		return strings.Repeat("░", breakdownBarWidth)
+	}
+	// Round where each segment ends rather than each length, so the bar of
+	// the longest endpoint is always full
+	var b strings.Builder
+	var elapsed time.Duration
+	used := 0
+	for _, c := range components {
+		elapsed += c.PerRequest
+		end := min(int(math.Round(float64(elapsed)/float64(longest)*breakdownBarWidth)), breakdownBarWidth)
+		if end > used {
+			b.WriteString(colors[c.Component].Render(strings.Repeat("█", end-used)))
+			used = end
+		}
+	}
+	return b.String() + strings.Repeat("░", breakdownBarWidth-used)
+}
@@ -0,0 +1,78 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+const maxCacheEndpoints = 5
+
+// renderCacheTrend charts the cache hit ratio over the trend history from
+// start, or returns "" when the logs carry no cache status.
+func (m Model) renderCacheTrend(start int) string {
+	history := m.metrics.TrendHistory
+	seen := false
+	for _, tp := range history[start:] {
+		if tp.CacheLookups > 0 {
+			seen = true
+			break
+		}
+	}
+	if !seen {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Cache Hit Ratio:\n")
+	for _, tp := range history[start:] {
+		if tp.CacheLookups == 0 {
+			b.WriteString(drawBar(0, 0, 20) + " -\n")
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%s %.1f%% of %d\n", drawBar(tp.CacheHitRatio, 100, 20), tp.CacheHitRatio, tp.CacheLookups))
+	}
+	if wm, ok := m.metrics.Windows["5m"]; ok && wm.Cache.Lookups > 0 {
+		b.WriteString("\n" + renderCacheSummary(wm))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// renderCacheSummary shows a window's hit ratio, status counts and the
+// endpoints with the most cache misses.
+func renderCacheSummary(wm types.WindowedMetrics) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Cache hit ratio: %.1f%% (%d/%d)\n", wm.Cache.HitRatio(), wm.Cache.Hits, wm.Cache.Lookups))
+
+	statuses := make([]string, 0, len(wm.CacheStatuses))
+	for status := range wm.CacheStatuses {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return wm.CacheStatuses[statuses[i]] > wm.CacheStatuses[statuses[j]] })
+	var parts []string
+	for _, status := range statuses {
+		parts = append(parts, fmt.Sprintf("%s %d", status, wm.CacheStatuses[status]))
+	}
+	b.WriteString(strings.Join(parts, " | ") + "\n")
+
+	endpoints := make([]string, 0, len(wm.EndpointCache))
+	for endpoint := range wm.EndpointCache {
+		endpoints = append(endpoints, endpoint)
+	}
+	misses := func(endpoint string) int {
+		c := wm.EndpointCache[endpoint]
+		return c.Lookups - c.Hits
+	}
+	sort.Slice(endpoints, func(i, j int) bool { return misses(endpoints[i]) > misses(endpoints[j]) })
+	if len(endpoints) > maxCacheEndpoints {
+		endpoints = endpoints[:maxCacheEndpoints]
+	}
+	for _, endpoint := range endpoints {
+		c := wm.EndpointCache[endpoint]
+		b.WriteString(fmt.Sprintf("  %s: %.1f%% hit (%d/%d)\n", endpoint, c.HitRatio(), c.Hits, c.Lookups))
+	}
+	return b.String()
+}
@@ -0,0 +1,49 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/locale"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// lowHeadroom is the headroom percentage below which it is highlighted.
+const lowHeadroom = 20
+
+// renderCapacity shows the estimated RPS at which P95 would breach its
+// objective and how much of it is still unused.
+func (m Model) renderCapacity() string {
+	c := m.metrics.Capacity
+	if c.GeneratedAt.IsZero() {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Capacity (P95 objective %s):\n", locale.Duration(c.P95SLO)))
+	if c.BreachRPS == 0 {
+		b.WriteString(fmt.Sprintf("No estimate: %s (%s minutes with traffic)\n", c.Note, locale.Int(int64(c.Samples))))
+	} else {
+		b.WriteString(fmt.Sprintf("P95 breaches the objective at about %s RPS; now %s RPS\n", locale.Float(c.BreachRPS, 1), locale.Float(c.CurrentRPS, 1)))
+		b.WriteString("Headroom: " + renderHeadroom(c) + "\n")
+		b.WriteString(fmt.Sprintf("Fitted on %s minutes, R² %s\n", locale.Int(int64(c.Samples)), locale.Float(c.R2, 2)))
+	}
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#00FF00")).
+		Padding(1).
+		Render(b.String()) + "\n"
+}
+
+// renderHeadroom formats the headroom percentage, orange when low and red
+// once the estimated breach point is passed.
+func renderHeadroom(c types.Capacity) string {
+	text := locale.Percent(c.Headroom, 1)
+	switch {
+	case c.Headroom < 0:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")).Render(text)
+	case c.Headroom < lowHeadroom:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("#FF8C00")).Render(text)
+	}
+	return text
+}
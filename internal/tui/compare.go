@@ -0,0 +1,185 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/locale"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+const maxCompareEndpoints = 15
+
+// EndpointTrends loads per-endpoint time series.
+type EndpointTrends interface {
+	EndpointTrend(endpoint string) ([]types.SeriesPoint, error)
+}
+
+type endpointTrendMsg struct {
+	endpoint string
+	points   []types.SeriesPoint
+	err      error
+}
+
+// endpointCompare renders the trends of two endpoints side by side, e.g. to
+// validate a traffic migration from an old route to a new one.
+type endpointCompare struct {
+	source EndpointTrends
+	cursor int
+	a, b   string
+	trends map[string][]types.SeriesPoint
+	err    error
+}
+
+func newEndpointCompare(source EndpointTrends) endpointCompare {
+	return endpointCompare{source: source, trends: make(map[string][]types.SeriesPoint)}
+}
+
+// endpoints lists the busiest endpoints of the 5m window.
+func (c endpointCompare) endpoints(metrics types.Metrics) []string {
+	counts := metrics.Windows["5m"].TopEndpoints
+	endpoints := make([]string, 0, len(counts))
+	for endpoint := range counts {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if counts[endpoints[i]] != counts[endpoints[j]] {
+			return counts[endpoints[i]] > counts[endpoints[j]]
+		}
+		return endpoints[i] < endpoints[j]
+	})
+	if len(endpoints) > maxCompareEndpoints {
+		endpoints = endpoints[:maxCompareEndpoints]
+	}
+	return endpoints
+}
+
+// load fetches the selected endpoints' trends off the UI goroutine.
+func (c endpointCompare) load() tea.Cmd {
+	if c.source == nil {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for _, endpoint := range []string{c.a, c.b} {
+		if endpoint == "" {
+			continue
+		}
+		source, endpoint := c.source, endpoint
+		cmds = append(cmds, func() tea.Msg {
+			points, err := source.EndpointTrend(endpoint)
+			return endpointTrendMsg{endpoint: endpoint, points: points, err: err}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// update handles a key in the Compare tab.
+func (c *endpointCompare) update(key string, metrics types.Metrics) tea.Cmd {
+	endpoints := c.endpoints(metrics)
+	if c.cursor >= len(endpoints) {
+		c.cursor = max(len(endpoints)-1, 0)
+	}
+	switch key {
+	case "up":
+		if c.cursor > 0 {
+			c.cursor--
+		}
+	case "down":
+		if c.cursor < len(endpoints)-1 {
+			c.cursor++
+		}
+	case "left":
+		if len(endpoints) > 0 {
+			c.a = endpoints[c.cursor]
+			return c.load()
+		}
+	case "right":
+		if len(endpoints) > 0 {
+			c.b = endpoints[c.cursor]
+			return c.load()
+		}
+	}
+	return nil
+}
+
+func (c endpointCompare) view(metrics types.Metrics) string {
+	if c.source == nil {
+		return "Endpoint comparison is not available.\n"
+	}
+
+	endpoints := c.endpoints(metrics)
+	counts := metrics.Windows["5m"].TopEndpoints
+	var list strings.Builder
+	list.WriteString("Endpoints (5m) - up/down move | left: set A | right: set B\n")
+	if len(endpoints) == 0 {
+		list.WriteString("No endpoints seen yet.\n")
+	}
+	for i, endpoint := range endpoints {
+		cursor := "  "
+		if i == c.cursor {
+			cursor = "> "
+		}
+		mark := "  "
+		switch endpoint {
+		case c.a:
+			mark = "A "
+		case c.b:
+			mark = "B "
+		}
+		list.WriteString(fmt.Sprintf("%s%s%-40s %d\n", cursor, mark, truncate(endpoint, 40), counts[endpoint]))
+	}
+
+	boxStyle := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	out := boxStyle.Render(list.String()) + "\n"
+	if c.err != nil {
+		return out + fmt.Sprintf("Error loading trends: %v\n", c.err)
+	}
+	if c.a == "" || c.b == "" {
+		return out + "Select endpoints A and B to compare their trends.\n"
+	}
+
+	// Both columns share scales so the bars compare directly
+	a, b := c.trends[c.a], c.trends[c.b]
+	var maxRPS, maxErr float64
+	var maxLat time.Duration
+	for _, p := range append(append([]types.SeriesPoint{}, a...), b...) {
+		maxRPS = max(maxRPS, p.RPS)
+		maxErr = max(maxErr, p.ErrorRate)
+		maxLat = max(maxLat, p.P95Latency)
+	}
+	columnStyle := boxStyle.Width(48)
+	return out + lipgloss.JoinHorizontal(lipgloss.Top,
+		columnStyle.Render(renderSeries("A: "+c.a, a, maxRPS, maxErr, maxLat)),
+		columnStyle.BorderForeground(lipgloss.Color("#7D56F4")).Render(renderSeries("B: "+c.b, b, maxRPS, maxErr, maxLat)),
+	) + "\n"
+}
+
+// renderSeries draws an endpoint's RPS, error rate, and P95 per minute as
+// bars against the given maxima.
+func renderSeries(title string, points []types.SeriesPoint, maxRPS, maxErr float64, maxLat time.Duration) string {
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render(truncate(title, 44)))
+	b.WriteString("\n")
+	if len(points) == 0 {
+		b.WriteString("Loading...\n")
+		return b.String()
+	}
+
+	b.WriteString("\nRPS:\n")
+	for _, p := range points {
+		b.WriteString(fmt.Sprintf("%s %s %s\n", locale.ShortTime(p.Start), drawBar(p.RPS, maxRPS, 20), locale.Float(p.RPS, 2)))
+	}
+	b.WriteString("\nError rate:\n")
+	for _, p := range points {
+		b.WriteString(fmt.Sprintf("%s %s %s\n", locale.ShortTime(p.Start), drawBar(p.ErrorRate, maxErr, 20), locale.Percent(p.ErrorRate, 1)))
+	}
+	b.WriteString("\nP95 latency:\n")
+	for _, p := range points {
+		b.WriteString(fmt.Sprintf("%s %s %s\n", locale.ShortTime(p.Start), drawBar(float64(p.P95Latency), float64(maxLat), 20), locale.Duration(p.P95Latency.Truncate(time.Millisecond))))
+	}
+	return b.String()
+}
@@ -0,0 +1,17 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// renderErrorCategories lists a window's errors per configured category.
+func renderErrorCategories(categories []types.ErrorCategoryStats) string {
+	var b strings.Builder
+	for _, c := range categories {
+		b.WriteString(fmt.Sprintf("%-24s %6d errors %6.2f%% of requests\n", truncate(c.Name, 24), c.Errors, c.Rate))
+	}
+	return b.String()
+}
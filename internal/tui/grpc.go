@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const maxGRPCMethods = 15
+
+// renderGRPC shows gRPC calls by status code for each window, then each
+// method's calls, error rate, and failing codes for the widest window,
+// since gRPC failures hide behind HTTP 200s in the status code breakdown.
+func (m Model) renderGRPC() string {
+	var b strings.Builder
+	widest := ""
+	for _, window := range []string{"1m", "5m", "1h", "all"} {
+		wm, ok := m.metrics.Windows[window]
+		if !ok || len(wm.GRPCStatuses) == 0 {
+			continue
+		}
+		widest = window
+		b.WriteString(lipgloss.NewStyle().Bold(true).Render(window))
+		b.WriteString("\n")
+		b.WriteString(renderGRPCStatuses(wm.GRPCStatuses))
+		b.WriteString("\n")
+	}
+	if widest == "" {
+		return "No gRPC calls seen yet.\n"
+	}
+
+	methods := m.metrics.Windows[widest].GRPCMethods
+	names := make([]string, 0, len(methods))
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if methods[names[i]].Calls != methods[names[j]].Calls {
+			return methods[names[i]].Calls > methods[names[j]].Calls
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > maxGRPCMethods {
+		names = names[:maxGRPCMethods]
+	}
+
+	var ms strings.Builder
+	ms.WriteString(fmt.Sprintf("Methods (%s):\n", widest))
+	for _, name := range names {
+		stats := methods[name]
+		line := fmt.Sprintf("%-40s %7d calls %6.2f%% errors", truncate(name, 40), stats.Calls, stats.ErrorRate())
+		if failing := failingCodes(stats.Statuses); failing != "" {
+			line += " | " + failing
+		}
+		ms.WriteString(line + "\n")
+	}
+
+	boxStyle := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	return boxStyle.Render(strings.TrimSuffix(b.String(), "\n")) + "\n" + boxStyle.Render(ms.String()) + "\n"
+}
+
+// renderGRPCStatuses draws each status code's share of the calls, most
+// frequent first.
+func renderGRPCStatuses(statuses map[string]int) string {
+	total := 0
+	for _, n := range statuses {
+		total += n
+	}
+	var b strings.Builder
+	for _, code := range sortedCodes(statuses) {
+		share := float64(statuses[code]) / float64(total) * 100
+		b.WriteString(fmt.Sprintf("  %-18s %s %5.1f%% (%d)\n", code, drawBar(share, 100, 20), share, statuses[code]))
+	}
+	return b.String()
+}
+
+// failingCodes lists the codes other than OK, most frequent first, e.g.
+// "Unavailable 12, DeadlineExceeded 3".
+func failingCodes(statuses map[string]int) string {
+	var parts []string
+	for _, code := range sortedCodes(statuses) {
+		if code != "OK" {
+			parts = append(parts, fmt.Sprintf("%s %d", code, statuses[code]))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sortedCodes(statuses map[string]int) []string {
+	codes := make([]string, 0, len(statuses))
+	for code := range statuses {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if statuses[codes[i]] != statuses[codes[j]] {
+			return statuses[codes[i]] > statuses[codes[j]]
+		}
+		return codes[i] < codes[j]
+	})
+	return codes
+}
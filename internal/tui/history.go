@@ -0,0 +1,212 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/locale"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+const maxHistoryRows = 200
+
+// AnomalyHistory queries persisted anomalies.
+type AnomalyHistory interface {
+	AnomalyHistory(filter types.AnomalyFilter) ([]types.Anomaly, error)
+	AnomalyEvidence(a types.Anomaly) ([]types.LogEntry, error)
+}
+
+var historyRanges = []struct {
+	label string
+	span  time.Duration // 0 means all time
+}{
+	{"1h", time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"all", 0},
+}
+
+var historySeverities = []string{"", types.SeverityCritical, types.SeverityWarning, types.SeverityInfo}
+
+type anomalyHistoryMsg struct {
+	seq       int
+	anomalies []types.Anomaly
+	err       error
+}
+
+type anomalyEvidenceMsg struct {
+	anomaly  types.Anomaly
+	evidence []types.LogEntry
+}
+
+// anomalyBrowser lists persisted anomalies with filters by type, severity
+// and time range, and shows the selected one in detail.
+type anomalyBrowser struct {
+	source    AnomalyHistory
+	anomalies []types.Anomaly
+	evidence  []types.LogEntry // Of the selected anomaly
+	selected  int
+	rangeIdx  int
+	severity  int
+	typeText  string
+	err       error
+	seq       int // Incremented per query so stale results are dropped
+}
+
+func newAnomalyBrowser(source AnomalyHistory) anomalyBrowser {
+	return anomalyBrowser{source: source, rangeIdx: 1}
+}
+
+func (b anomalyBrowser) filter() types.AnomalyFilter {
+	f := types.AnomalyFilter{
+		Type:     b.typeText,
+		Severity: historySeverities[b.severity],
+		Limit:    maxHistoryRows,
+	}
+	if span := historyRanges[b.rangeIdx].span; span > 0 {
+		f.Since = time.Now().Add(-span)
+	}
+	return f
+}
+
+// load queries the history off the UI goroutine.
+func (b *anomalyBrowser) load() tea.Cmd {
+	if b.source == nil {
+		return nil
+	}
+	b.seq++
+	source, f, seq := b.source, b.filter(), b.seq
+	return func() tea.Msg {
+		anomalies, err := source.AnomalyHistory(f)
+		return anomalyHistoryMsg{seq: seq, anomalies: anomalies, err: err}
+	}
+}
+
+func (b anomalyBrowser) loadEvidence() tea.Cmd {
+	if b.source == nil || len(b.anomalies) == 0 {
+		return nil
+	}
+	source, a := b.source, b.anomalies[b.selected]
+	return func() tea.Msg {
+		evidence, _ := source.AnomalyEvidence(a)
+		return anomalyEvidenceMsg{anomaly: a, evidence: evidence}
+	}
+}
+
+// setAnomalies replaces the list, keeping the same anomaly selected if it is
+// still listed. It returns whether the selection changed.
+func (b *anomalyBrowser) setAnomalies(anomalies []types.Anomaly) bool {
+	var previous types.Anomaly
+	if b.selected < len(b.anomalies) {
+		previous = b.anomalies[b.selected]
+	}
+	b.anomalies = anomalies
+	b.selected = 0
+	for i, a := range anomalies {
+		if a.Type == previous.Type && a.Timestamp.Equal(previous.Timestamp) {
+			b.selected = i
+			return false
+		}
+	}
+	b.evidence = nil
+	return true
+}
+
+// update handles a browser key and returns the command it triggers, if any.
+func (b *anomalyBrowser) update(key string) tea.Cmd {
+	switch key {
+	case "up":
+		if b.selected > 0 {
+			b.selected--
+			b.evidence = nil
+			return b.loadEvidence()
+		}
+	case "down":
+		if b.selected < len(b.anomalies)-1 {
+			b.selected++
+			b.evidence = nil
+			return b.loadEvidence()
+		}
+	case "left":
+		b.rangeIdx = (b.rangeIdx + len(historyRanges) - 1) % len(historyRanges)
+		return b.load()
+	case "right":
+		b.rangeIdx = (b.rangeIdx + 1) % len(historyRanges)
+		return b.load()
+	case "shift+left":
+		b.severity = (b.severity + len(historySeverities) - 1) % len(historySeverities)
+		return b.load()
+	case "shift+right":
+		b.severity = (b.severity + 1) % len(historySeverities)
+		return b.load()
+	}
+	return nil
+}
+
+func (b anomalyBrowser) view() string {
+	if b.source == nil {
+		return "Anomaly history is not available.\n"
+	}
+
+	severity := historySeverities[b.severity]
+	if severity == "" {
+		severity = "all"
+	}
+	typeText := b.typeText
+	if typeText == "" {
+		typeText = "any"
+	}
+	header := fmt.Sprintf("Range: %s | Severity: %s | Type: %s\n", historyRanges[b.rangeIdx].label, severity, typeText)
+	help := "up/down select | left/right range | shift+left/right severity | filter input matches type\n"
+
+	if b.err != nil {
+		return header + fmt.Sprintf("Error loading anomalies: %v\n", b.err)
+	}
+	if len(b.anomalies) == 0 {
+		return header + help + "\nNo anomalies match.\n"
+	}
+
+	var list strings.Builder
+	list.WriteString(header)
+	list.WriteString(fmt.Sprintf("Anomalies (%d):\n", len(b.anomalies)))
+	first := max(b.selected-maxAnomalyListRows+1, 0)
+	for i := first; i < len(b.anomalies) && i < first+maxAnomalyListRows; i++ {
+		a := b.anomalies[i]
+		cursor := "  "
+		if i == b.selected {
+			cursor = "> "
+		}
+		list.WriteString(fmt.Sprintf("%s[%s] %-8s %s\n", cursor, locale.ShortDate(a.Timestamp)+" "+locale.Time(a.Timestamp), a.Severity, a.Type))
+	}
+	list.WriteString(help)
+
+	a := b.anomalies[b.selected]
+	var detail strings.Builder
+	detail.WriteString(fmt.Sprintf("%s (%s) at %s\n\n%s\n", a.Type, a.Severity, locale.DateTime(a.Timestamp), a.Message))
+	snap := a.Snapshot
+	detail.WriteString(fmt.Sprintf("\nAt firing (1m): %s rps | %s requests | %s errors | p50 %s p95 %s p99 %s\n",
+		locale.Float(snap.RPS, 2), locale.Int(int64(snap.Requests)), locale.Percent(snap.ErrorRate, 2),
+		locale.Duration(snap.P50Latency), locale.Duration(snap.P95Latency), locale.Duration(snap.P99Latency)))
+	if len(a.Contributors) > 0 {
+		detail.WriteString("\nContributors:\n")
+		for _, c := range a.Contributors {
+			detail.WriteString(fmt.Sprintf("  %s=%s: %.0f%% of window (baseline %.0f%%)\n", c.Dimension, c.Value, c.Share, c.BaselineShare))
+		}
+	}
+	if len(b.evidence) > 0 {
+		detail.WriteString("\nEvidence:\n")
+		for _, entry := range b.evidence {
+			if entry.Endpoint == "" {
+				detail.WriteString(fmt.Sprintf("  %s %s\n", locale.Time(entry.Timestamp), entry.Message))
+				continue
+			}
+			detail.WriteString(fmt.Sprintf("  %s %d %s %s\n", locale.Time(entry.Timestamp), entry.StatusCode, entry.Endpoint, locale.Duration(entry.Latency.Truncate(time.Millisecond))))
+		}
+	}
+
+	boxStyle := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	return boxStyle.Render(list.String()) + "\n" + boxStyle.BorderForeground(lipgloss.Color("#FF0000")).Render(detail.String()) + "\n"
+}
@@ -0,0 +1,33 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// behindLag is the read lag at which an input is shown as falling behind.
+const behindLag = 1 << 20
+
+// renderInputHealth lists each input's throughput, read lag, and
+// reconnects, so an input the dashboard can't keep up with stands out.
+func renderInputHealth(inputs []types.InputHealth) string {
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render("Inputs"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("  %-24s %10s %12s %10s %10s\n", "Input", "Lines/s", "Bytes/s", "Lag", "Reconnects"))
+	for _, h := range inputs {
+		lag := "-"
+		if h.LagBytes >= 0 {
+			lag = formatBytes(h.LagBytes)
+		}
+		line := fmt.Sprintf("  %-24s %10.1f %12s %10s %10d", truncate(h.Name, 24), h.LinesPerSec, formatBytes(int64(h.BytesPerSec))+"/s", lag, h.Reconnects)
+		if h.LagBytes >= behindLag {
+			line = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF8C00")).Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
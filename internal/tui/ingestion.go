@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/locale"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// IngestionControl pauses and resumes ingestion, holding the records that
+// arrive meanwhile.
+type IngestionControl interface {
+	Pause() bool
+	Resume() int
+	Flush() int
+	State() types.IngestState
+}
+
+// SetIngestion lets ctrl+b pause and resume ingestion and ctrl+n flush it
+// through c. Call it before the program starts.
+func (m *Model) SetIngestion(c IngestionControl) {
+	m.ingestion = c
+}
+
+// toggleIngestion pauses ingestion, or resumes it when paused.
+func (m *Model) toggleIngestion() {
+	if m.ingestion == nil {
+		return
+	}
+	if m.ingestion.Pause() {
+		m.logs = append(m.logs, "Ingestion paused: new lines are held (ctrl+b resumes, ctrl+n processes the held ones)")
+	} else {
+		held := m.ingestion.Resume()
+		m.logs = append(m.logs, fmt.Sprintf("Ingestion resumed: processing %s held lines", locale.Int(int64(held))))
+	}
+	m.applyFilter()
+}
+
+// flushIngestion processes the held records while staying paused.
+func (m *Model) flushIngestion() {
+	if m.ingestion == nil || !m.ingestion.State().Paused {
+		return
+	}
+	held := m.ingestion.Flush()
+	m.logs = append(m.logs, fmt.Sprintf("Processing %s held lines; ingestion stays paused", locale.Int(int64(held))))
+	m.applyFilter()
+}
+
+// renderIngestion notes in the header that ingestion is paused.
+func (m Model) renderIngestion() string {
+	if m.ingestion == nil {
+		return ""
+	}
+	st := m.ingestion.State()
+	if !st.Paused {
+		return ""
+	}
+	text := fmt.Sprintf("PAUSED %s, %s lines held", locale.Duration(time.Since(st.Since).Truncate(time.Second)), locale.Int(int64(st.Held)))
+	if st.Full {
+		text += " (buffer full; inputs not read)"
+	}
+	return "  " + lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF8C00")).Render(text)
+}
@@ -0,0 +1,38 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// renderInputSources lists each input of a multi-source session with its
+// share of entries, so a quiet or failing input stands out.
+func renderInputSources(sources map[string]types.SourceStats) string {
+	var b strings.Builder
+	for _, source := range sortedSources(sources) {
+		s := sources[source]
+		b.WriteString(fmt.Sprintf("%-20s %s %5.1f%% | %6.2f/s | %5.2f%% errors | avg %s\n",
+			truncate(source, 20), drawBar(s.Share, 100, 10), s.Share, s.RPS, s.ErrorRate,
+			s.AvgLatency.Truncate(time.Millisecond)))
+	}
+	return b.String()
+}
+
+// sortedSources returns the names of the sources, busiest first.
+func sortedSources(sources map[string]types.SourceStats) []string {
+	names := make([]string, 0, len(sources))
+	for source := range sources {
+		names = append(names, source)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if sources[names[i]].Entries != sources[names[j]].Entries {
+			return sources[names[i]].Entries > sources[names[j]].Entries
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func (m Model) renderInternals() string {
+	st := m.metrics.Internals.Storage
+	if st.TableRows == nil {
+		return "Internals: collecting storage stats...\n"
+	}
+
+	var b strings.Builder
+	if len(m.metrics.Internals.Inputs) > 0 {
+		b.WriteString(renderInputHealth(m.metrics.Internals.Inputs))
+		b.WriteString("\n")
+	}
+	if len(m.metrics.Internals.Pipeline) > 0 {
+		b.WriteString(renderPipeline(m.metrics.Internals.Pipeline))
+		b.WriteString("\n")
+	}
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render("Storage"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("DB size:        %s (WAL %s)\n", formatBytes(st.SizeBytes), formatBytes(st.WALBytes)))
+	b.WriteString(fmt.Sprintf("Inserts:        %d (%.1f/s)\n", st.Inserts, st.InsertRate))
+	b.WriteString(fmt.Sprintf("Queries:        %d (avg %s)\n", st.Queries, st.AvgQueryLatency.Round(time.Microsecond)))
+	b.WriteString(fmt.Sprintf("Last prune:     %s (%d rows pruned)\n", formatSince(st.LastPrune), st.PrunedRows))
+	b.WriteString(fmt.Sprintf("Last vacuum:    %s\n", formatSince(st.LastVacuum)))
+
+	b.WriteString("\nRows per table:\n")
+	tables := make([]string, 0, len(st.TableRows))
+	for table := range st.TableRows {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		b.WriteString(fmt.Sprintf("  %-18s %d\n", table, st.TableRows[table]))
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#00FF00")).
+		Padding(1).
+		Render(b.String()) + "\n"
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatSince(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return time.Since(t).Round(time.Second).String() + " ago"
+}
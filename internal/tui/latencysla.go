@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// renderLatencySLA lists the share of requests within each latency
+// threshold after sep, marking missed targets; empty without objectives.
+func renderLatencySLA(slas []types.LatencySLA, sep string) string {
+	if len(slas) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, s := range slas {
+		line := types.FormatLatencySLA([]types.LatencySLA{s}, "")
+		if s.Missed() {
+			line = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF8C00")).Render(line)
+		}
+		b.WriteString(sep + line)
+	}
+	return b.String()
+}
+
+// renderEndpointLatencySLA lists the endpoints with their own objectives.
+func renderEndpointLatencySLA(eps map[string][]types.LatencySLA) string {
+	endpoints := make([]string, 0, len(eps))
+	for endpoint := range eps {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+
+	var b strings.Builder
+	for _, endpoint := range endpoints {
+		b.WriteString(fmt.Sprintf("%s: %s\n", endpoint, types.FormatLatencySLA(eps[endpoint], " | ")))
+	}
+	return b.String()
+}
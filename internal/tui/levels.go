@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nitis/pulseWatch/internal/locale"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// sparkTicks are the bar heights of a sparkline, lowest first.
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// renderLevels lists a window's entries per log level, most severe first,
+// e.g. "ERROR 0.5/s (2.1%)"; empty when no levels were logged.
+func renderLevels(levels map[types.LogLevel]types.LevelStats, sep string, withRPS bool) string {
+	var parts []string
+	for _, level := range types.LevelOrder {
+		ls, ok := levels[level]
+		if !ok {
+			continue
+		}
+		if withRPS {
+			parts = append(parts, fmt.Sprintf("%s %s/s (%s)", level, locale.Float(ls.RPS, 1), locale.Percent(ls.Share, 1)))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s %s (%s)", level, locale.Int(int64(ls.Count)), locale.Percent(ls.Share, 1)))
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// renderWindowLevels is renderLevels for a window box, one level per line
+// after a blank line.
+func renderWindowLevels(levels map[types.LogLevel]types.LevelStats) string {
+	if len(levels) == 0 {
+		return ""
+	}
+	return "\n\n" + renderLevels(levels, "\n", true)
+}
+
+// renderLevelTrend draws a sparkline of entries per second for each log
+// level seen in the trend history from start on.
+func (m Model) renderLevelTrend(start int) string {
+	history := m.metrics.TrendHistory[start:]
+	var b strings.Builder
+	for _, level := range types.LevelOrder {
+		values := make([]float64, len(history))
+		seen := false
+		for i, tp := range history {
+			if rps, ok := tp.LevelRPS[level]; ok {
+				values[i] = rps
+				seen = true
+			}
+		}
+		if !seen {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteString("Entries by Level (per second):\n")
+		}
+		b.WriteString(fmt.Sprintf("%-7s %s %s\n", level, sparkline(values), locale.Float(values[len(values)-1], 1)))
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// sparkline draws values scaled to their maximum, one rune each.
+func sparkline(values []float64) string {
+	maxValue := 0.0
+	for _, v := range values {
+		maxValue = max(maxValue, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		tick := 0
+		if maxValue > 0 {
+			tick = int(v / maxValue * float64(len(sparkTicks)-1))
+		}
+		b.WriteRune(sparkTicks[tick])
+	}
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+const maxMethodEndpoints = 10
+
+// renderMethods shows requests and error rate by HTTP method for each window,
+// then per endpoint for the widest window, since failing writes are easily
+// hidden by healthy read traffic in the overall error rate.
+func (m Model) renderMethods() string {
+	var b strings.Builder
+	widest := ""
+	for _, window := range []string{"1m", "5m", "1h", "all"} {
+		wm, ok := m.metrics.Windows[window]
+		if !ok || len(wm.Methods) == 0 {
+			continue
+		}
+		widest = window
+		b.WriteString(lipgloss.NewStyle().Bold(true).Render(window))
+		b.WriteString("\n")
+		b.WriteString(renderMethodTable(wm.Methods))
+		b.WriteString("\n")
+	}
+	if widest == "" {
+		return "No HTTP methods seen yet.\n"
+	}
+
+	wm := m.metrics.Windows[widest]
+	endpoints := make([]string, 0, len(wm.EndpointMethods))
+	for endpoint := range wm.EndpointMethods {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		return wm.TopEndpoints[endpoints[i]] > wm.TopEndpoints[endpoints[j]]
+	})
+	if len(endpoints) > maxMethodEndpoints {
+		endpoints = endpoints[:maxMethodEndpoints]
+	}
+
+	var eps strings.Builder
+	eps.WriteString(fmt.Sprintf("Error rate by method per endpoint (%s):\n", widest))
+	for _, endpoint := range endpoints {
+		methods := wm.EndpointMethods[endpoint]
+		var parts []string
+		for _, method := range sortedMethods(methods) {
+			ms := methods[method]
+			parts = append(parts, fmt.Sprintf("%s %.1f%% of %d", method, ms.ErrorRate(), ms.Requests))
+		}
+		eps.WriteString(fmt.Sprintf("%s: %s\n", endpoint, strings.Join(parts, " | ")))
+	}
+
+	boxStyle := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	return boxStyle.Render(strings.TrimSuffix(b.String(), "\n")) + "\n" + boxStyle.Render(eps.String()) + "\n"
+}
+
+// renderMethodTable lists each method's requests, errors and error rate,
+// busiest first.
+func renderMethodTable(methods map[string]types.MethodStats) string {
+	maxRate := 0.0
+	for _, ms := range methods {
+		if ms.ErrorRate() > maxRate {
+			maxRate = ms.ErrorRate()
+		}
+	}
+
+	var b strings.Builder
+	for _, method := range sortedMethods(methods) {
+		ms := methods[method]
+		b.WriteString(fmt.Sprintf("%-7s %s %6.2f%% errors (%d/%d)\n", method, drawBar(ms.ErrorRate(), maxRate, 15), ms.ErrorRate(), ms.Errors, ms.Requests))
+	}
+	return b.String()
+}
+
+// sortedMethods orders methods by request count, then name.
+func sortedMethods(methods map[string]types.MethodStats) []string {
+	names := make([]string, 0, len(methods))
+	for method := range methods {
+		names = append(names, method)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if methods[names[i]].Requests != methods[names[j]].Requests {
+			return methods[names[i]].Requests > methods[names[j]].Requests
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
@@ -0,0 +1,37 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// failureGapLabels name the types.FailureGapBuckets buckets.
+var failureGapLabels = []string{"<1s", "<10s", "<1m", "<10m", "<1h", ">1h"}
+
+// renderFailureIntervals lists endpoints' median time between failures with
+// a histogram of the gaps, marking endpoints whose failures are speeding up.
+func renderFailureIntervals(intervals []types.FailureInterval) string {
+	shrinkingStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF8C00")).Bold(true)
+
+	var b strings.Builder
+	b.WriteString("Time between failures:\n")
+	for _, fi := range intervals {
+		line := fmt.Sprintf("%s: every %s", fi.Endpoint, fi.MTBF.Round(time.Millisecond))
+		if fi.PreviousMTBF > 0 {
+			line += fmt.Sprintf(" (was %s)", fi.PreviousMTBF.Round(time.Millisecond))
+		}
+		if fi.Shrinking {
+			line = shrinkingStyle.Render("WORSENING ") + line
+		}
+		buckets := make([]string, len(fi.Histogram))
+		for i, n := range fi.Histogram {
+			buckets[i] = fmt.Sprintf("%s %d", failureGapLabels[i], n)
+		}
+		b.WriteString(line + "\n    " + strings.Join(buckets, " | ") + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// renderPipeline lists each pipeline consumer's buffer and counters.
+func renderPipeline(stats []types.PipelineStat) string {
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render("Pipeline"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("  %-10s %-10s %-12s %11s %12s %10s\n", "Topic", "Consumer", "Policy", "Queued", "Delivered", "Dropped"))
+	for _, s := range stats {
+		line := fmt.Sprintf("  %-10s %-10s %-12s %11s %12d %10d", s.Topic, s.Consumer, s.Policy, fmt.Sprintf("%d/%d", s.Queued, s.Buffer), s.Delivered, s.Dropped)
+		if s.Dropped > 0 {
+			line = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF8C00")).Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	if backlog := renderBacklog(stats); backlog != "" {
+		b.WriteString("\nQueue depth (per refresh, full height = buffer size):\n")
+		b.WriteString(backlog)
+	}
+	return b.String()
+}
+
+// maxBacklogWidth bounds the samples drawn per consumer.
+const maxBacklogWidth = 60
+
+// renderBacklog charts each consumer's recent queue depth against its
+// buffer size with its peak, so stutter can be matched with saturation.
+func renderBacklog(stats []types.PipelineStat) string {
+	width := 0
+	for _, s := range stats {
+		width = max(width, min(len(s.History), maxBacklogWidth))
+	}
+	var b strings.Builder
+	for _, s := range stats {
+		if len(s.History) < 2 || s.Buffer <= 0 {
+			continue
+		}
+		history := s.History
+		if len(history) > width {
+			history = history[len(history)-width:]
+		}
+		peak := 0
+		var chart strings.Builder
+		for _, depth := range history {
+			peak = max(peak, depth)
+			tick := min(depth*(len(sparkTicks)-1)/s.Buffer, len(sparkTicks)-1)
+			chart.WriteRune(sparkTicks[tick])
+		}
+		line := fmt.Sprintf("  %-21s %-*s peak %d/%d", s.Topic+"/"+s.Consumer, width, chart.String(), peak, s.Buffer)
+		if peak >= s.Buffer {
+			line = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF8C00")).Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// pipelineWarning notes how many raw lines and entries consumers have
+// dropped under load; empty when none were.
+func pipelineWarning(stats []types.PipelineStat) string {
+	var lines, entries int64
+	for _, s := range stats {
+		switch s.Topic {
+		case "raw_lines":
+			lines += s.Dropped
+		case "entries":
+			entries += s.Dropped
+		}
+	}
+	switch {
+	case entries > 0:
+		return fmt.Sprintf("%d entries dropped under load - metrics are incomplete", entries)
+	case lines > 0:
+		return fmt.Sprintf("%d log lines dropped from the log pane under load", lines)
+	}
+	return ""
+}
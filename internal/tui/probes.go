@@ -0,0 +1,35 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/locale"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// renderProbes lists the synthetic probes with their latest result and
+// recent success rate.
+func renderProbes(probes []types.ProbeStatus) string {
+	upStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#00FF00")).Bold(true)
+	downStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")).Bold(true)
+
+	var b strings.Builder
+	b.WriteString("Probes (5m):\n")
+	for _, p := range probes {
+		if p.Last.Timestamp.IsZero() {
+			b.WriteString(fmt.Sprintf("WAIT %s: no check yet\n", p.Name))
+			continue
+		}
+		state := upStyle.Render("UP  ")
+		detail := fmt.Sprintf("%d in %s", p.Last.StatusCode, locale.Duration(p.Last.Latency))
+		if !p.Last.OK {
+			state = downStyle.Render("DOWN")
+			detail = fmt.Sprintf("%s, %d in a row", p.Last.Error, p.Failing)
+		}
+		b.WriteString(fmt.Sprintf("%s %s: %s, %d/%d passed, checked %s\n",
+			state, p.Name, detail, p.Successes, p.Checks, locale.Time(p.Last.Timestamp)))
+	}
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// renderProtocols shows the HTTP and TLS version mix per window, e.g. to
+// follow an HTTP/3 or TLS 1.3 rollout at the edge.
+func (m Model) renderProtocols() string {
+	var b strings.Builder
+	for _, window := range []string{"1m", "5m", "1h", "all"} {
+		wm, ok := m.metrics.Windows[window]
+		if !ok || (len(wm.Protocols) == 0 && len(wm.TLSVersions) == 0) {
+			continue
+		}
+		b.WriteString(lipgloss.NewStyle().Bold(true).Render(window))
+		b.WriteString("\n")
+		if len(wm.Protocols) > 0 {
+			b.WriteString("HTTP versions:\n" + renderDistribution(wm.Protocols))
+		}
+		if len(wm.TLSVersions) > 0 {
+			b.WriteString("TLS versions:\n" + renderDistribution(wm.TLSVersions))
+		}
+		b.WriteString("\n")
+	}
+	if b.Len() == 0 {
+		return "No HTTP or TLS versions seen yet.\n"
+	}
+
+	boxStyle := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	return boxStyle.Render(strings.TrimSuffix(b.String(), "\n")) + "\n"
+}
+
+// renderDistribution draws each value's share of the total, largest first.
+func renderDistribution(counts map[string]int) string {
+	total := 0
+	keys := make([]string, 0, len(counts))
+	for k, n := range counts {
+		keys = append(keys, k)
+		total += n
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	var b strings.Builder
+	for _, k := range keys {
+		share := float64(counts[k]) / float64(total) * 100
+		b.WriteString(fmt.Sprintf("  %-9s %s %5.1f%% (%d)\n", k, drawBar(share, 100, 20), share, counts[k]))
+	}
+	return b.String()
+}
@@ -0,0 +1,25 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// renderRateLimits shows how many requests each simulated rate-limit policy
+// would have limited, and the keys it would have limited most.
+func renderRateLimits(limits []types.RateLimitStats) string {
+	var b strings.Builder
+	for i, l := range limits {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(fmt.Sprintf("%s (%g/s, burst %d per %s): %d of %d requests limited (%.2f%%), %d keys\n",
+			l.Name, l.Rate, l.Burst, l.Key, l.Limited, l.Requests, l.LimitedPct, l.LimitedKeys))
+		for _, k := range l.TopKeys {
+			b.WriteString(fmt.Sprintf("  %-30s %6d of %6d limited\n", truncate(k.Key, 30), k.Limited, k.Requests))
+		}
+	}
+	return b.String()
+}
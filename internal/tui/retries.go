@@ -0,0 +1,19 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// renderRetries shows how much retries amplify a window's traffic and the
+// most retried endpoints.
+func renderRetries(r types.RetryStats) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Retries: %d of %d requests | Amplification: %.2fx\n", r.Retries, r.Requests, r.Amplification))
+	for _, e := range r.TopEndpoints {
+		b.WriteString(fmt.Sprintf("%-30s %6d retries %6.2fx\n", truncate(e.Endpoint, 30), e.Retries, e.Amplification))
+	}
+	return b.String()
+}
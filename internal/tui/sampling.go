@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+	"github.com/nitis/pulseWatch/internal/locale"
+	"github.com/nitis/pulseWatch/internal/patterns"
+)
+
+// maxSamplePatterns bounds the patterns shown per sampling interval.
+const maxSamplePatterns = 50
+
+type sampleTickMsg struct{ seq int }
+
+// sampling replaces the raw log pane with one representative line per
+// pattern per interval, for inputs too busy to read line by line.
+type sampling struct {
+	on       bool
+	interval time.Duration
+	sampler  *patterns.Sampler
+	seq      int // Tells ticks of an earlier toggle apart
+}
+
+func newSampling(interval time.Duration) sampling {
+	return sampling{interval: interval, sampler: patterns.NewSampler(maxSamplePatterns)}
+}
+
+// SetSampling sets the sampling interval and whether the log pane starts
+// in sampling mode. Call it before the program starts.
+func (m *Model) SetSampling(interval time.Duration, on bool) {
+	m.sampling = newSampling(interval)
+	if on {
+		m.sampling.on = true
+		m.sampling.seq++
+		m.logs = append(m.logs, fmt.Sprintf("Sampling one line per pattern every %s...", interval))
+		m.applyFilter()
+	}
+}
+
+// toggleSampling switches between the raw lines and the sampled view.
+func (m *Model) toggleSampling() tea.Cmd {
+	m.sampling.on = !m.sampling.on
+	m.sampling.seq++
+	m.sampling.sampler.Flush()
+	m.logs = m.logs[:0]
+	if m.sampling.on {
+		m.logs = append(m.logs, fmt.Sprintf("Sampling one line per pattern every %s...", m.sampling.interval))
+	}
+	m.applyFilter()
+	return m.sampling.tick()
+}
+
+// tick schedules the next flush while sampling is on.
+func (s sampling) tick() tea.Cmd {
+	if !s.on {
+		return nil
+	}
+	seq := s.seq
+	return tea.Tick(s.interval, func(time.Time) tea.Msg { return sampleTickMsg{seq} })
+}
+
+// flushSamples appends the interval's samples to the log pane.
+func (m *Model) flushSamples(now time.Time) {
+	samples, lines, dropped := m.sampling.sampler.Flush()
+	if lines == 0 {
+		return
+	}
+	summary := fmt.Sprintf("-- %s: %s lines, %s patterns", locale.Time(now), locale.Int(int64(lines)), locale.Int(int64(len(samples))))
+	if dropped > 0 {
+		summary += fmt.Sprintf(", %s lines in patterns beyond the first %d", locale.Int(int64(dropped)), maxSamplePatterns)
+	}
+	m.logs = append(m.logs, summary+" --")
+	for _, s := range samples {
+		m.logs = append(m.logs, fmt.Sprintf("%8s x  %s", locale.Int(int64(s.Count)), s.Line))
+	}
+	if len(m.logs) > maxLogEntries {
+		m.logs = m.logs[len(m.logs)-maxLogEntries:]
+	}
+	m.applyFilter()
+}
@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// MetricsScoper recomputes the live windows over the entries containing a
+// text, or read from one source, in addition to the unscoped ones.
+type MetricsScoper interface {
+	SetMetricsScope(text string)
+	SetSourceScope(source string)
+}
+
+// toggleScope switches whether the log filter also scopes the metrics.
+func (m *Model) toggleScope() tea.Cmd {
+	m.scopeMetrics = !m.scopeMetrics
+	return m.updateScope()
+}
+
+// updateScope passes the filter to scope the metrics to, or "" for none,
+// to the engine. It runs as a command since the engine may be mid-tick.
+func (m *Model) updateScope() tea.Cmd {
+	if m.scoper == nil {
+		return nil
+	}
+	text := ""
+	if m.scopeMetrics {
+		text = m.currentFilter
+	}
+	scoper := m.scoper
+	return func() tea.Msg {
+		scoper.SetMetricsScope(text)
+		return nil
+	}
+}
+
+// cycleSourceScope scopes the metrics to the next source of the last hour,
+// busiest first, and after the last one back to every source.
+func (m *Model) cycleSourceScope() tea.Cmd {
+	if m.scoper == nil {
+		return nil
+	}
+	next := ""
+	if m.sourceScope == "" {
+		if len(m.sourceNames) > 0 {
+			next = m.sourceNames[0]
+		}
+	} else {
+		for i, name := range m.sourceNames {
+			if name == m.sourceScope && i+1 < len(m.sourceNames) {
+				next = m.sourceNames[i+1]
+			}
+		}
+	}
+	m.sourceScope = next
+	scoper := m.scoper
+	return func() tea.Msg {
+		scoper.SetSourceScope(next)
+		return nil
+	}
+}
+
+// scopedMetrics swaps in the scoped windows when they match the filter and
+// source scope.
+func (m Model) scopedMetrics(metrics types.Metrics) types.Metrics {
+	text := ""
+	if m.scopeMetrics {
+		text = m.currentFilter
+	}
+	if (text != "" || m.sourceScope != "") && metrics.Scope == text && metrics.ScopeSource == m.sourceScope && metrics.ScopedWindows != nil {
+		metrics.Windows = metrics.ScopedWindows
+	}
+	return metrics
+}
+
+// renderScope notes in the header what the metrics are limited to.
+func (m Model) renderScope() string {
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color("#00BFFF"))
+	var s string
+	if m.scopeMetrics {
+		if m.currentFilter == "" {
+			s += "  " + style.Render("Metrics follow the filter (none applied)")
+		} else {
+			s += "  " + style.Render(fmt.Sprintf("Metrics scoped to %q", m.currentFilter))
+		}
+	}
+	if m.sourceScope != "" {
+		s += "  " + style.Render(fmt.Sprintf("Source: %s", m.sourceScope))
+	}
+	return s
+}
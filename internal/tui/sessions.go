@@ -0,0 +1,24 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// renderSessions summarises a window's sessions and lists the most common
+// endpoint-to-endpoint transitions.
+func renderSessions(s types.SessionStats) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Sessions: %d | Requests/session: %.1f\n", s.Sessions, s.RequestsPerSession))
+	if len(s.TopTransitions) == 0 {
+		return b.String()
+	}
+
+	b.WriteString("Top transitions:\n")
+	for _, t := range s.TopTransitions {
+		b.WriteString(fmt.Sprintf("%-25s -> %-25s %d\n", truncate(t.From, 25), truncate(t.To, 25), t.Count))
+	}
+	return b.String()
+}
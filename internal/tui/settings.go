@@ -0,0 +1,118 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// ThresholdController reads and adjusts detection thresholds at runtime.
+type ThresholdController interface {
+	Thresholds() types.Thresholds
+	SetThresholds(types.Thresholds)
+}
+
+// thresholdSetting is one adjustable row of the settings overlay.
+type thresholdSetting struct {
+	name     string
+	unit     string
+	step     float64
+	min, max float64
+	field    func(*types.Thresholds) *float64
+}
+
+var thresholdSettings = []thresholdSetting{
+	{"Sigma", "std devs", 0.5, 0.5, 10, func(t *types.Thresholds) *float64 { return &t.Sigma }},
+	{"Error spike multiplier", "x 1h rate", 0.5, 1.5, 20, func(t *types.Thresholds) *float64 { return &t.ErrorSpike }},
+	{"EWMA deviation", "x smoothed", 0.05, 0.05, 5, func(t *types.Thresholds) *float64 { return &t.EWMADeviation }},
+	{"SLO target", "%", 0.01, 90, 99.99, func(t *types.Thresholds) *float64 { return &t.SLOTarget }},
+}
+
+// settingsOverlay lets the user view and adjust detection thresholds live,
+// and optionally write them back to the config file.
+type settingsOverlay struct {
+	open       bool
+	selected   int
+	controller ThresholdController
+	save       func(types.Thresholds) error
+	status     string
+}
+
+func newSettingsOverlay(controller ThresholdController, save func(types.Thresholds) error) settingsOverlay {
+	return settingsOverlay{controller: controller, save: save}
+}
+
+func (o *settingsOverlay) toggle() {
+	if o.controller == nil {
+		return
+	}
+	o.open = !o.open
+	o.status = ""
+}
+
+func (o *settingsOverlay) update(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "esc":
+		o.open = false
+	case "up":
+		if o.selected > 0 {
+			o.selected--
+		}
+	case "down":
+		if o.selected < len(thresholdSettings)-1 {
+			o.selected++
+		}
+	case "left", "-":
+		o.adjust(-1)
+	case "right", "+", "=":
+		o.adjust(1)
+	case "w":
+		if o.save == nil {
+			o.status = "Saving is not available"
+			return
+		}
+		if err := o.save(o.controller.Thresholds()); err != nil {
+			o.status = fmt.Sprintf("Save failed: %v", err)
+			return
+		}
+		o.status = "Saved to config file"
+	}
+}
+
+// adjust moves the selected threshold by dir steps and applies it at once.
+func (o *settingsOverlay) adjust(dir float64) {
+	setting := thresholdSettings[o.selected]
+	t := o.controller.Thresholds()
+	v := setting.field(&t)
+	*v = math.Round((*v+dir*setting.step)*100) / 100
+	*v = math.Max(setting.min, math.Min(setting.max, *v))
+	o.controller.SetThresholds(t)
+	o.status = "Applied (press 'w' to save)"
+}
+
+func (o settingsOverlay) view() string {
+	t := o.controller.Thresholds()
+	selectedStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#7D56F4"))
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render("Detection Settings"))
+	b.WriteString("\n\n")
+	for i, setting := range thresholdSettings {
+		line := fmt.Sprintf("%-24s %8g %s", setting.name, *setting.field(&t), setting.unit)
+		if i == o.selected {
+			b.WriteString(selectedStyle.Render("> " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\nup/down: select | left/right: adjust | w: write to config | esc: close\n")
+	if o.status != "" {
+		b.WriteString("\n" + o.status + "\n")
+	}
+	return lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(1).Render(b.String())
+}
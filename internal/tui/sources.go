@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+const (
+	sourcePollInterval = 1 * time.Second
+	staleSourceAfter   = 5 * time.Minute // No writes for this long marks a file as stalled
+	recentTruncation   = 1 * time.Minute // Truncations are flagged for this long
+)
+
+// SourceReporter reports how far a log source, such as a tailed file, is
+// behind.
+type SourceReporter interface {
+	Status() types.SourceStatus
+}
+
+type sourcesMsg struct{ statuses []types.SourceStatus }
+
+// pollSources checks every source after sourcePollInterval. Status reads the
+// files, so it runs in the command's goroutine rather than in Update.
+func (m Model) pollSources() tea.Cmd {
+	if len(m.sources) == 0 {
+		return nil
+	}
+	sources := m.sources
+	return tea.Tick(sourcePollInterval, func(time.Time) tea.Msg {
+		statuses := make([]types.SourceStatus, len(sources))
+		for i, source := range sources {
+			statuses[i] = source.Status()
+		}
+		return sourcesMsg{statuses}
+	})
+}
+
+// sourceWarning is a short note for the first source that looks stalled or
+// was just truncated; empty when all are fine.
+func sourceWarning(statuses []types.SourceStatus) string {
+	for _, s := range statuses {
+		switch {
+		case s.Err != "":
+			return fmt.Sprintf("%s: %s", s.Path, s.Err)
+		case !s.LastTruncate.IsZero() && time.Since(s.LastTruncate) < recentTruncation:
+			return fmt.Sprintf("%s was truncated", s.Path)
+		case !s.LastWrite.IsZero() && time.Since(s.LastWrite) > staleSourceAfter:
+			return fmt.Sprintf("%s stalled, last write %s", s.Path, formatSince(s.LastWrite))
+		}
+	}
+	return ""
+}
+
+// renderSources lists each tailed file with the tailer's backlog.
+func renderSources(statuses []types.SourceStatus) string {
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render("Sources"))
+	b.WriteString("\n\n")
+	for _, s := range statuses {
+		b.WriteString(s.Path + "\n")
+		if s.Err != "" {
+			b.WriteString(fmt.Sprintf("  Error:        %s\n", s.Err))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("  Read:         %s of %s\n", formatBytes(s.Offset), formatBytes(s.Size)))
+		b.WriteString(fmt.Sprintf("  Pending:      %s, %d lines\n", formatBytes(s.PendingBytes), s.PendingLines))
+		b.WriteString(fmt.Sprintf("  Last write:   %s\n", formatSince(s.LastWrite)))
+		if s.Truncations > 0 {
+			b.WriteString(fmt.Sprintf("  Truncated:    %d times, last %s\n", s.Truncations, formatSince(s.LastTruncate)))
+		}
+		if s.Rotations > 0 {
+			b.WriteString(fmt.Sprintf("  Rotated:      %d times, last %s\n", s.Rotations, formatSince(s.LastRotate)))
+		}
+		if s.LongLines > 0 || s.BinaryLines > 0 {
+			b.WriteString(fmt.Sprintf("  Guarded:      %d long lines cut, %d binary lines skipped\n", s.LongLines, s.BinaryLines))
+		}
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#00FF00")).
+		Padding(1).
+		Render(b.String()) + "\n"
+}
@@ -0,0 +1,33 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/locale"
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// renderErrorStreaks lists endpoints whose latest responses are all server
+// errors, marking the ones failing continuously.
+func renderErrorStreaks(streaks []types.ErrorStreak) string {
+	downStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")).Bold(true)
+
+	var b strings.Builder
+	b.WriteString("Error streaks:\n")
+	for _, s := range streaks {
+		lastSuccess := "never"
+		if !s.LastSuccess.IsZero() {
+			lastSuccess = locale.Time(s.LastSuccess)
+		}
+		line := fmt.Sprintf("%s: %d in a row over %s (longest %d, last success %s)",
+			s.Endpoint, s.Length, s.Duration.Round(time.Second), s.Longest, lastSuccess)
+		if s.Continuous {
+			line = downStyle.Render("DOWN ") + line
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
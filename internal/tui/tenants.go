@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// renderTenants lists the busiest tenants with their share of traffic, so a
+// noisy neighbour stands out.
+func renderTenants(tenants map[string]types.RequestStats) string {
+	return renderRequestStats(tenants, 20)
+}
+
+// renderOperations lists the busiest GraphQL operations with their latency
+// and error rate.
+func renderOperations(operations map[string]types.RequestStats) string {
+	return renderRequestStats(operations, 32)
+}
+
+// renderRequestStats lists stats busiest first, with names cut to width.
+func renderRequestStats(stats map[string]types.RequestStats, width int) string {
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if stats[names[i]].Requests != stats[names[j]].Requests {
+			return stats[names[i]].Requests > stats[names[j]].Requests
+		}
+		return names[i] < names[j]
+	})
+
+	var b strings.Builder
+	for _, name := range names {
+		t := stats[name]
+		b.WriteString(fmt.Sprintf("%-*s %s %5.1f%% | %6.2f rps | %5.2f%% errors | avg %s p95 %s\n",
+			width, truncate(name, width), drawBar(t.Share, 100, 10), t.Share, t.RPS, t.ErrorRate,
+			t.AvgLatency.Truncate(time.Millisecond), t.P95Latency.Truncate(time.Millisecond)))
+	}
+	return b.String()
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -11,11 +11,31 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	"github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/nitis/pulseWatch/internal/locale"
 	"github.com/nitis/pulseWatch/internal/types"
 )
 
 const maxLogEntries = 1000
 
+const (
+	tabOverview = iota
+	tabTrends
+	tabAnomalies
+	tabMethods
+	tabProtocols
+	tabGRPC
+	tabTiming
+	tabCompare
+	tabInternals
+)
+
+var tabNames = []string{"Overview", "Trends", "Anomalies", "Methods", "Protocols", "gRPC", "Timing", "Compare", "Internals"}
+
+const (
+	maxAnomalyListRows = 10
+	maxInlineAnomalies = 5 // Shown on the Overview tab
+)
+
 func drawBar(value float64, maxValue float64, width int) string {
 	if maxValue == 0 {
 		return strings.Repeat("░", width)
@@ -41,13 +61,29 @@ type Model struct {
 	filterInput         textinput.Model
 	currentFilter       string
 	quitAfterFirstReport bool
+	activeTab           int
+	settings            settingsOverlay
+	sources             []SourceReporter
+	sourceStatuses      []types.SourceStatus
+	history             anomalyBrowser
+	compare             endpointCompare
+	scoper              MetricsScoper
+	scopeMetrics        bool   // The filter also scopes the metrics
+	sourceScope         string // Input the metrics are scoped to, "" for all
+	sourceNames         []string
+	sampling            sampling
+	baseline            *types.SessionBaseline // Saved session the live cards are compared with
+	ingestion           IngestionControl // nil unless SetIngestion was called
+	alertsCh            <-chan types.Anomaly // nil unless SetAlerts was called
+	flash               alertFlash
+	annotate            annotationPrompt
 }
 
 type metricsMsg struct{ metrics types.Metrics }
 type rawLogMsg struct{ line string }
 
 // NewModel creates a new TUI model.
-func NewModel(metricsCh <-chan types.Metrics, rawLogsCh <-chan string, quitAfterFirstReport bool) Model {
+func NewModel(metricsCh <-chan types.Metrics, rawLogsCh <-chan string, quitAfterFirstReport bool, thresholds ThresholdController, saveThresholds func(types.Thresholds) error, sources []SourceReporter, history AnomalyHistory, trends EndpointTrends, scoper MetricsScoper) Model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
@@ -71,6 +107,13 @@ func NewModel(metricsCh <-chan types.Metrics, rawLogsCh <-chan string, quitAfter
 		filterInput:          ti,
 		logScrollPane:        vp,
 		quitAfterFirstReport: quitAfterFirstReport,
+		settings:             newSettingsOverlay(thresholds, saveThresholds),
+		sources:              sources,
+		history:              newAnomalyBrowser(history),
+		compare:              newEndpointCompare(trends),
+		scoper:               scoper,
+		sampling:             newSampling(10 * time.Second),
+		annotate:             newAnnotationPrompt(),
 	}
 }
 
@@ -82,6 +125,9 @@ func (m Model) Init() tea.Cmd {
 		m.filterInput.Focus(),
 		m.waitForMetrics,
 		m.waitForRawLogs,
+		m.waitForAlerts,
+		m.pollSources(),
+		m.sampling.tick(),
 	)
 }
 
@@ -92,7 +138,10 @@ func (m Model) waitForMetrics() tea.Msg {
 
 // New function to receive raw log entries
 func (m Model) waitForRawLogs() tea.Msg {
-	line := <-m.rawLogsCh
+	line, ok := <-m.rawLogsCh
+	if !ok {
+		return nil // Input ended
+	}
 	return rawLogMsg{line}
 }
 
@@ -105,24 +154,94 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if msg.String() == "ctrl+s" && !m.quitAfterFirstReport {
+			m.settings.toggle()
+			return m, nil
+		}
+		if m.settings.open {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			m.settings.update(msg)
+			return m, nil
+		}
+		if m.annotate.open {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			return m, m.annotate.update(msg)
+		}
 		switch msg.String() {
 		case "ctrl+c", "q":
 			return m, tea.Quit
+		case "ctrl+f": // Toggle scoping the metrics to the filter
+			if !m.quitAfterFirstReport {
+				cmds = append(cmds, m.toggleScope())
+			}
+		case "ctrl+o": // Scope the metrics to the next input source
+			if !m.quitAfterFirstReport {
+				cmds = append(cmds, m.cycleSourceScope())
+			}
+		case "ctrl+b": // Pause or resume ingestion
+			if !m.quitAfterFirstReport {
+				m.toggleIngestion()
+			}
+		case "ctrl+n": // Process the held lines while paused
+			if !m.quitAfterFirstReport {
+				m.flushIngestion()
+			}
+		case "ctrl+p": // Toggle one sampled line per pattern instead of every line
+			if !m.quitAfterFirstReport {
+				cmds = append(cmds, m.toggleSampling())
+			}
+		case "ctrl+t": // Annotate the log line at the bottom of the log pane
+			if !m.quitAfterFirstReport {
+				cmds = append(cmds, m.tagLine())
+			}
+		case "ctrl+r": // Start or end an annotated time range
+			if !m.quitAfterFirstReport {
+				cmds = append(cmds, m.tagRange())
+			}
 		case "esc": // Clear filter when esc is pressed
 			if m.filterInput.Focused() {
 				m.filterInput.Blur()
 				m.filterInput.SetValue("")
 				m.currentFilter = ""
 				m.applyFilter()
+				cmds = append(cmds, m.filterAnomalies(), m.updateScope())
 			}
 		case "enter": // Apply filter when enter is pressed
 			if m.filterInput.Focused() {
 				m.filterInput.Blur()
 				m.currentFilter = m.filterInput.Value()
 				m.applyFilter()
+				cmds = append(cmds, m.filterAnomalies(), m.updateScope())
 			}
 		case "/": // Focus filter input on '/'
 			m.filterInput.Focus()
+		case "tab": // Cycle through tabs
+			m.activeTab = (m.activeTab + 1) % len(tabNames)
+			if m.activeTab == tabAnomalies {
+				cmds = append(cmds, m.history.load())
+			}
+		case "left", "right", "shift+left", "shift+right":
+			if m.activeTab == tabAnomalies {
+				cmds = append(cmds, m.history.update(msg.String()))
+			} else if m.activeTab == tabCompare {
+				cmds = append(cmds, m.compare.update(msg.String(), m.metrics))
+			} else if m.filterInput.Focused() {
+				m.filterInput, cmd = m.filterInput.Update(msg)
+				cmds = append(cmds, cmd)
+			}
+		case "up", "down":
+			if m.activeTab == tabAnomalies {
+				cmds = append(cmds, m.history.update(msg.String()))
+			} else if m.activeTab == tabCompare {
+				cmds = append(cmds, m.compare.update(msg.String(), m.metrics))
+			} else {
+				m.logScrollPane, cmd = m.logScrollPane.Update(msg)
+				cmds = append(cmds, cmd)
+			}
 		default:
 			// If filter input is focused, send key messages to it
 			if m.filterInput.Focused() {
@@ -140,8 +259,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.filterInput.Width = m.width - 10
 
 	case metricsMsg:
-		m.metrics = msg.metrics
+		if names := sortedSources(msg.metrics.Windows["1h"].Sources); len(names) > 0 {
+			m.sourceNames = names
+		}
+		m.metrics = m.scopedMetrics(msg.metrics)
+		if m.metrics.Final {
+			// The input ended: show the historical report and exit
+			m.quitAfterFirstReport = true
+		}
 		cmds = append(cmds, m.waitForMetrics)
+		if m.activeTab == tabAnomalies {
+			cmds = append(cmds, m.history.load())
+		}
+		if m.activeTab == tabCompare {
+			cmds = append(cmds, m.compare.load())
+		}
 
 		// If quitAfterFirstReport is true, and we have received the first report, quit
 		if m.quitAfterFirstReport && len(m.metrics.Windows) > 0 {
@@ -149,7 +281,51 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 
+	case anomalyHistoryMsg:
+		if msg.seq == m.history.seq {
+			m.history.err = msg.err
+			if m.history.setAnomalies(msg.anomalies) {
+				cmds = append(cmds, m.history.loadEvidence())
+			}
+		}
+
+	case endpointTrendMsg:
+		m.compare.err = msg.err
+		if msg.err == nil {
+			m.compare.trends[msg.endpoint] = msg.points
+		}
+
+	case anomalyEvidenceMsg:
+		if a := m.history.anomalies; m.history.selected < len(a) &&
+			a[m.history.selected].Type == msg.anomaly.Type && a[m.history.selected].Timestamp.Equal(msg.anomaly.Timestamp) {
+			m.history.evidence = msg.evidence
+		}
+
+	case sourcesMsg:
+		m.sourceStatuses = msg.statuses
+		cmds = append(cmds, m.pollSources())
+
+	case alertMsg:
+		cmds = append(cmds, m.showAlert(msg.anomaly), m.waitForAlerts)
+
+	case flashTickMsg:
+		cmds = append(cmds, m.updateFlash(msg))
+
+	case annotationSavedMsg:
+		m.showAnnotationSaved(msg)
+
+	case sampleTickMsg:
+		if m.sampling.on && msg.seq == m.sampling.seq {
+			m.flushSamples(time.Now())
+			cmds = append(cmds, m.sampling.tick())
+		}
+
 	case rawLogMsg:
+		if m.sampling.on {
+			m.sampling.sampler.Add(msg.line)
+			cmds = append(cmds, m.waitForRawLogs)
+			break
+		}
 		// Add new log entry, trimming if buffer is too large
 		m.logs = append(m.logs, msg.line)
 		if len(m.logs) > maxLogEntries {
@@ -169,6 +345,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// filterAnomalies matches the anomaly history's type against the log filter.
+func (m *Model) filterAnomalies() tea.Cmd {
+	m.history.typeText = m.currentFilter
+	if m.activeTab != tabAnomalies {
+		return nil
+	}
+	return m.history.load()
+}
+
 // applyFilter updates m.filteredLogs based on m.currentFilter
 func (m *Model) applyFilter() {
 	if m.currentFilter == "" {
@@ -215,6 +400,72 @@ func (m Model) View() string {
 	header := headerStyle.Render("PulseWatch - Log Analysis Tool")
 	s.WriteString(header + "\n")
 
+	if !m.quitAfterFirstReport {
+		s.WriteString(m.renderFlash())
+		s.WriteString(m.renderTabBar())
+		if m.metrics.Learning {
+			learningStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFD700"))
+			s.WriteString("  " + learningStyle.Render(fmt.Sprintf("Learning baselines %.0f%% - anomaly detection paused", m.metrics.WarmupProgress*100)))
+		}
+		s.WriteString(m.renderScope())
+		s.WriteString(m.renderIngestion())
+		s.WriteString(m.renderAnnotation())
+		if warning := sourceWarning(m.sourceStatuses); warning != "" {
+			s.WriteString("  " + lipgloss.NewStyle().Foreground(lipgloss.Color("#FF8C00")).Render(warning))
+		}
+		if warning := pipelineWarning(m.metrics.Internals.Pipeline); warning != "" {
+			s.WriteString("  " + lipgloss.NewStyle().Foreground(lipgloss.Color("#FF8C00")).Render(warning))
+		}
+		s.WriteString("\n\n")
+		if m.settings.open {
+			s.WriteString(m.settings.view())
+			s.WriteString(m.renderFooter())
+			return s.String()
+		}
+		if m.annotate.open {
+			s.WriteString(m.annotate.view())
+			s.WriteString(m.renderFooter())
+			return s.String()
+		}
+		switch m.activeTab {
+		case tabTrends:
+			s.WriteString(m.renderTrends())
+			s.WriteString(m.renderFooter())
+			return s.String()
+		case tabAnomalies:
+			s.WriteString(m.history.view())
+			s.WriteString(m.renderFooter())
+			return s.String()
+		case tabMethods:
+			s.WriteString(m.renderMethods())
+			s.WriteString(m.renderFooter())
+			return s.String()
+		case tabProtocols:
+			s.WriteString(m.renderProtocols())
+			s.WriteString(m.renderFooter())
+			return s.String()
+		case tabGRPC:
+			s.WriteString(m.renderGRPC())
+			s.WriteString(m.renderFooter())
+			return s.String()
+		case tabTiming:
+			s.WriteString(m.renderBreakdown())
+			s.WriteString(m.renderFooter())
+			return s.String()
+		case tabCompare:
+			s.WriteString(m.compare.view(m.metrics))
+			s.WriteString(m.renderFooter())
+			return s.String()
+		case tabInternals:
+			s.WriteString(m.renderInternals())
+			if len(m.sourceStatuses) > 0 {
+				s.WriteString(renderSources(m.sourceStatuses))
+			}
+			s.WriteString(m.renderFooter())
+			return s.String()
+		}
+	}
+
 	// Display metrics
 	if m.quitAfterFirstReport {
 		// Historical report
@@ -226,56 +477,116 @@ func (m Model) View() string {
 			// Stats
 			statsStyle := lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(1)
 			stats := fmt.Sprintf(
-				"Total Requests: %d | Errors: %.2f%%",
-				wm.TotalRequests,
-				wm.ErrorRate,
+				"Total Requests: %s | Errors: %s",
+				locale.Int(int64(wm.TotalRequests)),
+				locale.Percent(wm.ErrorRate, 2),
 			)
+			if levels := renderLevels(wm.Levels, " | ", false); levels != "" {
+				stats += "\nLevels: " + levels
+			}
 			s.WriteString(statsStyle.Render(stats))
 			s.WriteString("\n\n")
 
 			// Latency
 			latencyStyle := lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(1)
-			latency := fmt.Sprintf(
-				"P50: %s | P90: %s | P95: %s | P99: %s",
-				wm.P50Latency.Truncate(time.Millisecond),
-				wm.P90Latency.Truncate(time.Millisecond),
-				wm.P95Latency.Truncate(time.Millisecond),
-				wm.P99Latency.Truncate(time.Millisecond),
-			)
+			latency := types.FormatPercentiles(wm.Percentiles, " | ") + renderTiming(wm.Timing, " | ") + renderLatencySLA(wm.LatencySLA, "\n")
 			s.WriteString(latencyStyle.Render(latency))
 			s.WriteString("\n\n")
 
-			// Top Endpoints
-			if len(wm.TopEndpoints) > 0 {
+			// Top Groups
+			if len(wm.TopGroups) > 0 {
 				endpointsStyle := lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(1)
 				var endpoints strings.Builder
-				endpoints.WriteString("Top Endpoints:\n")
-				// Sort endpoints by count
-				type endpointCount struct {
-					endpoint string
-					count    int
-				}
-				var ec []endpointCount
-				for ep, cnt := range wm.TopEndpoints {
-					ec = append(ec, endpointCount{ep, cnt})
-				}
-				sort.Slice(ec, func(i, j int) bool { return ec[i].count > ec[j].count })
-				for i, e := range ec {
-					if i >= 5 { // Top 5
-						break
-					}
-					endpoints.WriteString(fmt.Sprintf("%s: %d\n", e.endpoint, e.count))
-				}
+				endpoints.WriteString(m.renderTopGroups(wm))
+				endpoints.WriteString(renderEndpointPercentiles(wm.EndpointPercentiles))
+				endpoints.WriteString(renderEndpointLatencySLA(wm.EndpointLatencySLA))
 				s.WriteString(endpointsStyle.Render(endpoints.String()))
 				s.WriteString("\n\n")
 			}
 
+			// Error streaks
+			if len(m.metrics.ErrorStreaks) > 0 {
+				streaksStyle := lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(1)
+				s.WriteString(streaksStyle.Render(renderErrorStreaks(m.metrics.ErrorStreaks)))
+				s.WriteString("\n\n")
+			}
+
+			// Time between failures
+			if len(m.metrics.FailureIntervals) > 0 {
+				intervalsStyle := lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(1)
+				s.WriteString(intervalsStyle.Render(renderFailureIntervals(m.metrics.FailureIntervals)))
+				s.WriteString("\n\n")
+			}
+
+			// Tenants
+			if len(wm.Tenants) > 0 {
+				tenantsStyle := lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(1)
+				s.WriteString(tenantsStyle.Render("Top Tenants:\n" + renderTenants(wm.Tenants)))
+				s.WriteString("\n\n")
+			}
+
+			// Versions
+			if len(wm.Versions) > 0 {
+				versionsStyle := lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(1)
+				s.WriteString(versionsStyle.Render("Versions:\n" + renderVersions(wm.Versions)))
+				s.WriteString("\n\n")
+			}
+
+			// GraphQL operations
+			if len(wm.Operations) > 0 {
+				operationsStyle := lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(1)
+				s.WriteString(operationsStyle.Render("Top GraphQL Operations:\n" + renderOperations(wm.Operations)))
+				s.WriteString("\n\n")
+			}
+
+			// Sessions
+			if wm.Sessions.Sessions > 0 {
+				sessionsStyle := lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(1)
+				s.WriteString(sessionsStyle.Render(renderSessions(wm.Sessions)))
+				s.WriteString("\n\n")
+			}
+
+			// Retries
+			if wm.Retries.Retries > 0 {
+				retriesStyle := lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(1)
+				s.WriteString(retriesStyle.Render(renderRetries(wm.Retries)))
+				s.WriteString("\n\n")
+			}
+
+			// Error categories
+			if len(wm.ErrorCategories) > 0 {
+				categoriesStyle := lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(1)
+				s.WriteString(categoriesStyle.Render("Error categories:\n" + renderErrorCategories(wm.ErrorCategories)))
+				s.WriteString("\n\n")
+			}
+
+			// Rate-limit simulation
+			if len(m.metrics.RateLimits) > 0 {
+				limitsStyle := lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(1)
+				s.WriteString(limitsStyle.Render(renderRateLimits(m.metrics.RateLimits)))
+				s.WriteString("\n\n")
+			}
+
+			// Cache
+			if wm.Cache.Lookups > 0 {
+				cacheStyle := lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(1)
+				s.WriteString(cacheStyle.Render(renderCacheSummary(wm)))
+				s.WriteString("\n\n")
+			}
+
+			// Methods
+			if len(wm.Methods) > 0 {
+				methodsStyle := lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(1)
+				s.WriteString(methodsStyle.Render("Methods:\n" + renderMethodTable(wm.Methods)))
+				s.WriteString("\n\n")
+			}
+
 			// Status Code Distribution
 			statusCodeStyle := lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(1)
 			var statusCodes strings.Builder
 			statusCodes.WriteString("Status Codes:\n")
 			for code, count := range wm.StatusCodeDistribution {
-				statusCodes.WriteString(fmt.Sprintf("%s: %d\n", code, count))
+				statusCodes.WriteString(fmt.Sprintf("%s: %s\n", code, locale.Int(int64(count))))
 			}
 			s.WriteString(statusCodeStyle.Render(statusCodes.String()))
 			s.WriteString("\n\n")
@@ -294,21 +605,142 @@ func (m Model) View() string {
 				BorderForeground(lipgloss.Color("#7D56F4")).
 				Padding(1).
 				Width(35).
-				Render(fmt.Sprintf(
-					"%s\n\nRPS: %.2f\nErrors: %.2f%%\nRequests: %d\n\nP50: %s\nP95: %s",
-					window,
-					wm.RPS,
-					wm.ErrorRate,
-					wm.TotalRequests,
-					wm.P50Latency.Truncate(time.Millisecond),
-					wm.P95Latency.Truncate(time.Millisecond),
-				))
+				Render(window + "\n\n" + m.renderCardMetrics(wm) + renderTiming(wm.Timing, "\n") + renderLatencySLA(wm.LatencySLA, "\n") + renderWindowLevels(wm.Levels))
 			boxes = append(boxes, box)
 		}
+		if m.baseline != nil {
+			s.WriteString(m.renderBaselineHeader())
+		}
 		metricsRow := lipgloss.JoinHorizontal(lipgloss.Top, boxes...)
 		s.WriteString(metricsRow)
 		s.WriteString("\n\n")
 
+		if wm, ok := m.metrics.Windows["1m"]; ok && (len(wm.EndpointPercentiles) > 0 || len(wm.EndpointLatencySLA) > 0) {
+			s.WriteString(lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("#7D56F4")).
+				Padding(1).
+				Render("Endpoint latency (1m):\n" + renderEndpointPercentiles(wm.EndpointPercentiles) + renderEndpointLatencySLA(wm.EndpointLatencySLA)))
+			s.WriteString("\n\n")
+		}
+
+		if len(m.metrics.ErrorStreaks) > 0 {
+			s.WriteString(lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("#FF0000")).
+				Padding(1).
+				Render(renderErrorStreaks(m.metrics.ErrorStreaks)))
+			s.WriteString("\n\n")
+		}
+
+		if len(m.metrics.FailureIntervals) > 0 {
+			s.WriteString(lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("#FF8C00")).
+				Padding(1).
+				Render(renderFailureIntervals(m.metrics.FailureIntervals)))
+			s.WriteString("\n\n")
+		}
+
+		if len(m.metrics.Probes) > 0 {
+			s.WriteString(lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("#7D56F4")).
+				Padding(1).
+				Render(renderProbes(m.metrics.Probes)))
+			s.WriteString("\n\n")
+		}
+
+		if wm, ok := m.metrics.Windows["5m"]; ok && len(wm.TopGroups) > 0 {
+			s.WriteString(lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("#7D56F4")).
+				Padding(1).
+				Render(m.renderTopGroups(wm)))
+			s.WriteString("\n\n")
+		}
+
+		if wm, ok := m.metrics.Windows["5m"]; ok && len(wm.Tenants) > 0 {
+			s.WriteString(lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("#7D56F4")).
+				Padding(1).
+				Render("Top tenants (5m):\n" + renderTenants(wm.Tenants)))
+			s.WriteString("\n\n")
+		}
+
+		if wm, ok := m.metrics.Windows["5m"]; ok && len(wm.Versions) > 0 {
+			s.WriteString(lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("#7D56F4")).
+				Padding(1).
+				Render("Versions (5m):\n" + renderVersions(wm.Versions)))
+			s.WriteString("\n\n")
+		}
+
+		if wm, ok := m.metrics.Windows["5m"]; ok && len(wm.Operations) > 0 {
+			s.WriteString(lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("#7D56F4")).
+				Padding(1).
+				Render("Top GraphQL operations (5m):\n" + renderOperations(wm.Operations)))
+			s.WriteString("\n\n")
+		}
+
+		if wm, ok := m.metrics.Windows["5m"]; ok && len(wm.Sources) > 0 {
+			s.WriteString(lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("#7D56F4")).
+				Padding(1).
+				Render("Sources (5m):\n" + renderInputSources(wm.Sources)))
+			s.WriteString("\n\n")
+		}
+
+		if wm, ok := m.metrics.Windows["1h"]; ok && wm.Sessions.Sessions > 0 {
+			s.WriteString(lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("#7D56F4")).
+				Padding(1).
+				Render("User journeys (1h):\n" + renderSessions(wm.Sessions)))
+			s.WriteString("\n\n")
+		}
+
+		if wm, ok := m.metrics.Windows["5m"]; ok && wm.Retries.Retries > 0 {
+			s.WriteString(lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("#7D56F4")).
+				Padding(1).
+				Render("Client retries (5m):\n" + renderRetries(wm.Retries)))
+			s.WriteString("\n\n")
+		}
+
+		if wm, ok := m.metrics.Windows["5m"]; ok && len(wm.ErrorCategories) > 0 {
+			s.WriteString(lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("#7D56F4")).
+				Padding(1).
+				Render("Error categories (5m):\n" + renderErrorCategories(wm.ErrorCategories)))
+			s.WriteString("\n\n")
+		}
+
+		if len(m.metrics.RateLimits) > 0 {
+			s.WriteString(lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("#7D56F4")).
+				Padding(1).
+				Render("Rate-limit simulation (since start):\n" + renderRateLimits(m.metrics.RateLimits)))
+			s.WriteString("\n\n")
+		}
+
+		if c := m.metrics.Capacity; c.BreachRPS > 0 {
+			s.WriteString(lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("#7D56F4")).
+				Padding(1).
+				Render(fmt.Sprintf("Capacity headroom: %s (P95 over %s at about %s RPS)", renderHeadroom(c), locale.Duration(c.P95SLO), locale.Float(c.BreachRPS, 1))))
+			s.WriteString("\n\n")
+		}
+
 		// Trends
 		if len(m.metrics.TrendHistory) > 0 {
 			trendBox := lipgloss.NewStyle().
@@ -316,7 +748,7 @@ func (m Model) View() string {
 				BorderForeground(lipgloss.Color("#00FF00")).
 				Padding(1).
 				Width(80).
-				Render("Trends: RPS, Errors, Latency over time")
+				Render("Trends: press 'tab' for RPS, error rate, latency and forecast charts")
 			s.WriteString(trendBox)
 			s.WriteString("\n\n")
 		}
@@ -324,8 +756,9 @@ func (m Model) View() string {
 		// Anomalies
 		if len(m.metrics.Anomalies) > 0 {
 			var anomalies strings.Builder
-			anomalies.WriteString("Anomalies:\n")
-			for _, anomaly := range m.metrics.Anomalies {
+			anomalies.WriteString("Recent anomalies (older ones in the Anomalies tab):\n")
+			recent := m.metrics.Anomalies[max(len(m.metrics.Anomalies)-maxInlineAnomalies, 0):]
+			for _, anomaly := range recent {
 				anomalies.WriteString(fmt.Sprintf("• %s: %s\n", anomaly.Type, anomaly.Message))
 			}
 			anomalyBox := lipgloss.NewStyle().
@@ -344,83 +777,202 @@ func (m Model) View() string {
 		var anomalies strings.Builder
 		anomalies.WriteString("Anomalies:\n")
 		for _, anomaly := range m.metrics.Anomalies {
-			anomalies.WriteString(fmt.Sprintf("[%s] %s: %s\n", anomaly.Timestamp.Format("15:04:05"), anomaly.Type, anomaly.Message))
+			anomalies.WriteString(fmt.Sprintf("[%s] %s: %s\n", locale.Time(anomaly.Timestamp), anomaly.Type, anomaly.Message))
 		}
 		s.WriteString(anomaliesStyle.Render(anomalies.String()))
 		s.WriteString("\n")
 	}
 
-	// Trends
-	if !m.quitAfterFirstReport && len(m.metrics.TrendHistory) > 0 {
+	// Bottom half: Filter input and Log pane
+	s.WriteString(m.filterInput.View())
+	s.WriteString("\n")
+	s.WriteString(m.logScrollPane.View())
+
+	s.WriteString(m.renderFooter())
+
+	return s.String()
+}
+
+// renderTopGroups lists the busiest groups with their share of requests.
+func (m Model) renderTopGroups(wm types.WindowedMetrics) string {
+	var b strings.Builder
+	if m.metrics.GroupBy == "" {
+		b.WriteString("Top Endpoints:\n")
+	} else {
+		b.WriteString(fmt.Sprintf("Top groups by %s:\n", m.metrics.GroupBy))
+	}
+	for _, g := range wm.TopGroups {
+		share := 0.0
+		if wm.TotalRequests > 0 {
+			share = float64(g.Count) / float64(wm.TotalRequests) * 100
+		}
+		key := g.Key
+		if g.Other {
+			key = "(other)"
+		}
+		b.WriteString(fmt.Sprintf("%s %s: %s (%s)\n", drawBar(share, 100, 10), key, locale.Int(int64(g.Count)), locale.Percent(share, 1)))
+	}
+	return b.String()
+}
+
+// renderTiming appends the queue/service split, or "" when the logs carry no
+// service times.
+func renderTiming(t types.TimingStats, sep string) string {
+	if t.Samples == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%sQueue P50/P95: %s/%s%sService P50/P95: %s/%s%sQueueing: %s of time",
+		sep, locale.Duration(t.QueueP50.Truncate(time.Millisecond)), locale.Duration(t.QueueP95.Truncate(time.Millisecond)),
+		sep, locale.Duration(t.ServiceP50.Truncate(time.Millisecond)), locale.Duration(t.ServiceP95.Truncate(time.Millisecond)),
+		sep, locale.Percent(t.QueueShare, 0))
+}
+
+// renderEndpointPercentiles lists the endpoints with percentile overrides.
+func renderEndpointPercentiles(eps map[string][]types.PercentileValue) string {
+	endpoints := make([]string, 0, len(eps))
+	for endpoint := range eps {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+
+	var b strings.Builder
+	for _, endpoint := range endpoints {
+		b.WriteString(fmt.Sprintf("%s: %s\n", endpoint, types.FormatPercentiles(eps[endpoint], " | ")))
+	}
+	return b.String()
+}
+
+func (m Model) renderFooter() string {
+	footerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#333333")).
+		Width(m.width).
+		Align(lipgloss.Left)
+	return "\n" + footerStyle.Render(" Press 'q' to quit | 'tab' to switch view | 'ctrl+s' for settings | 'esc' to clear filter | 'enter' to apply filter | 'ctrl+f' to scope metrics to filter | 'ctrl+o' to scope metrics to a source | 'ctrl+p' to sample by pattern"+m.renderRefresh()+" ")
+}
+
+// renderRefresh describes the effective refresh interval for the footer.
+func (m Model) renderRefresh() string {
+	r := m.metrics.Refresh
+	if r.Tick == 0 {
+		return ""
+	}
+	mode := ""
+	if r.Adaptive {
+		mode = " adaptive"
+	}
+	return fmt.Sprintf(" | refresh %v%s (%.0f lines/s)", r.Tick, mode, r.IngestRate)
+}
+
+func (m Model) renderTabBar() string {
+	activeStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FAFAFA")).Background(lipgloss.Color("#7D56F4")).Padding(0, 1)
+	inactiveStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Padding(0, 1)
+	var tabs []string
+	for i, name := range tabNames {
+		if i == m.activeTab {
+			tabs = append(tabs, activeStyle.Render(name))
+		} else {
+			tabs = append(tabs, inactiveStyle.Render(name))
+		}
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+}
+
+// renderTrends renders the trend bar charts and the capacity forecast.
+func (m Model) renderTrends() string {
+	var s strings.Builder
+
+	if len(m.metrics.TrendHistory) > 0 {
 		s.WriteString("Trends (Recent Updates):\n\n")
 
 		// RPS Trend
 		maxRPS := 0.0
 		for _, tp := range m.metrics.TrendHistory {
-			if tp.RPS > maxRPS {
-				maxRPS = tp.RPS
+			if tp.SmoothedRPS > maxRPS {
+				maxRPS = tp.SmoothedRPS
 			}
 		}
-		s.WriteString("RPS:\n")
+		s.WriteString("RPS (EWMA):\n")
 		start := len(m.metrics.TrendHistory) - 10
 		if start < 0 {
 			start = 0
 		}
 		for i := start; i < len(m.metrics.TrendHistory); i++ {
 			tp := m.metrics.TrendHistory[i]
-			bar := drawBar(tp.RPS, maxRPS, 20)
-			s.WriteString(fmt.Sprintf("%s %.1f\n", bar, tp.RPS))
+			bar := drawBar(tp.SmoothedRPS, maxRPS, 20)
+			s.WriteString(fmt.Sprintf("%s %s (raw %s)\n", bar, locale.Float(tp.SmoothedRPS, 1), locale.Float(tp.RPS, 1)))
 		}
 		s.WriteString("\n")
 
 		// P95 Latency Trend
 		maxLat := time.Duration(0)
 		for _, tp := range m.metrics.TrendHistory {
-			if tp.P95Latency > maxLat {
-				maxLat = tp.P95Latency
+			if tp.SmoothedP95 > maxLat {
+				maxLat = tp.SmoothedP95
 			}
 		}
-		s.WriteString("P95 Latency:\n")
+		s.WriteString("P95 Latency (EWMA):\n")
 		for i := start; i < len(m.metrics.TrendHistory); i++ {
 			tp := m.metrics.TrendHistory[i]
-			latMs := float64(tp.P95Latency.Milliseconds())
+			latMs := float64(tp.SmoothedP95.Milliseconds())
 			maxLatMs := float64(maxLat.Milliseconds())
 			bar := drawBar(latMs, maxLatMs, 20)
-			s.WriteString(fmt.Sprintf("%s %v\n", bar, tp.P95Latency.Truncate(time.Millisecond)))
+			s.WriteString(fmt.Sprintf("%s %s (raw %s)\n", bar, locale.Duration(tp.SmoothedP95.Truncate(time.Millisecond)), locale.Duration(tp.P95Latency.Truncate(time.Millisecond))))
 		}
 		s.WriteString("\n")
 
 		// Error Rate Trend
 		maxErr := 0.0
 		for _, tp := range m.metrics.TrendHistory {
-			if tp.ErrorRate > maxErr {
-				maxErr = tp.ErrorRate
+			if tp.SmoothedErrorRate > maxErr {
+				maxErr = tp.SmoothedErrorRate
 			}
 		}
-		s.WriteString("Error Rate:\n")
+		s.WriteString("Error Rate (EWMA):\n")
 		for i := start; i < len(m.metrics.TrendHistory); i++ {
 			tp := m.metrics.TrendHistory[i]
-			bar := drawBar(tp.ErrorRate*100, maxErr*100, 20) // Scale to 0-100
-			s.WriteString(fmt.Sprintf("%s %.2f%%\n", bar, tp.ErrorRate))
+			bar := drawBar(tp.SmoothedErrorRate*100, maxErr*100, 20) // Scale to 0-100
+			s.WriteString(fmt.Sprintf("%s %s (raw %s)\n", bar, locale.Percent(tp.SmoothedErrorRate, 2), locale.Percent(tp.ErrorRate, 2)))
 		}
 		s.WriteString("\n")
-	}
 
-	// Bottom half: Filter input and Log pane
-	s.WriteString(m.filterInput.View())
-	s.WriteString("\n")
-	s.WriteString(m.logScrollPane.View())
+		s.WriteString(m.renderCacheTrend(start))
+		s.WriteString(m.renderLevelTrend(start))
+	}
 
-	// Footer
-	footerStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FAFAFA")).
-		Background(lipgloss.Color("#333333")).
-		Width(m.width).
-		Align(lipgloss.Left)
-	footer := footerStyle.Render(" Press 'q' to quit | 'esc' to clear filter | 'enter' to apply filter ")
-	s.WriteString("\n" + footer)
+	s.WriteString(m.renderForecast())
+	s.WriteString(m.renderCapacity())
 
 	return s.String()
 }
 
+func (m Model) renderForecast() string {
+	f := m.metrics.Forecast
+	if len(f.Points) == 0 {
+		return "Forecast: not enough stored history yet\n"
+	}
 
+	var b strings.Builder
+	b.WriteString("Forecast (next hours):\n")
+	maxRPS := 0.0
+	for _, p := range f.Points {
+		if p.RPS > maxRPS {
+			maxRPS = p.RPS
+		}
+	}
+	for _, p := range f.Points {
+		bar := drawBar(p.RPS, maxRPS, 20)
+		b.WriteString(fmt.Sprintf("%s %s RPS %s | Errors %s\n", locale.ShortTime(p.Time), bar, locale.Float(p.RPS, 1), locale.Percent(p.ErrorRate, 2)))
+	}
+	b.WriteString(fmt.Sprintf("\nError budget (SLO %s): %s remaining", locale.Percent(f.SLOTarget, 2), locale.Percent(f.ErrorBudgetRemaining, 1)))
+	if f.BudgetExhaustedIn > 0 {
+		b.WriteString(fmt.Sprintf(" | projected exhaustion in %s", locale.Duration(f.BudgetExhaustedIn)))
+	}
+	b.WriteString("\n")
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#00FF00")).
+		Padding(1).
+		Render(b.String()) + "\n"
+}
@@ -0,0 +1,48 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nitis/pulseWatch/internal/types"
+)
+
+// renderVersions lists each version with its traffic, then how the others
+// compare with the busiest one, taken as the stable release, so a canary's
+// extra errors or latency show at a glance.
+func renderVersions(versions map[string]types.RequestStats) string {
+	names := make([]string, 0, len(versions))
+	for version := range versions {
+		names = append(names, version)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if versions[names[i]].Requests != versions[names[j]].Requests {
+			return versions[names[i]].Requests > versions[names[j]].Requests
+		}
+		return names[i] < names[j]
+	})
+
+	var b strings.Builder
+	b.WriteString(renderRequestStats(versions, 20))
+	if len(names) < 2 {
+		return b.String()
+	}
+	stable := versions[names[0]]
+	b.WriteString(fmt.Sprintf("\nCompared with %s:\n", names[0]))
+	for _, name := range names[1:] {
+		v := versions[name]
+		b.WriteString(fmt.Sprintf("%-20s errors %+.2f pp | avg %s | p95 %s\n", truncate(name, 20),
+			v.ErrorRate-stable.ErrorRate, latencyChange(v.AvgLatency, stable.AvgLatency), latencyChange(v.P95Latency, stable.P95Latency)))
+	}
+	return b.String()
+}
+
+// latencyChange describes a latency relative to a baseline, e.g. "+35%".
+func latencyChange(latency, baseline time.Duration) string {
+	if latency == 0 || baseline == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.0f%%", (float64(latency)/float64(baseline)-1)*100)
+}
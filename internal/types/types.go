@@ -1,7 +1,12 @@
 package types
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/nitis/pulseWatch/internal/locale"
 )
 
 // LogLevel defines the level of a log entry.
@@ -15,6 +20,16 @@ const (
 	UnknownLevel LogLevel = "UNKNOWN"
 )
 
+// LevelOrder lists the log levels most severe first, for display.
+var LevelOrder = []LogLevel{ErrorLevel, WarnLevel, InfoLevel, DebugLevel, UnknownLevel}
+
+// LevelStats counts a window's entries at one log level.
+type LevelStats struct {
+	Count int
+	RPS   float64 // Entries per second; 0 for the "all" window of a scan
+	Share float64 // Percent of the window's entries
+}
+
 // LogEntry represents a single, parsed log line.
 type LogEntry struct {
 	Timestamp time.Time
@@ -23,14 +38,105 @@ type LogEntry struct {
 	StatusCode int
 	Latency   time.Duration
 	Endpoint  string
+	Method    string // HTTP method, upper-case; empty when unknown
+	CacheStatus string // CDN/proxy cache result, e.g. HIT, MISS, BYPASS; empty when not logged
+	QueueTime   time.Duration // Time waiting before a handler/upstream picked the request up; 0 when unknown
+	ServiceTime time.Duration // Time spent in the handler/upstream; 0 when unknown
+	Tenant      string        // Value of the configured tenant field; empty when unset
+	GroupKey    string        // Value of the configured grouping expression; empty when unset
+	Protocol    string        // HTTP version, e.g. HTTP/1.1, HTTP/2; empty when unknown
+	TLSVersion  string        // e.g. TLSv1.3; empty for plain HTTP or when not logged
+	Session     string        // Value of the configured session field; empty when unset
+	PrevEndpoint string       // Endpoint of the session's previous request; empty for its first
+	Source      string        // Name of the input the entry was read from, e.g. a file path or "syslog"
+	GRPCStatus  string        // gRPC status code name, e.g. OK, DeadlineExceeded; empty for other requests
+	Operation   string        // GraphQL operation name, e.g. GetUser; empty for other requests
+	Version     string        // Value of the configured version field; empty when unset
+	Retry       bool          // Likely a client retrying an earlier request; see RetryStats
+	ErrorCategory string      // Configured error category of a failed entry; empty when none matched
+	Timings     map[string]float64 // Component (e.g. db) -> milliseconds, from timing fields such as db_ms
 	Fields    map[string]interface{}
 }
 
 // Anomaly represents a detected anomaly in the log stream.
 type Anomaly struct {
+	Timestamp    time.Time
+	Type         string
+	Severity     string // SeverityCritical, SeverityWarning, or SeverityInfo
+	Message      string
+	Contributors []Contributor
+	Evidence     []LogEntry      // Slowest or failing raw entries captured when the anomaly fired
+	Snapshot     MetricsSnapshot // Headline metrics of the 1m window when the anomaly fired
+}
+
+// Anomaly severities, most severe first.
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// SeverityRank orders severities, most severe first; unknown ones rank last.
+func SeverityRank(severity string) int {
+	switch severity {
+	case SeverityCritical:
+		return 0
+	case SeverityWarning:
+		return 1
+	case SeverityInfo:
+		return 2
+	}
+	return 3
+}
+
+// Event is an external occurrence, such as a deployment, feature-flag flip,
+// or incident declaration, recorded as a timeline marker.
+type Event struct {
 	Timestamp time.Time
-	Type      string
-	Message   string
+	Type      string // e.g. "deploy", "flag", "incident"
+	Title     string
+	Text      string
+	Source    string // Who reported it, e.g. "github-actions"
+}
+
+// Annotation is an operator's label and note on a log line or a time range,
+// recorded during an incident. A line annotation has End equal to Start.
+type Annotation struct {
+	Created time.Time
+	Start   time.Time
+	End     time.Time
+	Label   string
+	Note    string
+	Line    string // The tagged log line, empty for a time range
+}
+
+// MetricsSnapshot captures a window's headline metrics.
+type MetricsSnapshot struct {
+	RPS        float64
+	ErrorRate  float64
+	Requests   int
+	Errors     int
+	P50Latency time.Duration
+	P95Latency time.Duration
+	P99Latency time.Duration
+}
+
+// AnomalyFilter selects persisted anomalies. Zero fields match everything.
+type AnomalyFilter struct {
+	Type     string // Case-insensitive substring of the anomaly type
+	Severity string
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+}
+
+// Contributor is a dimension value that accounts for part of an anomaly's
+// change versus the baseline window.
+type Contributor struct {
+	Dimension     string  // e.g. "endpoint", "status", "client_ip", "source"
+	Value         string
+	Share         float64 // Share of the current window, 0-100
+	BaselineShare float64 // Share of the baseline window, 0-100
 }
 
 // TrendPoint holds key metrics for trend visualization.
@@ -38,6 +144,18 @@ type TrendPoint struct {
 	RPS       float64
 	P95Latency time.Duration
 	ErrorRate float64
+
+	// EWMA-smoothed values of the above
+	SmoothedRPS       float64
+	SmoothedP95       time.Duration
+	SmoothedErrorRate float64
+
+	// CacheHitRatio is only meaningful when CacheLookups > 0.
+	CacheHitRatio float64
+	CacheLookups  int
+
+	// LevelRPS is the entries per second at each log level seen.
+	LevelRPS map[LogLevel]float64
 }
 
 // CustomMetric defines a user-defined metric.
@@ -60,12 +178,587 @@ type WindowedMetrics struct {
 	TotalErrors   int
 	StatusCodeDistribution map[string]int
 	Custom      map[string]int
+
+	// Percentiles holds the configured latency percentiles in display order;
+	// EndpointPercentiles holds those of endpoints with configured overrides.
+	Percentiles         []PercentileValue
+	EndpointPercentiles map[string][]PercentileValue
+
+	// LatencySLA holds the share of requests within each configured latency
+	// threshold; EndpointLatencySLA holds those of endpoints with their own.
+	LatencySLA         []LatencySLA
+	EndpointLatencySLA map[string][]LatencySLA
+
+	// Methods breaks requests down by HTTP method; EndpointMethods does the
+	// same per endpoint. Entries without a method are not counted.
+	Methods         map[string]MethodStats
+	EndpointMethods map[string]map[string]MethodStats
+
+	// Cache summarises entries carrying a cache status; CacheStatuses counts
+	// each status and EndpointCache holds the per-endpoint hit ratio.
+	Cache         CacheStats
+	CacheStatuses map[string]int
+	EndpointCache map[string]CacheStats
+
+	Timing TimingStats
+
+	// Breakdown splits request time into the components logged in timing
+	// fields (db_ms, cache_ms, ...), overall and for the endpoints spending
+	// the most time in them.
+	Breakdown TimingBreakdown
+
+	// Tenants holds the busiest tenants when a tenant field is configured.
+	Tenants map[string]RequestStats
+
+	// TopGroups lists the busiest groups (endpoints unless a grouping
+	// expression is configured), busiest first, with the rest folded into a
+	// final Other group.
+	TopGroups []GroupCount
+
+	// Protocols and TLSVersions count entries by HTTP version and TLS
+	// version; entries that don't log them are not counted.
+	Protocols   map[string]int
+	TLSVersions map[string]int
+
+	// Levels counts entries by log level, so application logs without
+	// status codes get rates and trends too.
+	Levels map[LogLevel]LevelStats
+
+	// Sessions summarises user journeys when a session field is configured.
+	Sessions SessionStats
+
+	// Sources breaks the window down by input when a run reads several.
+	Sources map[string]SourceStats
+
+	// GRPCStatuses counts gRPC calls by status code and GRPCMethods breaks
+	// them down by full method name; other requests are not counted.
+	GRPCStatuses map[string]int
+	GRPCMethods  map[string]GRPCMethodStats
+
+	// Operations holds the busiest GraphQL operations, so one slow or
+	// failing operation doesn't hide in the totals of /graphql.
+	Operations map[string]RequestStats
+
+	// Versions holds the busiest application versions when a version field
+	// is configured, to compare a canary with the stable release.
+	Versions map[string]RequestStats
+
+	// Retries estimates how much of the traffic is clients retrying, which
+	// inflates RPS without adding work users asked for.
+	Retries RetryStats
+
+	// ErrorCategories counts errors by the configured categories, busiest
+	// first, so failures read in the team's terms rather than as codes.
+	ErrorCategories []ErrorCategoryStats
+}
+
+// Thresholds are the detection settings that can be adjusted at runtime.
+type Thresholds struct {
+	Sigma         float64 // Standard deviations from the baseline before an anomaly fires
+	ErrorSpike    float64 // 1m error rate as a multiple of the 1h error rate that counts as a spike
+	EWMADeviation float64 // Relative deviation from the EWMA that fires, e.g. 0.5 = 50%
+	SLOTarget     float64 // Availability target in percent for the error budget
+}
+
+// ErrorStreak is an endpoint's current run of consecutive server errors.
+type ErrorStreak struct {
+	Endpoint    string
+	Length      int
+	Longest     int           // Longest streak seen this session
+	Since       time.Time     // First error of the current streak
+	Duration    time.Duration // From the first to the latest error of the streak
+	LastSuccess time.Time     // Zero if the endpoint never succeeded this session
+	Continuous  bool          // Streak passed the configured thresholds
+}
+
+// FailureGapBuckets are the upper bounds of the FailureInterval histogram
+// buckets; a last, open bucket holds longer gaps.
+var FailureGapBuckets = []time.Duration{time.Second, 10 * time.Second, time.Minute, 10 * time.Minute, time.Hour}
+
+// FailureGapBucket returns the histogram bucket of a gap between failures.
+func FailureGapBucket(gap time.Duration) int {
+	for i, bound := range FailureGapBuckets {
+		if gap < bound {
+			return i
+		}
+	}
+	return len(FailureGapBuckets)
+}
+
+// FailureInterval describes the time between an endpoint's server errors.
+type FailureInterval struct {
+	Endpoint     string
+	Failures     int
+	LastFailure  time.Time
+	MTBF         time.Duration // Median gap over the latest detection.mtbf.window failures
+	PreviousMTBF time.Duration // The same over the window before; 0 until there are enough
+	Histogram    []int         // Gaps per FailureGapBuckets bucket
+	Shrinking    bool          // MTBF fell by the configured factor
+}
+
+// ProbeResult is the outcome of one synthetic probe request.
+type ProbeResult struct {
+	Name       string
+	Timestamp  time.Time
+	OK         bool
+	StatusCode int // 0 if no response was received
+	Latency    time.Duration
+	Error      string // Why the check failed; empty when OK
+}
+
+// ProbeStatus summarizes a synthetic probe's recent results.
+type ProbeStatus struct {
+	Name        string
+	URL         string
+	Last        ProbeResult // Zero before the first check
+	Failing     int         // Consecutive failed checks
+	Checks      int         // Checks in the last ProbeStatusWindow
+	Successes   int
+	LastSuccess time.Time // Zero if no check succeeded this session
+}
+
+// ProbeStatusWindow is the period ProbeStatus counts checks over.
+const ProbeStatusWindow = 5 * time.Minute
+
+// GroupCount is the request count of one group.
+type GroupCount struct {
+	Key   string
+	Count int
+	Other bool // Aggregates every group beyond the top N
+}
+
+// SessionStats summarises the sessions active in a window.
+type SessionStats struct {
+	Sessions           int // Distinct session values
+	Requests           int // Requests that carried a session
+	RequestsPerSession float64
+	TopTransitions     []Transition // Most common endpoint-to-endpoint steps, most frequent first
+}
+
+// Transition counts how often sessions moved from one endpoint to the next.
+type Transition struct {
+	From  string
+	To    string
+	Count int
+}
+
+// RequestStats summarises the traffic of one tenant, GraphQL operation, or
+// application version in a window.
+type RequestStats struct {
+	Requests   int
+	Errors     int
+	RPS        float64
+	ErrorRate  float64
+	AvgLatency time.Duration
+	P95Latency time.Duration
+	Share      float64 // Percentage of the window's requests
+}
+
+// RetryStats counts the requests that look like retries: a client
+// repeating a request to the same endpoint shortly after the last, or an
+// idempotency key seen before.
+type RetryStats struct {
+	Requests      int
+	Retries       int
+	Amplification float64           // Requests per original request; 1 without retries
+	TopEndpoints  []EndpointRetries // Most retried first
+}
+
+// EndpointRetries counts the retries of one endpoint.
+type EndpointRetries struct {
+	Endpoint      string
+	Requests      int
+	Retries       int
+	Amplification float64
+}
+
+// ErrorCategoryStats counts a window's errors in one configured category.
+type ErrorCategoryStats struct {
+	Name   string
+	Errors int
+	Rate   float64 // Percentage of the window's requests
+}
+
+// RateLimitStats is what a simulated rate-limit policy would have done to
+// the requests seen since startup.
+type RateLimitStats struct {
+	Name        string
+	Key         string // Field limited on
+	Rate        float64
+	Burst       int
+	Requests    int // Requests carrying the key field
+	Limited     int // Requests that found the bucket empty
+	LimitedPct  float64
+	LimitedKeys int          // Keys limited at least once
+	TopKeys     []KeyLimited // Most limited first
+}
+
+// KeyLimited counts the requests of one key a policy would have limited.
+type KeyLimited struct {
+	Key      string
+	Requests int
+	Limited  int
+}
+
+// Amplification is requests per original request: 2 means every request
+// was sent twice on average. Originals that fell out of the window count
+// as one.
+func Amplification(requests, retries int) float64 {
+	if requests == 0 {
+		return 1
+	}
+	return float64(requests) / float64(max(requests-retries, 1))
+}
+
+// SourceStats summarises the entries read from one input.
+type SourceStats struct {
+	Entries    int
+	Errors     int
+	RPS        float64 // Entries per second; 0 for the "all" window of a scan
+	ErrorRate  float64
+	AvgLatency time.Duration
+	Share      float64 // Percentage of the window's entries
+}
+
+// TimingStats separates queueing delay from service time for entries that log
+// both, so saturation (growing queue) can be told apart from slow handlers
+// (growing service time).
+type TimingStats struct {
+	Samples    int
+	QueueP50   time.Duration
+	QueueP95   time.Duration
+	ServiceP50 time.Duration
+	ServiceP95 time.Duration
+	QueueShare float64 // Percentage of total time spent queueing
+}
+
+// TimingBreakdown splits request time by component.
+type TimingBreakdown struct {
+	Components []ComponentTiming // Largest share first
+	Endpoints  []EndpointTimings // Most component time first
+}
+
+// EndpointTimings breaks one endpoint's request time down by component.
+type EndpointTimings struct {
+	Endpoint   string
+	Requests   int // Requests logging any component
+	Components []ComponentTiming
+}
+
+// ComponentTiming summarises one component of request time, e.g. db.
+type ComponentTiming struct {
+	Component  string
+	Samples    int // Requests logging the component
+	Avg        time.Duration
+	P95        time.Duration
+	PerRequest time.Duration // Time per request logging any component, for stacking
+	Share      float64       // Percentage of the time of all components
+}
+
+// CacheHit is the normalized cache status of a request served from cache.
+const CacheHit = "HIT"
+
+// CacheStats counts cache lookups (entries with a cache status) and hits.
+type CacheStats struct {
+	Lookups int
+	Hits    int
+}
+
+// HitRatio returns the percentage of lookups served from cache.
+func (c CacheStats) HitRatio() float64 {
+	if c.Lookups == 0 {
+		return 0
+	}
+	return float64(c.Hits) / float64(c.Lookups) * 100
+}
+
+// MethodStats counts the requests and errors (status >= 400) for one HTTP method.
+type MethodStats struct {
+	Requests int
+	Errors   int
+}
+
+// ErrorRate returns the percentage of requests that failed.
+func (m MethodStats) ErrorRate() float64 {
+	if m.Requests == 0 {
+		return 0
+	}
+	return float64(m.Errors) / float64(m.Requests) * 100
+}
+
+// GRPCMethodStats counts the calls of one gRPC method, the errors among
+// them (status codes that map to an HTTP status >= 400), and each status.
+type GRPCMethodStats struct {
+	Calls    int
+	Errors   int
+	Statuses map[string]int
+}
+
+// ErrorRate returns the percentage of calls that failed.
+func (m GRPCMethodStats) ErrorRate() float64 {
+	if m.Calls == 0 {
+		return 0
+	}
+	return float64(m.Errors) / float64(m.Calls) * 100
+}
+
+// PercentileValue is a latency percentile, e.g. {99.9, 1.2s}.
+type PercentileValue struct {
+	Percentile float64
+	Latency    time.Duration
+}
+
+// Label returns the percentile's display name, e.g. "P99.9".
+func (p PercentileValue) Label() string {
+	return "P" + strconv.FormatFloat(p.Percentile, 'f', -1, 64)
+}
+
+// FormatPercentiles renders percentiles as "P50: 12ms<sep>P99: 80ms".
+func FormatPercentiles(ps []PercentileValue, sep string) string {
+	parts := make([]string, 0, len(ps))
+	for _, p := range ps {
+		parts = append(parts, fmt.Sprintf("%s: %s", p.Label(), locale.Duration(p.Latency.Truncate(time.Millisecond))))
+	}
+	return strings.Join(parts, sep)
+}
+
+// LatencySLA is the share of requests that finished within a latency
+// threshold, e.g. 98.7% within 300ms.
+type LatencySLA struct {
+	Threshold time.Duration
+	Percent   float64 // Of the successful requests that log a latency
+	Requests  int     // Those requests
+	Target    float64 // Percent that should be within Threshold; 0 for none
+}
+
+// Missed reports whether requests were seen and fewer than Target percent
+// were fast enough.
+func (s LatencySLA) Missed() bool {
+	return s.Target > 0 && s.Requests > 0 && s.Percent < s.Target
+}
+
+// FormatLatencySLA renders objectives as "≤300ms: 98.7% (target 99%)<sep>...".
+func FormatLatencySLA(slas []LatencySLA, sep string) string {
+	parts := make([]string, 0, len(slas))
+	for _, s := range slas {
+		part := fmt.Sprintf("≤%s: %s", locale.Duration(s.Threshold), locale.Percent(s.Percent, 1))
+		if s.Target > 0 {
+			part += fmt.Sprintf(" (target %s)", locale.Percent(s.Target, -1))
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, sep)
+}
+
+// ForecastPoint is a projected value for one future hour.
+type ForecastPoint struct {
+	Time      time.Time
+	RPS       float64
+	ErrorRate float64
+}
+
+// Forecast projects RPS and error budget consumption over the next hours.
+type Forecast struct {
+	GeneratedAt          time.Time
+	Points               []ForecastPoint
+	SLOTarget            float64       // Availability target in percent, e.g. 99.9
+	ErrorBudgetRemaining float64       // Percent of the error budget left over the history span
+	BudgetExhaustedIn    time.Duration // Zero if the budget is not projected to run out within the horizon
+}
+
+// Capacity estimates the RPS at which P95 latency would breach its
+// objective, from how latency rose with load in the stored history. The
+// fit is a single-server queue: 1/P95 falls linearly as RPS rises.
+type Capacity struct {
+	GeneratedAt time.Time
+	P95SLO      time.Duration
+	Samples     int     // Minutes of history fitted
+	R2          float64 // Goodness of fit, 0 to 1
+	CurrentRPS  float64 // 5m window
+	BreachRPS   float64 // Estimated RPS at which P95 reaches P95SLO; 0 without an estimate
+	Headroom    float64 // Percent of BreachRPS not yet used; negative past it
+	Note        string  // Why there is no estimate
 }
 
 // Metrics holds the aggregated data points for the TUI display.
 type Metrics struct {
 	Windows      map[string]WindowedMetrics // Key: "1m", "5m", "1h"
+
+	// ScopedWindows holds the live windows recomputed over only the entries
+	// matching Scope, the text set with Engine.SetMetricsScope, and read from
+	// ScopeSource, set with Engine.SetSourceScope; nil when unscoped.
+	Scope         string
+	ScopeSource   string
+	ScopedWindows map[string]WindowedMetrics
 	Anomalies    []Anomaly
 	StartTime    time.Time
 	TrendHistory []TrendPoint // For trend visualization
-}
\ No newline at end of file
+	Forecast     Forecast
+	Capacity     Capacity
+
+	// GroupBy is the grouping expression behind TopGroups; empty means endpoint.
+	GroupBy string
+
+	// ErrorStreaks lists endpoints whose latest responses are consecutive
+	// server errors, longest streak first.
+	ErrorStreaks []ErrorStreak
+
+	// FailureIntervals lists endpoints' time between server errors, those
+	// whose failures are speeding up first.
+	FailureIntervals []FailureInterval
+
+	// RateLimits is the outcome of each simulated rate-limit policy since
+	// startup, in config order.
+	RateLimits []RateLimitStats
+
+	// Probes is the state of each synthetic probe, in config order.
+	Probes []ProbeStatus
+
+	// Learning is true while detection is suppressed during warm-up;
+	// WarmupProgress goes from 0 to 1.
+	Learning       bool
+	WarmupProgress float64
+
+	Internals Internals
+
+	// Refresh is the effective refresh interval and the ingest rate behind it.
+	Refresh RefreshStatus
+
+	// Final is set on the report sent when finite input ends; Windows then
+	// holds only the "all" window covering the whole input.
+	Final bool
+}
+
+// RefreshStatus reports how often metrics are being recomputed.
+type RefreshStatus struct {
+	Tick       time.Duration
+	Adaptive   bool
+	IngestRate float64 // Lines per second over the last tick
+}
+
+// Internals reports pulsewatch's own health for the internals view.
+type Internals struct {
+	Storage  StorageStats
+	Pipeline []PipelineStat
+	Inputs   []InputHealth // Sorted by name
+}
+
+// InputHealth is one input's ingest telemetry: how fast it reads, and
+// whether it is keeping up with its source.
+type InputHealth struct {
+	Name        string
+	Lines       int64 // Lines read since startup
+	Bytes       int64
+	LinesPerSec float64
+	BytesPerSec float64
+	LagBytes    int64 // Written to the source but not read yet; -1 if unknown
+	Reconnects  int64 // Reopened files, restarted streams, returning clients
+	Updated     time.Time
+}
+
+// PipelineStat describes one consumer of a pipeline topic.
+type PipelineStat struct {
+	Topic     string // raw_lines, entries, metrics, or anomalies
+	Consumer  string
+	Policy    string // block or drop_oldest
+	Buffer    int
+	Queued    int   // Values waiting in the buffer
+	History   []int // Queued at each recent engine tick, oldest first
+	Delivered int64
+	Dropped   int64 // Values lost because the buffer was full
+}
+
+// StorageStats describes the SQLite store.
+type StorageStats struct {
+	SizeBytes       int64
+	WALBytes        int64
+	TableRows       map[string]int64
+	Inserts         int64   // Log entries inserted since startup
+	InsertRate      float64 // Inserts per second since the previous sample
+	Queries         int64
+	AvgQueryLatency time.Duration
+	PrunedRows      int64
+	LastPrune       time.Time
+	LastVacuum      time.Time
+}
+
+// SourceStatus describes how far a tailer is behind the file it reads.
+type SourceStatus struct {
+	Path         string
+	Size         int64     // File size at the last check
+	Offset       int64     // Bytes consumed by the tailer
+	PendingBytes int64     // Bytes written but not yet read
+	PendingLines int       // Complete lines written but not yet read
+	LastWrite    time.Time // File modification time
+	Truncations  int       // Times the file shrank and was re-read from the start
+	LastTruncate time.Time
+	Rotations    int // Times a new file replaced the one at the path
+	LastRotate   time.Time
+	LongLines    int    // Lines cut at the maximum line length
+	BinaryLines  int    // Lines skipped as binary data
+	Err          string // Why the file could not be checked; empty when fine
+}
+
+// SeriesPoint summarises one time bucket of a series, e.g. one endpoint's
+// traffic in one minute.
+type SeriesPoint struct {
+	Start      time.Time
+	Requests   int
+	Errors     int
+	RPS        float64
+	ErrorRate  float64 // Percent
+	P95Latency time.Duration
+}
+
+// Status is a running instance's headline metrics for the last minute, as
+// served to pulsewatch status.
+type Status struct {
+	Snapshot  MetricsSnapshot
+	Anomalies int // Anomalies in the last StatusAnomalyWindow
+	Critical  int // Critical ones among them
+	Learning  bool
+	Updated   time.Time
+	Started   time.Time // Start of the current session
+}
+
+// LatencyHistogram counts the session's request latencies into buckets,
+// as exported at GET /metrics and by remote write.
+type LatencyHistogram struct {
+	Bounds    []time.Duration // Upper bounds; the last bucket, +Inf, has none
+	Counts    []uint64        // Cumulative, one per bucket including +Inf
+	Exemplars []Exemplar      // One per bucket; zero when no request had a trace ID
+	Sum       time.Duration
+	Count     uint64
+	Created   time.Time
+}
+
+// Exemplar is a request representative of a histogram bucket, linking it to
+// its trace.
+type Exemplar struct {
+	TraceID   string
+	Latency   time.Duration
+	Timestamp time.Time
+}
+
+// IngestState reports whether ingestion is paused.
+type IngestState struct {
+	Paused bool      `json:"paused"`
+	Since  time.Time `json:"since"` // When it was paused
+	Held   int       `json:"held"`  // Records held until it resumes
+	Full   bool      `json:"full"`  // Held reached the buffer size; the inputs aren't read
+}
+
+// StatusAnomalyWindow is how far back Status counts anomalies.
+const StatusAnomalyWindow = 5 * time.Minute
+
+// SessionBaseline summarizes a saved session, e.g. an earlier load test, for
+// comparison with the live one: its rates over the minutes that had traffic
+// and the median of their per-minute percentiles.
+type SessionBaseline struct {
+	Source      string // Database the session was loaded from
+	From, To    time.Time
+	Minutes     int
+	RPS         float64
+	ErrorRate   float64 // Percent
+	Percentiles []PercentileValue
+}
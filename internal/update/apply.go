@@ -0,0 +1,175 @@
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Release files besides the binaries.
+const (
+	ChecksumsName = "checksums.txt"     // sha256sum output for every binary
+	SignatureName = "checksums.txt.sig" // Base64 Ed25519 signature of checksums.txt
+)
+
+// PublicKey is the hex-encoded Ed25519 key that signs release checksums. It
+// is set when building releases:
+//
+//	go build -ldflags "-X github.com/nitis/pulseWatch/internal/update.PublicKey=<hex>"
+//
+// Builds without it refuse to update themselves; see ErrUnsigned.
+var PublicKey string
+
+// ErrUnsigned is returned by Apply in builds without a signing key, which
+// cannot tell a genuine release from one uploaded with a stolen token.
+var ErrUnsigned = errors.New("this build has no update signing key")
+
+// CanApply reports whether this build can verify and apply updates.
+func CanApply() bool {
+	return PublicKey != ""
+}
+
+// maxBinarySize bounds a download so a bad response can't fill the disk.
+const maxBinarySize = 256 << 20
+
+// Apply downloads r's binary for this platform, verifies the signature of
+// the release's checksum file and the binary's checksum in it, and replaces
+// the executable at path with it. Without a built-in signing key it returns
+// ErrUnsigned before downloading anything.
+func (c *Checker) Apply(ctx context.Context, r *Release, path string) error {
+	if !CanApply() {
+		return ErrUnsigned
+	}
+	binary, ok := r.Asset(BinaryName())
+	if !ok {
+		return fmt.Errorf("release %s has no %s", r.Version, BinaryName())
+	}
+	checksumsAsset, ok := r.Asset(ChecksumsName)
+	if !ok {
+		return fmt.Errorf("release %s has no %s; refusing an unverifiable update", r.Version, ChecksumsName)
+	}
+	sigAsset, ok := r.Asset(SignatureName)
+	if !ok {
+		return fmt.Errorf("release %s has no %s; refusing an unverifiable update", r.Version, SignatureName)
+	}
+
+	checksums, err := c.download(ctx, checksumsAsset.URL, 1<<20)
+	if err != nil {
+		return err
+	}
+	sig, err := c.download(ctx, sigAsset.URL, 4<<10)
+	if err != nil {
+		return err
+	}
+	if err := verifySignature(checksums, sig); err != nil {
+		return err
+	}
+	want, err := checksumFor(checksums, binary.Name)
+	if err != nil {
+		return err
+	}
+
+	data, err := c.download(ctx, binary.URL, maxBinarySize)
+	if err != nil {
+		return err
+	}
+	if got := sha256.Sum256(data); hex.EncodeToString(got[:]) != want {
+		return fmt.Errorf("checksum mismatch for %s: got %x, want %s", binary.Name, got, want)
+	}
+	return replaceExecutable(path, data)
+}
+
+func (c *Checker) download(ctx context.Context, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("download %s exceeds %d bytes", url, limit)
+	}
+	return data, nil
+}
+
+func verifySignature(checksums, sig []byte) error {
+	key, err := hex.DecodeString(PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("built-in update signing key is invalid")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", SignatureName, err)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), checksums, raw) {
+		return fmt.Errorf("%s signature does not match the built-in signing key", ChecksumsName)
+	}
+	return nil
+}
+
+// checksumFor finds name's SHA-256 in sha256sum output.
+func checksumFor(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s lists no checksum for %s", ChecksumsName, name)
+}
+
+// replaceExecutable writes data next to path and renames it over path, so
+// the binary is never left half-written. Windows can't replace a running
+// executable, so the old one is moved aside first.
+func replaceExecutable(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".pulsewatch-update-*")
+	if err != nil {
+		return fmt.Errorf("cannot write next to %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0o111); err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,132 @@
+// Package update checks GitHub releases for a newer pulsewatch and replaces
+// the running binary with it after verifying the checksum file's signature
+// against the built-in signing key and the binary's checksum.
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Release is a published version and its downloadable files.
+type Release struct {
+	Version string  `json:"tag_name"`
+	URL     string  `json:"html_url"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset is one file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Checker looks up releases of one GitHub repository.
+type Checker struct {
+	apiURL string
+	repo   string
+	http   *http.Client
+}
+
+// NewChecker creates a Checker for repo ("owner/name") on the GitHub API at
+// apiURL; an empty apiURL means api.github.com.
+func NewChecker(apiURL, repo string) *Checker {
+	if apiURL == "" {
+		apiURL = "https://api.github.com"
+	}
+	return &Checker{apiURL: strings.TrimSuffix(apiURL, "/"), repo: repo, http: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Latest returns the newest published release.
+func (c *Checker) Latest(ctx context.Context) (*Release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL+"/repos/"+c.repo+"/releases/latest", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the latest release: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("failed to fetch the latest release: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var r Release
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("failed to decode the latest release: %w", err)
+	}
+	return &r, nil
+}
+
+// Asset returns the release file called name.
+func (r *Release) Asset(name string) (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// BinaryName is the release asset holding the binary for this platform,
+// e.g. pulsewatch_linux_amd64 or pulsewatch_windows_amd64.exe.
+func BinaryName() string {
+	name := "pulsewatch_" + runtime.GOOS + "_" + runtime.GOARCH
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Newer reports whether version latest is newer than current. Versions are
+// dotted numbers with an optional "v" prefix; a pre-release suffix
+// ("-rc.1") ranks below the release. Unparseable versions, such as the
+// "dev" of a source build, are never newer and never current.
+func Newer(latest, current string) bool {
+	l, lpre, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	c, cpre, ok := parseVersion(current)
+	if !ok {
+		return true
+	}
+	for i := 0; i < max(len(l), len(c)); i++ {
+		var a, b int
+		if i < len(l) {
+			a = l[i]
+		}
+		if i < len(c) {
+			b = c[i]
+		}
+		if a != b {
+			return a > b
+		}
+	}
+	return cpre && !lpre
+}
+
+func parseVersion(v string) (parts []int, prerelease bool, ok bool) {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		prerelease = v[i] == '-'
+		v = v[:i]
+	}
+	for _, p := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, prerelease, true
+}
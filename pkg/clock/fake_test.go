@@ -0,0 +1,121 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// received returns what ch holds, without blocking.
+func received(ch <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-ch:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFakeAfter(t *testing.T) {
+	f := NewFake(start)
+	ch := f.After(time.Minute)
+	if f.Waiters() != 1 {
+		t.Fatalf("Waiters = %d, want 1", f.Waiters())
+	}
+
+	f.Advance(59 * time.Second)
+	if _, ok := received(ch); ok {
+		t.Fatal("After fired before its time")
+	}
+	f.Advance(2 * time.Second)
+	got, ok := received(ch)
+	if !ok || !got.Equal(start.Add(time.Minute)) {
+		t.Fatalf("After received %v, %v; want %v", got, ok, start.Add(time.Minute))
+	}
+	if f.Waiters() != 0 {
+		t.Errorf("Waiters = %d after firing, want 0", f.Waiters())
+	}
+	if want := start.Add(61 * time.Second); !f.Now().Equal(want) {
+		t.Errorf("Now = %v, want %v", f.Now(), want)
+	}
+}
+
+func TestFakeAfterNonPositive(t *testing.T) {
+	f := NewFake(start)
+	if got, ok := received(f.After(0)); !ok || !got.Equal(start) {
+		t.Fatalf("After(0) received %v, %v; want %v at once", got, ok, start)
+	}
+	if f.Waiters() != 0 {
+		t.Errorf("Waiters = %d, want 0", f.Waiters())
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	f := NewFake(start)
+	ticker := f.NewTicker(10 * time.Second)
+
+	f.Advance(10 * time.Second)
+	if got, ok := received(ticker.C()); !ok || !got.Equal(start.Add(10*time.Second)) {
+		t.Fatalf("first tick %v, %v", got, ok)
+	}
+
+	// Like time.Ticker, ticks that aren't received are dropped rather than
+	// queued: only the first of these three is kept
+	f.Advance(30 * time.Second)
+	if got, ok := received(ticker.C()); !ok || !got.Equal(start.Add(20*time.Second)) {
+		t.Fatalf("tick after 40s %v, %v; want the one due at 20s", got, ok)
+	}
+	if _, ok := received(ticker.C()); ok {
+		t.Fatal("dropped ticks were queued")
+	}
+
+	ticker.Reset(time.Minute)
+	f.Advance(59 * time.Second)
+	if _, ok := received(ticker.C()); ok {
+		t.Fatal("tick before the reset interval")
+	}
+	f.Advance(time.Second)
+	if _, ok := received(ticker.C()); !ok {
+		t.Fatal("no tick after the reset interval")
+	}
+
+	ticker.Stop()
+	if f.Waiters() != 0 {
+		t.Errorf("Waiters = %d after Stop, want 0", f.Waiters())
+	}
+	f.Advance(time.Hour)
+	if _, ok := received(ticker.C()); ok {
+		t.Error("stopped ticker ticked")
+	}
+}
+
+func TestFakeSetFiresInOrder(t *testing.T) {
+	f := NewFake(start)
+	late := f.After(2 * time.Minute)
+	early := f.After(time.Minute)
+
+	f.Set(start.Add(time.Hour))
+	e, _ := received(early)
+	l, _ := received(late)
+	if !e.Equal(start.Add(time.Minute)) || !l.Equal(start.Add(2*time.Minute)) {
+		t.Fatalf("timers received %v and %v, want their due times", e, l)
+	}
+}
+
+func TestFakeSetBackwards(t *testing.T) {
+	f := NewFake(start)
+	ch := f.After(time.Minute)
+
+	f.Set(start.Add(-time.Hour))
+	if !f.Now().Equal(start.Add(-time.Hour)) {
+		t.Fatalf("Now = %v, want an hour before start", f.Now())
+	}
+	if _, ok := received(ch); ok {
+		t.Fatal("timer fired when the clock moved backwards")
+	}
+	f.Set(start.Add(time.Minute))
+	if _, ok := received(ch); !ok {
+		t.Fatal("timer didn't fire once due")
+	}
+}